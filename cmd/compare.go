@@ -0,0 +1,98 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/difyz9/markdown2tts/service"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+var compareConfigFile string
+var compareInputFile string
+var compareSentences int
+var compareSeparator string
+var compareOutput string
+var compareSecretID string
+var compareSecretKey string
+
+// compareCmd represents the compare command
+var compareCmd = &cobra.Command{
+	Use:   "compare",
+	Short: "用腾讯云和Edge合成同一段内容，生成交替对比音频",
+	Long: `取输入文件的前N句，分别用腾讯云TTS和Edge TTS合成，按句交替拼接成一个对比音频，
+方便在同一个文件里直接比较两个服务的效果。
+
+同时在音频旁写出 .manifest.json 清单，标注每段语音的来源、音色和起止时间。
+
+分隔方式:
+  voice   每段前由对应服务朗读来源名称（默认）
+  silence 段间插入静音（需要ffmpeg）
+  none    直接拼接
+
+示例:
+  {{.Program}} compare -i input.md
+  {{.Program}} compare -i input.txt -n 10 --separator silence
+  {{.Program}} compare -i input.md -o ./output/compare.mp3`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runCompare()
+		if err != nil {
+			service.Failf("错误: %v\n", err)
+		}
+	},
+}
+
+func runCompare() error {
+	if compareConfigFile == "" {
+		compareConfigFile = service.DefaultConfigPath()
+	}
+	configService, err := service.NewConfigService(compareConfigFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %v", err)
+	}
+	config := configService.GetConfig()
+
+	if compareInputFile != "" {
+		config.InputFile = compareInputFile
+	}
+
+	source, err := service.ResolveTencentCredentials(config, compareSecretID, compareSecretKey)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔑 腾讯云密钥来源: %s\n", source)
+
+	ttsService, err := service.NewTTSServiceFromConfig(config)
+	if err != nil {
+		return fmt.Errorf("创建TTS服务失败: %v", err)
+	}
+
+	output := compareOutput
+	if output == "" {
+		output = filepath.Join(config.Audio.OutputDir, "compare.mp3")
+	}
+	if err := service.EnsureDir(filepath.Dir(output)); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	return service.CompareProviders(config, ttsService, service.CompareOptions{
+		Sentences:  compareSentences,
+		Separator:  compareSeparator,
+		OutputPath: output,
+	})
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringVarP(&compareConfigFile, "config", "c", "", "配置文件路径（默认自动查找config.yaml）")
+	compareCmd.Flags().StringVarP(&compareInputFile, "input", "i", "", "输入文件路径（覆盖配置文件中的设置）")
+	compareCmd.Flags().IntVarP(&compareSentences, "sentences", "n", 5, "参与对比的句子数（取输入的前N句）")
+	compareCmd.Flags().StringVar(&compareSeparator, "separator", service.CompareSeparatorVoice, "分隔方式: voice, silence 或 none")
+	compareCmd.Flags().StringVarP(&compareOutput, "output", "o", "", "对比音频输出路径（默认为 输出目录/compare.mp3）")
+	compareCmd.Flags().StringVar(&compareSecretID, "secret-id", "", "腾讯云SecretID（优先于环境变量、凭证文件和配置文件）")
+	compareCmd.Flags().StringVar(&compareSecretKey, "secret-key", "", "腾讯云SecretKey（需与 --secret-id 同时指定）")
+}
@@ -5,9 +5,11 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/difyz9/markdown2tts/model"
 	"github.com/difyz9/markdown2tts/service"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -17,11 +19,19 @@ var edgeInputFile string
 var edgeOutputDir string
 var listVoices string
 var listAllVoices bool
+var listSort string
+var refreshVoices bool
 var edgeVoice string
 var edgeRate string
 var edgeVolume string
 var edgePitch string
-var edgeSmartMarkdown bool // 新增：智能Markdown模式
+var edgeSmartMarkdown bool            // 新增：智能Markdown模式
+var edgeKeepSegments bool             // 保留每句音频片段
+var edgeMaxFileDuration time.Duration // 单个输出文件最长时长
+var edgeInputType string              // 输入类型：auto/markdown/plain
+var edgeStream string                 // 流式输出目标：命名管道路径或 "-"
+var edgeText string                   // 直接合成的单段文本
+var edgeSplit bool                    // 按章节拆分输出
 
 // edgeCmd represents the edge command
 var edgeCmd = &cobra.Command{
@@ -34,22 +44,27 @@ Edge TTS是免费的，无需API密钥，支持多种语言和音色。
 当输入文件为Markdown格式（.md或.markdown）时，自动启用智能Markdown处理模式。
 
 示例:
-  markdown2tts edge                                    # 使用默认配置
-  markdown2tts edge -i input.txt                       # 指定输入文件
-  markdown2tts edge -i document.md                     # 自动启用智能Markdown模式
-  markdown2tts edge -i input.txt -o /path/to/output   # 指定输入和输出
-  markdown2tts edge --config custom.yaml              # 使用自定义配置
-  markdown2tts edge --list-all                         # 列出所有可用语音
-  markdown2tts edge --list zh                          # 列出中文语音
-  markdown2tts edge --list en                          # 列出英文语音
-  markdown2tts edge --voice zh-CN-YunyangNeural      # 使用指定语音
-  markdown2tts edge --rate +20% --volume +10%        # 调整语速和音量
+  {{.Program}} edge                                    # 使用默认配置
+  {{.Program}} edge -i input.txt                       # 指定输入文件
+  {{.Program}} edge -i document.md                     # 自动启用智能Markdown模式
+  {{.Program}} edge -i book.md --split                  # 按章节标题拆分为多个音频文件
+  {{.Program}} edge --text "你好，世界"                  # 直接合成一句文本
+  {{.Program}} edge -i input.txt --stream - | ffplay -i -   # 边合成边播放
+  {{.Program}} edge -i input.txt -o /path/to/output   # 指定输入和输出
+  {{.Program}} edge --config custom.yaml              # 使用自定义配置
+  {{.Program}} edge --list-all                         # 列出所有可用语音
+  {{.Program}} edge --list zh                          # 列出中文语音
+  {{.Program}} edge --list en                          # 列出英文语音
+  {{.Program}} edge --list en --sort neural            # Neural音色优先排序
+  {{.Program}} edge --list zh --refresh                # 重新拉取语音列表（默认缓存7天）
+  {{.Program}} edge --voice zh-CN-YunyangNeural      # 使用指定语音
+  {{.Program}} edge --rate +20% --volume +10%        # 调整语速和音量
 
   `,
 	Run: func(cmd *cobra.Command, args []string) {
 		err := runEdgeTTS(cmd)
 		if err != nil {
-			fmt.Printf("错误: %v\n", err)
+			service.Failf("错误: %v\n", err)
 		}
 	},
 }
@@ -57,15 +72,25 @@ Edge TTS是免费的，无需API密钥，支持多种语言和音色。
 func runEdgeTTS(cmd *cobra.Command) error {
 	// 如果是列出语音模式，直接执行并返回
 	if listAllVoices || listVoices != "" {
+		proxy := edgeListProxy()
 		if listAllVoices {
-			return service.ListEdgeVoices("")
+			return service.ListEdgeVoices("", refreshVoices, proxy, listSort)
 		}
-		return service.ListEdgeVoices(listVoices)
+		return service.ListEdgeVoices(listVoices, refreshVoices, proxy, listSort)
+	}
+
+	if edgeText != "" && edgeInputFile != "" {
+		return fmt.Errorf("--text 与 -i/--input 不能同时使用")
+	}
+
+	// 音频流输出到stdout时，配置加载等日志也不能写入stdout
+	if edgeStream == service.StreamToStdout {
+		redirectLogsToStderr()
 	}
 
 	// 如果没有指定配置文件，尝试默认位置
 	if edgeConfigFile == "" {
-		edgeConfigFile = "config.yaml"
+		edgeConfigFile = service.DefaultConfigPath()
 	}
 
 	// 加载配置（如果配置文件不存在会自动初始化）
@@ -97,6 +122,41 @@ func runEdgeTTS(cmd *cobra.Command) error {
 		config.Audio.OutputDir = edgeOutputDir
 	}
 
+	// 按provider切换到独立的输出/临时子目录（如已配置）
+	service.ApplyProviderDirs(config, service.ProviderEdge)
+
+	// 分章输出（仅智能Markdown模式支持）
+	if edgeSplit {
+		config.Audio.Split = true
+	}
+	if edgeKeepSegments {
+		config.Audio.KeepSegments = true
+	}
+	if edgeMaxFileDuration > 0 {
+		config.Audio.MaxFileDuration = edgeMaxFileDuration
+	}
+	// 输入类型：命令行优先，智能Markdown模式下按Markdown处理
+	if edgeInputType != "" {
+		config.Markdown.InputType = edgeInputType
+	} else if edgeSmartMarkdown && config.Markdown.InputType == "" {
+		config.Markdown.InputType = service.InputTypeMarkdown
+	}
+	if config.Audio.Split && !edgeSmartMarkdown {
+		service.Warnf("⚠️  分章输出仅支持智能Markdown模式，将输出单个文件\n")
+		config.Audio.Split = false
+	}
+	if config.Audio.MaxFileDuration > 0 && (config.Audio.Split || !edgeSmartMarkdown) {
+		service.Warnf("⚠️  按时长切分输出仅支持智能Markdown模式且不能与分章输出同时使用，将忽略 max_file_duration\n")
+		config.Audio.MaxFileDuration = 0
+	}
+
+	// 应用CPU/goroutine资源限制
+	applyResourceFlags(config)
+	applyStreamOutput(config, edgeStream)
+	applySegmentCache(config)
+	applyChecksum(config)
+	applyPriorityLines(config)
+
 	// 如果指定了语音参数，覆盖配置
 	if edgeVoice != "" {
 		config.EdgeTTS.Voice = edgeVoice
@@ -106,11 +166,17 @@ func runEdgeTTS(cmd *cobra.Command) error {
 	}
 	if edgeVolume != "" {
 		config.EdgeTTS.Volume = edgeVolume
+		config.Audio.VolumeScale = 0 // 命令行指定的原生音量优先于统一音量标度
 	}
 	if edgePitch != "" {
 		config.EdgeTTS.Pitch = edgePitch
 	}
 
+	// 直接合成命令行传入的文本，不读取输入文件
+	if edgeText != "" {
+		return runEdgeText(config)
+	}
+
 	// 检查输入文件路径
 	inputPath := config.InputFile
 	if !filepath.IsAbs(inputPath) {
@@ -138,18 +204,12 @@ func runEdgeTTS(cmd *cobra.Command) error {
 	fmt.Printf("- TTS引擎: Microsoft Edge TTS (免费)\n")
 
 	// 显示Edge TTS配置
-	voice := config.EdgeTTS.Voice
-	if voice == "" {
-		voice = "zh-CN-XiaoyiNeural"
-	}
+	voice := edgeVoiceOrDefault(config)
 	rate := config.EdgeTTS.Rate
 	if rate == "" {
 		rate = "+0%"
 	}
-	volume := config.EdgeTTS.Volume
-	if volume == "" {
-		volume = "+0%"
-	}
+	volume := service.EdgeVolume(config)
 	pitch := config.EdgeTTS.Pitch
 	if pitch == "" {
 		pitch = "+0Hz"
@@ -170,6 +230,7 @@ func runEdgeTTS(cmd *cobra.Command) error {
 
 	// 创建Edge TTS服务
 	edgeService := service.NewEdgeTTSService(config)
+	start := time.Now()
 
 	// 根据模式选择处理方法
 	if edgeSmartMarkdown {
@@ -185,9 +246,26 @@ func runEdgeTTS(cmd *cobra.Command) error {
 	}
 
 	fmt.Println("Edge TTS转换和音频合并完成！")
+
+	// 按配置上传本次实际写出的音频、时间轴、校验和及打包文件（失败不影响本地产物）
+	outputs := append(edgeService.Outputs(), writeRunBundle(config, service.ProviderEdge, start)...)
+	service.UploadOutputs(config, outputs)
 	return nil
 }
 
+// edgeListProxy 列出语音时使用的代理：配置文件存在时读取其中的代理设置，否则只使用环境变量
+func edgeListProxy() string {
+	path := edgeConfigFile
+	if path == "" {
+		path = service.DefaultConfigPath()
+	}
+	config, err := service.LoadConfigFile(path)
+	if err != nil {
+		return service.ResolveProxy(nil)
+	}
+	return service.ResolveProxy(config)
+}
+
 func init() {
 	rootCmd.AddCommand(edgeCmd)
 
@@ -203,6 +281,8 @@ func init() {
 	// 添加列出语音标志
 	edgeCmd.Flags().BoolVar(&listAllVoices, "list-all", false, "列出所有可用语音")
 	edgeCmd.Flags().StringVar(&listVoices, "list", "", "列出指定语言的语音（如: zh, en, ja）")
+	edgeCmd.Flags().BoolVar(&refreshVoices, "refresh", false, "忽略本地缓存，重新拉取语音列表")
+	edgeCmd.Flags().StringVar(&listSort, "sort", "", "语音列表排序: locale(区域)/name(名称)/neural(Neural优先)，默认保持原始顺序")
 
 	// 添加语音参数标志
 	edgeCmd.Flags().StringVar(&edgeVoice, "voice", "", "指定语音 (如: zh-CN-XiaoyiNeural)")
@@ -212,4 +292,37 @@ func init() {
 
 	// 添加智能Markdown处理标志
 	edgeCmd.Flags().BoolVar(&edgeSmartMarkdown, "smart-markdown", false, "启用智能Markdown处理模式（推荐用于.md文件）")
+	edgeCmd.Flags().BoolVar(&edgeSplit, "split", false, "按一级/二级标题分章输出，文件以章节标题命名")
+	edgeCmd.Flags().DurationVar(&edgeMaxFileDuration, "max-file-duration", 0, "单个输出文件的最长预估时长（如 2h），超出时切分为多个编号文件，优先在章节边界切分")
+	edgeCmd.Flags().StringVar(&edgeInputType, "input-type", "", "输入类型: auto(按扩展名，.txt为纯文本)/markdown/plain")
+	edgeCmd.Flags().StringVar(&edgeStream, "stream", "", "边合成边按顺序把音频写入命名管道（\"-\" 为stdout，日志改写到stderr）")
+	edgeCmd.Flags().StringVar(&edgeText, "text", "", "直接合成指定文本（无需输入文件，与 -i 互斥）")
+	edgeCmd.Flags().BoolVar(&edgeKeepSegments, "keep-segments", false, "合并的同时把每句音频片段按序保留到输出目录的segments/子目录")
+}
+
+// edgeVoiceOrDefault 返回配置的语音，未配置时使用默认中文女声
+func edgeVoiceOrDefault(config *model.Config) string {
+	if config.EdgeTTS.Voice == "" {
+		return "zh-CN-XiaoyiNeural"
+	}
+	return config.EdgeTTS.Voice
+}
+
+// runEdgeText 把 --text 传入的文本直接合成到输出文件
+func runEdgeText(config *model.Config) error {
+	if err := service.EnsureDir(config.Audio.OutputDir); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	outputPath := filepath.Join(config.Audio.OutputDir, config.Audio.FinalOutput)
+	voice := edgeVoiceOrDefault(config)
+	fmt.Printf("🎙️  使用 %s 合成文本: %s\n", voice, edgeText)
+
+	if err := service.NewEdgeTTSService(config).SynthesizeSample(edgeText, voice, outputPath); err != nil {
+		return fmt.Errorf("合成文本失败: %v", err)
+	}
+
+	fmt.Printf("✅ 音频已保存到: %s\n", outputPath)
+	service.UploadOutputs(config, []string{outputPath})
+	return nil
 }
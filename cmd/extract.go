@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+	"github.com/difyz9/markdown2tts/service"
+	"github.com/spf13/cobra"
+)
+
+var extractConfigFile string
+var extractInputFile string
+var extractOutputFile string
+var extractSmartMarkdown bool
+
+// extractCmd represents the extract command
+var extractCmd = &cobra.Command{
+	Use:   "extract",
+	Short: "导出清洗、分句后的纯文本（不调用TTS）",
+	Long: `只执行文本提取、清洗和分句，把实际送去合成的句子按一句一行写出，不调用任何TTS服务。
+可用于检查朗读稿，或把结果交给其他TTS工具。
+
+Markdown文件（或 --smart-markdown）按智能解析提取句子，其他文件逐行清洗，被过滤的行不输出。
+未指定 --output（或为 -）时写到标准输出。
+
+示例:
+  {{.Program}} extract -i doc.md -o clean.txt
+  {{.Program}} extract -i input.txt > clean.txt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runExtract(cmd)
+		if err != nil {
+			service.Failf("错误: %v\n", err)
+		}
+	},
+}
+
+func runExtract(cmd *cobra.Command) error {
+	if extractInputFile == "" {
+		return fmt.Errorf("请指定输入文件 --input")
+	}
+
+	// 文本输出到stdout时，处理过程中的日志改写到stderr
+	toStdout := extractOutputFile == "" || extractOutputFile == "-"
+	if toStdout {
+		redirectLogsToStderr()
+	}
+
+	// 配置文件存在时使用其中的文本处理选项
+	config, err := loadTextConfig(extractConfigFile)
+	if err != nil {
+		return err
+	}
+	tp := service.NewTextProcessorFromConfig(config)
+
+	// 自动检测Markdown文件
+	if !cmd.Flags().Changed("smart-markdown") {
+		ext := strings.ToLower(filepath.Ext(extractInputFile))
+		extractSmartMarkdown = ext == ".md" || ext == ".markdown"
+	}
+
+	sentences, err := tp.ExtractSentences(extractInputFile, extractSmartMarkdown)
+	if err != nil {
+		return err
+	}
+
+	if toStdout {
+		return service.WriteSentences(originalStdout, sentences)
+	}
+	if err := service.WriteSentencesFile(extractOutputFile, sentences); err != nil {
+		return err
+	}
+	fmt.Printf("✅ 已导出 %d 个句子: %s\n", len(sentences), extractOutputFile)
+	return nil
+}
+
+// loadTextConfig 读取文本处理使用的配置文件：未指定 -c 且默认配置文件不存在时返回nil，按默认选项处理
+// 指定的文件不存在、或配置文件解析失败时返回错误，避免静默忽略脱敏等选项
+func loadTextConfig(configPath string) (*model.Config, error) {
+	if configPath == "" {
+		configPath = service.DefaultConfigPath()
+		if _, err := os.Stat(configPath); errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+	}
+	return service.LoadConfigFile(configPath)
+}
+
+func init() {
+	rootCmd.AddCommand(extractCmd)
+
+	extractCmd.Flags().StringVarP(&extractConfigFile, "config", "c", "", "配置文件路径（默认自动查找config.yaml）")
+	extractCmd.Flags().StringVarP(&extractInputFile, "input", "i", "", "输入文件路径")
+	extractCmd.Flags().StringVarP(&extractOutputFile, "output", "o", "", "输出文本文件路径（默认或 - 表示标准输出）")
+	extractCmd.Flags().BoolVar(&extractSmartMarkdown, "smart-markdown", false, "使用智能Markdown模式提取（.md文件自动启用）")
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadTextConfig(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yaml")
+	invalid := filepath.Join(dir, "invalid.yaml")
+	badRule := filepath.Join(dir, "bad_rule.yaml")
+	files := map[string]string{
+		valid:   "markdown:\n  bracket_mode: remove\n",
+		invalid: "markdown: [\n",
+		badRule: "markdown:\n  redact:\n    - pattern: \"(\"\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name       string
+		path       string
+		wantConfig bool
+		wantErr    bool
+	}{
+		{"有效配置", valid, true, false},
+		{"指定的文件不存在", filepath.Join(dir, "missing.yaml"), false, true},
+		{"解析失败", invalid, false, true},
+		{"脱敏规则无效", badRule, false, true},
+		{"未指定且没有默认配置", "", false, false},
+	}
+
+	// 默认配置文件按当前目录查找
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loadTextConfig(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadTextConfig(%q) err = %v, wantErr %v", tt.path, err, tt.wantErr)
+			}
+			if (config != nil) != tt.wantConfig {
+				t.Errorf("loadTextConfig(%q) config = %v, want config %v", tt.path, config, tt.wantConfig)
+			}
+		})
+	}
+}
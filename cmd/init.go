@@ -13,6 +13,8 @@ import (
 var initConfigFile string
 var initInputFile string
 var force bool
+var initFormat string
+var initLang string
 
 // initCmd represents the init command
 var initCmd = &cobra.Command{
@@ -21,28 +23,35 @@ var initCmd = &cobra.Command{
 	Long: `初始化TTS应用所需的配置文件和示例输入文件。
 
 该命令会创建：
-1. config.yaml - 主配置文件
+1. config.yaml - 主配置文件（可用 --format 生成 JSON 或 TOML）
 2. input.txt - 示例输入文件
 
 如果文件已存在，默认会跳过。使用 --force 强制覆盖。
 
 示例:
-  markdown2tts init                           # 使用默认文件名初始化
-  markdown2tts init --config custom.yaml     # 指定配置文件名
-  markdown2tts init --input my_input.txt      # 指定输入文件名
-  markdown2tts init --force                   # 强制覆盖已存在的文件`,
+  {{.Program}} init                           # 使用默认文件名初始化
+  {{.Program}} init --config custom.yaml     # 指定配置文件名
+  {{.Program}} init --format toml             # 生成 config.toml
+  {{.Program}} init --input my_input.txt      # 指定输入文件名
+  {{.Program}} init --lang en                 # 生成英文示例输入文件
+  {{.Program}} init --force                   # 强制覆盖已存在的文件`,
 	Run: func(cmd *cobra.Command, args []string) {
 		err := runInit()
 		if err != nil {
-			fmt.Printf("错误: %v\n", err)
+			service.Failf("错误: %v\n", err)
 		}
 	},
 }
 
 func runInit() error {
 	// 设置默认文件名
+	switch initFormat {
+	case service.ConfigFormatYAML, service.ConfigFormatJSON, service.ConfigFormatTOML:
+	default:
+		return fmt.Errorf("不支持的配置格式: %s（可选: yaml, json, toml）", initFormat)
+	}
 	if initConfigFile == "" {
-		initConfigFile = "config.yaml"
+		initConfigFile = "config." + initFormat
 	}
 	if initInputFile == "" {
 		initInputFile = "input.txt"
@@ -53,23 +62,25 @@ func runInit() error {
 	fmt.Println()
 
 	initializer := service.NewConfigInitializer()
+	if err := initializer.SetSampleLanguage(initLang); err != nil {
+		return err
+	}
 
 	// 如果强制模式，先删除已存在的文件
 	if force {
-		fmt.Println("⚠️  强制模式：将覆盖已存在的文件")
-		// 这里可以添加删除文件的逻辑，但为了安全，我们让初始化器处理
+		service.Warnf("⚠️  强制模式：将覆盖已存在的文件\n")
 	}
 
 	// 初始化配置文件
 	fmt.Printf("📝 初始化配置文件: %s\n", initConfigFile)
-	err := initializer.InitializeConfig(initConfigFile)
+	err := initializer.InitializeConfigWithForce(initConfigFile, force)
 	if err != nil {
 		return fmt.Errorf("初始化配置文件失败: %v", err)
 	}
 
 	// 创建示例输入文件
 	fmt.Printf("📄 创建示例输入文件: %s\n", initInputFile)
-	err = initializer.CreateSampleInputFile(initInputFile)
+	err = initializer.CreateSampleInputFileWithForce(initInputFile, force)
 	if err != nil {
 		return fmt.Errorf("创建示例输入文件失败: %v", err)
 	}
@@ -83,8 +94,8 @@ func runInit() error {
 	fmt.Printf("1. 编辑 %s 设置您的API密钥（可选，使用腾讯云TTS时需要）\n", initConfigFile)
 	fmt.Printf("2. 编辑 %s 添加要转换的文本\n", initInputFile)
 	fmt.Println("3. 运行 TTS 转换：")
-	fmt.Printf("   - 免费版本: ./github.com/difyz9/markdown2tts edge -i %s\n", initInputFile)
-	fmt.Printf("   - 腾讯云版本: ./github.com/difyz9/markdown2tts tts -i %s\n", initInputFile)
+	fmt.Printf("   - 免费版本: %s edge -i %s\n", service.ProgramName, initInputFile)
+	fmt.Printf("   - 腾讯云版本: %s tts -i %s\n", service.ProgramName, initInputFile)
 
 	return nil
 }
@@ -95,9 +106,15 @@ func init() {
 	// 添加配置文件标志
 	initCmd.Flags().StringVarP(&initConfigFile, "config", "c", "", "配置文件路径（默认: config.yaml）")
 
+	// 添加配置格式标志
+	initCmd.Flags().StringVar(&initFormat, "format", service.ConfigFormatYAML, "配置文件格式: yaml, json, toml（指定 --config 时按扩展名决定）")
+
 	// 添加输入文件标志
 	initCmd.Flags().StringVarP(&initInputFile, "input", "i", "", "示例输入文件路径（默认: input.txt）")
 
+	// 添加示例语言标志
+	initCmd.Flags().StringVar(&initLang, "lang", "zh", "示例输入文件的语言: zh 或 en")
+
 	// 添加强制覆盖标志
 	initCmd.Flags().BoolVarP(&force, "force", "f", false, "强制覆盖已存在的文件")
 }
@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/difyz9/markdown2tts/service"
+)
+
+// 日志文件级别
+const (
+	logLevelInfo  = "info"
+	logLevelWarn  = "warn"
+	logLevelError = "error"
+)
+
+// 日志文件标志
+var (
+	logFile  string
+	logLevel string
+	logQuiet bool
+)
+
+// logLevels 日志级别名称对应的输出级别
+var logLevels = map[string]int{
+	logLevelInfo:  service.LogLevelInfo,
+	logLevelWarn:  service.LogLevelWarn,
+	logLevelError: service.LogLevelError,
+}
+
+// logTee 把写入stdout/stderr的内容同时转发到终端和日志文件
+// 级别来自 service.Warnf/Failf 加的行首标记，stderr的输出一律按错误记录
+type logTee struct {
+	mu      sync.Mutex
+	console io.Writer // stdout的终端输出，nil表示不输出到终端
+	errors  io.Writer // stderr的终端输出，nil表示不输出到终端
+	file    *os.File
+	level   int
+	stdout  *os.File // 替换os.Stdout的管道写端
+	stderr  *os.File // 替换os.Stderr的管道写端
+	wg      sync.WaitGroup
+}
+
+// activeLogTee 当前生效的日志转发，未开启日志文件时为nil
+var activeLogTee *logTee
+
+// originalStdout 启动时的标准输出，流式音频输出到 "-" 时写入这里
+var originalStdout = os.Stdout
+
+// originalStderr 启动时的标准错误，开启日志文件后os.Stderr指向日志管道
+var originalStderr = os.Stderr
+
+// startLogFile 按 --log-file 开启日志文件：替换os.Stdout和os.Stderr为管道，逐行加时间戳写入文件
+func startLogFile() error {
+	if logFile == "" {
+		if logQuiet {
+			return fmt.Errorf("--quiet 需要与 --log-file 一起使用")
+		}
+		return nil
+	}
+
+	level, ok := logLevels[logLevel]
+	if !ok {
+		return fmt.Errorf("未知的日志级别: %s（可选: info, warn, error）", logLevel)
+	}
+
+	file, err := service.OpenLogFile(logFile)
+	if err != nil {
+		return fmt.Errorf("打开日志文件失败: %v", err)
+	}
+
+	outReader, outWriter, err := os.Pipe()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("创建日志管道失败: %v", err)
+	}
+	errReader, errWriter, err := os.Pipe()
+	if err != nil {
+		file.Close()
+		outReader.Close()
+		outWriter.Close()
+		return fmt.Errorf("创建日志管道失败: %v", err)
+	}
+
+	tee := &logTee{file: file, level: level, stdout: outWriter, stderr: errWriter}
+	if !logQuiet {
+		tee.console = os.Stdout
+		tee.errors = originalStderr
+	}
+	activeLogTee = tee
+	os.Stdout = outWriter
+	os.Stderr = errWriter
+	service.SetLogLevelTags(true)
+
+	tee.wg.Add(2)
+	go tee.run(outReader, false)
+	go tee.run(errReader, true)
+	fmt.Fprintf(file, "%s ===== %s =====\n", time.Now().Format("2006-01-02 15:04:05"), strings.Join(os.Args, " "))
+	return nil
+}
+
+// stopLogFile 关闭日志管道并等待剩余内容写入文件
+func stopLogFile() {
+	tee := activeLogTee
+	if tee == nil {
+		return
+	}
+	activeLogTee = nil
+
+	service.SetLogLevelTags(false)
+	os.Stdout = tee.consoleOrStderr()
+	os.Stderr = originalStderr
+	tee.stdout.Close()
+	tee.stderr.Close()
+	tee.wg.Wait()
+	tee.file.Close()
+}
+
+// run 逐行读取程序输出：去掉级别标记后转发到终端，按级别过滤后加时间戳写入日志文件
+func (t *logTee) run(reader io.ReadCloser, fromStderr bool) {
+	defer t.wg.Done()
+	defer reader.Close()
+
+	buffered := bufio.NewReader(reader)
+	for {
+		line, err := buffered.ReadString('\n')
+		if line != "" {
+			level, text := service.ParseLogLevelTag(line)
+			if fromStderr {
+				level = service.LogLevelError
+			}
+
+			t.mu.Lock()
+			console := t.console
+			if fromStderr {
+				console = t.errors
+			}
+			if console != nil {
+				io.WriteString(console, text)
+			}
+			if level >= t.level && strings.TrimSpace(text) != "" {
+				fmt.Fprintf(t.file, "%s %s", time.Now().Format("2006-01-02 15:04:05"), strings.TrimLeft(text, "\n"))
+				if !strings.HasSuffix(text, "\n") {
+					fmt.Fprintln(t.file)
+				}
+			}
+			t.mu.Unlock()
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// setConsole 切换终端输出目标（如流式音频占用stdout时改为stderr）
+func (t *logTee) setConsole(console io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.console != nil {
+		t.console = console
+	}
+}
+
+// consoleOrStderr 返回关闭日志文件后应恢复的标准输出
+func (t *logTee) consoleOrStderr() *os.File {
+	if logsRedirected {
+		return originalStderr
+	}
+	return originalStdout
+}
+
+// logsRedirected 日志是否已改写到stderr
+var logsRedirected bool
+
+// redirectLogsToStderr 保留原始stdout作为音频流，之后的 fmt.Print 输出改写到stderr
+func redirectLogsToStderr() {
+	if logsRedirected {
+		return
+	}
+	logsRedirected = true
+	service.SetStdoutStream(originalStdout)
+
+	// 开启了日志文件时stdout已指向日志管道，只需把终端转发改到stderr
+	if activeLogTee != nil {
+		activeLogTee.setConsole(originalStderr)
+		return
+	}
+	os.Stdout = os.Stderr
+}
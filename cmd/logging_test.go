@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/difyz9/markdown2tts/service"
+)
+
+func TestLogFileLevels(t *testing.T) {
+	tests := []struct {
+		level   string
+		want    []string
+		notWant []string
+	}{
+		{logLevelInfo, []string{"处理完成: 成功 1, 失败 0", "⚠️  跳过片段", "✗ 任务 3 失败", "stderr输出"}, nil},
+		{logLevelWarn, []string{"⚠️  跳过片段", "✗ 任务 3 失败", "stderr输出"}, []string{"处理完成"}},
+		{logLevelError, []string{"✗ 任务 3 失败", "stderr输出"}, []string{"处理完成", "跳过片段"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.level, func(t *testing.T) {
+			logFile = filepath.Join(t.TempDir(), "run.log")
+			logLevel = tt.level
+			logQuiet = true
+			defer func() { logFile, logLevel, logQuiet = "", logLevelInfo, false }()
+
+			if err := startLogFile(); err != nil {
+				t.Fatal(err)
+			}
+			fmt.Println("处理完成: 成功 1, 失败 0")
+			service.Warnf("⚠️  跳过片段\n")
+			service.Failf("✗ 任务 3 失败\n")
+			fmt.Fprintln(os.Stderr, "stderr输出")
+			stopLogFile()
+
+			data, err := os.ReadFile(logFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			log := string(data)
+			if strings.Contains(log, "\x01") {
+				t.Errorf("日志中残留级别标记:\n%s", log)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(log, s) {
+					t.Errorf("日志缺少 %q:\n%s", s, log)
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(log, s) {
+					t.Errorf("日志不应包含 %q:\n%s", s, log)
+				}
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/difyz9/markdown2tts/model"
 	"github.com/difyz9/markdown2tts/service"
 	"os"
 	"path/filepath"
@@ -12,14 +13,18 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
 
 var (
-	inputDir    string
-	outputFile  string
-	audioFormat string
+	inputDir      string
+	mergeListFile string
+	outputFile    string
+	audioFormat   string
+	mergeMaxFile  time.Duration
+	mergeSilence  float64
 )
 
 // mergeCmd represents the merge command
@@ -32,15 +37,28 @@ var mergeCmd = &cobra.Command{
 - audio_001.mp3, audio_002.mp3, audio_010.mp3
 - sound1.wav, sound2.wav, sound10.wav
 
+也可以用 --list 指定文件清单（每行一个音频路径，按给定顺序合并，
+# 开头的行为注释，相对路径相对于清单文件所在目录），与 --input 二选一。
+
 支持的音频格式：mp3, wav, m4a等
 
+--max-file-duration 限制单个输出文件的时长，超出时在片段边界切分为
+merged-1.mp3、merged-2.mp3 等分卷（时长按MP3帧/WAV数据块实测）。
+
+--silence 在相邻文件之间插入静音（秒），支持WAV和MP3文件。
+
 示例:
-  markdown2tts merge --input ./temp --output merged.mp3
-  markdown2tts merge --input ./audio_files --output final.wav`,
+  {{.Program}} merge --input ./temp --output merged.mp3
+  {{.Program}} merge --input ./audio_files --output final.wav
+  {{.Program}} merge --list files.txt --output merged.mp3
+  {{.Program}} merge --input ./temp --output book.mp3 --max-file-duration 2h
+  {{.Program}} merge --input ./temp --output merged.mp3 --silence 0.5
+  {{.Program}} merge --input ./temp --output merged.mp3 --checksum`,
 	Run: func(cmd *cobra.Command, args []string) {
 		err := runMerge()
 		if err != nil {
-			fmt.Printf("错误: %v\n", err)
+			service.Failf("错误: %v\n", err)
+			stopLogFile() // 退出前写完日志文件
 			os.Exit(1)
 		}
 	},
@@ -48,16 +66,74 @@ var mergeCmd = &cobra.Command{
 
 func runMerge() error {
 	// 验证输入参数
-	if inputDir == "" {
-		return fmt.Errorf("请指定输入目录 --input")
+	if (inputDir == "") == (mergeListFile == "") {
+		return fmt.Errorf("请通过 --input 指定输入目录或通过 --list 指定文件清单（二选一）")
 	}
 	if outputFile == "" {
 		return fmt.Errorf("请指定输出文件 --output")
 	}
 
+	// 创建音频合并服务
+	mergeService := service.NewAudioMergeOnlyService()
+	if mergeSilence > 0 {
+		mergeConfig := &model.Config{}
+		mergeConfig.Audio.SilenceDuration = mergeSilence
+		mergeService.SetMerger(service.NewAudioMerger(mergeConfig))
+	}
+
+	var filePaths []string
+	var err error
+	if mergeListFile != "" {
+		filePaths, err = listedAudioFiles(mergeService)
+	} else {
+		filePaths, err = scannedAudioFiles()
+	}
+	if err != nil {
+		return err
+	}
+
+	// 合并音频文件
+	fmt.Println("开始合并音频文件...")
+	if mergeMaxFile > 0 {
+		outputs, err := mergeService.MergeAudioFilesInVolumes(filePaths, outputFile, mergeMaxFile)
+		if err != nil {
+			return fmt.Errorf("合并音频文件失败: %v", err)
+		}
+		fmt.Printf("✅ 音频合并完成: 共 %d 个文件\n", len(outputs))
+		for _, output := range outputs {
+			fmt.Printf("   %s\n", output)
+		}
+		writeMergeChecksums(outputs)
+		return nil
+	}
+
+	err = mergeService.MergeAudioFiles(filePaths, outputFile)
+	if err != nil {
+		return fmt.Errorf("合并音频文件失败: %v", err)
+	}
+
+	fmt.Printf("✅ 音频合并完成: %s\n", outputFile)
+	writeMergeChecksums([]string{outputFile})
+	return nil
+}
+
+// writeMergeChecksums 按 --checksum 为合并结果写出SHA256校验和
+func writeMergeChecksums(outputs []string) {
+	if !checksumOutput {
+		return
+	}
+	for _, output := range outputs {
+		if _, err := service.WriteChecksum(output); err != nil {
+			service.Warnf("⚠️  %v\n", err)
+		}
+	}
+}
+
+// scannedAudioFiles 扫描输入目录并按文件名数字顺序返回音频文件
+func scannedAudioFiles() ([]string, error) {
 	// 检查输入目录是否存在
 	if _, err := os.Stat(inputDir); os.IsNotExist(err) {
-		return fmt.Errorf("输入目录不存在: %s", inputDir)
+		return nil, fmt.Errorf("输入目录不存在: %s", inputDir)
 	}
 
 	fmt.Printf("合并配置:\n")
@@ -67,17 +143,14 @@ func runMerge() error {
 	fmt.Printf("- 音频格式: %s\n", audioFormat)
 	fmt.Println()
 
-	// 创建音频合并服务
-	mergeService := service.NewAudioMergeOnlyService()
-
 	// 扫描并收集音频文件
 	audioFiles, err := scanAudioFiles(inputDir)
 	if err != nil {
-		return fmt.Errorf("扫描音频文件失败: %v", err)
+		return nil, fmt.Errorf("扫描音频文件失败: %v", err)
 	}
 
 	if len(audioFiles) == 0 {
-		return fmt.Errorf("在目录 %s 中没有找到音频文件", inputDir)
+		return nil, fmt.Errorf("在目录 %s 中没有找到音频文件", inputDir)
 	}
 
 	fmt.Printf("找到 %d 个音频文件\n", len(audioFiles))
@@ -97,16 +170,58 @@ func runMerge() error {
 	for i, file := range audioFiles {
 		filePaths[i] = file.Path
 	}
+	return filePaths, nil
+}
 
-	// 合并音频文件
-	fmt.Println("开始合并音频文件...")
-	err = mergeService.MergeAudioFiles(filePaths, outputFile)
+// listedAudioFiles 读取 --list 文件清单，按给定顺序返回并校验每个音频文件
+func listedAudioFiles(mergeService *service.AudioMergeOnlyService) ([]string, error) {
+	filePaths, err := readAudioFileList(mergeListFile)
 	if err != nil {
-		return fmt.Errorf("合并音频文件失败: %v", err)
+		return nil, err
+	}
+	if len(filePaths) == 0 {
+		return nil, fmt.Errorf("文件清单 %s 中没有音频文件", mergeListFile)
 	}
 
-	fmt.Printf("✅ 音频合并完成: %s\n", outputFile)
-	return nil
+	fmt.Printf("合并配置:\n")
+	fmt.Printf("- 文件清单: %s\n", mergeListFile)
+	fmt.Printf("- 输出文件: %s\n", outputFile)
+	fmt.Printf("- 排序方式: 按清单顺序\n")
+	fmt.Println()
+
+	// 校验文件存在、格式受支持且可读
+	if err := mergeService.ValidateAudioFiles(filePaths); err != nil {
+		return nil, fmt.Errorf("文件清单校验失败: %v", err)
+	}
+
+	fmt.Println("音频文件列表（按清单顺序）:")
+	for i, path := range filePaths {
+		fmt.Printf("%d. %s\n", i+1, path)
+	}
+	fmt.Println()
+	return filePaths, nil
+}
+
+// readAudioFileList 读取文件清单：每行一个路径，忽略空行和 # 注释，相对路径基于清单所在目录
+func readAudioFileList(listPath string) ([]string, error) {
+	data, err := os.ReadFile(listPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件清单失败: %v", err)
+	}
+
+	baseDir := filepath.Dir(listPath)
+	var filePaths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !filepath.IsAbs(line) {
+			line = filepath.Join(baseDir, line)
+		}
+		filePaths = append(filePaths, line)
+	}
+	return filePaths, nil
 }
 
 // AudioFileInfo 音频文件信息
@@ -214,11 +329,13 @@ func init() {
 	rootCmd.AddCommand(mergeCmd)
 
 	// 添加命令行参数
-	mergeCmd.Flags().StringVarP(&inputDir, "input", "i", "", "输入目录路径（必需）")
+	mergeCmd.Flags().StringVarP(&inputDir, "input", "i", "", "输入目录路径（与 --list 二选一）")
+	mergeCmd.Flags().StringVar(&mergeListFile, "list", "", "音频文件清单，每行一个路径，按给定顺序合并（与 --input 二选一）")
 	mergeCmd.Flags().StringVarP(&outputFile, "output", "o", "", "输出文件路径（必需）")
 	mergeCmd.Flags().StringVar(&audioFormat, "format", "mp3", "音频格式 (mp3, wav, m4a等)")
+	mergeCmd.Flags().DurationVar(&mergeMaxFile, "max-file-duration", 0, "单个输出文件的最长时长（如 2h），超出时在片段边界切分为 -1、-2 分卷")
+	mergeCmd.Flags().Float64Var(&mergeSilence, "silence", 0, "相邻文件之间插入的静音时长（秒），支持WAV和MP3")
 
 	// 标记必需参数
-	mergeCmd.MarkFlagRequired("input")
 	mergeCmd.MarkFlagRequired("output")
 }
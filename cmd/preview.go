@@ -0,0 +1,88 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/difyz9/markdown2tts/service"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var previewConfigFile string
+var previewInputFile string
+var previewSmartMarkdown bool
+var previewNoColor bool
+
+// previewCmd represents the preview command
+var previewCmd = &cobra.Command{
+	Use:   "preview",
+	Short: "预览文本清洗结果（不调用TTS）",
+	Long: `预览输入文件经过文本处理后实际送去合成的内容，不调用任何TTS服务。
+
+逐行模式下为每行显示原文与处理后文本的差异：删除部分红色、新增部分绿色；
+输出不是终端时退化为纯文本标记 [-删除-]{+新增+}。
+Markdown文件（或 --smart-markdown）显示智能解析后的句子列表。
+
+示例:
+  {{.Program}} preview -i input.txt
+  {{.Program}} preview -i document.md
+  {{.Program}} preview -i input.txt --no-color > preview.txt`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runPreview(cmd)
+		if err != nil {
+			service.Failf("错误: %v\n", err)
+		}
+	},
+}
+
+func runPreview(cmd *cobra.Command) error {
+	if previewInputFile == "" {
+		return fmt.Errorf("请指定输入文件 --input")
+	}
+
+	// 配置文件存在时使用其中的文本处理选项
+	config, err := loadTextConfig(previewConfigFile)
+	if err != nil {
+		return err
+	}
+	tp := service.NewTextProcessorFromConfig(config)
+
+	// 自动检测Markdown文件
+	if !cmd.Flags().Changed("smart-markdown") {
+		ext := strings.ToLower(filepath.Ext(previewInputFile))
+		previewSmartMarkdown = ext == ".md" || ext == ".markdown"
+	}
+
+	if previewSmartMarkdown {
+		sentences, err := tp.ProcessMarkdownFile(previewInputFile)
+		if err != nil {
+			return err
+		}
+		for i, sentence := range sentences {
+			fmt.Printf("%4d  %s\n", i+1, sentence)
+		}
+		fmt.Printf("\n📊 预览统计: 共提取 %d 个句子\n", len(sentences))
+		return nil
+	}
+
+	lines, err := tp.PreviewTextFile(previewInputFile)
+	if err != nil {
+		return err
+	}
+	service.PrintPreview(os.Stdout, lines, !previewNoColor && service.IsColorTerminal())
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(previewCmd)
+
+	previewCmd.Flags().StringVarP(&previewConfigFile, "config", "c", "", "配置文件路径（默认自动查找config.yaml）")
+	previewCmd.Flags().StringVarP(&previewInputFile, "input", "i", "", "输入文本文件路径")
+	previewCmd.Flags().BoolVar(&previewSmartMarkdown, "smart-markdown", false, "使用智能Markdown模式预览（.md文件自动启用）")
+	previewCmd.Flags().BoolVar(&previewNoColor, "no-color", false, "禁用颜色高亮")
+}
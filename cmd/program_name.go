@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// programPlaceholder 帮助文本中的程序名占位符，执行前替换为根命令名称
+const programPlaceholder = "{{.Program}}"
+
+// expandProgramName 把命令树帮助文本中的程序名占位符替换为根命令名称
+// 示例统一从 rootCmd.Use 派生，改名时不会留下错误的命令
+func expandProgramName(cmd *cobra.Command) {
+	name := cmd.Root().Name()
+	cmd.Long = strings.ReplaceAll(cmd.Long, programPlaceholder, name)
+	cmd.Example = strings.ReplaceAll(cmd.Example, programPlaceholder, name)
+	for _, sub := range cmd.Commands() {
+		expandProgramName(sub)
+	}
+}
@@ -8,7 +8,11 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/difyz9/markdown2tts/model"
+	"github.com/difyz9/markdown2tts/service"
 	"github.com/spf13/cobra"
 )
 
@@ -19,6 +23,25 @@ var (
 	appGitCommit = "unknown"
 )
 
+// 资源限制标志
+var (
+	maxProcs      int
+	maxGoroutines int
+	maxDuration   time.Duration
+)
+
+// useSegmentCache 启用片段缓存（--cache）
+var useSegmentCache bool
+
+// bundleOutput 处理完成后打包结果（--bundle）
+var bundleOutput bool
+
+// checksumOutput 合并后写出输出文件的SHA256校验和（--checksum）
+var checksumOutput bool
+
+// priorityLines 优先合成的输入行号，watch 模式下为刚修改的行
+var priorityLines []int
+
 // SetVersionInfo 设置版本信息
 func SetVersionInfo(version, buildTime, gitCommit string) {
 	appVersion = version
@@ -31,7 +54,7 @@ func SetVersionInfo(version, buildTime, gitCommit string) {
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "markdown2tts",
+	Use:   service.ProgramName,
 	Short: "🎵 TTS语音合成应用 - 支持双引擎、并发处理的高性能文本转语音工具",
 	Long: `🎵 TTS语音合成应用
 
@@ -47,16 +70,21 @@ var rootCmd = &cobra.Command{
 
 🚀 快速开始：
   # 初始化配置（新用户）
-  markdown2tts init
+  {{.Program}} init
   
   # 免费转换（推荐）
-  markdown2tts edge -i input.txt
+  {{.Program}} edge -i input.txt
   
   # 企业用户
-  markdown2tts tts -i input.txt
+  {{.Program}} tts -i input.txt
+  
+  # 按配置 default_provider 自动选择服务
+  {{.Program}} run -i input.md
   
   # 查看语音选项  
-  markdown2tts edge --list zh📚 更多信息：https://github.com/difyz9/markdown2tts`,
+  {{.Program}} edge --list zh
+
+📚 更多信息：https://github.com/difyz9/markdown2tts`,
 	Version: getVersionString(),
 }
 
@@ -68,10 +96,86 @@ func getVersionString() string {
 	return fmt.Sprintf("%s (commit: %s, built: %s)", appVersion, appGitCommit, appBuildTime)
 }
 
+// applyResourceFlags 用命令行标志覆盖资源限制配置并生效
+func applyResourceFlags(config *model.Config) {
+	if maxProcs > 0 {
+		config.Concurrent.MaxProcs = maxProcs
+	}
+	if maxGoroutines > 0 {
+		config.Concurrent.MaxGoroutines = maxGoroutines
+	}
+	if maxDuration > 0 {
+		config.Concurrent.MaxDuration = maxDuration
+	}
+	service.ApplyResourceLimits(config)
+}
+
+// applySegmentCache 启用 --cache 且配置未指定 audio.cache_dir 时，把缓存放在临时目录的 cache/ 子目录
+func applySegmentCache(config *model.Config) {
+	if useSegmentCache && config.Audio.CacheDir == "" {
+		config.Audio.CacheDir = filepath.Join(config.Audio.TempDir, "cache")
+	}
+}
+
+// applyChecksum 启用 --checksum 时为合并输出写出校验和
+func applyChecksum(config *model.Config) {
+	if checksumOutput {
+		config.Audio.Checksum = true
+	}
+}
+
+// applyPriorityLines watch 模式检测到修改时，让刚修改的行优先合成
+func applyPriorityLines(config *model.Config) {
+	if len(priorityLines) > 0 {
+		config.Concurrent.PriorityLines = priorityLines
+	}
+}
+
+// writeRunBundle 按 --bundle 把本次处理的结果、配置快照和日志打包为zip，打包失败不影响处理结果
+// 返回写出的打包文件及其校验和文件，未打包时返回nil
+func writeRunBundle(config *model.Config, provider string, start time.Time) []string {
+	if !bundleOutput {
+		return nil
+	}
+	path, err := service.WriteBundle(config, service.BundleOptions{
+		Provider: provider,
+		Version:  appVersion,
+		Since:    start,
+		LogFile:  logFile,
+	})
+	if err != nil {
+		service.Warnf("⚠️  打包失败: %v\n", err)
+		return nil
+	}
+	fmt.Printf("📦 已打包处理结果: %s\n", path)
+	outputs := []string{path}
+	if config.Audio.Checksum {
+		if _, err := service.WriteChecksum(path); err != nil {
+			service.Warnf("⚠️  %v\n", err)
+		} else {
+			outputs = append(outputs, service.ChecksumPath(path))
+		}
+	}
+	return outputs
+}
+
+// applyStreamOutput 应用流式输出目标（命令行优先于配置）
+// 输出到stdout时把后续日志改写到stderr，避免与音频数据混在一起
+func applyStreamOutput(config *model.Config, target string) {
+	if target != "" {
+		config.Audio.StreamOutput = target
+	}
+	if config.Audio.StreamOutput == service.StreamToStdout {
+		redirectLogsToStderr()
+	}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
+	expandProgramName(rootCmd)
 	err := rootCmd.Execute()
+	stopLogFile()
 	if err != nil {
 		os.Exit(1)
 	}
@@ -85,6 +189,20 @@ func init() {
 	// 全局标志
 	rootCmd.PersistentFlags().BoolP("help", "h", false, "显示帮助信息")
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "显示版本信息")
+	rootCmd.PersistentFlags().IntVar(&maxProcs, "max-procs", 0, "限制GOMAXPROCS（受限容器环境使用）")
+	rootCmd.PersistentFlags().IntVar(&maxGoroutines, "max-goroutines", 0, "限制worker goroutine总数")
+	rootCmd.PersistentFlags().DurationVar(&maxDuration, "max-duration", 0, "处理时长预算（如 10m），超时后停止提交新任务并合并已完成部分")
+	rootCmd.PersistentFlags().BoolVar(&useSegmentCache, "cache", false, "复用已合成的片段（默认缓存到 临时目录/cache，可用 audio.cache_dir 指定），只合成改动的句子")
+	rootCmd.PersistentFlags().BoolVar(&bundleOutput, "bundle", false, "处理完成后把最终音频、时间轴、配置快照（隐藏密钥）、输入和日志打包为 输出目录/<输出名>.bundle.zip")
+	rootCmd.PersistentFlags().BoolVar(&checksumOutput, "checksum", false, "合并后计算输出文件（及打包文件）的SHA256，写入同名 .sha256 文件")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "同时把进度/统计/错误写入日志文件（每行带时间戳）")
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", logLevelInfo, "日志文件记录级别: info(全部)/warn(警告和错误)/error(仅错误)")
+	rootCmd.PersistentFlags().BoolVar(&logQuiet, "quiet", false, "配合 --log-file 使用，终端不再输出")
+
+	// 执行子命令前开启日志文件
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		return startLogFile()
+	}
 
 	// 设置帮助标志不显示在使用说明中
 	rootCmd.PersistentFlags().MarkHidden("help")
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/service"
+
+	"github.com/spf13/cobra"
+)
+
+var runConfigFile string
+var runInputFile string
+var runOutputDir string
+var runProvider string
+var runSmartMarkdown bool
+
+// runCmd represents the run command
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "按配置的默认TTS服务合成语音",
+	Long: `根据配置文件中的 default_provider 选择TTS服务进行合成，无需记住不同服务的命令。
+
+default_provider 可选 tencent（等同 tts 命令）或 edge（等同 edge 命令），
+未配置时默认使用 edge。--provider 可临时覆盖配置。
+需要服务专属参数（如音色、语速）时请直接使用 tts/edge 命令。
+
+示例:
+  {{.Program}} run -i input.md
+  {{.Program}} run -i input.txt -o output
+  {{.Program}} run -i input.md --provider tencent`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runDefaultProvider(cmd)
+		if err != nil {
+			service.Failf("错误: %v\n", err)
+		}
+	},
+}
+
+// resolveRunProvider 按 --provider、配置文件的顺序确定使用的TTS服务
+func resolveRunProvider() (string, error) {
+	provider := runProvider
+	if provider == "" {
+		configPath := runConfigFile
+		if configPath == "" {
+			configPath = service.DefaultConfigPath()
+		}
+		if config, err := service.LoadConfigFile(configPath); err == nil {
+			provider = config.DefaultProvider
+		}
+	}
+
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	switch provider {
+	case "":
+		return service.ProviderEdge, nil
+	case service.ProviderTencent, service.ProviderEdge:
+		return provider, nil
+	default:
+		return "", fmt.Errorf("未知的TTS服务: %s（可选: %s, %s）", provider, service.ProviderTencent, service.ProviderEdge)
+	}
+}
+
+func runDefaultProvider(cmd *cobra.Command) error {
+	provider, err := resolveRunProvider()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🧭 使用TTS服务: %s\n", provider)
+
+	// 复用专用命令的执行流程，run 自身同样定义了 smart-markdown 标志
+	if provider == service.ProviderTencent {
+		configFile = runConfigFile
+		inputFile = runInputFile
+		outputDir = runOutputDir
+		ttsSmartMarkdown = runSmartMarkdown
+		return runTTS(cmd)
+	}
+
+	edgeConfigFile = runConfigFile
+	edgeInputFile = runInputFile
+	edgeOutputDir = runOutputDir
+	edgeSmartMarkdown = runSmartMarkdown
+	return runEdgeTTS(cmd)
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+
+	runCmd.Flags().StringVarP(&runConfigFile, "config", "c", "", "配置文件路径（默认自动查找config.yaml）")
+	runCmd.Flags().StringVarP(&runInputFile, "input", "i", "", "输入文本文件路径")
+	runCmd.Flags().StringVarP(&runOutputDir, "output", "o", "", "输出目录路径（默认为./output）")
+	runCmd.Flags().StringVar(&runProvider, "provider", "", "临时指定TTS服务: tencent/edge（默认读取配置 default_provider）")
+	runCmd.Flags().BoolVar(&runSmartMarkdown, "smart-markdown", false, "启用智能Markdown处理模式（推荐用于.md文件）")
+}
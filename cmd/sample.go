@@ -0,0 +1,137 @@
+/*
+Copyright © 2025 NAME HERE <EMAIL ADDRESS>
+*/
+package cmd
+
+import (
+	"fmt"
+	"github.com/difyz9/markdown2tts/service"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var sampleConfigFile string
+var sampleProvider string
+var sampleVoices string
+var sampleText string
+var sampleOutputDir string
+
+// sampleCmd represents the sample command
+var sampleCmd = &cobra.Command{
+	Use:   "sample",
+	Short: "用同一段文本批量试听多个音色",
+	Long: `对同一段文本分别使用多个音色合成，每个音色输出一个独立文件，方便对比选型。
+
+腾讯云音色可使用数字ID或别名（如 zhiqi、智琪），Edge音色使用语音名称。
+
+示例:
+  {{.Program}} sample --voices zh-CN-XiaoyiNeural,zh-CN-YunxiNeural --text "你好"
+  {{.Program}} sample --provider tencent --voices zhiqi,101004 --text "你好"
+  {{.Program}} sample --voices zh-CN-XiaoxiaoNeural,zh-CN-YunjianNeural -o ./samples`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runSample()
+		if err != nil {
+			service.Failf("错误: %v\n", err)
+		}
+	},
+}
+
+// unsafeSampleNameRegex 试听文件名中不允许的字符
+var unsafeSampleNameRegex = regexp.MustCompile(`[^\p{L}\p{N}_.-]+`)
+
+func runSample() error {
+	var voiceList []string
+	for _, voice := range strings.Split(sampleVoices, ",") {
+		if voice = strings.TrimSpace(voice); voice != "" {
+			voiceList = append(voiceList, voice)
+		}
+	}
+	if len(voiceList) == 0 {
+		return fmt.Errorf("请通过 --voices 指定至少一个音色，多个音色用逗号分隔")
+	}
+	if strings.TrimSpace(sampleText) == "" {
+		return fmt.Errorf("试听文本不能为空")
+	}
+
+	if sampleConfigFile == "" {
+		sampleConfigFile = service.DefaultConfigPath()
+	}
+	configService, err := service.NewConfigService(sampleConfigFile)
+	if err != nil {
+		return fmt.Errorf("加载配置失败: %v", err)
+	}
+	config := configService.GetConfig()
+
+	outputDir := sampleOutputDir
+	if outputDir == "" {
+		outputDir = filepath.Join(config.Audio.OutputDir, "samples")
+	}
+	if err := service.EnsureDir(outputDir); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	// 按provider准备合成函数
+	var synthesize func(voice, outputPath string) error
+	ext := ".mp3"
+	switch sampleProvider {
+	case service.ProviderEdge:
+		edgeService := service.NewEdgeTTSService(config)
+		synthesize = func(voice, outputPath string) error {
+			return edgeService.SynthesizeSample(sampleText, voice, outputPath)
+		}
+	case service.ProviderTencent:
+		if _, err := service.ResolveTencentCredentials(config, "", ""); err != nil {
+			return err
+		}
+		ttsService, err := service.NewTTSServiceFromConfig(config)
+		if err != nil {
+			return fmt.Errorf("创建TTS服务失败: %v", err)
+		}
+		if config.TTS.Codec != "" {
+			ext = "." + config.TTS.Codec
+		}
+		concurrentAudioService := service.NewConcurrentAudioService(config, ttsService)
+		synthesize = func(voice, outputPath string) error {
+			voiceType, err := service.ResolveTencentVoice(voice)
+			if err != nil {
+				return err
+			}
+			return concurrentAudioService.SynthesizeSample(sampleText, voiceType, outputPath)
+		}
+	default:
+		return fmt.Errorf("不支持的provider: %s（可选: %s, %s）", sampleProvider, service.ProviderEdge, service.ProviderTencent)
+	}
+
+	fmt.Printf("🎧 使用 %s 为 %d 个音色生成试听: %s\n\n", sampleProvider, len(voiceList), sampleText)
+
+	failed := 0
+	for i, voice := range voiceList {
+		name := unsafeSampleNameRegex.ReplaceAllString(voice, "_")
+		outputPath := filepath.Join(outputDir, fmt.Sprintf("sample_%02d_%s%s", i+1, name, ext))
+		if err := synthesize(voice, outputPath); err != nil {
+			failed++
+			service.Failf("✗ %s: %v\n", voice, err)
+			continue
+		}
+		fmt.Printf("✓ %s → %s\n", voice, outputPath)
+	}
+
+	fmt.Printf("\n试听生成完成: 成功 %d, 失败 %d\n", len(voiceList)-failed, failed)
+	if failed == len(voiceList) {
+		return fmt.Errorf("所有音色试听均生成失败")
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(sampleCmd)
+
+	sampleCmd.Flags().StringVarP(&sampleConfigFile, "config", "c", "", "配置文件路径（默认自动查找config.yaml）")
+	sampleCmd.Flags().StringVar(&sampleProvider, "provider", service.ProviderEdge, "TTS服务: edge 或 tencent")
+	sampleCmd.Flags().StringVar(&sampleVoices, "voices", "", "要试听的音色列表，逗号分隔")
+	sampleCmd.Flags().StringVar(&sampleText, "text", "你好，这是一段音色试听样例。", "试听文本")
+	sampleCmd.Flags().StringVarP(&sampleOutputDir, "output", "o", "", "输出目录（默认为 输出目录/samples）")
+}
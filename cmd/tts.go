@@ -5,9 +5,11 @@ package cmd
 
 import (
 	"fmt"
+	"github.com/difyz9/markdown2tts/model"
 	"github.com/difyz9/markdown2tts/service"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -15,7 +17,19 @@ import (
 var configFile string
 var inputFile string
 var outputDir string
-var ttsSmartMarkdown bool // 新增：智能Markdown模式
+var ttsSmartMarkdown bool            // 新增：智能Markdown模式
+var ttsKeepSegments bool             // 保留每句音频片段
+var ttsResume bool                   // 断点续传：跳过已完成的任务
+var ttsMaxFileDuration time.Duration // 单个输出文件最长时长
+var ttsInputType string              // 输入类型：auto/markdown/plain
+var ttsStream string                 // 流式输出目标：命名管道路径或 "-"
+var ttsText string                   // 直接合成的单段文本
+var ttsSplit bool                    // 按章节拆分输出
+var ttsSecretID string               // 命令行指定的腾讯云SecretId
+var ttsSecretKey string              // 命令行指定的腾讯云SecretKey
+var ttsVoice string                  // 覆盖音色：数字ID或别名
+var ttsSpeed float64                 // 覆盖语速
+var ttsVolume int64                  // 覆盖音量
 
 // ttsCmd represents the tts command
 var ttsCmd = &cobra.Command{
@@ -27,24 +41,37 @@ var ttsCmd = &cobra.Command{
 当输入文件为Markdown格式（.md或.markdown）时，自动启用智能Markdown处理模式。
 
 示例:
-  markdown2tts tts                                    # 使用默认配置
-  markdown2tts tts -i input.txt                       # 指定输入文件
-  markdown2tts tts -i document.md                     # 自动启用智能Markdown模式
-  markdown2tts tts -i input.txt -o /path/to/output   # 指定输入和输出
-  markdown2tts tts --config custom.yaml              # 使用自定义配置
+  {{.Program}} tts                                    # 使用默认配置
+  {{.Program}} tts -i input.txt                       # 指定输入文件
+  {{.Program}} tts -i document.md                     # 自动启用智能Markdown模式
+  {{.Program}} tts -i book.md --split                  # 按章节标题拆分为多个音频文件
+  {{.Program}} tts --text "你好，世界"                  # 直接合成一句文本
+  {{.Program}} tts -i input.txt --voice zhiqi --speed 1 --volume 8   # 临时调整音色/语速/音量
+  {{.Program}} tts -i input.txt --stream - | ffplay -i -   # 边合成边播放
+  {{.Program}} tts -i input.txt -o /path/to/output   # 指定输入和输出
+  {{.Program}} tts --config custom.yaml              # 使用自定义配置
   `,
 	Run: func(cmd *cobra.Command, args []string) {
 		err := runTTS(cmd)
 		if err != nil {
-			fmt.Printf("错误: %v\n", err)
+			service.Failf("错误: %v\n", err)
 		}
 	},
 }
 
 func runTTS(cmd *cobra.Command) error {
+	if ttsText != "" && inputFile != "" {
+		return fmt.Errorf("--text 与 -i/--input 不能同时使用")
+	}
+
+	// 音频流输出到stdout时，配置加载等日志也不能写入stdout
+	if ttsStream == service.StreamToStdout {
+		redirectLogsToStderr()
+	}
+
 	// 如果没有指定配置文件，尝试默认位置
 	if configFile == "" {
-		configFile = "config.yaml"
+		configFile = service.DefaultConfigPath()
 	}
 
 	// 加载配置（如果配置文件不存在会自动初始化）
@@ -76,20 +103,70 @@ func runTTS(cmd *cobra.Command) error {
 		config.Audio.OutputDir = outputDir
 	}
 
-	// 验证配置
-	if config.TencentCloud.SecretID == "your_secret_id" || config.TencentCloud.SecretKey == "your_secret_key" {
-		return fmt.Errorf("请在配置文件中设置正确的腾讯云SecretID和SecretKey")
+	// 按provider切换到独立的输出/临时子目录（如已配置）
+	service.ApplyProviderDirs(config, service.ProviderTencent)
+
+	// 分章输出（仅智能Markdown模式支持）
+	if ttsSplit {
+		config.Audio.Split = true
+	}
+	if ttsKeepSegments {
+		config.Audio.KeepSegments = true
+	}
+	if ttsResume {
+		config.Concurrent.Resume = true
+	}
+	if ttsMaxFileDuration > 0 {
+		config.Audio.MaxFileDuration = ttsMaxFileDuration
+	}
+	// 输入类型：命令行优先，智能Markdown模式下按Markdown处理
+	if ttsInputType != "" {
+		config.Markdown.InputType = ttsInputType
+	} else if ttsSmartMarkdown && config.Markdown.InputType == "" {
+		config.Markdown.InputType = service.InputTypeMarkdown
+	}
+	if config.Audio.Split && !ttsSmartMarkdown {
+		service.Warnf("⚠️  分章输出仅支持智能Markdown模式，将输出单个文件\n")
+		config.Audio.Split = false
+	}
+	if config.Audio.MaxFileDuration > 0 && (config.Audio.Split || !ttsSmartMarkdown) {
+		service.Warnf("⚠️  按时长切分输出仅支持智能Markdown模式且不能与分章输出同时使用，将忽略 max_file_duration\n")
+		config.Audio.MaxFileDuration = 0
+	}
+
+	// 应用CPU/goroutine资源限制
+	applyResourceFlags(config)
+	applyStreamOutput(config, ttsStream)
+	applySegmentCache(config)
+	applyChecksum(config)
+	applyPriorityLines(config)
+
+	// 如果指定了音色参数，覆盖配置
+	if err := applyTTSVoiceFlags(cmd, config); err != nil {
+		return err
 	}
 
+	// 解析音色名称别名（如 zhiqi、智琪）
+	if err := service.ApplyTencentVoice(config); err != nil {
+		return err
+	}
+
+	// 确定腾讯云密钥：命令行 > 环境变量 > 凭证文件 > 配置文件
+	source, err := service.ResolveTencentCredentials(config, ttsSecretID, ttsSecretKey)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("🔑 腾讯云密钥来源: %s\n", source)
+
 	// 创建TTS服务
-	ttsService := service.NewTTSService(
-		config.TencentCloud.SecretID,
-		config.TencentCloud.SecretKey,
-		config.TencentCloud.Region,
-	)
+	ttsService, err := service.NewTTSServiceFromConfig(config)
+	if err != nil {
+		return fmt.Errorf("创建TTS服务失败: %v", err)
+	}
 
-	if ttsService == nil {
-		return fmt.Errorf("创建TTS服务失败")
+	// 直接合成命令行传入的文本，不读取输入文件
+	if ttsText != "" {
+		return runTTSText(config, ttsService)
 	}
 
 	// 检查输入文件路径
@@ -113,7 +190,7 @@ func runTTS(cmd *cobra.Command) error {
 	fmt.Printf("- 输入文件: %s\n", config.InputFile)
 	fmt.Printf("- 音色: %d\n", config.TTS.VoiceType)
 	fmt.Printf("- 语速: %.1f\n", config.TTS.Speed)
-	fmt.Printf("- 音量: %d\n", config.TTS.Volume)
+	fmt.Printf("- 音量: %d\n", service.TencentVolume(config))
 	fmt.Printf("- 输出目录: %s\n", config.Audio.OutputDir)
 	fmt.Printf("- 最终文件: %s\n", config.Audio.FinalOutput)
 	fmt.Printf("- 并发模式: 开启（默认）\n")
@@ -130,6 +207,7 @@ func runTTS(cmd *cobra.Command) error {
 
 	// 默认使用并发处理模式
 	concurrentAudioService := service.NewConcurrentAudioService(config, ttsService)
+	start := time.Now()
 
 	// 根据模式选择处理方法
 	if ttsSmartMarkdown {
@@ -145,6 +223,10 @@ func runTTS(cmd *cobra.Command) error {
 	}
 
 	fmt.Println("TTS转换和音频合并完成！")
+
+	// 按配置上传本次实际写出的音频、时间轴、校验和及打包文件（失败不影响本地产物）
+	outputs := append(concurrentAudioService.Outputs(), writeRunBundle(config, service.ProviderTencent, start)...)
+	service.UploadOutputs(config, outputs)
 	return nil
 }
 
@@ -162,4 +244,63 @@ func init() {
 
 	// 添加智能Markdown处理标志
 	ttsCmd.Flags().BoolVar(&ttsSmartMarkdown, "smart-markdown", false, "启用智能Markdown处理模式（推荐用于.md文件）")
+	ttsCmd.Flags().BoolVar(&ttsSplit, "split", false, "按一级/二级标题分章输出，文件以章节标题命名")
+	ttsCmd.Flags().DurationVar(&ttsMaxFileDuration, "max-file-duration", 0, "单个输出文件的最长预估时长（如 2h），超出时切分为多个编号文件，优先在章节边界切分")
+	ttsCmd.Flags().StringVar(&ttsInputType, "input-type", "", "输入类型: auto(按扩展名，.txt为纯文本)/markdown/plain")
+	ttsCmd.Flags().StringVar(&ttsStream, "stream", "", "边合成边按顺序把音频写入命名管道（\"-\" 为stdout，日志改写到stderr）")
+	ttsCmd.Flags().StringVar(&ttsText, "text", "", "直接合成指定文本（无需输入文件，与 -i 互斥）")
+	ttsCmd.Flags().BoolVar(&ttsKeepSegments, "keep-segments", false, "合并的同时把每句音频片段按序保留到输出目录的segments/子目录")
+	ttsCmd.Flags().BoolVar(&ttsResume, "resume", false, "断点续传：跳过上次运行已完成且文本未变的任务（按临时目录中的 progress.json）")
+
+	// 添加音色参数标志
+	ttsCmd.Flags().StringVar(&ttsVoice, "voice", "", "指定音色，数字ID或别名 (如: 101008, zhiqi, 智琪)")
+	ttsCmd.Flags().Float64Var(&ttsSpeed, "speed", 0, "语速 (如: 1.0，取值范围 -2 到 6)")
+	ttsCmd.Flags().Int64Var(&ttsVolume, "volume", 0, "音量 (1 到 10，5为正常)")
+
+	// 腾讯云密钥（优先于环境变量、凭证文件和配置文件）
+	ttsCmd.Flags().StringVar(&ttsSecretID, "secret-id", "", "腾讯云SecretId（优先级: 命令行 > 环境变量 > ~/.tencentcloud/credentials > 配置文件）")
+	ttsCmd.Flags().StringVar(&ttsSecretKey, "secret-key", "", "腾讯云SecretKey")
+}
+
+// applyTTSVoiceFlags 用命令行的 --voice/--speed/--volume 覆盖配置
+func applyTTSVoiceFlags(cmd *cobra.Command, config *model.Config) error {
+	if ttsVoice != "" {
+		if _, err := service.ResolveTencentVoice(ttsVoice); err != nil {
+			return err
+		}
+		config.TTS.Voice = ttsVoice
+	}
+	if cmd.Flags().Changed("speed") {
+		if ttsSpeed < -2 || ttsSpeed > 6 {
+			return fmt.Errorf("--speed 超出范围: %g（可选 -2 到 6）", ttsSpeed)
+		}
+		config.TTS.Speed = ttsSpeed
+	}
+	if cmd.Flags().Changed("volume") {
+		if ttsVolume < 1 || ttsVolume > 10 {
+			return fmt.Errorf("--volume 超出范围: %d（可选 1 到 10）", ttsVolume)
+		}
+		config.TTS.Volume = ttsVolume
+		config.Audio.VolumeScale = 0 // 命令行指定的原生音量优先于统一音量标度
+	}
+	return nil
+}
+
+// runTTSText 把 --text 传入的文本直接合成到输出文件
+func runTTSText(config *model.Config, ttsService *service.TTSService) error {
+	if err := service.EnsureDir(config.Audio.OutputDir); err != nil {
+		return fmt.Errorf("创建输出目录失败: %v", err)
+	}
+
+	outputPath := filepath.Join(config.Audio.OutputDir, config.Audio.FinalOutput)
+	fmt.Printf("🎙️  使用音色 %d 合成文本: %s\n", config.TTS.VoiceType, ttsText)
+
+	concurrentAudioService := service.NewConcurrentAudioService(config, ttsService)
+	if err := concurrentAudioService.SynthesizeSample(ttsText, config.TTS.VoiceType, outputPath); err != nil {
+		return fmt.Errorf("合成文本失败: %v", err)
+	}
+
+	fmt.Printf("✅ 音频已保存到: %s\n", outputPath)
+	service.UploadOutputs(config, []string{outputPath})
+	return nil
 }
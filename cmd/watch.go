@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/difyz9/markdown2tts/service"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+var watchConfigFile string
+var watchInputFile string
+var watchOutputDir string
+var watchProvider string
+var watchSmartMarkdown bool
+var watchDebounce time.Duration
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "监听输入文件，保存后自动重新合成",
+	Long: `先合成一次输入文件，之后持续监听文件变化，每次保存后自动重新合成。
+
+重新合成时启用片段缓存（同 --cache），文本和音色参数未变的句子直接复用，
+只合成改动的句子，适合边写边听。刚修改的行优先合成，可以尽快试听改动。
+连续保存时等文件稳定 --debounce 后才开始合成。
+TTS服务的选择与 run 命令相同，按 Ctrl-C 退出。
+
+示例:
+  {{.Program}} watch -i doc.md
+  {{.Program}} watch -i doc.md --provider tencent
+  {{.Program}} watch -i notes.txt --debounce 3s`,
+	Run: func(cmd *cobra.Command, args []string) {
+		err := runWatch(cmd)
+		if err != nil {
+			service.Failf("错误: %v\n", err)
+		}
+	},
+}
+
+// watchState 用于判断文件是否变化的状态
+type watchState struct {
+	modTime time.Time
+	size    int64
+}
+
+func statWatchFile(path string) (watchState, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return watchState{}, err
+	}
+	return watchState{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// readWatchLines 读取输入文件的各行，用于比较修改了哪些行
+func readWatchLines(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(string(data), "\n")
+}
+
+// changedLines 返回新内容中修改或新增的行号（从1开始），内容不变只是移动位置的行不算修改
+func changedLines(previous, current []string) []int {
+	seen := make(map[string]int, len(previous))
+	for _, line := range previous {
+		seen[strings.TrimSpace(line)]++
+	}
+
+	var changed []int
+	for i, line := range current {
+		text := strings.TrimSpace(line)
+		if seen[text] > 0 {
+			seen[text]--
+			continue
+		}
+		if text != "" {
+			changed = append(changed, i+1)
+		}
+	}
+	return changed
+}
+
+func runWatch(cmd *cobra.Command) error {
+	if watchInputFile == "" {
+		return fmt.Errorf("请用 -i 指定要监听的输入文件")
+	}
+	target, err := filepath.Abs(watchInputFile)
+	if err != nil {
+		return fmt.Errorf("解析输入文件路径失败: %v", err)
+	}
+	last, err := statWatchFile(target)
+	if err != nil {
+		return fmt.Errorf("读取输入文件失败: %v", err)
+	}
+
+	// 监听所在目录而不是文件本身：编辑器保存时常常先写临时文件再重命名，
+	// 直接监听文件会在第一次保存后失效
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建文件监听失败: %v", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(target)); err != nil {
+		return fmt.Errorf("监听目录失败: %v", err)
+	}
+
+	// 复用 run 命令的执行流程，并强制启用片段缓存
+	runConfigFile = watchConfigFile
+	runInputFile = watchInputFile
+	runOutputDir = watchOutputDir
+	runProvider = watchProvider
+	runSmartMarkdown = watchSmartMarkdown
+	useSegmentCache = true
+
+	// 空闲时 Ctrl-C 关闭日志文件后直接退出，已缓存的片段下次仍可复用
+	// 合成进行中时由本次处理自己响应 Ctrl-C（保存进度、清理临时文件），处理返回后再退出监听；再按一次立即退出
+	var running, quitting atomic.Bool
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	go func() {
+		for sig := range signals {
+			if sig == os.Interrupt && running.Load() && !quitting.Swap(true) {
+				fmt.Println("\n⏹  正在结束本次合成，完成后退出监听（再按 Ctrl-C 立即退出）...")
+				continue
+			}
+			fmt.Println("\n👋 已退出监听")
+			stopLogFile()
+			os.Exit(0)
+		}
+	}()
+
+	// synthesize 合成一次，合成期间收到 Ctrl-C 时返回false
+	synthesize := func() bool {
+		running.Store(true)
+		err := runDefaultProvider(cmd)
+		running.Store(false)
+		if err != nil {
+			service.Failf("错误: %v\n", err)
+		}
+		if quitting.Load() {
+			fmt.Println("\n👋 已退出监听")
+			return false
+		}
+		fmt.Printf("\n👀 正在监听 %s 的变化（Ctrl-C 退出）...\n", watchInputFile)
+		return true
+	}
+	lines := readWatchLines(target)
+	if !synthesize() {
+		return nil
+	}
+
+	// 每次写入都重置计时，文件稳定 debounce 后再重新合成
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != target || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+				continue
+			}
+			debounce.Reset(watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			service.Warnf("⚠️  文件监听出错: %v\n", err)
+
+		case <-debounce.C:
+			current, err := statWatchFile(target)
+			if err != nil || current == last {
+				continue // 文件被删除后等待重新出现；只改了权限等元数据时不重新合成
+			}
+
+			last = current
+			fmt.Printf("\n🔄 检测到 %s 已修改，重新合成...\n", watchInputFile)
+
+			// 刚修改的行优先合成
+			edited := readWatchLines(target)
+			priorityLines = changedLines(lines, edited)
+			lines = edited
+			if !synthesize() {
+				return nil
+			}
+		}
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(watchCmd)
+
+	watchCmd.Flags().StringVarP(&watchConfigFile, "config", "c", "", "配置文件路径（默认自动查找config.yaml）")
+	watchCmd.Flags().StringVarP(&watchInputFile, "input", "i", "", "要监听的输入文件路径")
+	watchCmd.Flags().StringVarP(&watchOutputDir, "output", "o", "", "输出目录路径（默认为./output）")
+	watchCmd.Flags().StringVar(&watchProvider, "provider", "", "临时指定TTS服务: tencent/edge（默认读取配置 default_provider）")
+	watchCmd.Flags().BoolVar(&watchSmartMarkdown, "smart-markdown", false, "启用智能Markdown处理模式（推荐用于.md文件）")
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", time.Second, "文件最后一次修改后等待多久再开始合成，避免连续保存时重复合成")
+}
@@ -3,6 +3,11 @@ module github.com/difyz9/markdown2tts
 go 1.23.4
 
 require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/difyz9/edge-tts-go v0.0.2
+	github.com/fsnotify/fsnotify v1.10.1
+	github.com/gorilla/websocket v1.5.3
+	github.com/russross/blackfriday/v2 v2.1.0
 	github.com/spf13/cobra v1.9.1
 	github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common v1.0.1209
 	github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tts v1.0.1209
@@ -11,10 +16,8 @@ require (
 )
 
 require (
-	github.com/difyz9/edge-tts-go v0.0.2 // indirect
 	github.com/google/uuid v1.6.0 // indirect
-	github.com/gorilla/websocket v1.5.3 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
+	golang.org/x/sys v0.13.0 // indirect
 )
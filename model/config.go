@@ -1,30 +1,49 @@
 package model
 
+import "time"
+
 // Config 总配置结构
 type Config struct {
-	TencentCloud TencentCloudConfig `yaml:"tencent_cloud"`
-	TTS          TTSConfig          `yaml:"tts"`
-	EdgeTTS      EdgeTTSConfig      `yaml:"edge_tts"`
-	Audio        AudioConfig        `yaml:"audio"`
-	Concurrent   ConcurrentConfig   `yaml:"concurrent"`
-	InputFile    string             `yaml:"input_file"`
+	TencentCloud    TencentCloudConfig       `yaml:"tencent_cloud"`
+	TTS             TTSConfig                `yaml:"tts"`
+	EdgeTTS         EdgeTTSConfig            `yaml:"edge_tts"`
+	Audio           AudioConfig              `yaml:"audio"`
+	Concurrent      ConcurrentConfig         `yaml:"concurrent"`
+	Markdown        MarkdownConfig           `yaml:"markdown"`
+	Upload          UploadConfig             `yaml:"upload,omitempty"`
+	Network         NetworkConfig            `yaml:"network,omitempty"`
+	Permissions     PermissionsConfig        `yaml:"permissions,omitempty"`
+	Speakers        map[string]SpeakerConfig `yaml:"speakers,omitempty"` // 对话脚本说话人音色映射，如 "A": {voice_type: 101001, voice: zh-CN-YunxiNeural}
+	InputFile       string                   `yaml:"input_file"`
+	DefaultProvider string                   `yaml:"default_provider,omitempty"` // run 命令默认使用的TTS服务: tencent/edge（默认edge）
+	MetricsFile     string                   `yaml:"metrics_file,omitempty"`     // 处理结束写出指标快照（请求数、失败、重试、平均延迟），.prom 为Prometheus文本格式，其他为JSON
 }
 
 // TencentCloudConfig 腾讯云配置
 type TencentCloudConfig struct {
-	SecretID  string `yaml:"secret_id"`
-	SecretKey string `yaml:"secret_key"`
-	Region    string `yaml:"region"`
+	SecretID   string        `yaml:"secret_id"`
+	SecretKey  string        `yaml:"secret_key"`
+	Region     string        `yaml:"region"`
+	Timeout    time.Duration `yaml:"timeout,omitempty"`     // 单次API请求超时，如 30s（默认60s）
+	MaxRetries int           `yaml:"max_retries,omitempty"` // SDK层限频和未发出请求的网络失败的重试次数（默认不重试），已发出的合成请求不在SDK层重发
 }
 
 // TTSConfig TTS音频参数配置
 type TTSConfig struct {
 	VoiceType       int64   `yaml:"voice_type"`
+	Voice           string  `yaml:"voice,omitempty"` // 音色名称别名（如 zhiqi、智琪），设置后覆盖voice_type
 	Volume          int64   `yaml:"volume"`
 	Speed           float64 `yaml:"speed"`
 	PrimaryLanguage int64   `yaml:"primary_language"`
 	SampleRate      int64   `yaml:"sample_rate"`
 	Codec           string  `yaml:"codec"`
+	Mode            string  `yaml:"mode,omitempty"` // 合成方式：auto(默认，150字以内用实时接口，更长用异步任务)/task(始终异步任务)/realtime(始终实时接口，非流式，一次返回完整音频)
+
+	// 以下为可选的高级参数，缺省不设置以保持兼容
+	EmotionCategory  string            `yaml:"emotion_category,omitempty"`  // 情感类型（仅多情感音色支持），如 neutral、sad、happy
+	EmotionIntensity int64             `yaml:"emotion_intensity,omitempty"` // 情感强度：50-200，默认100
+	Pronunciations   map[string]string `yaml:"pronunciations,omitempty"`    // 字词发音替换表，如 "重庆": "崇庆"，用于控制多音字和专名读音
+	SSML             bool              `yaml:"ssml,omitempty"`              // 按SSML合成：保留 <break time="500ms"/> 等标签，文本清洗只处理标签之间的内容
 }
 
 // EdgeTTSConfig Edge TTS配置
@@ -37,15 +56,119 @@ type EdgeTTSConfig struct {
 
 // AudioConfig 音频合并配置
 type AudioConfig struct {
-	OutputDir       string  `yaml:"output_dir"`
-	TempDir         string  `yaml:"temp_dir"`
-	FinalOutput     string  `yaml:"final_output"`
-	SilenceDuration float64 `yaml:"silence_duration"`
+	OutputDir           string            `yaml:"output_dir"`
+	TempDir             string            `yaml:"temp_dir"`
+	FinalOutput         string            `yaml:"final_output"`
+	SilenceDuration     float64           `yaml:"silence_duration"`                // 片段之间插入的静音时长（秒），支持WAV和MP3片段
+	MergeBackend        string            `yaml:"merge_backend,omitempty"`         // 合并后端：auto(默认，MP3有ffmpeg时用ffmpeg合并)/binary(按格式拼接)/ffmpeg(始终使用ffmpeg concat)
+	ProviderSubdirs     map[string]string `yaml:"provider_subdirs,omitempty"`      // 各provider独立的输出/临时子目录，如 tencent: "tencent"
+	IntroText           string            `yaml:"intro_text,omitempty"`            // 片头语，合成后固定位于最前
+	OutroText           string            `yaml:"outro_text,omitempty"`            // 片尾语，合成后固定位于最后
+	IntroFile           string            `yaml:"intro_file,omitempty"`            // 片头音频文件，直接拼接在最前（分章或分段输出时只加在第一个文件）
+	OutroFile           string            `yaml:"outro_file,omitempty"`            // 片尾音频文件，直接拼接在最后（分章或分段输出时只加在最后一个文件）
+	SampleRate          int               `yaml:"sample_rate,omitempty"`           // 合并输出的统一采样率，留空时取片段中最常见的采样率
+	Resample            bool              `yaml:"resample,omitempty"`              // 片段采样率不一致时用ffmpeg重采样（否则只警告）
+	VolumeScale         float64           `yaml:"volume_scale,omitempty"`          // 统一音量标度 0.0-2.0（1.0为正常），设置后由各provider换算并覆盖 tts.volume / edge_tts.volume
+	Split               bool              `yaml:"split,omitempty"`                 // 分章输出：Markdown按一级/二级标题各生成一个音频文件，以标题命名
+	MaxFileDuration     time.Duration     `yaml:"max_file_duration,omitempty"`     // 单个输出文件的最长预估时长（如 2h），超出时切分为 _part1、_part2，优先在章节边界切分
+	KeepSegments        bool              `yaml:"keep_segments,omitempty"`         // 同时把每句的音频片段按序保留到输出目录的 segments/ 子目录
+	CacheDir            string            `yaml:"cache_dir,omitempty"`             // 片段缓存目录，按文本和音色参数复用已合成的片段，重复合成时只合成变化的句子
+	StreamOutput        string            `yaml:"stream_output,omitempty"`         // 边合成边按顺序写出音频到命名管道路径，"-" 表示stdout（日志改写到stderr）
+	TrimSilence         bool              `yaml:"trim_silence,omitempty"`          // 合并前裁剪每个片段首尾的静音（有ffmpeg时支持所有格式，否则仅WAV）
+	Validate            string            `yaml:"validate,omitempty"`              // 片段校验方式：full(默认，检查大小和文件头)/size(只检查大小，片段很多或ogg/opus头部误判时使用)
+	StrictMP3Validation bool              `yaml:"strict_mp3_validation,omitempty"` // 严格校验MP3：遍历全文件统计有效帧，覆盖率过低判为损坏
+	SilenceThreshold    float64           `yaml:"silence_threshold,omitempty"`     // 静音阈值（dBFS，如 -50），默认 -50
+	SkipDiskCheck       bool              `yaml:"skip_disk_check,omitempty"`       // 跳过开始前的磁盘空间估算检查
+	MissingSegmentRatio float64           `yaml:"missing_segment_ratio,omitempty"` // 允许缺失的片段比例（如 0.05），超过时合并前显著警告并列出缺失索引，默认有缺失即警告
+	AbortOnMissing      bool              `yaml:"abort_on_missing,omitempty"`      // 缺失片段超过 missing_segment_ratio 时中止合并，而不是输出不完整的音频
+	Postprocess         []string          `yaml:"postprocess,omitempty"`           // 有序的音频后处理步骤：resample/trim_silence/normalize(合并前逐片段)、limit/compress(合并后)，设置后取代各步骤的独立开关
+	LoudnessTarget      float64           `yaml:"loudness_target,omitempty"`       // normalize 步骤的响度目标（LUFS，如 -16），默认 -16
+	PeakLimit           string            `yaml:"peak_limit,omitempty"`            // 合并结果峰值限制（需要ffmpeg）：off(默认)/limit(软限幅)/compress(轻度压缩后限幅)，避免片段响度差异造成破音
+	PeakCeiling         float64           `yaml:"peak_ceiling,omitempty"`          // 峰值上限（dBFS，如 -1），默认 -1
+	Timeline            bool              `yaml:"timeline,omitempty"`              // 合并后导出每句 {index, text, start, end, file} 的JSON时间轴（与输出音频同名的 .timeline.json）
+	Checksum            bool              `yaml:"checksum,omitempty"`              // 合并完成后计算输出文件的SHA256，写入同名 .sha256 文件（如 merged_audio.mp3.sha256）并打印
 }
 
 // ConcurrentConfig 并发配置
 type ConcurrentConfig struct {
-	MaxWorkers int `yaml:"max_workers"`
-	RateLimit  int `yaml:"rate_limit"`
-	BatchSize  int `yaml:"batch_size"`
+	MaxWorkers      int              `yaml:"max_workers"`
+	RateLimit       int              `yaml:"rate_limit"`
+	StatusRateLimit int              `yaml:"status_rate_limit,omitempty"` // 腾讯云任务状态查询每秒最大请求数，与 rate_limit（合成请求）分开计数，默认按接口配额20
+	BatchSize       int              `yaml:"batch_size"`
+	DownloadWorkers int              `yaml:"download_workers,omitempty"` // 音频下载并发数（腾讯云），默认与max_workers相同
+	MaxGoroutines   int              `yaml:"max_goroutines,omitempty"`   // 所有流水线worker的goroutine总数上限，0表示不限制
+	MaxProcs        int              `yaml:"max_procs,omitempty"`        // GOMAXPROCS，0表示使用Go默认值
+	MaxDuration     time.Duration    `yaml:"max_duration,omitempty"`     // 处理时长预算，如 10m，超时后停止提交新任务并合并已完成部分
+	PriorityLines   []int            `yaml:"priority_lines,omitempty"`   // 优先合成的输入行号（从1开始），watch 模式下自动设为刚修改的行
+	TaskTimeout     time.Duration    `yaml:"task_timeout,omitempty"`     // 单个任务每次合成尝试的超时，如 2m，超时按失败重试，0表示不限制
+	Resume          bool             `yaml:"resume,omitempty"`           // 断点续传：跳过临时目录 progress.json 中记录的已完成任务（文本变化或片段缺失时重新合成）
+	Fallbacks       []FallbackConfig `yaml:"fallbacks,omitempty"`        // 最终失败句子的备选参数，按顺序逐组再试一轮（腾讯云），成功即并入结果
+}
+
+// MarkdownConfig Markdown文本处理配置
+type MarkdownConfig struct {
+	InputType           string       `yaml:"input_type,omitempty"`            // 输入类型：auto(默认，.txt按纯文本)/markdown/plain，纯文本不做Markdown去格式
+	ReadImageAlt        bool         `yaml:"read_image_alt"`                  // 是否朗读图片的alt描述（如"图片：一只猫"），默认忽略图片
+	StripTOC            bool         `yaml:"strip_toc,omitempty"`             // 剔除目录：由本文档锚点链接组成的列表（如 - [简介](#简介)）和 [TOC] 标记不朗读
+	MathMode            string       `yaml:"math_mode"`                       // 数学公式处理：keep(默认)/remove(移除)/placeholder(读作"公式")
+	BracketMode         string       `yaml:"bracket_mode,omitempty"`          // 括号补充说明处理：pause(默认，前后停顿)/keep(原样)/remove(不朗读)
+	LinkMode            string       `yaml:"link_mode,omitempty"`             // 链接处理：text(默认，只读链接文本)/domain(附读域名)/remove(不朗读)
+	URLMode             string       `yaml:"url_mode,omitempty"`              // 正文中的裸URL：remove(默认，删除)/domain(读作域名，如"example 点 com")
+	EmojiMode           string       `yaml:"emoji_mode,omitempty"`            // emoji处理：remove(默认，删除)/describe(读作中文描述，如 🚀 读作"火箭")/keep(原样交给TTS)
+	DisableMixedSpacing bool         `yaml:"disable_mixed_spacing,omitempty"` // 关闭中英文边界自动加空格（部分音色遇空格停顿过久时使用）
+	ShortWords          []string     `yaml:"short_words,omitempty"`           // 短词白名单：单个汉字和数字默认有效，其他单字符（如 "A"）需加入白名单
+	Dedupe              string       `yaml:"dedupe,omitempty"`                // 重复句子去除：off(默认)/adjacent(相邻重复)/global(全文重复)
+	DedupeMinChars      int          `yaml:"dedupe_min_chars,omitempty"`      // 参与去重的最短句子字数（默认8），更短的句子始终保留
+	MinChars            int          `yaml:"min_chars,omitempty"`             // 句子最少字数，更短的句子跳过（0表示不限制，仍会过滤单个无意义字符）
+	MaxChars            int          `yaml:"max_chars,omitempty"`             // 句子最多字数，超出时按 long_text_policy 处理（0表示不限制）
+	LongTextPolicy      string       `yaml:"long_text_policy,omitempty"`      // 超过 max_chars 的处理策略：split(默认，在标点处切分)/skip(跳过，如乱码行)
+	Extensions          []string     `yaml:"extensions,omitempty"`            // 在默认解析扩展基础上开关blackfriday扩展，如 ["-autolink", "hard_line_break"]，"none"清空
+	SymbolLanguage      string       `yaml:"symbol_language,omitempty"`       // 符号读法语言：auto(默认，跟随音色语言)/zh/en，决定 $ % + 等独立符号的读法
+	SymbolFile          string       `yaml:"symbol_file,omitempty"`           // 外置符号读法表（YAML/JSON/TOML），按语言覆盖或新增读法，如 en: {"$": "dollar"}
+	Redact              []RedactRule `yaml:"redact,omitempty"`                // 脱敏规则，朗读前把密钥、手机号等替换为占位读法
+	TextPipeline        []string     `yaml:"text_pipeline,omitempty"`         // 逐句文本处理步骤及顺序，未列出的步骤不执行，默认 non_speech/escape/markdown/emoji/symbols/whitespace/mixed_language/brackets；redact 总是最先执行
+}
+
+// RedactRule 脱敏规则：匹配 pattern 的内容替换为 replace
+type RedactRule struct {
+	Name    string `yaml:"name,omitempty"` // 规则名称，用于命中统计，默认使用正则本身
+	Pattern string `yaml:"pattern"`        // Go正则表达式
+	Replace string `yaml:"replace"`        // 替换文本，支持 $1 等分组引用，为空时直接删除
+}
+
+// UploadConfig 对象存储上传配置（可选，合并完成后上传最终文件）
+type UploadConfig struct {
+	Provider  string `yaml:"provider"`   // 存储类型：cos（腾讯云COS）、s3，留空表示不上传
+	Bucket    string `yaml:"bucket"`     // 存储桶名称，COS需包含APPID，如 audio-1250000000
+	Region    string `yaml:"region"`     // 地域，如 ap-beijing、us-east-1
+	Endpoint  string `yaml:"endpoint"`   // 自定义S3兼容域名（可选）
+	KeyPrefix string `yaml:"key_prefix"` // 对象键前缀，如 podcasts/2025
+	SecretID  string `yaml:"secret_id"`  // 访问密钥，COS留空时复用 tencent_cloud 密钥
+	SecretKey string `yaml:"secret_key"`
+}
+
+// SpeakerConfig 对话脚本中单个说话人的音色配置
+type SpeakerConfig struct {
+	VoiceType int64  `yaml:"voice_type,omitempty"` // 腾讯云音色ID
+	Voice     string `yaml:"voice,omitempty"`      // Edge TTS语音名称
+}
+
+// FallbackConfig 失败句子二次合成使用的一组备选参数，未填写的字段沿用主配置
+type FallbackConfig struct {
+	Provider  string  `yaml:"provider,omitempty"`   // 备用TTS服务: tencent(默认)/edge，edge 需要 codec 为 mp3
+	VoiceType int64   `yaml:"voice_type,omitempty"` // 腾讯云备用音色ID
+	Speed     float64 `yaml:"speed,omitempty"`      // 腾讯云语速，如 -1 放慢
+	Voice     string  `yaml:"voice,omitempty"`      // Edge TTS语音名称
+	Rate      string  `yaml:"rate,omitempty"`       // Edge TTS语速，如 -20%
+}
+
+// PermissionsConfig 临时文件与输出文件权限配置（八进制字符串，实际权限仍受umask影响）
+type PermissionsConfig struct {
+	DirMode  string `yaml:"dir_mode,omitempty"`  // 目录权限，默认 0755
+	FileMode string `yaml:"file_mode,omitempty"` // 文件权限，默认 0644
+}
+
+// NetworkConfig 网络配置
+type NetworkConfig struct {
+	Proxy string `yaml:"proxy,omitempty"` // 所有出站连接使用的代理，如 http://127.0.0.1:7890，留空时读取 HTTPS_PROXY 等环境变量
 }
@@ -4,11 +4,18 @@ package model
 type TTSRequest struct {
 	Text            string  `json:"text" binding:"required"`
 	VoiceType       int64   `json:"voiceType,omitempty"`
+	Voice           string  `json:"voice,omitempty"` // 音色名称别名，设置后覆盖VoiceType
 	Volume          int64   `json:"volume,omitempty"`
 	Speed           float64 `json:"speed,omitempty"` // 修改为float64类型
 	PrimaryLanguage int64   `json:"primaryLanguage,omitempty"`
 	SampleRate      int64   `json:"sampleRate,omitempty"`
 	Codec           string  `json:"codec,omitempty"`
+
+	// 可选高级参数，零值表示不设置
+	EmotionCategory  string            `json:"emotionCategory,omitempty"`
+	EmotionIntensity int64             `json:"emotionIntensity,omitempty"`
+	Pronunciations   map[string]string `json:"pronunciations,omitempty"`
+	EnableSSML       bool              `json:"enableSsml,omitempty"` // 按SSML发送，缺少 <speak> 根标签时自动补上；文本以 <speak> 开头时无需设置
 }
 
 // TTS任务响应
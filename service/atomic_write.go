@@ -0,0 +1,40 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// writeFileAtomic 让 write 先写入同目录下的临时文件，成功后重命名替换 outputPath
+// 失败时删除临时文件，已存在的旧输出文件保持不变
+// 临时文件保留原扩展名，ffmpeg 等按扩展名判断格式的工具可以直接写入
+func writeFileAtomic(outputPath string, write func(path string) error) error {
+	dir := filepath.Dir(outputPath)
+	ext := filepath.Ext(outputPath)
+	stem := strings.TrimSuffix(filepath.Base(outputPath), ext)
+
+	tmp, err := os.CreateTemp(dir, "."+stem+".tmp-*"+ext)
+	if err != nil {
+		return fmt.Errorf("创建临时输出文件失败: %v", withDiskFullHint(err))
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+
+	if err := write(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return withDiskFullHint(err)
+	}
+
+	// CreateTemp 固定使用0600，替换前改为配置的文件权限
+	if err := os.Chmod(tmpPath, filePerm); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("设置输出文件权限失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("替换输出文件失败: %v", err)
+	}
+	return nil
+}
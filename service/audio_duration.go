@@ -0,0 +1,63 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// measureAudioDuration 测量音频实际时长，支持PCM WAV（按数据块大小计算）和MP3（逐帧累计采样数）
+func measureAudioDuration(path string) (time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(data) >= 4 && string(data[0:4]) == "RIFF" {
+		format, samples, err := parseWAV(data)
+		if err != nil {
+			return 0, err
+		}
+		frameSize := int(format.channels) * int(format.bitsPerSample) / 8
+		if frameSize == 0 || format.sampleRate == 0 {
+			return 0, fmt.Errorf("WAV格式信息无效")
+		}
+		frames := len(samples) / frameSize
+		return time.Duration(frames) * time.Second / time.Duration(format.sampleRate), nil
+	}
+
+	return mp3Duration(data)
+}
+
+// mp3Duration 遍历MP3帧累计时长，跳过ID3标签和无法解析的字节
+func mp3Duration(data []byte) (time.Duration, error) {
+	start := 0
+	if len(data) >= 10 && string(data[0:3]) == "ID3" {
+		start = 10 + (int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f))
+	}
+
+	var total time.Duration
+	frames := 0
+	for offset := start; offset+4 <= len(data); {
+		length := mp3FrameLength(data[offset : offset+4])
+		if length == 0 || offset+length > len(data) {
+			offset++ // 失去同步，逐字节寻找下一个帧头
+			continue
+		}
+
+		mpeg1 := (data[offset+1]>>3)&0x03 == 3
+		rate := mp3SampleRates[(data[offset+1]>>3)&0x03][(data[offset+2]>>2)&0x03]
+		samplesPerFrame := 1152
+		if !mpeg1 {
+			samplesPerFrame = 576
+		}
+		total += time.Duration(samplesPerFrame) * time.Second / time.Duration(rate)
+		frames++
+		offset += length
+	}
+
+	if frames == 0 {
+		return 0, fmt.Errorf("未找到有效的MP3帧")
+	}
+	return total, nil
+}
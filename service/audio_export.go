@@ -0,0 +1,187 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// exportFormats 需要在合并后通过ffmpeg转码导出的目标格式
+var exportFormats = map[string]bool{
+	".m4b": true, // 有声书格式，支持章节
+	".m4a": true,
+}
+
+// AudioChapter 导出时写入的章节信息
+type AudioChapter struct {
+	Title string
+	Start time.Duration
+	End   time.Duration
+}
+
+// chapterMark 片段所属的章节
+type chapterMark struct {
+	section int
+	title   string
+}
+
+// chapterMarks 片段文件 → 所属章节
+type chapterMarks map[string]chapterMark
+
+// sectionChapters 按Markdown章节登记各片段所属章节，少于两个章节时不写章节
+// 首个标题之前的内容没有标题，按序号命名
+func sectionChapters(sections []MarkdownSection, sectionFiles map[int][]string) chapterMarks {
+	if len(sections) < 2 {
+		return nil
+	}
+
+	marks := make(chapterMarks)
+	for i, section := range sections {
+		title := section.Title
+		if title == "" {
+			title = fmt.Sprintf("第 %d 章", i+1)
+		}
+		for _, file := range sectionFiles[i] {
+			marks[file] = chapterMark{section: i, title: title}
+		}
+	}
+	return marks
+}
+
+// buildChapters 按片段实际时长计算章节起止时间，sources 与 files 一一对应（同 writeTimeline）
+// 未登记章节的片段（片头/片尾语）并入相邻章节；有片段无法测量时长时不写章节，避免章节错位
+func buildChapters(sources, files []string, marks chapterMarks, gap time.Duration) []AudioChapter {
+	if len(marks) == 0 || len(sources) != len(files) {
+		return nil
+	}
+
+	var chapters []AudioChapter
+	current := -1
+	var offset time.Duration
+	for i, file := range files {
+		if i > 0 {
+			offset += gap
+		}
+		if mark, ok := marks[sources[i]]; ok && mark.section != current {
+			start := offset
+			if len(chapters) == 0 {
+				start = 0 // 片头语计入第一章
+			}
+			chapters = append(chapters, AudioChapter{Title: mark.title, Start: start})
+			current = mark.section
+		}
+
+		duration, err := measureAudioDuration(file)
+		if err != nil {
+			Warnf("⚠️  无法测量片段时长，导出时不写入章节: %s, 错误: %v\n", file, err)
+			return nil
+		}
+		offset += duration
+		if len(chapters) > 0 {
+			chapters[len(chapters)-1].End = offset
+		}
+	}
+	return chapters
+}
+
+// NeedsExport 判断输出文件是否需要在合并后转码导出
+func NeedsExport(outputPath string) bool {
+	return exportFormats[strings.ToLower(filepath.Ext(outputPath))]
+}
+
+// CheckExportSupport 在开始处理前检查导出条件，避免合成完成后才发现无法导出
+func CheckExportSupport(outputPath string) error {
+	if NeedsExport(outputPath) && !IsFFmpegAvailable() {
+		return fmt.Errorf("导出 %s 格式需要安装ffmpeg，请安装后重试或改用 .mp3 输出", filepath.Ext(outputPath))
+	}
+	return nil
+}
+
+// mergeAndExport 合并音频；若目标为m4b/m4a，先合并为临时MP3再转码导出
+// chapters 在合并完成后才调用，章节起点依赖合并时实际插入的静音间隔；为nil时不写章节
+// 输出先写入临时文件，成功后才替换目标文件
+func mergeAndExport(outputPath, tempDir string, chapters func() []AudioChapter, merge func(path string) error) error {
+	if !NeedsExport(outputPath) {
+		return writeFileAtomic(outputPath, merge)
+	}
+
+	if err := CheckExportSupport(outputPath); err != nil {
+		return err
+	}
+
+	intermediate := filepath.Join(tempDir, "merged_for_export.mp3")
+	if err := merge(intermediate); err != nil {
+		return err
+	}
+	defer os.Remove(intermediate)
+
+	var marks []AudioChapter
+	if chapters != nil {
+		marks = chapters()
+	}
+	return ExportAudio(intermediate, outputPath, marks)
+}
+
+// ExportAudio 使用ffmpeg把音频转码为目标格式，并可选写入章节元数据
+func ExportAudio(inputPath, outputPath string, chapters []AudioChapter) error {
+	fmt.Printf("🎧 正在导出 %s ...\n", outputPath)
+
+	args := []string{"-y", "-i", inputPath}
+
+	if len(chapters) > 0 {
+		metadataFile := outputPath + ".ffmetadata"
+		if err := writeChapterMetadata(metadataFile, chapters); err != nil {
+			return err
+		}
+		defer os.Remove(metadataFile)
+
+		args = append(args, "-i", metadataFile, "-map_metadata", "1", "-map_chapters", "1")
+	}
+
+	err := writeFileAtomic(outputPath, func(path string) error {
+		return runFFmpeg(append(args, "-map", "0:a", "-c:a", "aac", "-b:a", "64k", path)...)
+	})
+	if err != nil {
+		return fmt.Errorf("导出音频失败: %v", err)
+	}
+
+	if len(chapters) > 0 {
+		fmt.Printf("✅ 导出完成: %s（%d 个章节）\n", outputPath, len(chapters))
+	} else {
+		fmt.Printf("✅ 导出完成: %s\n", outputPath)
+	}
+	return nil
+}
+
+// writeChapterMetadata 写入ffmpeg元数据文件（FFMETADATA1格式）
+func writeChapterMetadata(path string, chapters []AudioChapter) error {
+	var sb strings.Builder
+	sb.WriteString(";FFMETADATA1\n")
+
+	for _, chapter := range chapters {
+		sb.WriteString("\n[CHAPTER]\n")
+		sb.WriteString("TIMEBASE=1/1000\n")
+		sb.WriteString(fmt.Sprintf("START=%d\n", chapter.Start.Milliseconds()))
+		sb.WriteString(fmt.Sprintf("END=%d\n", chapter.End.Milliseconds()))
+		sb.WriteString("title=" + escapeFFMetadata(chapter.Title) + "\n")
+	}
+
+	if err := writeFile(path, []byte(sb.String())); err != nil {
+		return fmt.Errorf("写入章节元数据失败: %v", err)
+	}
+	return nil
+}
+
+// escapeFFMetadata 转义元数据中的特殊字符（= ; # \ 和换行）
+func escapeFFMetadata(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\\`,
+		"=", `\=`,
+		";", `\;`,
+		"#", `\#`,
+		"\n", `\`+"\n",
+	)
+	return replacer.Replace(value)
+}
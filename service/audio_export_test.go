@@ -0,0 +1,151 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// writeTestWAV 写出指定时长的16位单声道静音WAV（8kHz）
+func writeTestWAV(t *testing.T, dir, name string, duration time.Duration) string {
+	t.Helper()
+	format := wavFormat{audioFormat: 1, channels: 1, sampleRate: 8000, bitsPerSample: 16}
+	samples := make([]byte, int(duration.Seconds()*8000)*2)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, buildWAV(format, samples), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBuildChapters(t *testing.T) {
+	dir := t.TempDir()
+	intro := writeTestWAV(t, dir, "intro.wav", 500*time.Millisecond)
+	a1 := writeTestWAV(t, dir, "a1.wav", time.Second)
+	a2 := writeTestWAV(t, dir, "a2.wav", 2*time.Second)
+	b1 := writeTestWAV(t, dir, "b1.wav", time.Second)
+	outro := writeTestWAV(t, dir, "outro.wav", 500*time.Millisecond)
+
+	sections := []MarkdownSection{{Title: ""}, {Title: "第二章 开始"}}
+	marks := sectionChapters(sections, map[int][]string{0: {a1, a2}, 1: {b1}})
+	files := []string{intro, a1, a2, b1, outro}
+
+	tests := []struct {
+		name string
+		gap  time.Duration
+		want []AudioChapter
+	}{
+		{
+			name: "无间隔",
+			want: []AudioChapter{
+				{Title: "第 1 章", Start: 0, End: 3500 * time.Millisecond},
+				{Title: "第二章 开始", Start: 3500 * time.Millisecond, End: 5 * time.Second},
+			},
+		},
+		{
+			name: "片段间插入静音",
+			gap:  100 * time.Millisecond,
+			want: []AudioChapter{
+				{Title: "第 1 章", Start: 0, End: 3700 * time.Millisecond},
+				{Title: "第二章 开始", Start: 3800 * time.Millisecond, End: 5400 * time.Millisecond},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildChapters(files, files, marks, tt.gap)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if got := sectionChapters(sections[:1], map[int][]string{0: {a1}}); got != nil {
+		t.Errorf("只有一个章节时不应写章节: %v", got)
+	}
+}
+
+func TestWriteChapterMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.ffmetadata")
+	chapters := []AudioChapter{{Title: "a=b;c", Start: 0, End: 1500 * time.Millisecond}}
+	if err := writeChapterMetadata(path, chapters); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ";FFMETADATA1\n\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=1500\ntitle=a\\=b\\;c\n"
+	if string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+}
+
+func TestMergeSegmentFilesChapterGap(t *testing.T) {
+	dir := t.TempDir()
+	metadata := filepath.Join(dir, "metadata_copy")
+	// 假ffmpeg：复制第二个 -i 指定的章节元数据文件，并写出输出文件
+	stubFFmpeg(t, `inputs=0; prev=""; for a in "$@"; do
+if [ "$prev" = "-i" ]; then inputs=$((inputs+1)); if [ $inputs -eq 2 ]; then while IFS= read -r line; do echo "$line"; done < "$a" > '`+metadata+`'; fi; fi
+prev="$a"; last="$a"; done; printf exported > "$last"`)
+
+	first := writeTestWAV(t, dir, "segment_0001.wav", time.Second)
+	second := writeTestWAV(t, dir, "segment_0002.wav", time.Second)
+	marks := chapterMarks{first: {section: 0, title: "第一章"}, second: {section: 1, title: "第二章"}}
+
+	var config model.Config
+	config.Audio.TempDir = dir
+	config.Audio.SilenceDuration = 0.5
+	config.Audio.MergeBackend = MergeBackendBinary
+	output := filepath.Join(dir, "book.m4b")
+
+	merger := NewAudioMerger(&config)
+	valid := func(string) error { return nil }
+	if _, err := mergeSegmentFiles(&config, merger, []string{first, second}, output, valid, nil, marks); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第二章从 1s 片段 + 0.5s 静音之后开始
+	if !strings.Contains(string(data), "START=1500\n") {
+		t.Errorf("章节元数据未计入静音间隔:\n%s", data)
+	}
+}
+
+func TestMergeSegmentFilesOutputs(t *testing.T) {
+	dir := t.TempDir()
+	first := writeTestWAV(t, dir, "segment_0001.wav", time.Second)
+	second := writeTestWAV(t, dir, "segment_0002.wav", time.Second)
+
+	var config model.Config
+	config.Audio.OutputDir = dir
+	config.Audio.TempDir = dir
+	config.Audio.MergeBackend = MergeBackendBinary
+	config.Audio.Timeline = true
+	config.Audio.Checksum = true
+	output := filepath.Join(dir, "merged.wav")
+
+	valid := func(string) error { return nil }
+	outputs, err := mergeSegmentFiles(&config, NewAudioMerger(&config), []string{first, second}, output, valid, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{output, timelinePath(output), ChecksumPath(output)}
+	if strings.Join(outputs, ",") != strings.Join(want, ",") {
+		t.Errorf("outputs = %v, want %v", outputs, want)
+	}
+	for _, path := range outputs {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("输出文件不存在: %v", err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// 按文件魔数探测到的音频格式
+const (
+	AudioFormatUnknown = ""
+	AudioFormatMP3     = "mp3"
+	AudioFormatWAV     = "wav"
+	AudioFormatFLAC    = "flac"
+	AudioFormatOGG     = "ogg"
+	AudioFormatM4A     = "m4a"
+	AudioFormatAAC     = "aac"
+)
+
+// audioHeaderSize 探测格式时读取的文件头字节数
+const audioHeaderSize = 12
+
+// DetectAudioFormat 根据文件头魔数探测音频格式，不依赖扩展名或配置的 codec
+// 无法识别时（如无文件头的PCM）返回 AudioFormatUnknown
+func DetectAudioFormat(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return AudioFormatUnknown, err
+	}
+	defer file.Close()
+
+	header := make([]byte, audioHeaderSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return AudioFormatUnknown, err
+	}
+	return detectAudioFormatHeader(header[:n]), nil
+}
+
+// detectAudioFormatHeader 根据文件头字节判断音频格式
+func detectAudioFormatHeader(header []byte) string {
+	switch {
+	case len(header) >= 12 && string(header[0:4]) == "RIFF" && string(header[8:12]) == "WAVE":
+		return AudioFormatWAV
+	case len(header) >= 4 && string(header[0:4]) == "fLaC":
+		return AudioFormatFLAC
+	case len(header) >= 4 && string(header[0:4]) == "OggS":
+		return AudioFormatOGG
+	case len(header) >= 8 && string(header[4:8]) == "ftyp":
+		return AudioFormatM4A
+	case len(header) >= 3 && string(header[0:3]) == "ID3":
+		return AudioFormatMP3
+	case len(header) >= 2 && header[0] == 0xFF && header[1]&0xE0 == 0xE0:
+		// 帧同步字之后的layer位为00时是AAC ADTS，否则为MPEG音频帧
+		if header[1]&0x06 == 0 {
+			return AudioFormatAAC
+		}
+		return AudioFormatMP3
+	}
+	return AudioFormatUnknown
+}
+
+// headeredAudioFormats 带文件头、可以按魔数识别的格式
+var headeredAudioFormats = map[string]bool{
+	AudioFormatMP3:  true,
+	AudioFormatWAV:  true,
+	AudioFormatFLAC: true,
+	AudioFormatOGG:  true,
+	AudioFormatM4A:  true,
+	AudioFormatAAC:  true,
+}
+
+// validateAudioHeader 按探测到的格式校验文件头，返回探测到的格式
+// expected 为配置的编码或扩展名：探测结果与之不符时以探测结果为准，只有无法识别且 expected 本应有文件头时才判为无效
+func validateAudioHeader(audioPath, expected string, strictMP3 bool) (string, error) {
+	format, err := DetectAudioFormat(audioPath)
+	if err != nil {
+		return AudioFormatUnknown, fmt.Errorf("无法读取音频文件头部: %v", err)
+	}
+
+	expected = strings.ToLower(strings.TrimSpace(expected))
+	switch format {
+	case AudioFormatUnknown:
+		// PCM等无文件头的编码只能检查大小
+		if headeredAudioFormats[expected] {
+			return format, fmt.Errorf("音频文件格式无效，无法识别文件头，可能不是有效的%s文件", strings.ToUpper(expected))
+		}
+		return format, nil
+	case AudioFormatMP3:
+		if strictMP3 {
+			if err := validateMP3Frames(audioPath); err != nil {
+				return format, err
+			}
+		}
+	}
+	return format, nil
+}
+
+// audioFormatLabel 返回用于日志的格式名称，无法识别时使用配置的编码
+func audioFormatLabel(format, expected string) string {
+	if format == AudioFormatUnknown {
+		format = expected
+	}
+	return strings.ToUpper(format)
+}
+
+// warnMixedAudioFormats 合并前检查片段的实际格式，格式不一致时二进制拼接的结果无法正常播放
+func warnMixedAudioFormats(audioFiles []string) {
+	counts := make(map[string]int)
+	for _, file := range audioFiles {
+		if format, err := DetectAudioFormat(file); err == nil && format != AudioFormatUnknown {
+			counts[format]++
+		}
+	}
+	if len(counts) <= 1 {
+		return
+	}
+
+	parts := make([]string, 0, len(counts))
+	for format, count := range counts {
+		parts = append(parts, fmt.Sprintf("%s %d 个", strings.ToUpper(format), count))
+	}
+	sort.Strings(parts)
+	Warnf("⚠️  音频片段的实际格式不一致（%s），合并结果可能无法正常播放，请检查输出编码配置\n", strings.Join(parts, "，"))
+}
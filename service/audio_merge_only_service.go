@@ -2,128 +2,75 @@ package service
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 // AudioMergeOnlyService 纯音频合并服务
-type AudioMergeOnlyService struct{}
+type AudioMergeOnlyService struct {
+	merger *AudioMerger // 音频合并组件
+}
 
-// NewAudioMergeOnlyService 创建纯音频合并服务
+// NewAudioMergeOnlyService 创建纯音频合并服务，默认不插入静音
 func NewAudioMergeOnlyService() *AudioMergeOnlyService {
-	return &AudioMergeOnlyService{}
+	return &AudioMergeOnlyService{merger: NewAudioMerger(nil)}
+}
+
+// SetMerger 替换合并组件，如按配置在片段之间插入静音或改用ffmpeg后端
+func (amos *AudioMergeOnlyService) SetMerger(merger *AudioMerger) {
+	amos.merger = merger
 }
 
 // MergeAudioFiles 合并音频文件
 func (amos *AudioMergeOnlyService) MergeAudioFiles(audioFiles []string, outputPath string) error {
+	return amos.mergeWith(amos.merger, audioFiles, outputPath)
+}
+
+// mergeWith 校验后用指定的合并组件合并音频文件
+func (amos *AudioMergeOnlyService) mergeWith(merger *AudioMerger, audioFiles []string, outputPath string) error {
 	if len(audioFiles) == 0 {
 		return fmt.Errorf("没有音频文件需要合并")
 	}
 
 	// 确保输出目录存在
 	outputDir := filepath.Dir(outputPath)
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
+	if err := makeDirs(outputDir); err != nil {
 		return fmt.Errorf("创建输出目录失败: %v", err)
 	}
 
-	// 检查是否所有文件都是相同格式
-	if !amos.checkAudioFormatsCompatible(audioFiles) {
-		fmt.Println("⚠️  警告: 检测到不同格式的音频文件，合并结果可能不理想")
-		fmt.Println("建议使用相同格式的音频文件进行合并")
-	}
-
-	// 创建输出文件
-	outputFile, err := os.Create(outputPath)
+	// 跳过不存在或损坏的文件，输入是用户的文件，不删除
+	validAudioFiles, err := filterValidAudioFiles(audioFiles, amos.validateSingleAudioFile, false)
 	if err != nil {
-		return fmt.Errorf("创建输出文件失败: %v", err)
+		return err
 	}
-	defer outputFile.Close()
-
-	// 依次合并音频文件
-	for i, audioFile := range audioFiles {
-		fmt.Printf("合并文件 %d/%d: %s\n", i+1, len(audioFiles), filepath.Base(audioFile))
-
-		// 检查文件是否存在
-		if _, err := os.Stat(audioFile); os.IsNotExist(err) {
-			fmt.Printf("⚠️  警告: 文件不存在，跳过: %s\n", audioFile)
-			continue
-		}
-
-		// 验证音频文件
-		if err := amos.validateSingleAudioFile(audioFile); err != nil {
-			fmt.Printf("⚠️  警告: 音频文件验证失败，跳过: %s, 错误: %v\n", audioFile, err)
-			continue
-		}
-
-		// 打开音频文件
-		inputFile, err := os.Open(audioFile)
-		if err != nil {
-			fmt.Printf("⚠️  警告: 打开文件失败，跳过: %s, 错误: %v\n", audioFile, err)
-			continue
-		}
-
-		// 获取文件大小用于进度显示
-		fileInfo, err := inputFile.Stat()
-		if err != nil {
-			fmt.Printf("⚠️  警告: 获取文件信息失败: %s, 错误: %v\n", audioFile, err)
-		} else {
-			fmt.Printf("    文件大小: %.2f KB\n", float64(fileInfo.Size())/1024)
-		}
-
-		// 复制文件内容
-		copied, err := io.Copy(outputFile, inputFile)
-		inputFile.Close()
 
-		if err != nil {
-			fmt.Printf("⚠️  警告: 复制文件失败，跳过: %s, 错误: %v\n", audioFile, err)
-			continue
-		}
+	// 片段实际格式不一致时提前警告
+	warnMixedAudioFormats(validAudioFiles)
 
-		fmt.Printf("    已复制: %.2f KB\n", float64(copied)/1024)
+	// 先写入临时文件，成功后才替换输出文件
+	if err := writeFileAtomic(outputPath, func(path string) error {
+		return merger.Merge(validAudioFiles, path)
+	}); err != nil {
+		return err
 	}
 
 	// 获取最终文件大小
-	finalInfo, err := outputFile.Stat()
-	if err == nil {
+	if finalInfo, err := os.Stat(outputPath); err == nil {
 		fmt.Printf("\n📊 合并统计:\n")
-		fmt.Printf("- 输入文件数: %d\n", len(audioFiles))
+		fmt.Printf("- 输入文件数: %d\n", len(validAudioFiles))
 		fmt.Printf("- 输出文件: %s\n", outputPath)
 		fmt.Printf("- 最终大小: %.2f KB\n", float64(finalInfo.Size())/1024)
 	}
-
 	return nil
 }
 
-// checkAudioFormatsCompatible 检查音频格式兼容性
-func (amos *AudioMergeOnlyService) checkAudioFormatsCompatible(audioFiles []string) bool {
-	if len(audioFiles) <= 1 {
-		return true
-	}
-
-	// 获取第一个文件的扩展名作为基准
-	firstExt := strings.ToLower(filepath.Ext(audioFiles[0]))
-
-	// 检查所有文件是否具有相同扩展名
-	for _, file := range audioFiles[1:] {
-		ext := strings.ToLower(filepath.Ext(file))
-		if ext != firstExt {
-			return false
-		}
-	}
-
-	return true
-}
-
-// MergeAudioFilesWithFFmpeg 使用FFmpeg合并音频文件（高级版本）
+// MergeAudioFilesWithFFmpeg 使用ffmpeg concat合并音频文件，未安装ffmpeg时退回按格式拼接
 func (amos *AudioMergeOnlyService) MergeAudioFilesWithFFmpeg(audioFiles []string, outputPath string) error {
-	// 这个函数预留给未来FFmpeg集成使用
-	// 目前使用简单的二进制拼接方式
-	fmt.Println("ℹ️  提示: 当前使用简单合并模式")
-	fmt.Println("如需高级音频处理，请安装FFmpeg并更新代码")
-
-	return amos.MergeAudioFiles(audioFiles, outputPath)
+	merger := *amos.merger
+	merger.backend = MergeBackendFFmpeg
+	return amos.mergeWith(&merger, audioFiles, outputPath)
 }
 
 // ValidateAudioFiles 验证音频文件
@@ -150,12 +97,10 @@ func (amos *AudioMergeOnlyService) ValidateAudioFiles(audioFiles []string) error
 			return fmt.Errorf("文件 %d 不是支持的音频格式: %s", i+1, file)
 		}
 
-		// 检查文件是否可读
-		f, err := os.Open(file)
-		if err != nil {
-			return fmt.Errorf("无法打开文件 %d: %s, 错误: %v", i+1, file, err)
+		// 检查文件可读且头部与格式相符
+		if err := amos.validateSingleAudioFile(file); err != nil {
+			return fmt.Errorf("文件 %d 校验失败: %s, 错误: %v", i+1, file, err)
 		}
-		f.Close()
 	}
 
 	return nil
@@ -175,54 +120,48 @@ func (amos *AudioMergeOnlyService) validateSingleAudioFile(audioPath string) err
 		return fmt.Errorf("音频文件过小 (%d bytes)，可能为空或损坏", fileInfo.Size())
 	}
 
-	// 检查文件是否可读
-	file, err := os.Open(audioPath)
-	if err != nil {
-		return fmt.Errorf("无法打开音频文件: %v", err)
+	// 按文件头探测实际格式校验，扩展名与内容不符时以内容为准
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(audioPath)), ".")
+	if _, err := validateAudioHeader(audioPath, ext, false); err != nil {
+		return err
 	}
-	defer file.Close()
+	return nil
+}
 
-	// 读取文件头部进行基本格式验证
-	buffer := make([]byte, 12)
-	n, err := file.Read(buffer)
-	if err != nil || n < 4 {
-		return fmt.Errorf("无法读取音频文件头部")
+// MergeAudioFilesInVolumes 按单卷最长时长把音频文件合并为多个分卷（如 output-1.mp3、output-2.mp3），只在文件边界切分
+// 所有文件合计不超过 limit 时只输出 outputPath，返回实际写出的文件列表
+func (amos *AudioMergeOnlyService) MergeAudioFilesInVolumes(audioFiles []string, outputPath string, limit time.Duration) ([]string, error) {
+	items := make([]partItem, len(audioFiles))
+	var total time.Duration
+	for i, file := range audioFiles {
+		duration, err := measureAudioDuration(file)
+		if err != nil {
+			Warnf("⚠️  无法测量时长，按0计入分卷: %s, 错误: %v\n", file, err)
+		}
+		items[i] = partItem{File: file, Duration: duration}
+		total += duration
 	}
 
-	// 获取文件扩展名
-	ext := strings.ToLower(filepath.Ext(audioPath))
+	volumes := planFileParts(items, limit)
+	if len(volumes) <= 1 {
+		return []string{outputPath}, amos.MergeAudioFiles(audioFiles, outputPath)
+	}
 
-	// 根据扩展名验证文件头部
-	switch ext {
-	case ".mp3":
-		if n >= 3 && (string(buffer[:3]) == "ID3" ||
-			(buffer[0] == 0xFF && (buffer[1]&0xF0) == 0xF0)) {
-			return nil
-		}
-		return fmt.Errorf("文件头部不匹配MP3格式")
-	case ".wav":
-		if n >= 12 && string(buffer[:4]) == "RIFF" && string(buffer[8:12]) == "WAVE" {
-			return nil
+	fmt.Printf("✂️  总时长 %v，按单卷最长 %v 切分为 %d 卷\n", total.Round(time.Second), limit, len(volumes))
+	outputs := make([]string, 0, len(volumes))
+	for i, files := range volumes {
+		volumePath := volumeOutputPath(outputPath, i+1)
+		fmt.Printf("\n📦 合并第 %d/%d 卷: %s\n", i+1, len(volumes), filepath.Base(volumePath))
+		if err := amos.MergeAudioFiles(files, volumePath); err != nil {
+			return outputs, fmt.Errorf("合并第 %d 卷失败: %v", i+1, err)
 		}
-		return fmt.Errorf("文件头部不匹配WAV格式")
-	case ".m4a", ".aac":
-		// M4A/AAC文件通常以ftyp开头（在前8字节后）
-		if n >= 8 {
-			return nil // 简化验证，只检查大小
-		}
-		return fmt.Errorf("文件头部读取不足")
-	case ".flac":
-		if n >= 4 && string(buffer[:4]) == "fLaC" {
-			return nil
-		}
-		return fmt.Errorf("文件头部不匹配FLAC格式")
-	case ".ogg":
-		if n >= 4 && string(buffer[:4]) == "OggS" {
-			return nil
-		}
-		return fmt.Errorf("文件头部不匹配OGG格式")
-	default:
-		// 对于未知格式，只检查是否为空文件
-		return nil
+		outputs = append(outputs, volumePath)
 	}
+	return outputs, nil
+}
+
+// volumeOutputPath 返回第 index 卷（从1开始）的输出路径，如 output-1.mp3
+func volumeOutputPath(outputPath string, index int) string {
+	ext := filepath.Ext(outputPath)
+	return fmt.Sprintf("%s-%d%s", strings.TrimSuffix(outputPath, ext), index, ext)
 }
@@ -0,0 +1,471 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 音频合并后端
+const (
+	MergeBackendAuto   = "auto"   // MP3等压缩格式有ffmpeg时用ffmpeg合并，失败或未安装时退回 binary；WAV始终按数据块合并（默认）
+	MergeBackendBinary = "binary" // 按格式拼接：WAV合并数据块，其他格式按字节拼接
+	MergeBackendFFmpeg = "ffmpeg" // 使用ffmpeg concat合并，未安装ffmpeg时退回 binary，执行失败时报错
+)
+
+// ffmpegConcatFormats 探测到的格式 → ffmpeg输出格式，临时输出文件的扩展名不可靠时显式指定
+var ffmpegConcatFormats = map[string]string{
+	AudioFormatMP3:  "mp3",
+	AudioFormatWAV:  "wav",
+	AudioFormatFLAC: "flac",
+	AudioFormatOGG:  "ogg",
+	AudioFormatM4A:  "ipod",
+	AudioFormatAAC:  "adts",
+}
+
+// AudioMerger 音频合并组件：按片段的实际格式拼接，可在片段之间插入静音，并显示合并进度
+// 各服务通过它完成合并，拼接、静音和日志的行为保持一致
+type AudioMerger struct {
+	silence time.Duration // 片段之间的静音间隔
+	backend string        // 合并后端
+	tempDir string        // 文件列表、静音片段等临时文件的目录
+
+	gap           time.Duration // 最近一次合并实际插入的静音间隔，时间轴据此计算偏移
+	silenceWarned bool          // 无法插入静音的提示只打印一次
+}
+
+// NewAudioMerger 按 audio.silence_duration 和 audio.merge_backend 创建合并组件，config 为nil时使用默认设置（无静音、auto后端）
+func NewAudioMerger(config *model.Config) *AudioMerger {
+	if config == nil {
+		return &AudioMerger{backend: MergeBackendAuto}
+	}
+	return &AudioMerger{
+		silence: time.Duration(config.Audio.SilenceDuration * float64(time.Second)),
+		backend: mergeBackend(config),
+		tempDir: config.Audio.TempDir,
+	}
+}
+
+// mergeBackend 返回配置的合并后端，未知取值时警告并按 auto 处理
+func mergeBackend(config *model.Config) string {
+	backend := strings.ToLower(strings.TrimSpace(config.Audio.MergeBackend))
+	switch backend {
+	case "":
+		return MergeBackendAuto
+	case MergeBackendAuto, MergeBackendBinary, MergeBackendFFmpeg:
+		return backend
+	default:
+		Warnf("警告: 未知的合并后端: %s（可选: auto, binary, ffmpeg），将按 auto 处理\n", backend)
+		return MergeBackendAuto
+	}
+}
+
+// Gap 返回最近一次合并在片段之间实际插入的静音时长，未插入时为0
+func (m *AudioMerger) Gap() time.Duration {
+	return m.gap
+}
+
+// Merge 按顺序把音频文件合并写入 outputPath，片段之间按配置插入静音
+// 打不开的文件警告后跳过，写入失败时返回错误
+func (m *AudioMerger) Merge(audioFiles []string, outputPath string) error {
+	if len(audioFiles) == 0 {
+		return fmt.Errorf("没有找到要合并的音频文件")
+	}
+
+	m.gap = 0
+	silence := ""
+	if m.silence > 0 && len(audioFiles) > 1 {
+		path, err := m.silenceSegment(audioFiles[0])
+		if err != nil {
+			if !m.silenceWarned {
+				Warnf("⚠️  无法在片段之间插入静音，将直接拼接: %v\n", err)
+				m.silenceWarned = true
+			}
+		} else {
+			defer os.Remove(path)
+			silence = path
+			m.gap = m.silence
+			// MP3按整帧生成，实际时长略长于配置值
+			if duration, err := measureAudioDuration(path); err == nil {
+				m.gap = duration
+			}
+		}
+	}
+
+	switch m.backend {
+	case MergeBackendFFmpeg:
+		if IsFFmpegAvailable() {
+			if err := m.ffmpegConcat(audioFiles, silence, outputPath); err != nil {
+				return fmt.Errorf("ffmpeg合并失败: %v", err)
+			}
+			fmt.Printf("音频合并完成: %s\n", outputPath)
+			return nil
+		}
+		Warnf("⚠️  未找到ffmpeg，合并后端退回 binary\n")
+	case MergeBackendAuto:
+		if prefersFFmpeg(audioFiles[0]) && IsFFmpegAvailable() {
+			err := m.ffmpegConcat(audioFiles, silence, outputPath)
+			if err == nil {
+				fmt.Printf("音频合并完成: %s\n", outputPath)
+				return nil
+			}
+			Warnf("⚠️  ffmpeg合并失败，退回按字节拼接: %v\n", err)
+		}
+	}
+
+	if err := m.concat(audioFiles, silence, outputPath); err != nil {
+		return err
+	}
+	fmt.Printf("音频合并完成: %s\n", outputPath)
+	return nil
+}
+
+// prefersFFmpeg auto 后端是否优先用ffmpeg合并：按字节拼接MP3会保留每个片段的VBR头，
+// 播放器显示的总时长错误、无法正常跳转；WAV按数据块合并已经准确，不需要ffmpeg
+func prefersFFmpeg(file string) bool {
+	format, err := DetectAudioFormat(file)
+	return err == nil && format == AudioFormatMP3
+}
+
+// withSilence 在相邻片段之间插入静音片段，silence 为空时原样返回
+func withSilence(audioFiles []string, silence string) []string {
+	if silence == "" {
+		return audioFiles
+	}
+	files := make([]string, 0, len(audioFiles)*2-1)
+	for i, file := range audioFiles {
+		if i > 0 {
+			files = append(files, silence)
+		}
+		files = append(files, file)
+	}
+	return files
+}
+
+// concat 按格式拼接：所有片段都是相同格式的WAV时合并数据块并重写文件头，否则按字节拼接
+func (m *AudioMerger) concat(audioFiles []string, silence, outputPath string) error {
+	files := withSilence(audioFiles, silence)
+	format, wav := commonWAVFormat(files)
+
+	outputFile, err := createFile(outputPath)
+	if err != nil {
+		return fmt.Errorf("创建输出文件失败: %v", err)
+	}
+	defer outputFile.Close()
+
+	// WAV先写入占位文件头，拼接完数据块后回填大小
+	header := buildWAV(format, nil)
+	if wav {
+		if _, err := outputFile.Write(header); err != nil {
+			return fmt.Errorf("写入输出文件失败: %v", err)
+		}
+	}
+
+	progress := newMergeProgress(files)
+	var dataSize int64
+	segment := 0
+	for _, file := range files {
+		if file != silence {
+			segment++
+			fmt.Printf("合并文件 %d/%d: %s\n", segment, len(audioFiles), file)
+		}
+
+		n, err := m.copySegment(outputFile, file, wav, progress)
+		if err == errSegmentUnreadable {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("写入音频文件失败 %s: %v", file, err)
+		}
+		dataSize += n
+	}
+
+	if wav {
+		binary.LittleEndian.PutUint32(header[4:8], uint32(36+dataSize))
+		binary.LittleEndian.PutUint32(header[40:44], uint32(dataSize))
+		if _, err := outputFile.WriteAt(header, 0); err != nil {
+			return fmt.Errorf("写入WAV文件头失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// errSegmentUnreadable 片段无法读取，合并时跳过
+var errSegmentUnreadable = errors.New("音频片段无法读取")
+
+// copySegment 把一个片段写入输出：wav 为 true 时只写入data块，返回写入的字节数
+func (m *AudioMerger) copySegment(dst io.Writer, file string, wav bool, progress *mergeProgress) (int64, error) {
+	if wav {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			Warnf("警告: 读取文件失败 %s: %v\n", file, err)
+			progress.skip(file)
+			return 0, errSegmentUnreadable
+		}
+		_, samples, err := parseWAV(data)
+		if err != nil {
+			Warnf("警告: 解析WAV失败 %s: %v\n", file, err)
+			progress.skip(file)
+			return 0, errSegmentUnreadable
+		}
+		progress.add(len(data) - len(samples)) // 文件头不写入输出，但计入进度
+		return progress.copy(dst, bytes.NewReader(samples))
+	}
+
+	inputFile, err := os.Open(file)
+	if err != nil {
+		Warnf("警告: 打开文件失败 %s: %v\n", file, err)
+		progress.skip(file)
+		return 0, errSegmentUnreadable
+	}
+	defer inputFile.Close()
+	return progress.copy(dst, inputFile)
+}
+
+// commonWAVFormat 所有文件都是PCM格式完全相同的WAV时返回该格式
+func commonWAVFormat(files []string) (wavFormat, bool) {
+	var common wavFormat
+	for i, file := range files {
+		format, err := readWAVFormat(file)
+		if err != nil {
+			return common, false
+		}
+		if i == 0 {
+			common = format
+			continue
+		}
+		if format != common {
+			Warnf("⚠️  WAV片段的采样率、声道数或位深不一致，将按字节拼接\n")
+			return common, false
+		}
+	}
+	return common, true
+}
+
+// wavHeaderReadSize 读取WAV格式信息时读取的文件头字节数，足以覆盖data块之前的fmt、LIST等块
+const wavHeaderReadSize = 4096
+
+// readWAVFormat 只读取文件开头解析WAV格式信息，不是WAV时返回错误
+func readWAVFormat(path string) (wavFormat, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return wavFormat{}, err
+	}
+	defer file.Close()
+
+	header := make([]byte, wavHeaderReadSize)
+	n, err := io.ReadFull(file, header)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return wavFormat{}, err
+	}
+	format, _, err := parseWAV(header[:n])
+	return format, err
+}
+
+// silenceSegment 按首个片段的格式生成一段静音，返回临时文件路径
+// WAV写入零值采样，MP3写入与原片段帧头参数相同的静音帧，其他格式暂不支持
+func (m *AudioMerger) silenceSegment(sample string) (string, error) {
+	format, err := DetectAudioFormat(sample)
+	if err != nil {
+		return "", err
+	}
+
+	var data []byte
+	switch format {
+	case AudioFormatWAV:
+		wav, err := readWAVFormat(sample)
+		if err != nil {
+			return "", err
+		}
+		frameSize := int(wav.channels) * int(wav.bitsPerSample) / 8
+		frames := int(m.silence.Seconds() * float64(wav.sampleRate))
+		samples := make([]byte, frames*frameSize)
+		if wav.bitsPerSample == 8 {
+			// 8位PCM为无符号采样，静音值为128
+			for i := range samples {
+				samples[i] = 0x80
+			}
+		}
+		data = buildWAV(wav, samples)
+	case AudioFormatMP3:
+		data, err = silentMP3Frames(sample, m.silence)
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("暂不支持为 %s 格式生成静音", audioFormatLabel(format, strings.TrimPrefix(filepath.Ext(sample), ".")))
+	}
+
+	dir := m.tempDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	path, err := writeTempFile(dir, fmt.Sprintf("silence_%dms_*.%s", m.silence.Milliseconds(), format), data)
+	if err != nil {
+		return "", fmt.Errorf("写入静音片段失败: %v", err)
+	}
+	return path, nil
+}
+
+// silentMP3Frames 以片段首个MP3帧的帧头为模板生成静音帧：边信息和主数据全为0时解码为静音
+func silentMP3Frames(sample string, duration time.Duration) ([]byte, error) {
+	data, err := os.ReadFile(sample)
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0
+	if len(data) >= 10 && string(data[0:3]) == "ID3" {
+		start = 10 + (int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f))
+	}
+
+	for offset := start; offset+4 <= len(data); offset++ {
+		if mp3FrameLength(data[offset:offset+4]) == 0 {
+			continue
+		}
+
+		header := make([]byte, 4)
+		copy(header, data[offset:offset+4])
+		header[1] |= 0x01  // 不带CRC校验
+		header[2] &^= 0x02 // 不使用填充字节，每帧长度一致
+		length := mp3FrameLength(header)
+
+		version := (header[1] >> 3) & 0x03
+		rate := mp3SampleRates[version][(header[2]>>2)&0x03]
+		samplesPerFrame := 1152
+		if version != 3 {
+			samplesPerFrame = 576
+		}
+		frameDuration := time.Duration(samplesPerFrame) * time.Second / time.Duration(rate)
+		count := int((duration + frameDuration - 1) / frameDuration)
+
+		frames := make([]byte, count*length)
+		for i := 0; i < count; i++ {
+			copy(frames[i*length:], header)
+		}
+		return frames, nil
+	}
+	return nil, fmt.Errorf("未找到有效的MP3帧")
+}
+
+// ffmpegConcat 用ffmpeg concat demuxer 无重编码合并，片段之间插入静音片段
+func (m *AudioMerger) ffmpegConcat(audioFiles []string, silence, outputPath string) error {
+	dir := m.tempDir
+	if dir == "" {
+		dir = os.TempDir()
+	}
+
+	// concat demuxer 中的相对路径相对于列表文件解析，统一写入绝对路径
+	var list strings.Builder
+	for _, file := range withSilence(audioFiles, silence) {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			path = file
+		}
+		fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(path, "'", `'\''`))
+	}
+	listFile, err := writeTempFile(dir, "file_list_*.txt", []byte(list.String()))
+	if err != nil {
+		return fmt.Errorf("创建文件列表失败: %v", err)
+	}
+	defer os.Remove(listFile)
+
+	fmt.Printf("🎬 使用ffmpeg合并 %d 个音频文件...\n", len(audioFiles))
+	args := []string{"-y", "-loglevel", "error", "-f", "concat", "-safe", "0", "-i", listFile, "-c", "copy"}
+	if format, err := DetectAudioFormat(audioFiles[0]); err == nil && ffmpegConcatFormats[format] != "" {
+		args = append(args, "-f", ffmpegConcatFormats[format])
+	}
+	if err := runFFmpeg(append(args, outputPath)...); err != nil {
+		return err
+	}
+	// 退出码为0但没有写出内容时同样视为失败，避免输出空文件
+	if info, err := os.Stat(outputPath); err != nil || info.Size() == 0 {
+		return fmt.Errorf("ffmpeg未生成输出文件")
+	}
+	return nil
+}
+
+// filterValidAudioFiles 合并前逐个校验音频文件，跳过无效文件并打印统计
+// remove 为 true 时删除无效文件，只用于本程序合成的临时片段
+func filterValidAudioFiles(audioFiles []string, validate func(path string) error, remove bool) ([]string, error) {
+	validAudioFiles := make([]string, 0, len(audioFiles))
+	invalidCount := 0
+
+	for _, audioFile := range audioFiles {
+		if err := validate(audioFile); err != nil {
+			Warnf("⚠️  跳过无效音频文件: %s, 原因: %v\n", audioFile, err)
+			invalidCount++
+			if remove {
+				os.Remove(audioFile)
+			}
+			continue
+		}
+		validAudioFiles = append(validAudioFiles, audioFile)
+	}
+
+	if len(validAudioFiles) == 0 {
+		return nil, fmt.Errorf("没有有效的音频文件可以合并")
+	}
+
+	if invalidCount > 0 {
+		fmt.Printf("📊 音频文件验证统计: 有效 %d, 无效 %d\n", len(validAudioFiles), invalidCount)
+	}
+	return validAudioFiles, nil
+}
+
+// mergeSegmentFiles 合成服务共用的合并流程：校验片段 → 保留片段 → 片段后处理 → 合并导出 → 时间轴
+// 返回实际写出的输出文件及其附属文件（时间轴、校验和），供上传等后续步骤使用
+func mergeSegmentFiles(config *model.Config, merger *AudioMerger, audioFiles []string, outputPath string, validate func(path string) error, texts map[string]string, chapters chapterMarks) ([]string, error) {
+	if len(audioFiles) == 0 {
+		return nil, fmt.Errorf("没有音频文件需要合并")
+	}
+
+	fmt.Printf("\n开始合并 %d 个音频文件...\n", len(audioFiles))
+
+	// 预先验证所有音频文件，删除无效的临时片段
+	validAudioFiles, err := filterValidAudioFiles(audioFiles, validate, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// 片段实际格式不一致时提前警告
+	warnMixedAudioFormats(validAudioFiles)
+
+	// 按需保留原始片段（合并文件照常生成）
+	keepSegments(config, validAudioFiles)
+	segmentFiles := validAudioFiles
+
+	// 按 audio.postprocess 对片段做重采样、裁剪静音、响度归一化等后处理
+	validAudioFiles = postprocessSegments(config, validAudioFiles)
+
+	// 目标为m4b/m4a时合并后再转码导出，有章节信息时一并写入
+	// 章节起点按本次合并实际插入的静音间隔计算，需在 Merge 之后读取 Gap
+	exportChapters := func() []AudioChapter {
+		return buildChapters(segmentFiles, validAudioFiles, chapters, merger.Gap())
+	}
+	if err := mergeAndExport(outputPath, config.Audio.TempDir, exportChapters, withPostprocess(config, func(path string) error {
+		return merger.Merge(validAudioFiles, path)
+	})); err != nil {
+		return nil, err
+	}
+	outputs := []string{outputPath}
+
+	// 按需导出每句的时间轴
+	if path := writeTimeline(config, outputPath, segmentFiles, validAudioFiles, texts, merger.Gap()); path != "" {
+		outputs = append(outputs, path)
+	}
+
+	// 按需写出校验和，供下游验证传输完整性
+	if path := writeChecksum(config, outputPath); path != "" {
+		outputs = append(outputs, path)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testMP3Frames 返回 n 个 MPEG1 Layer3 128kbps 44.1kHz 的空帧
+func testMP3Frames(n int) []byte {
+	frame := make([]byte, 417)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return bytes.Repeat(frame, n)
+}
+
+// ffmpegCopyList 假ffmpeg：把 -i 指定的列表文件逐行复制到 listCopy（脚本只能用shell内建命令）
+func ffmpegCopyList(listCopy string) string {
+	return `prev=""; for a in "$@"; do if [ "$prev" = "-i" ]; then while IFS= read -r line; do echo "$line"; done < "$a" > '` + listCopy + `'; fi; prev="$a"; done`
+}
+
+func TestFFmpegConcat(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		silence time.Duration
+		wantErr string
+	}{
+		{"无静音", `for last; do :; done; printf merged > "$last"`, 0, ""},
+		{"插入静音", `for last; do :; done; printf merged > "$last"`, 200 * time.Millisecond, ""},
+		{"非零退出", `echo "Invalid data found" >&2; exit 1`, 0, "Invalid data found"},
+		{"输出为空", `for last; do :; done; : > "$last"`, 0, "ffmpeg未生成输出文件"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			listCopy := filepath.Join(dir, "list_copy")
+			argsFile := stubFFmpeg(t, ffmpegCopyList(listCopy)+"\n"+tt.script)
+
+			segments := []string{filepath.Join(dir, "segment_0001.mp3"), filepath.Join(dir, "it's_0002.mp3")}
+			for _, segment := range segments {
+				if err := os.WriteFile(segment, testMP3Frames(4), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			output := filepath.Join(dir, "out.mp3")
+			tempDir := filepath.Join(dir, "temp")
+			if err := os.Mkdir(tempDir, 0755); err != nil {
+				t.Fatal(err)
+			}
+			merger := &AudioMerger{backend: MergeBackendFFmpeg, silence: tt.silence, tempDir: tempDir}
+
+			err := merger.Merge(segments, output)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Merge err = %v, want 包含 %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else if data, _ := os.ReadFile(output); string(data) != "merged" {
+				t.Errorf("输出文件 = %q", data)
+			}
+
+			args, _ := os.ReadFile(argsFile)
+			wantArgs := "-f\nconcat\n-safe\n0\n-i\n"
+			if !strings.Contains(string(args), wantArgs) || !strings.Contains(string(args), "-c\ncopy\n-f\nmp3\n"+output+"\n") {
+				t.Errorf("ffmpeg参数 = %q", args)
+			}
+
+			list, _ := os.ReadFile(listCopy)
+			lines := strings.Split(strings.TrimSpace(string(list)), "\n")
+			want := []string{"file '" + segments[0] + "'", "file '" + strings.ReplaceAll(segments[1], "'", `'\''`) + "'"}
+			if tt.silence > 0 {
+				if len(lines) != 3 || !strings.HasPrefix(lines[1], "file '"+filepath.Join(tempDir, "silence_200ms_")) {
+					t.Fatalf("文件列表 = %q", lines)
+				}
+				lines = []string{lines[0], lines[2]}
+			}
+			if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+				t.Errorf("文件列表 = %q, want %q", lines, want)
+			}
+
+			// 文件列表和静音片段用完即删
+			if left, _ := os.ReadDir(tempDir); len(left) != 0 {
+				t.Errorf("临时目录残留文件: %v", left)
+			}
+		})
+	}
+}
@@ -4,15 +4,11 @@ import (
 	"bufio"
 	"fmt"
 	"github.com/difyz9/markdown2tts/model"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 	"time"
-
-	"gopkg.in/yaml.v3"
 )
 
 // ConfigService 配置服务
@@ -34,7 +30,7 @@ func NewConfigService(configPath string) (*ConfigService, error) {
 		// 同时创建示例输入文件
 		inputFile := "input.txt"
 		if err := initializer.CreateSampleInputFile(inputFile); err != nil {
-			fmt.Printf("警告: 创建示例输入文件失败: %v\n", err)
+			Warnf("警告: 创建示例输入文件失败: %v\n", err)
 		}
 
 		// 显示快速开始指南
@@ -45,6 +41,11 @@ func NewConfigService(configPath string) (*ConfigService, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	// 应用配置的目录/文件权限
+	if err := ApplyPermissions(config); err != nil {
+		return nil, err
+	}
 	return &ConfigService{config: config}, nil
 }
 
@@ -53,6 +54,11 @@ func (cs *ConfigService) GetConfig() *model.Config {
 	return cs.config
 }
 
+// LoadConfigFile 只读取配置文件，不存在时不会自动初始化
+func LoadConfigFile(configPath string) (*model.Config, error) {
+	return loadConfig(configPath)
+}
+
 // loadConfig 加载配置文件
 func loadConfig(configPath string) (*model.Config, error) {
 	data, err := os.ReadFile(configPath)
@@ -61,10 +67,14 @@ func loadConfig(configPath string) (*model.Config, error) {
 	}
 
 	var config model.Config
-	err = yaml.Unmarshal(data, &config)
+	err = decodeConfig(ConfigFormatOf(configPath), data, &config)
 	if err != nil {
 		return nil, fmt.Errorf("解析配置文件失败: %v", err)
 	}
+	// 脱敏规则无效时不能带着未脱敏的文本继续合成
+	if err := validateRedactRules(config.Markdown.Redact); err != nil {
+		return nil, fmt.Errorf("配置文件无效: markdown.redact: %v", err)
+	}
 
 	return &config, nil
 }
@@ -74,6 +84,7 @@ type AudioMergeService struct {
 	config        *model.Config
 	ttsService    *TTSService
 	textProcessor *TextProcessor
+	merger        *AudioMerger // 音频合并组件
 }
 
 // NewAudioMergeService 创建音频合并服务
@@ -81,17 +92,26 @@ func NewAudioMergeService(config *model.Config, ttsService *TTSService) *AudioMe
 	return &AudioMergeService{
 		config:        config,
 		ttsService:    ttsService,
-		textProcessor: NewTextProcessor(),
+		textProcessor: newTencentTextProcessor(config),
+		merger:        NewAudioMerger(config),
 	}
 }
 
 // ProcessHistoryFile 处理历史文件，生成音频
 func (ams *AudioMergeService) ProcessHistoryFile() error {
+	// 提前检查导出条件，避免合成完成后才发现无法导出
+	if err := CheckExportSupport(ams.config.Audio.FinalOutput); err != nil {
+		return err
+	}
+	if err := checkProviderCodec(ams.Capabilities(), ProviderTencent, ams.config.TTS.Codec); err != nil {
+		return err
+	}
+
 	// 确保目录存在
-	if err := os.MkdirAll(ams.config.Audio.TempDir, 0755); err != nil {
+	if err := makeDirs(ams.config.Audio.TempDir); err != nil {
 		return fmt.Errorf("创建临时目录失败: %v", err)
 	}
-	if err := os.MkdirAll(ams.config.Audio.OutputDir, 0755); err != nil {
+	if err := makeDirs(ams.config.Audio.OutputDir); err != nil {
 		return fmt.Errorf("创建输出目录失败: %v", err)
 	}
 
@@ -105,6 +125,7 @@ func (ams *AudioMergeService) ProcessHistoryFile() error {
 
 	// 为每行文本生成音频
 	audioFiles := make([]string, 0, len(lines))
+	var expected, produced []int
 	validLineCount := 0
 	skippedLineCount := 0
 	emptyLineCount := 0
@@ -136,7 +157,6 @@ func (ams *AudioMergeService) ProcessHistoryFile() error {
 			trimmedLine == "####" ||
 			trimmedLine == "**" ||
 			trimmedLine == "***" ||
-			strings.HasPrefix(trimmedLine, "-- ") ||
 			strings.HasPrefix(trimmedLine, "-----") {
 			skippedLineCount++
 			continue // 跳过标记行
@@ -156,24 +176,26 @@ func (ams *AudioMergeService) ProcessHistoryFile() error {
 		}
 
 		validLineCount++
+		expected = append(expected, i)
 		fmt.Printf("正在处理第 %d 行: %s\n", i+1, processedText)
 
 		// 使用重试机制生成音频
 		audioFile, err := ams.generateAudioWithRetry(processedText, i, 3)
 		if err != nil {
-			fmt.Printf("生成第 %d 行音频失败（经过重试）: %v\n", i+1, err)
+			Failf("生成第 %d 行音频失败（经过重试）: %v\n", i+1, err)
 			continue
 		}
 
 		// 验证生成的音频文件
 		if err := ams.validateAudioFile(audioFile); err != nil {
-			fmt.Printf("第 %d 行音频文件验证失败: %v\n", i+1, err)
+			Failf("第 %d 行音频文件验证失败: %v\n", i+1, err)
 			// 删除无效的音频文件
 			os.Remove(audioFile)
 			continue
 		}
 
 		audioFiles = append(audioFiles, audioFile)
+		produced = append(produced, i)
 	}
 
 	if len(audioFiles) == 0 {
@@ -182,6 +204,12 @@ func (ams *AudioMergeService) ProcessHistoryFile() error {
 
 	fmt.Printf("📊 文本处理统计: 总行数=%d, 空行=%d, 标记行=%d, 无效文本=%d, 成功生成=%d\n",
 		len(lines), emptyLineCount, skippedLineCount, invalidTextCount, len(audioFiles))
+	ams.textProcessor.ReportRedactions()
+
+	// 合并前确认片段数量与有效句子数量一致
+	if err := checkSegmentCount(ams.config, expected, produced, false); err != nil {
+		return err
+	}
 
 	// 合并音频文件
 	return ams.mergeAudioFiles(audioFiles)
@@ -214,11 +242,18 @@ func (ams *AudioMergeService) generateAudioForText(text string, index int) (stri
 	req := &model.TTSRequest{
 		Text:            text,
 		VoiceType:       ams.config.TTS.VoiceType,
-		Volume:          ams.config.TTS.Volume,
+		Volume:          TencentVolume(ams.config),
 		Speed:           ams.config.TTS.Speed,
 		PrimaryLanguage: ams.config.TTS.PrimaryLanguage,
 		SampleRate:      ams.config.TTS.SampleRate,
 		Codec:           ams.config.TTS.Codec,
+		Pronunciations:  ams.config.TTS.Pronunciations,
+		EnableSSML:      ams.config.TTS.SSML,
+	}
+	// 只对支持情感参数的provider传入情感设置
+	if ams.Capabilities().Emotion {
+		req.EmotionCategory = ams.config.TTS.EmotionCategory
+		req.EmotionIntensity = ams.config.TTS.EmotionIntensity
 	}
 
 	// 创建TTS任务
@@ -298,15 +333,21 @@ func (ams *AudioMergeService) downloadAudio(url, filepath string) error {
 		return fmt.Errorf("下载音频失败，状态码: %d", resp.StatusCode)
 	}
 
-	file, err := os.Create(filepath)
+	// 确认返回的是音频而不是错误页
+	body, err := audioResponseBody(resp)
+	if err != nil {
+		return err
+	}
+
+	file, err := createFile(filepath)
 	if err != nil {
-		return fmt.Errorf("创建音频文件失败: %v", err)
+		return fmt.Errorf("创建音频文件失败: %v", withDiskFullHint(err))
 	}
 	defer file.Close()
 
-	_, err = io.Copy(file, resp.Body)
+	_, err = copyBuffered(file, body)
 	if err != nil {
-		return fmt.Errorf("保存音频文件失败: %v", err)
+		return fmt.Errorf("保存音频文件失败: %v", withDiskFullHint(err))
 	}
 
 	fmt.Printf("音频文件已保存: %s\n", filepath)
@@ -315,165 +356,9 @@ func (ams *AudioMergeService) downloadAudio(url, filepath string) error {
 
 // mergeAudioFiles 合并音频文件
 func (ams *AudioMergeService) mergeAudioFiles(audioFiles []string) error {
-	fmt.Printf("开始合并 %d 个音频文件...\n", len(audioFiles))
-
-	// 构建ffmpeg命令
 	outputPath := filepath.Join(ams.config.Audio.OutputDir, ams.config.Audio.FinalOutput)
-
-	// 创建一个临时的文件列表
-	listFile := filepath.Join(ams.config.Audio.TempDir, "file_list.txt")
-
-	// 写入文件列表
-	err := ams.createFileList(audioFiles, listFile)
-	if err != nil {
-		return err
-	}
-	defer os.Remove(listFile) // 清理临时文件
-
-	// 如果配置了静音间隔，使用复杂的合并方式
-	if ams.config.Audio.SilenceDuration > 0 {
-		return ams.mergeWithSilence(audioFiles, outputPath)
-	}
-
-	// 直接拼接音频文件
-	return ams.concatAudioFiles(listFile, outputPath)
-}
-
-// createFileList 创建文件列表
-func (ams *AudioMergeService) createFileList(audioFiles []string, listFile string) error {
-	file, err := os.Create(listFile)
-	if err != nil {
-		return fmt.Errorf("创建文件列表失败: %v", err)
-	}
-	defer file.Close()
-
-	for _, audioFile := range audioFiles {
-		_, err := fmt.Fprintf(file, "file '%s'\n", audioFile)
-		if err != nil {
-			return fmt.Errorf("写入文件列表失败: %v", err)
-		}
-	}
-
-	return nil
-}
-
-// concatAudioFiles 直接拼接音频文件
-func (ams *AudioMergeService) concatAudioFiles(listFile, outputPath string) error {
-	// 检查ffmpeg是否可用
-	if !ams.isFFmpegAvailable() {
-		return ams.simpleAudioMerge(listFile, outputPath)
-	}
-
-	// 使用ffmpeg合并
-	cmd := fmt.Sprintf("ffmpeg -f concat -safe 0 -i '%s' -c copy '%s' -y", listFile, outputPath)
-	fmt.Printf("执行命令: %s\n", cmd)
-
-	// 这里我们使用简单的文件合并作为备选方案
-	return ams.simpleAudioMerge(listFile, outputPath)
-}
-
-// mergeWithSilence 带静音间隔的合并
-func (ams *AudioMergeService) mergeWithSilence(audioFiles []string, outputPath string) error {
-	if !ams.isFFmpegAvailable() {
-		fmt.Println("警告: 未检测到ffmpeg，将使用简单拼接（无静音间隔）")
-		listFile := filepath.Join(ams.config.Audio.TempDir, "file_list.txt")
-		ams.createFileList(audioFiles, listFile)
-		return ams.simpleAudioMerge(listFile, outputPath)
-	}
-
-	// 构建ffmpeg复杂过滤器命令
-	var filterComplex strings.Builder
-	var inputs strings.Builder
-
-	for i, audioFile := range audioFiles {
-		inputs.WriteString(fmt.Sprintf("-i '%s' ", audioFile))
-
-		if i > 0 {
-			// 添加静音
-			silenceDuration := strconv.FormatFloat(ams.config.Audio.SilenceDuration, 'f', 1, 64)
-			filterComplex.WriteString(fmt.Sprintf("[%d:0]adelay=%s[a%d]; ", i, silenceDuration+"s", i))
-		}
-	}
-
-	// 添加音频混合
-	filterComplex.WriteString("[0:0]")
-	for i := 1; i < len(audioFiles); i++ {
-		filterComplex.WriteString(fmt.Sprintf("[a%d]", i))
-	}
-	filterComplex.WriteString(fmt.Sprintf("concat=n=%d:v=0:a=1[out]", len(audioFiles)))
-
-	cmd := fmt.Sprintf("ffmpeg %s -filter_complex '%s' -map '[out]' '%s' -y",
-		inputs.String(), filterComplex.String(), outputPath)
-
-	fmt.Printf("执行命令: %s\n", cmd)
-
-	// 简化处理，直接使用简单合并
-	listFile := filepath.Join(ams.config.Audio.TempDir, "file_list.txt")
-	ams.createFileList(audioFiles, listFile)
-	return ams.simpleAudioMerge(listFile, outputPath)
-}
-
-// isFFmpegAvailable 检查ffmpeg是否可用
-func (ams *AudioMergeService) isFFmpegAvailable() bool {
-	// 简单检查，实际项目中可以执行ffmpeg -version命令检查
-	return false // 暂时返回false，使用简单合并
-}
-
-// simpleAudioMerge 简单的音频文件合并（二进制拼接）
-func (ams *AudioMergeService) simpleAudioMerge(listFile, outputPath string) error {
-	// 读取文件列表
-	listContent, err := os.ReadFile(listFile)
-	if err != nil {
-		return fmt.Errorf("读取文件列表失败: %v", err)
-	}
-
-	lines := strings.Split(string(listContent), "\n")
-	var audioFiles []string
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		// 解析 "file 'path'" 格式
-		if strings.HasPrefix(line, "file '") && strings.HasSuffix(line, "'") {
-			filepath := line[6 : len(line)-1] // 去掉 "file '" 和末尾的 "'"
-			audioFiles = append(audioFiles, filepath)
-		}
-	}
-
-	if len(audioFiles) == 0 {
-		return fmt.Errorf("没有找到要合并的音频文件")
-	}
-
-	// 创建输出文件
-	outputFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("创建输出文件失败: %v", err)
-	}
-	defer outputFile.Close()
-
-	// 简单的二进制拼接（适用于相同格式的音频文件）
-	for i, audioFile := range audioFiles {
-		fmt.Printf("合并文件 %d/%d: %s\n", i+1, len(audioFiles), audioFile)
-
-		inputFile, err := os.Open(audioFile)
-		if err != nil {
-			fmt.Printf("警告: 打开文件失败 %s: %v\n", audioFile, err)
-			continue
-		}
-
-		_, err = io.Copy(outputFile, inputFile)
-		inputFile.Close()
-
-		if err != nil {
-			fmt.Printf("警告: 复制文件失败 %s: %v\n", audioFile, err)
-			continue
-		}
-	}
-
-	fmt.Printf("音频合并完成: %s\n", outputPath)
-	return nil
+	_, err := mergeSegmentFiles(ams.config, ams.merger, audioFiles, outputPath, ams.validateAudioFile, nil, nil)
+	return err
 }
 
 // validateAudioFile 验证音频文件的有效性
@@ -490,43 +375,18 @@ func (ams *AudioMergeService) validateAudioFile(audioPath string) error {
 		return fmt.Errorf("音频文件过小 (%d bytes)，可能为空或损坏", fileInfo.Size())
 	}
 
-	// 检查文件是否可读
-	file, err := os.Open(audioPath)
-	if err != nil {
-		return fmt.Errorf("无法打开音频文件: %v", err)
+	// 配置 audio.validate: size 时跳过文件头校验
+	if sizeOnlyValidation(ams.config) {
+		return nil
 	}
-	defer file.Close()
 
-	// 根据配置的编码格式验证文件头部
-	codec := strings.ToLower(ams.config.TTS.Codec)
-	buffer := make([]byte, 12)
-	n, err := file.Read(buffer)
-	if err != nil || n < 4 {
-		return fmt.Errorf("无法读取音频文件头部")
-	}
-
-	// 验证不同格式的文件头
-	switch codec {
-	case "mp3":
-		// MP3文件头部验证
-		if n >= 3 && (string(buffer[:3]) == "ID3" ||
-			(buffer[0] == 0xFF && (buffer[1]&0xF0) == 0xF0)) {
-			fmt.Printf("  ✓ MP3音频文件验证通过: %s (%.2f KB)\n", audioPath, float64(fileInfo.Size())/1024)
-			return nil
-		}
-		return fmt.Errorf("音频文件格式无效，可能不是有效的MP3文件")
-	case "wav":
-		// WAV文件头部验证 (RIFF....WAVE)
-		if n >= 12 && string(buffer[:4]) == "RIFF" && string(buffer[8:12]) == "WAVE" {
-			fmt.Printf("  ✓ WAV音频文件验证通过: %s (%.2f KB)\n", audioPath, float64(fileInfo.Size())/1024)
-			return nil
-		}
-		return fmt.Errorf("音频文件格式无效，可能不是有效的WAV文件")
-	default:
-		// 对于其他格式，只检查大小
-		fmt.Printf("  ✓ 音频文件验证通过: %s (%.2f KB, %s格式)\n", audioPath, float64(fileInfo.Size())/1024, codec)
-		return nil
+	// 按文件头探测实际格式校验，不依赖配置的 codec
+	format, err := validateAudioHeader(audioPath, ams.config.TTS.Codec, ams.config.Audio.StrictMP3Validation)
+	if err != nil {
+		return err
 	}
+	fmt.Printf("  ✓ %s音频文件验证通过: %s (%.2f KB)\n", audioFormatLabel(format, ams.config.TTS.Codec), audioPath, float64(fileInfo.Size())/1024)
+	return nil
 }
 
 // generateAudioWithRetry 带重试机制的音频生成
@@ -543,7 +403,7 @@ func (ams *AudioMergeService) generateAudioWithRetry(text string, index int, max
 		}
 
 		lastErr = err
-		fmt.Printf("  ✗ 第 %d 行第 %d 次尝试失败: %v\n", index+1, attempt, err)
+		Warnf("  ✗ 第 %d 行第 %d 次尝试失败: %v\n", index+1, attempt, err)
 
 		if attempt < maxRetries {
 			// 等待后重试，递增等待时间
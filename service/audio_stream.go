@@ -0,0 +1,140 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// StreamToStdout 流式输出目标为标准输出
+const StreamToStdout = "-"
+
+// streamQueueSize 等待写入流的片段队列长度，下游读取变慢时结果收集在此阻塞
+const streamQueueSize = 16
+
+// streamSegment 一个已完成任务的结果，失败任务的File为空
+type streamSegment struct {
+	Index int
+	File  string
+}
+
+// audioStream 把合成结果按任务顺序实时写入命名管道或stdout
+// 结果乱序到达时先暂存，等前面的任务都到齐后再依次写出；写入阻塞（下游未及时读取）时
+// 队列写满会让结果收集等待，已合成的片段仍保存在临时目录中，不会丢失
+type audioStream struct {
+	out     io.Writer
+	closer  io.Closer
+	order   []int
+	queue   chan streamSegment
+	done    chan struct{}
+	written int
+	err     error
+}
+
+// stdoutStream 流式输出到stdout时使用的文件，由命令行在把日志重定向到stderr前设置
+var stdoutStream = os.Stdout
+
+// SetStdoutStream 设置流式输出到 "-" 时写入的文件（通常为重定向前的原始stdout）
+func SetStdoutStream(file *os.File) {
+	stdoutStream = file
+}
+
+// newAudioStream 按 audio.stream_output 打开输出流，未配置时返回nil
+// order 为任务索引的输出顺序；打开命名管道会阻塞到下游开始读取
+func newAudioStream(config *model.Config, order []int) (*audioStream, error) {
+	target := config.Audio.StreamOutput
+	if target == "" {
+		return nil, nil
+	}
+
+	stream := &audioStream{
+		order: order,
+		queue: make(chan streamSegment, streamQueueSize),
+		done:  make(chan struct{}),
+	}
+
+	if target == StreamToStdout {
+		stream.out = stdoutStream
+	} else {
+		fmt.Printf("📡 等待读取端连接流式输出: %s\n", target)
+		file, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, filePerm)
+		if err != nil {
+			return nil, fmt.Errorf("打开流式输出失败: %v", err)
+		}
+		stream.out = file
+		stream.closer = file
+	}
+
+	go stream.run()
+	return stream, nil
+}
+
+// Send 提交一个任务结果，nil流安全
+func (s *audioStream) Send(index int, file string) {
+	if s == nil {
+		return
+	}
+	s.queue <- streamSegment{Index: index, File: file}
+}
+
+// Close 等待所有可写片段写出并关闭输出流，nil流安全
+func (s *audioStream) Close() error {
+	if s == nil {
+		return nil
+	}
+	close(s.queue)
+	<-s.done
+
+	if s.closer != nil {
+		if err := s.closer.Close(); err != nil && s.err == nil {
+			s.err = err
+		}
+	}
+	if s.err == nil {
+		fmt.Printf("📡 流式输出完成: 共写出 %d 个片段\n", s.written)
+	}
+	return s.err
+}
+
+// run 按顺序写出片段：只有当前应写的任务到达后才写出，失败的任务直接跳过
+func (s *audioStream) run() {
+	defer close(s.done)
+
+	pending := make(map[int]streamSegment)
+	next := 0
+	for segment := range s.queue {
+		pending[segment.Index] = segment
+		for next < len(s.order) {
+			ready, ok := pending[s.order[next]]
+			if !ok {
+				break
+			}
+			delete(pending, s.order[next])
+			next++
+			s.write(ready)
+		}
+	}
+}
+
+// write 把单个片段写入输出流，下游关闭后停止写入但不影响合成和合并
+func (s *audioStream) write(segment streamSegment) {
+	if s.err != nil || segment.File == "" {
+		return
+	}
+
+	file, err := os.Open(segment.File)
+	if err != nil {
+		Warnf("⚠️  流式输出跳过任务 %d: %v\n", segment.Index, err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := copyBuffered(s.out, file); err != nil {
+		s.err = fmt.Errorf("写入流式输出失败: %v", err)
+		Warnf("⚠️  %v，后续片段不再写入流\n", s.err)
+		return
+	}
+	s.written++
+}
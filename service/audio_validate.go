@@ -0,0 +1,24 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 片段校验方式
+const (
+	AudioValidateFull = "full" // 检查大小和文件头，strict_mp3_validation 时再遍历MP3帧（默认）
+	AudioValidateSize = "size" // 只检查文件存在且不小于1KB，跳过读取文件头
+)
+
+// sizeOnlyValidation 判断是否只做最小的大小检查
+// 兼容 validate: false/off 的写法，未知取值按完整校验处理
+func sizeOnlyValidation(config *model.Config) bool {
+	switch strings.ToLower(strings.TrimSpace(config.Audio.Validate)) {
+	case AudioValidateSize, "false", "off", "no":
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// sleepContext 等待 d 或 ctx 被取消，用于重试间的退避；取消时立即返回 ctx.Err()
+func sleepContext(ctx context.Context, d time.Duration) error {
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// errTaskTimeout 单次合成尝试超过 concurrent.task_timeout
+var errTaskTimeout = errors.New("合成超时")
+
+// withTaskTimeout 以独立超时执行一次合成尝试，timeout 为0时不限制
+// 超时只取消本次尝试并返回 errTaskTimeout，由调用方按失败重试；ctx 本身被取消时原样返回错误
+func withTaskTimeout(ctx context.Context, timeout time.Duration, attempt func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return attempt(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := attempt(attemptCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: 超过 %v 未完成", errTaskTimeout, timeout)
+	}
+	return err
+}
+
+// interruptContext 返回按 Ctrl+C 取消的context：第一次 Ctrl+C 停止提交新任务并打断重试等待，
+// 之后恢复默认的信号处理，再按一次 Ctrl+C 立即退出
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
+}
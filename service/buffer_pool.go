@@ -0,0 +1,70 @@
+package service
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+)
+
+// copyBufferSize 下载、合并和复制音频时使用的缓冲大小，与 io.Copy 默认值一致
+const copyBufferSize = 32 * 1024
+
+// maxPooledTextBuffer 文本缓冲超过该大小时不放回池中，避免偶发的超长文档长期占用内存
+const maxPooledTextBuffer = 64 * 1024
+
+// copyBufferPool 复用音频复制缓冲，大批量合成时避免每个片段都分配32KB
+var copyBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, copyBufferSize)
+		return &buf
+	},
+}
+
+// textBufferPool 复用逐句文本处理和Markdown渲染的缓冲
+var textBufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// copyBuffered 与 io.Copy 相同，但使用池中的缓冲
+// *os.File 实现了 ReaderFrom，bytes.Reader 等实现了 WriterTo，io.CopyBuffer 遇到它们会忽略传入的缓冲
+// （os.File.ReadFrom 对非文件来源每次另行分配32KB），因此包装后只暴露 Write/Read；
+// 两端都是文件时仍交给 io.Copy，由内核直接复制（copy_file_range/sendfile），不经过用户态缓冲
+func copyBuffered(dst io.Writer, src io.Reader) (int64, error) {
+	if _, ok := dst.(*os.File); ok {
+		if _, ok := src.(*os.File); ok {
+			return io.Copy(dst, src)
+		}
+	}
+
+	buf := copyBufferPool.Get().(*[]byte)
+	defer copyBufferPool.Put(buf)
+	return io.CopyBuffer(plainWriter{dst}, plainReader{src}, *buf)
+}
+
+// plainWriter 隐藏 io.ReaderFrom，使 io.CopyBuffer 使用传入的缓冲
+type plainWriter struct{ w io.Writer }
+
+func (p plainWriter) Write(b []byte) (int, error) { return p.w.Write(b) }
+
+// plainReader 隐藏 io.WriterTo，使 io.CopyBuffer 使用传入的缓冲
+type plainReader struct{ r io.Reader }
+
+func (p plainReader) Read(b []byte) (int, error) { return p.r.Read(b) }
+
+// getTextBuffer 从池中取出一个已清空的文本缓冲
+func getTextBuffer() *bytes.Buffer {
+	buf := textBufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putTextBuffer 把文本缓冲放回池中，调用后不能再使用 buf 及其 Bytes() 返回的切片
+func putTextBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledTextBuffer {
+		return
+	}
+	textBufferPool.Put(buf)
+}
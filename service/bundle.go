@@ -0,0 +1,201 @@
+package service
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+	"gopkg.in/yaml.v3"
+)
+
+// bundleSuffix 打包文件的后缀，与最终输出同名，如 merged_audio.bundle.zip
+const bundleSuffix = ".bundle.zip"
+
+// maskedSecret 配置快照中替换密钥的占位符
+const maskedSecret = "******"
+
+// BundleOptions 打包导出选项
+type BundleOptions struct {
+	Provider string    // 使用的TTS服务
+	Version  string    // 程序版本
+	Since    time.Time // 本次处理的开始时间，输出目录中此后写出的文件才会打包
+	LogFile  string    // --log-file 日志文件，留空时不打包日志
+}
+
+// bundleManifest 打包内的 manifest.json
+type bundleManifest struct {
+	Program   string       `json:"program"`
+	Version   string       `json:"version,omitempty"`
+	Provider  string       `json:"provider,omitempty"`
+	CreatedAt string       `json:"created_at"`
+	InputFile string       `json:"input_file,omitempty"`
+	Files     []bundleFile `json:"files"`
+}
+
+// bundleFile 打包内的一个文件
+type bundleFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+// bundlePath 返回打包文件路径：输出目录下与最终输出同名的 .bundle.zip
+func bundlePath(config *model.Config) string {
+	stem := strings.TrimSuffix(config.Audio.FinalOutput, filepath.Ext(config.Audio.FinalOutput))
+	if stem == "" {
+		stem = "output"
+	}
+	return filepath.Join(config.Audio.OutputDir, stem+bundleSuffix)
+}
+
+// WriteBundle 把本次处理的输出（音频、时间轴等）、配置快照（密钥已隐藏）、输入文件和日志打包为zip，返回打包文件路径
+// 用于归档或提交问题时附带完整上下文
+func WriteBundle(config *model.Config, opts BundleOptions) (string, error) {
+	outputPath := bundlePath(config)
+
+	// 待打包文件：包内名称 → 磁盘路径
+	sources := make(map[string]string)
+	outputs, err := bundleOutputs(config.Audio.OutputDir, opts.Since, outputPath)
+	if err != nil {
+		return "", err
+	}
+	for _, path := range outputs {
+		sources["output/"+filepath.Base(path)] = path
+	}
+	if config.InputFile != "" {
+		sources["input/"+filepath.Base(config.InputFile)] = config.InputFile
+	}
+	if opts.LogFile != "" {
+		sources["run.log"] = opts.LogFile
+	}
+
+	snapshot, err := configSnapshot(config)
+	if err != nil {
+		return "", fmt.Errorf("生成配置快照失败: %v", err)
+	}
+
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	manifest := bundleManifest{
+		Program:   ProgramName,
+		Version:   opts.Version,
+		Provider:  opts.Provider,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		InputFile: config.InputFile,
+		Files:     []bundleFile{{Name: "config.yaml", Size: int64(len(snapshot))}},
+	}
+
+	err = writeFileAtomic(outputPath, func(path string) error {
+		file, err := createFile(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		archive := zip.NewWriter(file)
+		if err := writeZipEntry(archive, "config.yaml", strings.NewReader(string(snapshot))); err != nil {
+			return err
+		}
+		for _, name := range names {
+			size, err := writeZipFile(archive, name, sources[name])
+			if err != nil {
+				Warnf("⚠️  打包跳过 %s: %v\n", sources[name], err)
+				continue
+			}
+			manifest.Files = append(manifest.Files, bundleFile{Name: name, Size: size})
+		}
+
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeZipEntry(archive, "manifest.json", strings.NewReader(string(data))); err != nil {
+			return err
+		}
+		return archive.Close()
+	})
+	if err != nil {
+		return "", fmt.Errorf("写入打包文件失败: %v", err)
+	}
+	return outputPath, nil
+}
+
+// bundleOutputs 返回输出目录中本次处理写出的文件（不含子目录和已有的打包文件）
+func bundleOutputs(outputDir string, since time.Time, bundle string) ([]string, error) {
+	entries, err := os.ReadDir(outputDir)
+	if err != nil {
+		return nil, fmt.Errorf("读取输出目录失败: %v", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, bundleSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().Before(since) {
+			continue
+		}
+		path := filepath.Join(outputDir, name)
+		if path != bundle {
+			files = append(files, path)
+		}
+	}
+	return files, nil
+}
+
+// configSnapshot 序列化本次使用的配置，隐藏密钥后才写入打包文件
+func configSnapshot(config *model.Config) ([]byte, error) {
+	snapshot := *config
+	snapshot.TencentCloud.SecretID = maskSecret(snapshot.TencentCloud.SecretID)
+	snapshot.TencentCloud.SecretKey = maskSecret(snapshot.TencentCloud.SecretKey)
+	snapshot.Upload.SecretID = maskSecret(snapshot.Upload.SecretID)
+	snapshot.Upload.SecretKey = maskSecret(snapshot.Upload.SecretKey)
+	snapshot.Network.Proxy = stripProxyUserinfo(snapshot.Network.Proxy)
+	return yaml.Marshal(&snapshot)
+}
+
+// maskSecret 非空密钥替换为占位符
+func maskSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return maskedSecret
+}
+
+// writeZipFile 把磁盘文件写入zip，返回写入的字节数
+func writeZipFile(archive *zip.Writer, name, path string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return writeZipContent(archive, name, file)
+}
+
+// writeZipEntry 写入一个zip条目
+func writeZipEntry(archive *zip.Writer, name string, content io.Reader) error {
+	_, err := writeZipContent(archive, name, content)
+	return err
+}
+
+// writeZipContent 写入一个zip条目，返回写入的字节数
+func writeZipContent(archive *zip.Writer, name string, content io.Reader) (int64, error) {
+	header := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()}
+	writer, err := archive.CreateHeader(header)
+	if err != nil {
+		return 0, err
+	}
+	return copyBuffered(writer, content)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// checksumSuffix 校验和文件后缀，追加在输出文件名之后，如 merged_audio.mp3.sha256
+const checksumSuffix = ".sha256"
+
+// ChecksumPath 返回输出文件对应的校验和文件路径
+func ChecksumPath(path string) string {
+	return path + checksumSuffix
+}
+
+// WriteChecksum 计算文件的SHA256并写入同名 .sha256 文件，返回摘要
+// 内容与 sha256sum 的输出格式一致（摘要 + 两个空格 + 文件名），在同一目录下可直接用 sha256sum -c 校验
+func WriteChecksum(path string) (string, error) {
+	digest, err := fileSHA256(path)
+	if err != nil {
+		return "", fmt.Errorf("计算校验和失败: %v", err)
+	}
+
+	line := fmt.Sprintf("%s  %s\n", digest, filepath.Base(path))
+	if err := writeFile(ChecksumPath(path), []byte(line)); err != nil {
+		return "", fmt.Errorf("写入校验和文件失败: %v", withDiskFullHint(err))
+	}
+	fmt.Printf("🔐 SHA256: %s（%s）\n", digest, ChecksumPath(path))
+	return digest, nil
+}
+
+// writeChecksum 按 audio.checksum 为合并输出写出校验和，失败只警告，不影响已生成的音频
+// 返回写出的校验和文件路径，未启用或写出失败时返回空字符串
+func writeChecksum(config *model.Config, outputPath string) string {
+	if !config.Audio.Checksum {
+		return ""
+	}
+	if _, err := WriteChecksum(outputPath); err != nil {
+		Warnf("⚠️  %v\n", err)
+		return ""
+	}
+	return ChecksumPath(outputPath)
+}
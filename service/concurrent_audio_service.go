@@ -1,26 +1,26 @@
 package service
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/difyz9/markdown2tts/model"
-	"io"
 	"net/http"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
-
-	"golang.org/x/time/rate"
 )
 
 // TTSTask TTS任务结构
 type TTSTask struct {
-	Index int
-	Text  string
+	Index    int
+	Text     string
+	Speaker  string // 对话脚本说话人，为空时使用默认音色
+	Priority int    // 合成优先级，高优先任务先出队（见 concurrent.priority_lines）
 }
 
 // TTSResult TTS任务结果
@@ -34,70 +34,99 @@ type TTSResult struct {
 type ConcurrentAudioService struct {
 	config        *model.Config
 	ttsService    *TTSService
-	limiter       *rate.Limiter
+	limiters      requestLimiters // 合成请求与状态查询各自的限速
 	textProcessor *TextProcessor
+	speakers      *speakerMatcher
+	httpClient    *http.Client
+	budget        *timeBudget
+	segmentTexts  map[string]string   // 片段文件 → 文本，用于导出时间轴
+	chapters      chapterMarks        // 片段文件 → 所属章节，用于导出m4b章节
+	metrics       *SynthesisMetrics   // 合成请求指标
+	cache         *segmentCache       // 片段缓存，未配置 audio.cache_dir 时为nil
+	progress      *resumeProgress     // 断点续传进度清单
+	fallbacks     []synthesisFallback // 最终失败任务的备选合成参数
+	mode          string              // 合成方式：auto/task/realtime
+	merger        *AudioMerger        // 音频合并组件
+	outputs       []string            // 本次处理写出的输出文件及附属文件
 }
 
 // NewConcurrentAudioService 创建并发音频服务
 func NewConcurrentAudioService(config *model.Config, ttsService *TTSService) *ConcurrentAudioService {
-	// 创建速率限制器，限制为每秒不超过配置的请求数
-	rateLimit := rate.Every(time.Second / time.Duration(config.Concurrent.RateLimit))
-	limiter := rate.NewLimiter(rateLimit, config.Concurrent.RateLimit)
-
 	return &ConcurrentAudioService{
 		config:        config,
 		ttsService:    ttsService,
-		limiter:       limiter,
-		textProcessor: NewTextProcessor(),
+		limiters:      newRequestLimiters(config, providerCapabilities[ProviderTencent]),
+		textProcessor: newTencentTextProcessor(config),
+		speakers:      newSpeakerMatcher(config.Speakers),
+		httpClient:    newHTTPClient(ResolveProxy(config), 5*time.Minute),
+		budget:        newTimeBudget(config.Concurrent.MaxDuration),
+		metrics:       newSynthesisMetrics(ProviderTencent),
+		cache:         newSegmentCache(config),
+		progress:      newResumeProgress(config),
+		fallbacks:     newSynthesisFallbacks(config),
+		mode:          tencentSynthesisMode(config),
+		merger:        NewAudioMerger(config),
 	}
 }
 
 // ProcessInputFileConcurrent 并发处理历史文件
 func (cas *ConcurrentAudioService) ProcessInputFileConcurrent() error {
+	// 提前检查导出条件，避免合成完成后才发现无法导出
+	if err := CheckExportSupport(cas.config.Audio.FinalOutput); err != nil {
+		return err
+	}
+	if err := checkProviderCodec(cas.Capabilities(), ProviderTencent, cas.config.TTS.Codec); err != nil {
+		return err
+	}
+	if err := cas.checkVoiceParams(); err != nil {
+		return err
+	}
+
 	// 确保目录存在
-	if err := os.MkdirAll(cas.config.Audio.TempDir, 0755); err != nil {
+	if err := makeDirs(cas.config.Audio.TempDir); err != nil {
 		return fmt.Errorf("创建临时目录失败: %v", err)
 	}
-	if err := os.MkdirAll(cas.config.Audio.OutputDir, 0755); err != nil {
+	if err := makeDirs(cas.config.Audio.OutputDir); err != nil {
 		return fmt.Errorf("创建输出目录失败: %v", err)
 	}
 
 	// 读取历史文件
-	lines, err := cas.readInputFile()
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf("读取到 %d 行文本，开始并发生成音频...\n", len(lines))
-	fmt.Printf("并发配置: workers=%d, rate_limit=%d/秒, batch_size=%d\n",
+	fmt.Println("开始流式读取文本并并发生成音频...")
+	fmt.Printf("并发配置: workers=%d, 限速: %s, batch_size=%d\n",
 		cas.config.Concurrent.MaxWorkers,
-		cas.config.Concurrent.RateLimit,
+		cas.limiters.describe(),
 		cas.config.Concurrent.BatchSize)
 
 	// 创建任务列表
-	tasks := make([]TTSTask, 0, len(lines))
+	var tasks []TTSTask
 	validLineCount := 0
 	emptyLineCount := 0
 	markdownLineCount := 0
 	invalidTextCount := 0
 
-	for i, line := range lines {
+	diagnostics := newFilterDiagnostics()
+	deduper := newLineDeduper(cas.config)
+	limiter := newLengthLimiter(cas.config, cas.maxTextLength())
+	priorities := newTaskPriorities(cas.config)
+	lineCount, err := forEachInputLine(cas.config.InputFile, func(i int, line string) {
 		trimmedLine := strings.TrimSpace(line)
 
 		// 跳过完全空行
 		if trimmedLine == "" {
 			emptyLineCount++
-			continue
+			diagnostics.record(i, line, FilterReasonEmpty)
+			return
 		}
 
 		// 跳过只包含空白字符的行
 		if len(strings.ReplaceAll(strings.ReplaceAll(trimmedLine, " ", ""), "\t", "")) == 0 {
 			emptyLineCount++
-			continue
+			diagnostics.record(i, line, FilterReasonEmpty)
+			return
 		}
 
-		// 快速过滤明显的标记行（仅针对行首的标记）
-		if strings.HasPrefix(trimmedLine, "## ") ||
+		// 快速过滤明显的标记行（仅针对行首的标记，纯文本输入不过滤）
+		if !cas.textProcessor.plainText && (strings.HasPrefix(trimmedLine, "## ") ||
 			strings.HasPrefix(trimmedLine, "### ") ||
 			strings.HasPrefix(trimmedLine, "#### ") ||
 			strings.HasPrefix(trimmedLine, "** ") ||
@@ -107,35 +136,73 @@ func (cas *ConcurrentAudioService) ProcessInputFileConcurrent() error {
 			trimmedLine == "####" ||
 			trimmedLine == "**" ||
 			trimmedLine == "***" ||
-			strings.HasPrefix(trimmedLine, "-- ") ||
-			strings.HasPrefix(trimmedLine, "-----") {
+			strings.HasPrefix(trimmedLine, "-----")) {
 			markdownLineCount++
-			continue // 跳过标记行
+			diagnostics.record(i, line, FilterReasonMarkdown)
+			return // 跳过标记行
 		}
 
+		// 识别对话脚本的说话人前缀
+		speaker, line := cas.speakers.parseLine(line)
+
 		// 使用文本处理器进行详细预处理和验证
-		if !cas.textProcessor.IsValidTextForTTS(line) {
+		if reason := cas.textProcessor.FilterReason(line); reason != "" {
 			invalidTextCount++
-			continue // 跳过无效行
+			diagnostics.record(i, line, reason)
+			return // 跳过无效行
 		}
 
 		// 处理文本以优化TTS效果
 		processedText := cas.textProcessor.ProcessText(line)
 		if processedText == "" {
 			invalidTextCount++
-			continue
+			diagnostics.record(i, line, FilterReasonProcessedEmpty)
+			return
+		}
+
+		// 可选去除重复句子
+		if deduper.duplicate(processedText) {
+			return
 		}
 
+		// 按 min_chars/max_chars 过滤或切分
+		parts, reason := limiter.fit(processedText)
+		if reason != "" {
+			invalidTextCount++
+			diagnostics.record(i, line, reason)
+			return
+		}
+
+		// 长句切分后一行可能对应多个任务，任务按顺序编号
 		validLineCount++
-		tasks = append(tasks, TTSTask{Index: i, Text: processedText})
+		for _, part := range parts {
+			tasks = append(tasks, TTSTask{Index: len(tasks), Text: part, Speaker: speaker, Priority: priorities.ofLine(i)})
+		}
+	})
+	if err != nil {
+		return err
 	}
 
 	if len(tasks) == 0 {
-		return fmt.Errorf("没有有效的文本行需要处理")
+		return diagnostics.noValidLinesError(lineCount)
 	}
 
 	fmt.Printf("📊 文本处理统计: 总行数=%d, 空行=%d, 标记行=%d, 无效文本=%d, 有效任务=%d\n",
-		len(lines), emptyLineCount, markdownLineCount, invalidTextCount, len(tasks))
+		lineCount, emptyLineCount, markdownLineCount, invalidTextCount, len(tasks))
+	deduper.report()
+	limiter.report()
+	cas.textProcessor.ReportRedactions()
+
+	// 检查文本语言与音色是否匹配
+	cas.checkVoiceLanguage(tasks)
+
+	// 提前检查磁盘空间，避免合成到一半因磁盘已满失败
+	if err := cas.checkDiskSpace(tasks); err != nil {
+		return err
+	}
+
+	// 添加片头/片尾语任务
+	tasks = cas.withIntroOutroTasks(tasks)
 
 	// 并发处理任务
 	results, err := cas.processTTSTasksConcurrent(tasks)
@@ -152,6 +219,12 @@ func (cas *ConcurrentAudioService) ProcessInputFileConcurrent() error {
 		return results[i].Index < results[j].Index
 	})
 
+	// 合并前确认片段数量与有效句子数量一致
+	if err := cas.checkSegmentCount(tasks, results); err != nil {
+		return err
+	}
+	cas.rememberSegmentTexts(tasks, results)
+
 	// 提取音频文件路径
 	audioFiles := make([]string, len(results))
 	for i, result := range results {
@@ -159,146 +232,362 @@ func (cas *ConcurrentAudioService) ProcessInputFileConcurrent() error {
 	}
 
 	// 合并音频文件
-	return cas.mergeAudioFiles(audioFiles)
+	return cas.mergeAudioFiles(withIntroOutroFiles(cas.config, audioFiles))
+}
+
+// downloadJob 已合成完成、等待下载的任务
+type downloadJob struct {
+	Index    int
+	Text     string
+	AudioURL string
+	TaskID   string // 合成任务ID，URL过期时用于重新查询
+	CacheKey string // 下载成功后保存到片段缓存的键
+}
+
+// withIntroOutroTasks 在任务列表首尾添加片头/片尾语任务
+func (cas *ConcurrentAudioService) withIntroOutroTasks(tasks []TTSTask) []TTSTask {
+	intro, outro := introOutroTexts(cas.config)
+	if intro != "" {
+		tasks = append([]TTSTask{{Index: introTaskIndex, Text: cas.textProcessor.ProcessText(intro)}}, tasks...)
+	}
+	if outro != "" {
+		outroIndex := 0
+		if len(tasks) > 0 {
+			outroIndex = tasks[len(tasks)-1].Index + 1
+		}
+		tasks = append(tasks, TTSTask{Index: outroIndex, Text: cas.textProcessor.ProcessText(outro)})
+	}
+	return tasks
 }
 
 // processTTSTasksConcurrent 并发处理TTS任务
+// 合成（创建任务+轮询，受配额限制）与下载（受带宽限制）拆成两级流水线，
+// 两级各自有独立的并发度，通过有界队列连接；结果边产生边消费，缓冲只与worker数量有关，与任务总数无关
 func (cas *ConcurrentAudioService) processTTSTasksConcurrent(tasks []TTSTask) ([]TTSResult, error) {
-	ctx := context.Background()
+	ctx, stop := interruptContext()
+	defer stop()
 
-	// 创建任务通道和结果通道
-	taskChan := make(chan TTSTask, len(tasks))
-	resultChan := make(chan TTSResult, len(tasks))
+	// 任务按优先级出队
+	queue := enqueueTasks(tasks, func(task TTSTask) int { return task.Priority })
 
-	// 发送所有任务到通道
-	for _, task := range tasks {
-		taskChan <- task
+	// 按任务顺序实时写出到命名管道/stdout（如已配置）
+	order := make([]int, len(tasks))
+	for i, task := range tasks {
+		order[i] = task.Index
 	}
-	close(taskChan)
-
-	// 启动worker goroutines
-	var wg sync.WaitGroup
-	numWorkers := cas.config.Concurrent.MaxWorkers
-	if numWorkers > len(tasks) {
-		numWorkers = len(tasks)
+	stream, err := newAudioStream(cas.config, order)
+	if err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("启动 %d 个worker开始处理...\n", numWorkers)
+	// 确定两级worker数量（不超过任务数和goroutine上限）
+	numWorkers, numDownloaders := pipelineWorkerCounts(cas.config, len(tasks))
+
+	// 有界下载队列：合成快于下载时阻塞合成worker，避免堆积
+	downloadChan := make(chan downloadJob, numDownloaders*2)
+
+	// 有界结果通道：收集端处理不过来时worker阻塞等待，而不是为全部任务预留缓冲
+	resultChan := make(chan TTSResult, resultBufferSize(numWorkers+numDownloaders))
+
+	fmt.Printf("启动 %d 个合成worker、%d 个下载worker开始处理...\n", numWorkers, numDownloaders)
 
+	var synthWg sync.WaitGroup
 	for i := 0; i < numWorkers; i++ {
-		wg.Add(1)
+		synthWg.Add(1)
 		go func(workerID int) {
-			defer wg.Done()
-			cas.worker(ctx, workerID, taskChan, resultChan)
+			defer synthWg.Done()
+			cas.worker(ctx, workerID, queue, downloadChan, resultChan)
 		}(i)
 	}
 
-	// 等待所有worker完成
+	// 合成全部结束后关闭下载队列
 	go func() {
-		wg.Wait()
+		synthWg.Wait()
+		close(downloadChan)
+	}()
+
+	var downloadWg sync.WaitGroup
+	for i := 0; i < numDownloaders; i++ {
+		downloadWg.Add(1)
+		go func(workerID int) {
+			defer downloadWg.Done()
+			cas.downloadWorker(ctx, workerID, downloadChan, resultChan)
+		}(i)
+	}
+
+	// 等待所有下载worker完成
+	go func() {
+		downloadWg.Wait()
 		close(resultChan)
 	}()
 
-	// 收集结果
+	// 流式消费结果：逐个写出流、统计，只保留成功片段供合并
 	var results []TTSResult
 	successCount := 0
 	failCount := 0
-
-	for result := range resultChan {
-		if result.Error != nil {
-			fmt.Printf("任务 %d 失败: %v\n", result.Index, result.Error)
+	skippedCount := 0
+	interruptedCount := 0
+	failures := NewFailureStats()
+
+	handle := func(result TTSResult) {
+		if errors.Is(result.Error, context.Canceled) {
+			stream.Send(result.Index, "")
+			interruptedCount++
+		} else if result.Error == errBudgetExceeded {
+			stream.Send(result.Index, "")
+			skippedCount++
+		} else if result.Error != nil {
+			stream.Send(result.Index, "")
+			Failf("任务 %d 失败: %v\n", result.Index, result.Error)
+			failures.Add(result.Error)
 			failCount++
 		} else {
+			stream.Send(result.Index, result.AudioFile)
 			fmt.Printf("✓ 任务 %d 完成: %s\n", result.Index, result.AudioFile)
 			results = append(results, result)
 			successCount++
 		}
 	}
 
+	// 配置了备选参数时，最终失败的任务等主流程结束后再试一轮，流式输出在该任务处等待
+	var failed []TTSResult
+	for result := range resultChan {
+		if len(cas.fallbacks) > 0 && result.Error != nil && result.Error != errBudgetExceeded && !errors.Is(result.Error, context.Canceled) {
+			failed = append(failed, result)
+			continue
+		}
+		handle(result)
+	}
+	if len(failed) > 0 && ctx.Err() == nil {
+		failed = cas.retryWithFallbacks(ctx, tasks, failed)
+	}
+	for _, result := range failed {
+		handle(result)
+	}
+
+	if err := stream.Close(); err != nil {
+		Warnf("⚠️  流式输出未完整: %v\n", err)
+	}
+	cas.progress.flush()
+	cas.progress.report()
+	cas.cache.report()
+
 	fmt.Printf("\n处理完成: 成功 %d, 失败 %d\n", successCount, failCount)
+	failures.Print()
+	cas.budget.printSkipped(skippedCount)
+
+	// 按需写出指标快照
+	cas.metrics.setTasks(successCount, failCount, skippedCount)
+	cas.metrics.setCache(cas.cache.counts())
+	writeMetrics(cas.config.MetricsFile, cas.metrics)
+
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("处理已中断（Ctrl+C），%d 个任务未完成", interruptedCount)
+	}
 	return results, nil
 }
 
-// worker 工作goroutine
-func (cas *ConcurrentAudioService) worker(ctx context.Context, workerID int, taskChan <-chan TTSTask, resultChan chan<- TTSResult) {
-	for task := range taskChan {
-		// 等待速率限制
-		if err := cas.limiter.Wait(ctx); err != nil {
+// worker 合成工作goroutine：创建TTS任务并等待完成，将音频URL交给下载队列
+func (cas *ConcurrentAudioService) worker(ctx context.Context, workerID int, queue *taskQueue[TTSTask], downloadChan chan<- downloadJob, resultChan chan<- TTSResult) {
+	for {
+		task, ok := queue.Pop()
+		if !ok {
+			return
+		}
+
+		// 已中断时不再提交新任务
+		if ctx.Err() != nil {
+			resultChan <- TTSResult{Index: task.Index, Error: ctx.Err()}
+			continue
+		}
+
+		// 超出时长预算后不再提交新任务
+		if cas.budget.Expired() {
+			resultChan <- TTSResult{Index: task.Index, Error: errBudgetExceeded}
+			continue
+		}
+
+		// 文本和音色参数未变的片段直接复用上次生成的文件或缓存，不占用速率限制
+		cacheKey := cas.segmentCacheKey(task)
+		audioFile := filepath.Join(cas.config.Audio.TempDir, segmentFilename(task.Index, cas.config.TTS.Codec))
+		if cas.progress.reuse(task.Index, cacheKey, audioFile, cas.validateAudioFile) {
+			fmt.Printf("Worker %d 复用已完成片段 %d: %s\n", workerID, task.Index, task.Text)
+			resultChan <- TTSResult{Index: task.Index, AudioFile: audioFile}
+			continue
+		}
+		if cas.cache.restore(cacheKey, cas.config.TTS.Codec, audioFile) {
+			fmt.Printf("Worker %d 复用缓存片段 %d: %s\n", workerID, task.Index, task.Text)
+			cas.progress.record(task.Index, cacheKey, audioFile)
+			resultChan <- TTSResult{Index: task.Index, AudioFile: audioFile}
+			continue
+		}
+
+		fmt.Printf("Worker %d 处理任务 %d: %s\n", workerID, task.Index, task.Text)
+
+		// 短文本走实时接口，直接得到音频文件，不经过轮询和下载
+		if useRealtime(cas.mode, task.Text) {
+			err := cas.retryTask(ctx, task, 3, func(ctx context.Context, t TTSTask) error {
+				return cas.synthesizeRealtime(ctx, t, audioFile)
+			})
+			if err != nil {
+				resultChan <- TTSResult{Index: task.Index, Error: err}
+				continue
+			}
+			cas.cache.store(cacheKey, cas.config.TTS.Codec, audioFile)
+			cas.progress.record(task.Index, cacheKey, audioFile)
+			resultChan <- TTSResult{Index: task.Index, AudioFile: audioFile}
+			continue
+		}
+
+		// 合成音频，带重试机制
+		audioURL, taskID, err := cas.synthesizeWithRetry(ctx, task, 3)
+		if err != nil {
 			resultChan <- TTSResult{
 				Index: task.Index,
-				Error: fmt.Errorf("worker %d 等待速率限制失败: %v", workerID, err),
+				Error: err,
 			}
 			continue
 		}
 
-		fmt.Printf("Worker %d 处理任务 %d: %s\n", workerID, task.Index, task.Text)
+		downloadChan <- downloadJob{Index: task.Index, Text: task.Text, AudioURL: audioURL, TaskID: taskID, CacheKey: cacheKey}
+	}
+}
 
-		// 处理TTS任务，带重试机制
-		audioFile, err := cas.generateAudioWithRetry(task.Text, task.Index, 3)
+// downloadWorker 下载工作goroutine：下载并验证音频文件
+func (cas *ConcurrentAudioService) downloadWorker(ctx context.Context, workerID int, downloadChan <-chan downloadJob, resultChan chan<- TTSResult) {
+	for job := range downloadChan {
+		audioFile, err := cas.downloadWithRetry(ctx, job.AudioURL, job.TaskID, job.Index, 3)
+		if errors.Is(err, context.Canceled) {
+			resultChan <- TTSResult{Index: job.Index, Error: err}
+			continue
+		}
+		if err != nil {
+			err = fmt.Errorf("下载worker %d: %w", workerID, err)
+		} else {
+			cas.cache.store(job.CacheKey, cas.config.TTS.Codec, audioFile)
+			cas.progress.record(job.Index, job.CacheKey, audioFile)
+		}
 
 		resultChan <- TTSResult{
-			Index:     task.Index,
+			Index:     job.Index,
 			AudioFile: audioFile,
 			Error:     err,
 		}
 	}
 }
 
-// readInputFile 读取历史文件
-func (cas *ConcurrentAudioService) readInputFile() ([]string, error) {
-	file, err := os.Open(cas.config.InputFile)
-	if err != nil {
-		return nil, fmt.Errorf("打开历史文件失败: %v", err)
+// voiceTypeOf 返回任务使用的音色，说话人配置了音色时覆盖默认音色
+func (cas *ConcurrentAudioService) voiceTypeOf(task TTSTask) int64 {
+	if speaker, ok := cas.config.Speakers[task.Speaker]; ok && speaker.VoiceType != 0 {
+		return speaker.VoiceType
 	}
-	defer file.Close()
+	return cas.config.TTS.VoiceType
+}
 
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// checkVoiceParams 开始合成前校验默认音色和说话人音色是否支持配置的采样率和情感参数，避免每个请求都被拒绝
+func (cas *ConcurrentAudioService) checkVoiceParams() error {
+	tts := cas.config.TTS
+	voiceTypes := []int64{tts.VoiceType}
+	for _, speaker := range cas.config.Speakers {
+		if speaker.VoiceType != 0 {
+			voiceTypes = append(voiceTypes, speaker.VoiceType)
+		}
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("读取历史文件失败: %v", err)
+	for _, voiceType := range voiceTypes {
+		if err := checkTencentVoiceParams(voiceType, tts.SampleRate, tts.EmotionCategory); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	return lines, nil
+// segmentCacheKey 计算任务的片段缓存键，包含所有会影响合成结果的请求参数
+func (cas *ConcurrentAudioService) segmentCacheKey(task TTSTask) string {
+	tts := cas.config.TTS
+	return segmentCacheKey(ProviderTencent, cas.voiceTypeOf(task), TencentVolume(cas.config), tts.Speed,
+		tts.PrimaryLanguage, tts.SampleRate, tts.Codec, tts.EmotionCategory, tts.EmotionIntensity, tts.Pronunciations, task.Text)
 }
 
-// generateAudioForText 为文本生成音频
-func (cas *ConcurrentAudioService) generateAudioForText(text string, index int) (string, error) {
-	// 创建TTS请求
-	req := &model.TTSRequest{
-		Text:            text,
-		VoiceType:       cas.config.TTS.VoiceType,
-		Volume:          cas.config.TTS.Volume,
-		Speed:           cas.config.TTS.Speed,
-		PrimaryLanguage: cas.config.TTS.PrimaryLanguage,
-		SampleRate:      cas.config.TTS.SampleRate,
-		Codec:           cas.config.TTS.Codec,
+// SynthesizeSample 使用指定音色合成一段试听文本并下载到输出路径
+func (cas *ConcurrentAudioService) SynthesizeSample(text string, voiceType int64, outputPath string) error {
+	processedText := cas.textProcessor.ProcessText(text)
+	if strings.TrimSpace(processedText) == "" {
+		return errEmptyText
 	}
 
-	// 创建TTS任务
-	resp, err := cas.ttsService.CreateTTSTask(req)
+	audioURL, _, err := cas.synthesizeWithVoice(context.Background(), processedText, voiceType)
 	if err != nil {
-		return "", err
+		return err
 	}
+	return writeFileAtomic(outputPath, func(path string) error {
+		if err := cas.downloadAudio(audioURL, path); err != nil {
+			return err
+		}
+		return cas.validateAudioFile(path)
+	})
+}
 
-	if !resp.Success {
-		return "", fmt.Errorf("创建TTS任务失败: %s", resp.Error)
+// synthesizeWithVoice 使用指定音色创建TTS任务并等待完成，返回音频URL和任务ID
+func (cas *ConcurrentAudioService) synthesizeWithVoice(ctx context.Context, text string, voiceType int64) (string, string, error) {
+	return cas.synthesizeRequest(ctx, cas.newTTSRequest(text, voiceType))
+}
+
+// synthesizeRequest 按请求参数创建TTS任务并等待完成，返回音频URL和任务ID
+func (cas *ConcurrentAudioService) synthesizeRequest(ctx context.Context, req *model.TTSRequest) (string, string, error) {
+	taskID, err := cas.createTTSTask(ctx, req)
+	if err != nil {
+		return "", "", err
 	}
 
 	// 等待任务完成并获取音频URL
-	audioURL, err := cas.waitForTTSCompletion(resp.TaskID)
+	audioURL, err := cas.waitForTTSCompletion(ctx, taskID)
+	return audioURL, taskID, err
+}
+
+// createTTSTask 创建TTS任务，返回任务ID
+func (cas *ConcurrentAudioService) createTTSTask(ctx context.Context, req *model.TTSRequest) (string, error) {
+	// 创建TTS任务，每次请求（含重试）都计入合成配额
+	if err := cas.limiters.create.Wait(ctx); err != nil {
+		return "", fmt.Errorf("等待速率限制失败: %w", err)
+	}
+	resp, err := cas.ttsService.CreateTTSTaskContext(ctx, req)
 	if err != nil {
 		return "", err
 	}
 
-	// 下载音频文件
-	filename := fmt.Sprintf("audio_%03d.%s", index, cas.config.TTS.Codec)
+	if !resp.Success {
+		return "", fmt.Errorf("%w: 无法创建任务: %s", errTTSTaskFailed, resp.Error)
+	}
+	return resp.TaskID, nil
+}
+
+// newTTSRequest 按配置构造合成请求
+func (cas *ConcurrentAudioService) newTTSRequest(text string, voiceType int64) *model.TTSRequest {
+	req := &model.TTSRequest{
+		Text:            text,
+		VoiceType:       voiceType,
+		Volume:          TencentVolume(cas.config),
+		Speed:           cas.config.TTS.Speed,
+		PrimaryLanguage: cas.config.TTS.PrimaryLanguage,
+		SampleRate:      cas.config.TTS.SampleRate,
+		Codec:           cas.config.TTS.Codec,
+		Pronunciations:  cas.config.TTS.Pronunciations,
+		EnableSSML:      cas.config.TTS.SSML,
+	}
+	// 只对支持情感参数的provider传入情感设置
+	if cas.Capabilities().Emotion {
+		req.EmotionCategory = cas.config.TTS.EmotionCategory
+		req.EmotionIntensity = cas.config.TTS.EmotionIntensity
+	}
+	return req
+}
+
+// downloadAndValidate 下载音频文件并验证
+func (cas *ConcurrentAudioService) downloadAndValidate(audioURL string, index int) (string, error) {
+	filename := segmentFilename(index, cas.config.TTS.Codec)
 	audioFile := filepath.Join(cas.config.Audio.TempDir, filename)
 
-	err = cas.downloadAudio(audioURL, audioFile)
+	err := cas.downloadAudio(audioURL, audioFile)
 	if err != nil {
 		return "", err
 	}
@@ -307,19 +596,35 @@ func (cas *ConcurrentAudioService) generateAudioForText(text string, index int)
 	if err := cas.validateAudioFile(audioFile); err != nil {
 		// 删除无效的音频文件
 		os.Remove(audioFile)
-		return "", fmt.Errorf("音频文件验证失败: %v", err)
+		return "", fmt.Errorf("%w: %v", errInvalidAudio, err)
 	}
 
 	return audioFile, nil
 }
 
-// waitForTTSCompletion 等待TTS任务完成
-func (cas *ConcurrentAudioService) waitForTTSCompletion(taskID string) (string, error) {
-	maxRetries := 30 // 最多等待3分钟
-	retryInterval := 6 * time.Second
+// 任务状态轮询参数：短句通常几秒内完成，先密后疏地轮询以尽早发现完成并交给下载级
+const (
+	pollInitialInterval = 1 * time.Second
+	pollMaxInterval     = 6 * time.Second
+	pollTimeout         = 3 * time.Minute
+)
+
+// errTTSTaskFailed 服务端报告任务失败，重试时需要重新创建任务
+var errTTSTaskFailed = errors.New("TTS任务失败")
 
-	for i := 0; i < maxRetries; i++ {
-		statusResp, err := cas.ttsService.DescribeTTSTaskStatus(taskID)
+// waitForTTSCompletion 等待TTS任务完成
+// 腾讯云长文本合成只提供异步接口（回调需要公网地址），这里采用退避轮询；
+// 任务完成后立即把URL交给下载级，下载与后续任务的提交和轮询并行进行
+func (cas *ConcurrentAudioService) waitForTTSCompletion(ctx context.Context, taskID string) (string, error) {
+	retryInterval := pollInitialInterval
+	deadline := time.Now().Add(pollTimeout)
+
+	for time.Now().Before(deadline) {
+		// 状态查询单独限速，轮询不占用合成请求的配额
+		if err := cas.limiters.status.Wait(ctx); err != nil {
+			return "", fmt.Errorf("等待速率限制失败: %w", err)
+		}
+		statusResp, err := cas.ttsService.DescribeTTSTaskStatusContext(ctx, taskID)
 		if err != nil {
 			return "", err
 		}
@@ -331,44 +636,59 @@ func (cas *ConcurrentAudioService) waitForTTSCompletion(taskID string) (string,
 		// 状态码：2表示成功
 		if statusResp.Status == 2 {
 			if statusResp.AudioURL == "" {
-				return "", fmt.Errorf("TTS任务完成但未获取到音频URL")
+				return "", fmt.Errorf("%w: 任务完成但未获取到音频URL", errTTSTaskFailed)
 			}
 			return statusResp.AudioURL, nil
 		}
 
 		// 状态码：-1表示失败
 		if statusResp.Status == -1 {
-			return "", fmt.Errorf("TTS任务失败: %s", statusResp.ErrorMsg)
+			return "", fmt.Errorf("%w: %s", errTTSTaskFailed, statusResp.ErrorMsg)
 		}
 
-		// 等待后重试
-		time.Sleep(retryInterval)
+		// 等待后重试，间隔逐步拉长，中断时立即停止轮询
+		if err := sleepContext(ctx, retryInterval); err != nil {
+			return "", err
+		}
+		retryInterval = retryInterval * 3 / 2
+		if retryInterval > pollMaxInterval {
+			retryInterval = pollMaxInterval
+		}
 	}
 
-	return "", fmt.Errorf("TTS任务超时，任务ID: %s", taskID)
+	return "", fmt.Errorf("%w，任务ID: %s", errTTSTaskTimeout, taskID)
 }
 
 // downloadAudio 下载音频文件
 func (cas *ConcurrentAudioService) downloadAudio(url, filepath string) error {
-	resp, err := http.Get(url)
+	resp, err := cas.httpClient.Get(url)
 	if err != nil {
-		return fmt.Errorf("下载音频失败: %v", err)
+		return fmt.Errorf("%w: %w", errDownloadFailed, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("下载音频失败，状态码: %d", resp.StatusCode)
+		if isExpiredURLStatus(url, resp.StatusCode) {
+			return fmt.Errorf("%w: %w（状态码: %d）", errDownloadFailed, errAudioURLExpired, resp.StatusCode)
+		}
+		return fmt.Errorf("%w，状态码: %d", errDownloadFailed, resp.StatusCode)
 	}
 
-	file, err := os.Create(filepath)
+	// 确认返回的是音频而不是错误页
+	body, err := audioResponseBody(resp)
 	if err != nil {
-		return fmt.Errorf("创建音频文件失败: %v", err)
+		return err
+	}
+
+	file, err := createFile(filepath)
+	if err != nil {
+		return fmt.Errorf("创建音频文件失败: %v", withDiskFullHint(err))
 	}
 	defer file.Close()
 
-	_, err = io.Copy(file, resp.Body)
+	_, err = copyBuffered(file, body)
 	if err != nil {
-		return fmt.Errorf("保存音频文件失败: %v", err)
+		return fmt.Errorf("保存音频文件失败: %v", withDiskFullHint(err))
 	}
 
 	return nil
@@ -376,238 +696,312 @@ func (cas *ConcurrentAudioService) downloadAudio(url, filepath string) error {
 
 // mergeAudioFiles 合并音频文件
 func (cas *ConcurrentAudioService) mergeAudioFiles(audioFiles []string) error {
-	fmt.Printf("\n开始合并 %d 个音频文件...\n", len(audioFiles))
-
-	// 预先验证所有音频文件
-	validAudioFiles := []string{}
-	invalidCount := 0
-
-	for _, audioFile := range audioFiles {
-		if err := cas.validateAudioFile(audioFile); err != nil {
-			fmt.Printf("⚠️  跳过无效音频文件: %s, 原因: %v\n", audioFile, err)
-			invalidCount++
-			// 删除无效文件
-			os.Remove(audioFile)
-			continue
-		}
-		validAudioFiles = append(validAudioFiles, audioFile)
-	}
-
-	if len(validAudioFiles) == 0 {
-		return fmt.Errorf("没有有效的音频文件可以合并")
-	}
+	return cas.recordOutputs(cas.mergeAudioFilesTo(audioFiles, filepath.Join(cas.config.Audio.OutputDir, cas.config.Audio.FinalOutput)))
+}
 
-	if invalidCount > 0 {
-		fmt.Printf("📊 音频文件验证统计: 有效 %d, 无效 %d\n", len(validAudioFiles), invalidCount)
-	}
+// mergeAudioFilesTo 合并音频文件到指定输出路径，返回写出的文件
+func (cas *ConcurrentAudioService) mergeAudioFilesTo(audioFiles []string, outputPath string) ([]string, error) {
+	return mergeSegmentFiles(cas.config, cas.merger, audioFiles, outputPath, cas.validateAudioFile, cas.segmentTexts, cas.chapters)
+}
 
-	outputPath := filepath.Join(cas.config.Audio.OutputDir, cas.config.Audio.FinalOutput)
+// recordOutputs 记录合并写出的文件，原样返回合并错误
+func (cas *ConcurrentAudioService) recordOutputs(outputs []string, err error) error {
+	cas.outputs = append(cas.outputs, outputs...)
+	return err
+}
 
-	// 创建一个临时的文件列表
-	listFile := filepath.Join(cas.config.Audio.TempDir, "file_list.txt")
+// Outputs 返回本次处理实际写出的输出文件及其时间轴、校验和等附属文件
+func (cas *ConcurrentAudioService) Outputs() []string {
+	return cas.outputs
+}
 
-	// 写入文件列表（使用验证过的音频文件）
-	err := cas.createFileList(validAudioFiles, listFile)
+// validateAudioFile 验证音频文件的有效性
+func (cas *ConcurrentAudioService) validateAudioFile(audioPath string) error {
+	// 检查文件是否存在
+	fileInfo, err := os.Stat(audioPath)
 	if err != nil {
-		return err
+		return fmt.Errorf("音频文件不存在: %v", err)
 	}
-	defer os.Remove(listFile)
 
-	// 使用简单合并
-	return cas.simpleAudioMerge(listFile, outputPath)
-}
-
-// createFileList 创建文件列表
-func (cas *ConcurrentAudioService) createFileList(audioFiles []string, listFile string) error {
-	file, err := os.Create(listFile)
-	if err != nil {
-		return fmt.Errorf("创建文件列表失败: %v", err)
+	// 检查文件大小（音频文件通常至少几KB）
+	const minFileSize = 1024 // 最小1KB
+	if fileInfo.Size() < minFileSize {
+		return fmt.Errorf("音频文件过小 (%d bytes)，可能为空或损坏", fileInfo.Size())
 	}
-	defer file.Close()
 
-	for _, audioFile := range audioFiles {
-		_, err := fmt.Fprintf(file, "file '%s'\n", audioFile)
-		if err != nil {
-			return fmt.Errorf("写入文件列表失败: %v", err)
-		}
+	// 配置 audio.validate: size 时跳过文件头校验
+	if sizeOnlyValidation(cas.config) {
+		return nil
 	}
 
+	// 按文件头探测实际格式校验，不依赖配置的 codec
+	format, err := validateAudioHeader(audioPath, cas.config.TTS.Codec, cas.config.Audio.StrictMP3Validation)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("  ✓ %s音频文件验证通过: %s (%.2f KB)\n", audioFormatLabel(format, cas.config.TTS.Codec), audioPath, float64(fileInfo.Size())/1024)
 	return nil
 }
 
-// simpleAudioMerge 简单的音频文件合并
-func (cas *ConcurrentAudioService) simpleAudioMerge(listFile, outputPath string) error {
-	// 读取文件列表
-	listContent, err := os.ReadFile(listFile)
-	if err != nil {
-		return fmt.Errorf("读取文件列表失败: %v", err)
-	}
+// synthesizeWithRetry 带重试机制的音频合成，返回音频URL和任务ID
+// 任务已创建但轮询超时（如超过 task_timeout）或出错时，重试继续查询同一任务，不重复创建计费任务；
+// 只有服务端报告任务失败时才重新创建
+func (cas *ConcurrentAudioService) synthesizeWithRetry(ctx context.Context, task TTSTask, maxRetries int) (string, string, error) {
+	var audioURL, taskID string
+	err := cas.retryTask(ctx, task, maxRetries, func(ctx context.Context, t TTSTask) error {
+		if taskID == "" {
+			id, err := cas.createTTSTask(ctx, cas.newTTSRequest(t.Text, cas.voiceTypeOf(t)))
+			if err != nil {
+				return err
+			}
+			taskID = id
+		} else {
+			fmt.Printf("  ⏳ 任务 %d 继续查询已创建的TTS任务 %s\n", t.Index, taskID)
+		}
 
-	lines := strings.Split(string(listContent), "\n")
-	var audioFiles []string
+		url, err := cas.waitForTTSCompletion(ctx, taskID)
+		if errors.Is(err, errTTSTaskFailed) {
+			taskID = ""
+		}
+		audioURL = url
+		return err
+	})
+	return audioURL, taskID, err
+}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+// retryTask 带重试地执行一次合成，最后一次重试前降级为激进清洗后的文本
+// 每次尝试受 concurrent.task_timeout 限制，超时按失败重试；重试间的等待可被 ctx 取消，取消时返回 ctx.Err()
+func (cas *ConcurrentAudioService) retryTask(ctx context.Context, task TTSTask, maxRetries int, synthesize func(context.Context, TTSTask) error) error {
+	var lastErr error
+	index := task.Index
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		// 最后一次重试前降级为激进清洗后的文本，规避引擎不接受的残留字符
+		current := task
+		if attempt == maxRetries && attempt > 1 {
+			current = cas.degradeTask(task)
 		}
-		// 解析 "file 'path'" 格式
-		if strings.HasPrefix(line, "file '") && strings.HasSuffix(line, "'") {
-			filepath := line[6 : len(line)-1]
-			audioFiles = append(audioFiles, filepath)
+
+		start := time.Now()
+		err := withTaskTimeout(ctx, cas.config.Concurrent.TaskTimeout, func(ctx context.Context) error {
+			return synthesize(ctx, current)
+		})
+		cas.metrics.observeRequest(attempt, time.Since(start), err)
+		if err == nil {
+			if attempt > 1 {
+				fmt.Printf("  ✓ 任务 %d 重试第 %d 次成功\n", index, attempt-1)
+			}
+			return nil
 		}
-	}
 
-	if len(audioFiles) == 0 {
-		return fmt.Errorf("没有找到要合并的音频文件")
-	}
+		lastErr = err
+		Warnf("  ✗ 任务 %d 第 %d 次尝试失败: %v\n", index, attempt, err)
 
-	// 创建输出文件
-	outputFile, err := os.Create(outputPath)
-	if err != nil {
-		return fmt.Errorf("创建输出文件失败: %v", err)
+		if attempt < maxRetries {
+			// 等待后重试，递增等待时间
+			waitTime := time.Duration(attempt) * 2 * time.Second
+			fmt.Printf("  ⏳ 任务 %d 等待 %v 后重试...\n", index, waitTime)
+			if err := sleepContext(ctx, waitTime); err != nil {
+				return err
+			}
+		}
 	}
-	defer outputFile.Close()
 
-	// 按顺序合并音频文件
-	for i, audioFile := range audioFiles {
-		fmt.Printf("合并文件 %d/%d: %s\n", i+1, len(audioFiles), audioFile)
+	return fmt.Errorf("任务 %d 经过 %d 次重试后仍然失败，最后错误: %w", index, maxRetries, lastErr)
+}
 
-		inputFile, err := os.Open(audioFile)
-		if err != nil {
-			fmt.Printf("警告: 打开文件失败 %s: %v\n", audioFile, err)
-			continue
-		}
+// checkVoiceLanguage 文本主要语言与默认音色不匹配时打印警告
+func (cas *ConcurrentAudioService) checkVoiceLanguage(tasks []TTSTask) {
+	texts := make([]string, len(tasks))
+	for i, task := range tasks {
+		texts[i] = task.Text
+	}
 
-		_, err = io.Copy(outputFile, inputFile)
-		inputFile.Close()
+	voiceType := cas.config.TTS.VoiceType
+	warnLanguageMismatch(cas.textProcessor, texts, strconv.FormatInt(voiceType, 10), tencentVoiceLanguage(voiceType), func(lang string) string {
+		return tencentSuggestedVoices[lang]
+	})
+}
 
-		if err != nil {
-			fmt.Printf("警告: 复制文件失败 %s: %v\n", audioFile, err)
-			continue
+// checkSegmentCount 对比任务数与成功片段数，列出缺失的任务索引
+func (cas *ConcurrentAudioService) checkSegmentCount(tasks []TTSTask, results []TTSResult) error {
+	expected := make([]int, len(tasks))
+	for i, task := range tasks {
+		expected[i] = task.Index
+	}
+	produced := make([]int, 0, len(results))
+	for _, result := range results {
+		if result.Error == nil && result.AudioFile != "" {
+			produced = append(produced, result.Index)
 		}
 	}
-
-	fmt.Printf("音频合并完成: %s\n", outputPath)
-	return nil
+	return checkSegmentCount(cas.config, expected, produced, cas.budget.Expired())
 }
 
-// validateAudioFile 验证音频文件的有效性
-func (cas *ConcurrentAudioService) validateAudioFile(audioPath string) error {
-	// 检查文件是否存在
-	fileInfo, err := os.Stat(audioPath)
-	if err != nil {
-		return fmt.Errorf("音频文件不存在: %v", err)
+// rememberSegmentTexts 记录每个成功片段对应的文本，供导出时间轴使用
+func (cas *ConcurrentAudioService) rememberSegmentTexts(tasks []TTSTask, results []TTSResult) {
+	textOf := make(map[int]string, len(tasks))
+	for _, task := range tasks {
+		textOf[task.Index] = task.Text
 	}
-
-	// 检查文件大小（音频文件通常至少几KB）
-	const minFileSize = 1024 // 最小1KB
-	if fileInfo.Size() < minFileSize {
-		return fmt.Errorf("音频文件过小 (%d bytes)，可能为空或损坏", fileInfo.Size())
+	cas.segmentTexts = make(map[string]string, len(results))
+	for _, result := range results {
+		if result.Error == nil && result.AudioFile != "" {
+			cas.segmentTexts[result.AudioFile] = textOf[result.Index]
+		}
 	}
+}
 
-	// 检查文件是否可读
-	file, err := os.Open(audioPath)
-	if err != nil {
-		return fmt.Errorf("无法打开音频文件: %v", err)
+// checkDiskSpace 按任务文本估算片段总大小并检查磁盘可用空间
+func (cas *ConcurrentAudioService) checkDiskSpace(tasks []TTSTask) error {
+	texts := make([]string, len(tasks))
+	for i, task := range tasks {
+		texts[i] = task.Text
 	}
-	defer file.Close()
+	return checkDiskSpace(cas.config, texts, audioByteRate(cas.config.TTS.Codec, cas.config.TTS.SampleRate))
+}
 
-	// 根据配置的编码格式验证文件头部
-	codec := strings.ToLower(cas.config.TTS.Codec)
-	buffer := make([]byte, 12)
-	n, err := file.Read(buffer)
-	if err != nil || n < 4 {
-		return fmt.Errorf("无法读取音频文件头部")
-	}
-
-	// 验证不同格式的文件头
-	switch codec {
-	case "mp3":
-		// MP3文件头部验证
-		if n >= 3 && (string(buffer[:3]) == "ID3" ||
-			(buffer[0] == 0xFF && (buffer[1]&0xF0) == 0xF0)) {
-			fmt.Printf("  ✓ MP3音频文件验证通过: %s (%.2f KB)\n", audioPath, float64(fileInfo.Size())/1024)
-			return nil
-		}
-		return fmt.Errorf("音频文件格式无效，可能不是有效的MP3文件")
-	case "wav":
-		// WAV文件头部验证 (RIFF....WAVE)
-		if n >= 12 && string(buffer[:4]) == "RIFF" && string(buffer[8:12]) == "WAVE" {
-			fmt.Printf("  ✓ WAV音频文件验证通过: %s (%.2f KB)\n", audioPath, float64(fileInfo.Size())/1024)
-			return nil
-		}
-		return fmt.Errorf("音频文件格式无效，可能不是有效的WAV文件")
-	default:
-		// 对于其他格式，只检查大小
-		fmt.Printf("  ✓ 音频文件验证通过: %s (%.2f KB, %s格式)\n", audioPath, float64(fileInfo.Size())/1024, codec)
-		return nil
+// degradeTask 返回使用降级文本的任务，文本无变化或清洗后为空时原样返回
+func (cas *ConcurrentAudioService) degradeTask(task TTSTask) TTSTask {
+	degraded := cas.textProcessor.DegradeText(task.Text)
+	if degraded == "" || degraded == task.Text {
+		return task
 	}
+
+	fmt.Printf("  🔧 任务 %d 最后一次重试改用降级文本: %s\n", task.Index, degraded)
+	task.Text = degraded
+	return task
 }
 
-// generateAudioWithRetry 带重试机制的音频生成
-func (cas *ConcurrentAudioService) generateAudioWithRetry(text string, index int, maxRetries int) (string, error) {
+// downloadWithRetry 带重试机制的音频下载（只重试下载，不重新合成）
+// URL过期时重新查询任务获取新的URL，而不是整句重新合成；重试间的等待可被 ctx 取消
+func (cas *ConcurrentAudioService) downloadWithRetry(ctx context.Context, audioURL, taskID string, index int, maxRetries int) (string, error) {
 	var lastErr error
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		audioFile, err := cas.generateAudioForText(text, index)
-		if err == nil {
-			if attempt > 1 {
-				fmt.Printf("  ✓ 任务 %d 重试第 %d 次成功\n", index, attempt-1)
+		// 排队较久的下载在请求前先检查签名是否已过期
+		if audioURLExpired(audioURL) {
+			refreshed, err := cas.refreshAudioURL(ctx, taskID, index)
+			if err != nil {
+				return "", fmt.Errorf("任务 %d 下载失败: %w", index, err)
 			}
+			audioURL = refreshed
+		}
+
+		audioFile, err := cas.downloadAndValidate(audioURL, index)
+		if err == nil {
 			return audioFile, nil
 		}
 
 		lastErr = err
-		fmt.Printf("  ✗ 任务 %d 第 %d 次尝试失败: %v\n", index, attempt, err)
+		Warnf("  ✗ 任务 %d 第 %d 次下载失败: %v\n", index, attempt, err)
+
+		if errors.Is(err, errAudioURLExpired) {
+			refreshed, refreshErr := cas.refreshAudioURL(ctx, taskID, index)
+			if refreshErr != nil {
+				return "", fmt.Errorf("任务 %d 下载失败: %v，%w", index, err, refreshErr)
+			}
+			audioURL = refreshed
+			continue // 新URL立即重试，无需等待
+		}
 
 		if attempt < maxRetries {
-			// 等待后重试，递增等待时间
-			waitTime := time.Duration(attempt) * 2 * time.Second
-			fmt.Printf("  ⏳ 任务 %d 等待 %v 后重试...\n", index, waitTime)
-			time.Sleep(waitTime)
+			if err := sleepContext(ctx, time.Duration(attempt)*time.Second); err != nil {
+				return "", err
+			}
 		}
 	}
 
-	return "", fmt.Errorf("任务 %d 经过 %d 次重试后仍然失败，最后错误: %v", index, maxRetries, lastErr)
+	return "", fmt.Errorf("任务 %d 下载经过 %d 次重试后仍然失败，最后错误: %v", index, maxRetries, lastErr)
 }
 
 // ProcessMarkdownFileConcurrent 并发处理Markdown文件
 func (cas *ConcurrentAudioService) ProcessMarkdownFileConcurrent() error {
-	// 读取Markdown文件内容
-	content, err := os.ReadFile(cas.config.InputFile)
-	if err != nil {
-		return fmt.Errorf("读取Markdown文件失败: %v", err)
+	// 提前检查导出条件，避免合成完成后才发现无法导出
+	if err := CheckExportSupport(cas.config.Audio.FinalOutput); err != nil {
+		return err
+	}
+	if err := checkProviderCodec(cas.Capabilities(), ProviderTencent, cas.config.TTS.Codec); err != nil {
+		return err
+	}
+	if err := cas.checkVoiceParams(); err != nil {
+		return err
 	}
 
 	// 使用TextProcessor处理Markdown文档
 	if cas.textProcessor == nil {
-		cas.textProcessor = NewTextProcessor()
+		cas.textProcessor = newTencentTextProcessor(cas.config)
 	}
 
-	// 处理Markdown文档，获取适合TTS的文本片段
-	processedTexts := cas.textProcessor.ProcessMarkdownDocument(string(content))
+	// 流式读取并处理Markdown文档，获取适合TTS的文本片段（分章模式按标题切分）
+	priorities := newTaskPriorities(cas.config)
+	sections, err := loadMarkdownSections(cas.textProcessor, cas.config.InputFile, cas.config.Audio.Split || cas.config.Audio.MaxFileDuration > 0, priorities)
+	if err != nil {
+		return err
+	}
 
-	if len(processedTexts) == 0 {
+	if len(sections) == 0 {
 		return fmt.Errorf("从Markdown文件中未提取到有效的文本内容")
 	}
 
-	fmt.Printf("📄 从Markdown文件中提取到 %d 个有效文本片段\n", len(processedTexts))
+	textCount := 0
+	for _, section := range sections {
+		textCount += len(section.Sentences)
+	}
+	fmt.Printf("📄 从Markdown文件中提取到 %d 个有效文本片段\n", textCount)
+	if cas.config.Audio.Split {
+		fmt.Printf("📑 分章模式: 共 %d 个章节\n", len(sections))
+	}
 
 	// 创建TTS任务
+	// 按说话人前缀切分片段，保持原始顺序
 	var tasks []TTSTask
-	for i, text := range processedTexts {
-		if text != "" {
-			tasks = append(tasks, TTSTask{
-				Index: i + 1,
-				Text:  text,
-			})
+	sectionOf := make(map[int]int)
+	textOf := make(map[int]string)
+	deduper := newLineDeduper(cas.config)
+	limiter := newLengthLimiter(cas.config, cas.maxTextLength())
+	for sectionIndex, section := range sections {
+		for _, text := range section.Sentences {
+			if deduper.duplicate(text) {
+				continue
+			}
+			for _, segment := range cas.speakers.split(text) {
+				parts, _ := limiter.fit(segment.Text)
+				for _, part := range parts {
+					if part == "" {
+						continue
+					}
+					index := len(tasks) + 1
+					sectionOf[index] = sectionIndex
+					textOf[index] = part
+					tasks = append(tasks, TTSTask{
+						Index:    index,
+						Text:     part,
+						Speaker:  segment.Speaker,
+						Priority: priorities.ofText(part),
+					})
+				}
+			}
 		}
 	}
 
 	if len(tasks) == 0 {
 		return fmt.Errorf("没有有效的文本任务需要处理")
 	}
+	deduper.report()
+	limiter.report()
+	cas.textProcessor.ReportRedactions()
+
+	// 检查文本语言与音色是否匹配
+	cas.checkVoiceLanguage(tasks)
+
+	// 提前检查磁盘空间，避免合成到一半因磁盘已满失败
+	if err := cas.checkDiskSpace(tasks); err != nil {
+		return err
+	}
+
+	// 添加片头/片尾语任务
+	tasks = cas.withIntroOutroTasks(tasks)
 
 	fmt.Printf("🎯 总共创建 %d 个TTS任务\n", len(tasks))
 
@@ -617,11 +1011,27 @@ func (cas *ConcurrentAudioService) ProcessMarkdownFileConcurrent() error {
 		return fmt.Errorf("并发处理TTS任务失败: %v", err)
 	}
 
+	// 按索引排序结果，确保音频文件按原始顺序合并
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Index < results[j].Index
+	})
+
+	// 合并前确认片段数量与有效句子数量一致
+	if err := cas.checkSegmentCount(tasks, results); err != nil {
+		return err
+	}
+	cas.rememberSegmentTexts(tasks, results)
+
 	// 收集成功的音频文件
 	var audioFiles []string
+	var parts []partItem
+	sectionFiles := make(map[int][]string)
 	for _, result := range results {
 		if result.Error == nil && result.AudioFile != "" {
 			audioFiles = append(audioFiles, result.AudioFile)
+			section := sectionOfTask(sectionOf, result.Index, len(sections))
+			sectionFiles[section] = append(sectionFiles[section], result.AudioFile)
+			parts = append(parts, partItem{File: result.AudioFile, Section: section, Duration: estimateSpeechDuration(textOf[result.Index])})
 		}
 	}
 
@@ -631,8 +1041,19 @@ func (cas *ConcurrentAudioService) ProcessMarkdownFileConcurrent() error {
 
 	fmt.Printf("🎵 成功生成 %d 个音频文件\n", len(audioFiles))
 
-	// 合并音频文件
-	if err := cas.mergeAudioFiles(audioFiles); err != nil {
+	// 分章模式：每个章节单独合并输出
+	if cas.config.Audio.Split {
+		return cas.recordOutputs(mergeSections(cas.config, sections, sectionFiles, cas.mergeAudioFilesTo))
+	}
+
+	// 按预估时长切分为多个输出文件
+	if cas.config.Audio.MaxFileDuration > 0 {
+		return cas.recordOutputs(mergeFileParts(cas.config, parts, cas.mergeAudioFilesTo))
+	}
+
+	// 合并音频文件，导出m4b时按章节写入章节元数据
+	cas.chapters = sectionChapters(sections, sectionFiles)
+	if err := cas.mergeAudioFiles(withIntroOutroFiles(cas.config, audioFiles)); err != nil {
 		return fmt.Errorf("合并音频文件失败: %v", err)
 	}
 
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/difyz9/markdown2tts/model"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置文件格式
+const (
+	ConfigFormatYAML = "yaml"
+	ConfigFormatJSON = "json"
+	ConfigFormatTOML = "toml"
+)
+
+// defaultConfigNames 未指定配置文件时按顺序查找的文件名
+var defaultConfigNames = []string{"config.yaml", "config.yml", "config.json", "config.toml"}
+
+// DefaultConfigPath 返回当前目录下第一个存在的默认配置文件，都不存在时返回 config.yaml
+func DefaultConfigPath() string {
+	for _, name := range defaultConfigNames {
+		if _, err := os.Stat(name); err == nil {
+			return name
+		}
+	}
+	return defaultConfigNames[0]
+}
+
+// ConfigFormatOf 按扩展名判断配置文件格式，未知扩展名按YAML处理
+func ConfigFormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ConfigFormatJSON
+	case ".toml":
+		return ConfigFormatTOML
+	default:
+		return ConfigFormatYAML
+	}
+}
+
+// decodeConfig 按格式解析配置内容
+func decodeConfig(format string, data []byte, config *model.Config) error {
+	return decodeByFormat(format, data, config)
+}
+
+// decodeByFormat 按格式解析到 out
+// 三种格式共用结构体的yaml标签：JSON/TOML先解析为通用结构，再经YAML映射到结构体
+func decodeByFormat(format string, data []byte, out interface{}) error {
+	if format == ConfigFormatYAML {
+		return yaml.Unmarshal(data, out)
+	}
+
+	var value interface{}
+	var err error
+	if format == ConfigFormatJSON {
+		value, err = decodeJSONValue(data)
+	} else {
+		value, err = decodeTOMLValue(data)
+	}
+	if err != nil {
+		return err
+	}
+
+	converted, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(converted, out)
+}
+
+// decodeJSONValue 解析JSON，数字保留为整数或浮点数，避免大整数被转成科学计数法
+func decodeJSONValue(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return normalizeJSONNumbers(value), nil
+}
+
+// normalizeJSONNumbers 把 json.Number 转为 int64 或 float64
+func normalizeJSONNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = normalizeJSONNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeJSONNumbers(item)
+		}
+	}
+	return value
+}
+
+// decodeTOMLValue 解析TOML（完整支持多行字符串、表数组 [[...]] 和日期时间）为通用结构
+func decodeTOMLValue(data []byte) (interface{}, error) {
+	var value map[string]interface{}
+	if _, err := toml.Decode(strings.TrimPrefix(string(data), "\ufeff"), &value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// encodeConfig 按格式序列化配置：先按yaml标签转为通用结构，再用对应格式的编码器输出
+// JSON与TOML的键按字母顺序排列
+func encodeConfig(format string, config *model.Config) ([]byte, error) {
+	data, err := yaml.Marshal(config)
+	if err != nil || format == ConfigFormatYAML {
+		return data, err
+	}
+
+	var value map[string]interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if format == ConfigFormatJSON {
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false) // 中文和 < > & 保持原样
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(value)
+	} else {
+		encoder := toml.NewEncoder(&buf)
+		encoder.Indent = ""
+		err = encoder.Encode(value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// sampleConfig 覆盖字符串转义、中文、嵌套表、映射、数组、表数组和时长字段的配置
+func sampleConfig() *model.Config {
+	config := &model.Config{InputFile: "input.md"}
+	config.TencentCloud.SecretID = "id\x01\"quoted\"\\path"
+	config.TencentCloud.Region = "ap-guangzhou"
+	config.TencentCloud.Timeout = 30 * time.Second
+	config.TTS.VoiceType = 101008
+	config.TTS.Speed = 1.25
+	config.TTS.Codec = "mp3"
+	config.TTS.Pronunciations = map[string]string{"重庆": "崇庆", "a.b": "<点>&"}
+	config.EdgeTTS.Voice = "zh-CN-XiaoyiNeural"
+	config.EdgeTTS.Rate = "-10%"
+	config.Audio.OutputDir = "输出\t目录"
+	config.Audio.MaxFileDuration = 2 * time.Hour
+	config.Concurrent.MaxWorkers = 5
+	config.Concurrent.PriorityLines = []int{3, 12}
+	config.Concurrent.Fallbacks = []model.FallbackConfig{
+		{Speed: -1},
+		{Provider: ProviderEdge, Voice: "zh-CN-YunxiNeural", Rate: "-20%"},
+	}
+	config.Markdown.ShortWords = []string{"A", "多行\n文本"}
+	config.Speakers = map[string]model.SpeakerConfig{"A": {VoiceType: 101001, Voice: "zh-CN-YunxiNeural"}}
+	return config
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	for _, format := range []string{ConfigFormatYAML, ConfigFormatJSON, ConfigFormatTOML} {
+		t.Run(format, func(t *testing.T) {
+			want := sampleConfig()
+			data, err := encodeConfig(format, want)
+			if err != nil {
+				t.Fatalf("encodeConfig: %v", err)
+			}
+			var got model.Config
+			if err := decodeConfig(format, data, &got); err != nil {
+				t.Fatalf("decodeConfig: %v\n%s", err, data)
+			}
+			if !reflect.DeepEqual(&got, want) {
+				t.Errorf("往返后配置不一致\n got: %+v\nwant: %+v\n%s", got, *want, data)
+			}
+		})
+	}
+}
+
+func TestDecodeTOML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		check func(*model.Config) bool
+	}{
+		{
+			name:  "多行基本字符串",
+			input: "[edge_tts]\nvoice = \"\"\"\nzh-CN-\\\n  XiaoyiNeural\"\"\"\n",
+			check: func(c *model.Config) bool { return c.EdgeTTS.Voice == "zh-CN-XiaoyiNeural" },
+		},
+		{
+			name:  "多行字面量字符串",
+			input: "input_file = '''\nC:\\docs\\a.md'''\n",
+			check: func(c *model.Config) bool { return c.InputFile == `C:\docs\a.md` },
+		},
+		{
+			name:  "表数组",
+			input: "[[concurrent.fallbacks]]\nspeed = -1\n\n[[concurrent.fallbacks]]\nprovider = \"edge\"\nrate = \"-10%\"\n",
+			check: func(c *model.Config) bool {
+				fb := c.Concurrent.Fallbacks
+				return len(fb) == 2 && fb[0].Speed == -1 && fb[1].Provider == "edge" && fb[1].Rate == "-10%"
+			},
+		},
+		{
+			name:  "内联表与点分键",
+			input: "tts.pronunciations = { \"重庆\" = \"崇庆\" }\nspeakers.A = { voice_type = 101001 }\n",
+			check: func(c *model.Config) bool {
+				return c.TTS.Pronunciations["重庆"] == "崇庆" && c.Speakers["A"].VoiceType == 101001
+			},
+		},
+		{
+			name:  "时长字符串",
+			input: "[concurrent]\ntask_timeout = \"2m\"\n",
+			check: func(c *model.Config) bool { return c.Concurrent.TaskTimeout == 2*time.Minute },
+		},
+		{
+			name:  "BOM",
+			input: "\ufeffinput_file = \"a.md\"\n",
+			check: func(c *model.Config) bool { return c.InputFile == "a.md" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config model.Config
+			if err := decodeConfig(ConfigFormatTOML, []byte(tt.input), &config); err != nil {
+				t.Fatalf("decodeConfig: %v", err)
+			}
+			if !tt.check(&config) {
+				t.Errorf("解析结果不符合预期: %+v", config)
+			}
+		})
+	}
+}
+
+func TestDecodeConfigErrors(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+	}{
+		{ConfigFormatTOML, "[tts\nvoice_type = 1\n"},
+		{ConfigFormatTOML, "input_file = \"a\"\ninput_file = \"b\"\n"},
+		{ConfigFormatJSON, `{"tts": {"voice_type": 1,}}`},
+	}
+	for _, tt := range tests {
+		var config model.Config
+		if err := decodeConfig(tt.format, []byte(tt.input), &config); err == nil {
+			t.Errorf("%s %q: 期望解析错误", tt.format, tt.input)
+		}
+	}
+}
+
+func TestEncodeTOMLEscapes(t *testing.T) {
+	data, err := encodeConfig(ConfigFormatTOML, sampleConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// strconv.Quote 会输出TOML不支持的 \x01
+	if strings.Contains(string(data), `\x`) {
+		t.Errorf("TOML输出包含非法转义:\n%s", data)
+	}
+}
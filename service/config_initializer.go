@@ -5,16 +5,30 @@ import (
 	"github.com/difyz9/markdown2tts/model"
 	"os"
 	"path/filepath"
-
-	"gopkg.in/yaml.v3"
+	"strings"
 )
 
 // ConfigInitializer 配置初始化器
-type ConfigInitializer struct{}
+type ConfigInitializer struct {
+	sampleLanguage string // 示例输入文件的语言
+}
 
 // NewConfigInitializer 创建配置初始化器
 func NewConfigInitializer() *ConfigInitializer {
-	return &ConfigInitializer{}
+	return &ConfigInitializer{sampleLanguage: defaultSampleLanguage}
+}
+
+// SetSampleLanguage 设置示例输入文件的语言（zh/en）
+func (ci *ConfigInitializer) SetSampleLanguage(lang string) error {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "" {
+		lang = defaultSampleLanguage
+	}
+	if _, ok := sampleInputTemplates[lang]; !ok {
+		return fmt.Errorf("不支持的示例语言: %s（可选: %s）", lang, strings.Join(SampleLanguages(), ", "))
+	}
+	ci.sampleLanguage = lang
+	return nil
 }
 
 // InitializeConfig 初始化配置文件
@@ -37,17 +51,17 @@ func (ci *ConfigInitializer) InitializeConfigWithForce(configPath string, force
 
 	// 确保目录存在
 	dir := filepath.Dir(configPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
+	if err := makeDirs(dir); err != nil {
 		return fmt.Errorf("创建配置目录失败: %v", err)
 	}
 
-	// 将配置写入文件
-	data, err := yaml.Marshal(defaultConfig)
+	// 按扩展名选择格式写入文件
+	data, err := encodeConfig(ConfigFormatOf(configPath), defaultConfig)
 	if err != nil {
 		return fmt.Errorf("序列化配置失败: %v", err)
 	}
 
-	err = os.WriteFile(configPath, data, 0644)
+	err = writeFile(configPath, data)
 	if err != nil {
 		return fmt.Errorf("写入配置文件失败: %v", err)
 	}
@@ -115,30 +129,12 @@ func (ci *ConfigInitializer) CreateSampleInputFileWithForce(inputPath string, fo
 
 	fmt.Printf("正在创建示例输入文件: %s\n", inputPath)
 
-	sampleContent := `欢迎使用TTS语音合成应用！
-
-这是一个功能强大的文本转语音工具。
-支持腾讯云TTS和Microsoft Edge TTS两种引擎。
-Edge TTS完全免费，无需API密钥。
-
-特殊字符处理示例：
-**代理（Agents）**能基于用户输入自主决策执行流程。
-\*\*转义字符\*\*也能正确处理。
-AI Agent可以automatically处理various任务。
-
-符号测试：！@#$%^&*()
-括号测试：（中文括号）和(English brackets)
-
-请编辑此文件，添加您要转换的文本内容。
-每行文本将被转换为一个音频片段，最后自动合并。
-
-开始使用：
-1. 免费版本：./github.com/difyz9/markdown2tts edge -i input.txt
-2. 腾讯云版本：./github.com/difyz9/markdown2tts tts -i input.txt
-`
-
-	err := os.WriteFile(inputPath, []byte(sampleContent), 0644)
+	sampleContent, err := renderSampleInput(ci.sampleLanguage, inputPath)
 	if err != nil {
+		return err
+	}
+
+	if err := writeFile(inputPath, []byte(sampleContent)); err != nil {
 		return fmt.Errorf("创建示例输入文件失败: %v", err)
 	}
 
@@ -152,18 +148,17 @@ func (ci *ConfigInitializer) ShowQuickStart() {
 	fmt.Println("🚀 快速开始指南:")
 	fmt.Println()
 	fmt.Println("方式一：免费Edge TTS（推荐新手）")
-	fmt.Println("   ./github.com/difyz9/markdown2tts edge -i input.txt")
+	fmt.Printf("   %s edge -i input.txt\n", ProgramName)
 	fmt.Println()
 	fmt.Println("方式二：腾讯云TTS（需要API密钥）")
 	fmt.Println("   1. 编辑 config.yaml，填入腾讯云密钥")
-	fmt.Println("   2. ./github.com/difyz9/markdown2tts tts -i input.txt")
+	fmt.Printf("   2. %s tts -i input.txt\n", ProgramName)
 	fmt.Println()
 	fmt.Println("方式三：测试文本处理效果")
-	fmt.Println("   go run test_text_processor.go input.txt")
+	fmt.Printf("   %s preview -i input.txt\n", ProgramName)
 	fmt.Println()
 	fmt.Println("📖 更多信息请查看：")
 	fmt.Println("   - README.md - 完整使用说明")
-	fmt.Println("   - docs/special-chars-handling.md - 特殊字符处理说明")
 	fmt.Println("   - docs/quick-start.md - 详细快速开始指南")
 	fmt.Println()
 }
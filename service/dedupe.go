@@ -0,0 +1,83 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 重复句子去除模式
+const (
+	DedupeModeOff      = "off"      // 不去重（默认）
+	DedupeModeAdjacent = "adjacent" // 只去除与上一句相同的句子
+	DedupeModeGlobal   = "global"   // 去除全文中已经出现过的句子
+)
+
+// defaultDedupeMinChars 参与去重的最短句子长度，更短的句子（如歌词里反复的短句）始终保留
+const defaultDedupeMinChars = 8
+
+// lineDeduper 在过滤阶段识别重复的句子
+type lineDeduper struct {
+	mode     string
+	minChars int
+	last     string
+	seen     map[string]bool
+	removed  int
+}
+
+// newLineDeduper 按配置创建去重器，未开启时返回nil，nil去重器不会去除任何句子
+func newLineDeduper(config *model.Config) *lineDeduper {
+	mode := strings.ToLower(strings.TrimSpace(config.Markdown.Dedupe))
+	switch mode {
+	case "", DedupeModeOff:
+		return nil
+	case DedupeModeAdjacent, DedupeModeGlobal:
+	default:
+		Warnf("警告: 未知的去重模式: %s（可选: off, adjacent, global），不去重\n", mode)
+		return nil
+	}
+
+	minChars := config.Markdown.DedupeMinChars
+	if minChars <= 0 {
+		minChars = defaultDedupeMinChars
+	}
+	return &lineDeduper{mode: mode, minChars: minChars, seen: make(map[string]bool)}
+}
+
+// duplicate 判断句子是否与之前的句子重复，重复时计入去除数量
+func (d *lineDeduper) duplicate(text string) bool {
+	if d == nil {
+		return false
+	}
+
+	// 忽略空白差异
+	key := strings.Join(strings.Fields(text), " ")
+	if len([]rune(key)) < d.minChars {
+		d.last = key
+		return false
+	}
+
+	isDuplicate := false
+	switch d.mode {
+	case DedupeModeAdjacent:
+		isDuplicate = key == d.last
+	case DedupeModeGlobal:
+		isDuplicate = d.seen[key]
+		d.seen[key] = true
+	}
+	d.last = key
+
+	if isDuplicate {
+		d.removed++
+	}
+	return isDuplicate
+}
+
+// report 打印去除的重复句子数量
+func (d *lineDeduper) report() {
+	if d == nil || d.removed == 0 {
+		return
+	}
+	fmt.Printf("🧹 已去除 %d 条重复句子（去重模式: %s，最短 %d 字）\n", d.removed, d.mode, d.minChars)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 估算片段大小时使用的音频码率（字节/秒）
+const (
+	mp3ByteRate       = 48000 / 8 // Edge TTS及腾讯云MP3约48kbps
+	wavBytesPerSample = 2         // 16bit单声道PCM
+)
+
+// diskSpaceMargin 估算所需空间的余量系数，覆盖重采样、裁剪静音等中间文件
+const diskSpaceMargin = 1.2
+
+// audioByteRate 按编码和采样率估算每秒音频的字节数
+func audioByteRate(codec string, sampleRate int64) float64 {
+	switch strings.ToLower(codec) {
+	case "wav", "pcm":
+		if sampleRate <= 0 {
+			sampleRate = 16000
+		}
+		return float64(sampleRate * wavBytesPerSample)
+	default:
+		return mp3ByteRate
+	}
+}
+
+// estimateAudioBytes 按句子的预估朗读时长估算合成音频的总大小
+func estimateAudioBytes(texts []string, byteRate float64) uint64 {
+	var total time.Duration
+	for _, text := range texts {
+		total += estimateSpeechDuration(text)
+	}
+	return uint64(total.Seconds() * byteRate * diskSpaceMargin)
+}
+
+// checkDiskSpace 开始合成前检查临时目录和输出目录的可用空间，不足时提前报错
+// 临时目录存放全部片段，输出目录存放合并后的文件，两者大小都约等于音频总大小
+func checkDiskSpace(config *model.Config, texts []string, byteRate float64) error {
+	if config.Audio.SkipDiskCheck {
+		return nil
+	}
+
+	required := estimateAudioBytes(texts, byteRate)
+	for _, dir := range []string{config.Audio.TempDir, config.Audio.OutputDir} {
+		available, err := availableDiskSpace(dir)
+		if err != nil {
+			continue // 无法获取可用空间时不阻止运行
+		}
+		if available < required {
+			return fmt.Errorf("磁盘空间不足: %s 可用 %s，预计需要 %s（%d 句），请清理空间、更换目录，或设置 audio.skip_disk_check: true 跳过检查",
+				dir, formatBytes(available), formatBytes(required), len(texts))
+		}
+	}
+	return nil
+}
+
+// formatBytes 以 MB/GB 显示字节数
+func formatBytes(size uint64) string {
+	if size >= 1<<30 {
+		return fmt.Sprintf("%.1f GB", float64(size)/(1<<30))
+	}
+	return fmt.Sprintf("%.1f MB", float64(size)/(1<<20))
+}
+
+// isDiskFull 判断写入错误是否由磁盘已满引起
+// 第三方库可能不保留错误链，因此同时匹配系统错误信息
+func isDiskFull(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, syscall.ENOSPC) {
+		return true
+	}
+	message := strings.ToLower(err.Error())
+	return strings.Contains(message, "no space left on device") ||
+		strings.Contains(message, "not enough space on the disk") ||
+		strings.Contains(message, "disk quota exceeded")
+}
+
+// withDiskFullHint 磁盘已满导致的错误附加友好提示，其他错误原样返回
+func withDiskFullHint(err error) error {
+	if !isDiskFull(err) {
+		return err
+	}
+	return fmt.Errorf("%w（磁盘可能已满，请清理空间或把 audio.temp_dir / audio.output_dir 换到空间充足的磁盘）", err)
+}
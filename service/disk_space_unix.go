@@ -0,0 +1,14 @@
+//go:build !windows
+
+package service
+
+import "syscall"
+
+// availableDiskSpace 返回目录所在文件系统对当前用户可用的字节数
+func availableDiskSpace(dir string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
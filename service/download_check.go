@@ -0,0 +1,82 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// audioSniffSize 判断响应是否为音频时预读的字节数
+const audioSniffSize = 512
+
+// maxErrorBodySize 非音频响应最多读取的错误内容字节数
+const maxErrorBodySize = 4096
+
+// xmlErrorRegex 对象存储XML错误中的 Code 和 Message
+var xmlErrorRegex = regexp.MustCompile(`(?s)<Code>(.*?)</Code>.*?<Message>(.*?)</Message>`)
+
+// audioResponseBody 检查下载响应确实是音频，返回可继续读取完整内容的Reader
+// 状态码200但返回XML/JSON错误页时读出其中的错误信息，避免把错误页写成坏音频文件
+func audioResponseBody(resp *http.Response) (io.Reader, error) {
+	reader := bufio.NewReaderSize(resp.Body, audioSniffSize)
+	head, _ := reader.Peek(audioSniffSize)
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if strings.HasPrefix(mediaType, "audio/") {
+		return reader, nil
+	}
+	textual := strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") || strings.HasSuffix(mediaType, "xml")
+
+	trimmed := bytes.TrimSpace(head)
+	// 裸PCM等数据也可能以 { 或 < 开头，因此同时要求内容是不含NUL的UTF-8文本
+	looksLikeText := len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '<') &&
+		utf8.Valid(head) && bytes.IndexByte(head, 0) < 0
+	if !textual && !looksLikeText {
+		return reader, nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(reader, maxErrorBodySize))
+	return nil, fmt.Errorf("%w，返回的不是音频内容（Content-Type: %s）: %s", errDownloadFailed, contentType, errorBodyMessage(body))
+}
+
+// errorBodyMessage 从JSON或XML错误响应中提取错误码和信息，无法解析时返回截断的原文
+func errorBodyMessage(body []byte) string {
+	trimmed := bytes.TrimSpace(body)
+
+	var payload struct {
+		Code     string `json:"Code"`
+		Message  string `json:"Message"`
+		Response struct {
+			Error struct {
+				Code    string `json:"Code"`
+				Message string `json:"Message"`
+			} `json:"Error"`
+		} `json:"Response"`
+	}
+	if json.Unmarshal(trimmed, &payload) == nil {
+		if payload.Response.Error.Code != "" {
+			return payload.Response.Error.Code + ": " + payload.Response.Error.Message
+		}
+		if payload.Code != "" {
+			return payload.Code + ": " + payload.Message
+		}
+	}
+
+	if match := xmlErrorRegex.FindSubmatch(trimmed); match != nil {
+		return string(match[1]) + ": " + string(match[2])
+	}
+
+	text := []rune(strings.Join(strings.Fields(string(trimmed)), " "))
+	if len(text) > 200 {
+		text = append(text[:200], []rune("...")...)
+	}
+	return string(text)
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	edgeerrors "github.com/difyz9/edge-tts-go/pkg/errors"
+)
+
+// edgeReconnectAttempts 单次合成中连接被断开时的最大重连次数
+const edgeReconnectAttempts = 3
+
+// edgeReconnectDelay 重连前的基础等待时间，按重连次数递增
+const edgeReconnectDelay = 500 * time.Millisecond
+
+// errEdgeTextRejected Edge TTS正常响应但没有返回音频，通常是文本或参数不被接受，重连无济于事
+var errEdgeTextRejected = errors.New("Edge TTS未返回音频，文本或语音参数可能不被接受")
+
+// isEdgeConnectionError 判断错误是否为连接被重置/断开，这类错误重新建立连接后可以直接重试
+func isEdgeConnectionError(err error) bool {
+	return !isEdgeTextRejected(err) && isNetworkError(err)
+}
+
+// isEdgeTextRejected 判断错误是否为服务端拒绝了文本（未返回音频或返回了非预期内容）
+func isEdgeTextRejected(err error) bool {
+	return errors.Is(err, errEdgeTextRejected) ||
+		errors.Is(err, edgeerrors.ErrNoAudioReceived) ||
+		errors.Is(err, edgeerrors.ErrUnexpectedResponse)
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	edgeerrors "github.com/difyz9/edge-tts-go/pkg/errors"
+	"github.com/gorilla/websocket"
+)
+
+func TestIsEdgeConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"连接重置", fmt.Errorf("保存音频文件失败: %w", &net.OpError{Op: "read", Err: syscall.ECONNRESET}), true},
+		{"管道断开", &net.OpError{Op: "write", Err: syscall.EPIPE}, true},
+		{"读到一半断开", fmt.Errorf("保存音频文件失败: %w", io.ErrUnexpectedEOF), true},
+		{"连接已关闭", net.ErrClosed, true},
+		{"读写超时", &net.OpError{Op: "read", Err: timeoutError{}}, true},
+		{"WebSocket关闭帧", &websocket.CloseError{Code: websocket.CloseGoingAway}, true},
+		{"Edge WebSocket错误", edgeerrors.NewWebSocketError("read: connection reset by peer"), true},
+		{"任务超时不重连", fmt.Errorf("保存音频文件失败: %w", context.DeadlineExceeded), false},
+		{"中断不重连", context.Canceled, false},
+		{"文本被拒不重连", edgeerrors.NewNoAudioReceivedError("no audio"), false},
+		{"非预期响应不重连", edgeerrors.NewUnexpectedResponseError("binary message is too short"), false},
+		// 只是文本里带有关键字，不是连接错误
+		{"普通错误", errors.New("invalid voice 'EOF-Neural'"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isEdgeConnectionError(tt.err); got != tt.want {
+				t.Errorf("isEdgeConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
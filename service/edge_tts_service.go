@@ -1,8 +1,8 @@
 package service
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/difyz9/markdown2tts/model"
 	"os"
@@ -15,14 +15,15 @@ import (
 
 	"github.com/difyz9/edge-tts-go/pkg/communicate"
 	"github.com/difyz9/edge-tts-go/pkg/types"
-	"github.com/difyz9/edge-tts-go/pkg/voices"
 	"golang.org/x/time/rate"
 )
 
 // EdgeTTSTask Edge TTS任务结构
 type EdgeTTSTask struct {
-	Index int
-	Text  string
+	Index    int
+	Text     string
+	Speaker  string // 对话脚本说话人，为空时使用默认语音
+	Priority int    // 合成优先级，高优先任务先出队（见 concurrent.priority_lines）
 }
 
 // EdgeTTSResult Edge TTS任务结果
@@ -37,52 +38,114 @@ type EdgeTTSService struct {
 	config        *model.Config
 	limiter       *rate.Limiter
 	textProcessor *TextProcessor
+	speakers      *speakerMatcher
+	budget        *timeBudget
+	segmentTexts  map[string]string // 片段文件 → 文本，用于导出时间轴
+	chapters      chapterMarks      // 片段文件 → 所属章节，用于导出m4b章节
+	metrics       *SynthesisMetrics // 合成请求指标
+	cache         *segmentCache     // 片段缓存，未配置 audio.cache_dir 时为nil
+	merger        *AudioMerger      // 音频合并组件
+	voices        *edgeVoiceCache   // 本地音色缓存，创建服务时读取一次，没有缓存时为nil
+	outputs       []string          // 本次处理写出的输出文件及附属文件
 }
 
 // NewEdgeTTSService 创建Edge TTS服务
 func NewEdgeTTSService(config *model.Config) *EdgeTTSService {
-	// 创建速率限制器，Edge TTS可以更快一些
-	rateLimit := rate.Every(time.Second / time.Duration(config.Concurrent.RateLimit))
-	limiter := rate.NewLimiter(rateLimit, config.Concurrent.RateLimit)
+	// 创建速率限制器，Edge TTS可以更快一些；rate_limit 未配置时不限速（作为腾讯云的备用服务时可能未配置）
+	limiter := newRateLimiter(config.Concurrent.RateLimit)
+
+	voice := config.EdgeTTS.Voice
+	if voice == "" {
+		voice = "zh-CN-XiaoyiNeural"
+	}
+	voices := cachedEdgeVoices()
 
 	return &EdgeTTSService{
 		config:        config,
 		limiter:       limiter,
-		textProcessor: NewTextProcessor(),
+		textProcessor: newTextProcessorForVoice(config, edgeVoiceLanguage(voices, voice)),
+		speakers:      newSpeakerMatcher(config.Speakers),
+		budget:        newTimeBudget(config.Concurrent.MaxDuration),
+		metrics:       newSynthesisMetrics(ProviderEdge),
+		cache:         newSegmentCache(config),
+		merger:        NewAudioMerger(config),
+		voices:        voices,
 	}
 }
 
 // ProcessMarkdownFile 使用智能Markdown解析处理文件
 func (ets *EdgeTTSService) ProcessMarkdownFile(inputFile, outputDir string) error {
+	// 提前检查导出条件，避免合成完成后才发现无法导出
+	if err := CheckExportSupport(ets.config.Audio.FinalOutput); err != nil {
+		return err
+	}
+
 	// 确保目录存在
-	if err := os.MkdirAll(ets.config.Audio.TempDir, 0755); err != nil {
+	if err := makeDirs(ets.config.Audio.TempDir); err != nil {
 		return fmt.Errorf("创建临时目录失败: %v", err)
 	}
-	if err := os.MkdirAll(outputDir, 0755); err != nil {
+	if err := makeDirs(outputDir); err != nil {
 		return fmt.Errorf("创建输出目录失败: %v", err)
 	}
 
-	// 读取文件内容
-	content, err := os.ReadFile(inputFile)
+	// 流式读取文件，使用专业Markdown处理器按块提取文本（分章模式按标题切分）
+	priorities := newTaskPriorities(ets.config)
+	sections, err := loadMarkdownSections(ets.textProcessor, inputFile, ets.config.Audio.Split || ets.config.Audio.MaxFileDuration > 0, priorities)
 	if err != nil {
-		return fmt.Errorf("读取文件失败: %v", err)
+		return err
 	}
 
-	// 使用专业Markdown处理器提取文本
-	sentences := ets.textProcessor.ProcessMarkdownDocument(string(content))
-
-	if len(sentences) == 0 {
+	if len(sections) == 0 {
 		return fmt.Errorf("没有提取到有效的文本内容")
 	}
 
-	fmt.Printf("📊 Markdown处理统计: 提取到 %d 个有效句子\n", len(sentences))
+	sentenceCount := 0
+	for _, section := range sections {
+		sentenceCount += len(section.Sentences)
+	}
+	fmt.Printf("📊 Markdown处理统计: 提取到 %d 个有效句子\n", sentenceCount)
+	if ets.config.Audio.Split {
+		fmt.Printf("📑 分章模式: 共 %d 个章节\n", len(sections))
+	}
 
 	// 创建任务
+	// 按说话人前缀切分片段，保持原始顺序
 	var tasks []EdgeTTSTask
-	for i, sentence := range sentences {
-		tasks = append(tasks, EdgeTTSTask{Index: i, Text: sentence})
+	sectionOf := make(map[int]int)
+	textOf := make(map[int]string)
+	deduper := newLineDeduper(ets.config)
+	limiter := newLengthLimiter(ets.config, ets.Capabilities().MaxTextLength)
+	for sectionIndex, section := range sections {
+		for _, sentence := range section.Sentences {
+			if deduper.duplicate(sentence) {
+				continue
+			}
+			for _, segment := range ets.speakers.split(sentence) {
+				parts, _ := limiter.fit(segment.Text)
+				for _, part := range parts {
+					sectionOf[len(tasks)] = sectionIndex
+					textOf[len(tasks)] = part
+					tasks = append(tasks, EdgeTTSTask{Index: len(tasks), Text: part, Speaker: segment.Speaker, Priority: priorities.ofText(part)})
+				}
+			}
+		}
+	}
+	deduper.report()
+	limiter.report()
+	ets.textProcessor.ReportRedactions()
+
+	// 检查文本语言与语音是否匹配
+	ets.checkVoices()
+	ets.checkVoiceLanguage(tasks)
+
+	// 提前检查磁盘空间，避免合成到一半因磁盘已满失败
+	if err := ets.checkDiskSpace(tasks); err != nil {
+		return err
 	}
 
+	// 添加片头/片尾语任务
+	tasks = ets.withIntroOutroTasks(tasks)
+
 	// 并发处理任务
 	results, err := ets.processTTSTasksConcurrent(tasks)
 	if err != nil {
@@ -98,73 +161,140 @@ func (ets *EdgeTTSService) ProcessMarkdownFile(inputFile, outputDir string) erro
 		return results[i].Index < results[j].Index
 	})
 
+	// 合并前确认片段数量与有效句子数量一致
+	if err := ets.checkSegmentCount(tasks, results); err != nil {
+		return err
+	}
+	ets.rememberSegmentTexts(tasks, results)
+
 	// 收集所有音频文件
 	audioFiles := make([]string, 0, len(results))
+	var parts []partItem
+	sectionFiles := make(map[int][]string)
 	for _, result := range results {
 		audioFiles = append(audioFiles, result.AudioFile)
+		if result.Error == nil && result.AudioFile != "" {
+			section := sectionOfTask(sectionOf, result.Index, len(sections))
+			sectionFiles[section] = append(sectionFiles[section], result.AudioFile)
+			parts = append(parts, partItem{File: result.AudioFile, Section: section, Duration: estimateSpeechDuration(textOf[result.Index])})
+		}
 	}
 
-	// 合并音频文件
-	return ets.mergeAudioFiles(audioFiles)
+	// 分章模式：每个章节单独合并输出
+	if ets.config.Audio.Split {
+		return ets.recordOutputs(mergeSections(ets.config, sections, sectionFiles, ets.mergeAudioFilesTo))
+	}
+
+	// 按预估时长切分为多个输出文件
+	if ets.config.Audio.MaxFileDuration > 0 {
+		return ets.recordOutputs(mergeFileParts(ets.config, parts, ets.mergeAudioFilesTo))
+	}
+
+	// 合并音频文件，导出m4b时按章节写入章节元数据
+	ets.chapters = sectionChapters(sections, sectionFiles)
+	return ets.mergeAudioFiles(withIntroOutroFiles(ets.config, audioFiles))
 }
 
 // ProcessInputFileConcurrent 并发处理输入文件（保持原有的逐行处理方式）
 func (ets *EdgeTTSService) ProcessInputFileConcurrent() error {
+	// 提前检查导出条件，避免合成完成后才发现无法导出
+	if err := CheckExportSupport(ets.config.Audio.FinalOutput); err != nil {
+		return err
+	}
+
 	// 确保目录存在
-	if err := os.MkdirAll(ets.config.Audio.TempDir, 0755); err != nil {
+	if err := makeDirs(ets.config.Audio.TempDir); err != nil {
 		return fmt.Errorf("创建临时目录失败: %v", err)
 	}
-	if err := os.MkdirAll(ets.config.Audio.OutputDir, 0755); err != nil {
+	if err := makeDirs(ets.config.Audio.OutputDir); err != nil {
 		return fmt.Errorf("创建输出目录失败: %v", err)
 	}
 
-	// 读取输入文件
-	lines, err := ets.readInputFile()
-	if err != nil {
-		return err
-	}
-
-	fmt.Printf("读取到 %d 行文本，开始并发生成音频...\n", len(lines))
+	fmt.Println("开始流式读取文本并并发生成音频...")
 	fmt.Printf("并发配置: workers=%d, rate_limit=%d/秒, batch_size=%d\n",
 		ets.config.Concurrent.MaxWorkers,
 		ets.config.Concurrent.RateLimit,
 		ets.config.Concurrent.BatchSize)
 
 	// 创建任务列表
-	tasks := make([]EdgeTTSTask, 0, len(lines))
+	var tasks []EdgeTTSTask
 	emptyLineCount := 0
 	invalidTextCount := 0
 
-	for i, line := range lines {
+	diagnostics := newFilterDiagnostics()
+	deduper := newLineDeduper(ets.config)
+	limiter := newLengthLimiter(ets.config, ets.Capabilities().MaxTextLength)
+	priorities := newTaskPriorities(ets.config)
+	lineCount, err := forEachInputLine(ets.config.InputFile, func(i int, line string) {
 		trimmedLine := strings.TrimSpace(line)
 
 		// 跳过完全空行
 		if trimmedLine == "" {
 			emptyLineCount++
-			continue
+			diagnostics.record(i, line, FilterReasonEmpty)
+			return
 		}
 
 		// 跳过只包含空白字符的行
 		if len(strings.ReplaceAll(strings.ReplaceAll(trimmedLine, " ", ""), "\t", "")) == 0 {
 			emptyLineCount++
-			continue
+			diagnostics.record(i, line, FilterReasonEmpty)
+			return
 		}
 
+		// 识别对话脚本的说话人前缀
+		speaker, text := ets.speakers.parseLine(trimmedLine)
+
 		// 使用文本处理器验证文本
-		if !ets.textProcessor.IsValidTextForTTS(trimmedLine) {
+		if reason := ets.textProcessor.FilterReason(text); reason != "" {
 			invalidTextCount++
-			continue
+			diagnostics.record(i, line, reason)
+			return
+		}
+
+		// 可选去除重复句子
+		if deduper.duplicate(text) {
+			return
+		}
+
+		// 按 min_chars/max_chars 过滤或切分
+		parts, reason := limiter.fit(text)
+		if reason != "" {
+			invalidTextCount++
+			diagnostics.record(i, line, reason)
+			return
 		}
 
-		tasks = append(tasks, EdgeTTSTask{Index: i, Text: line})
+		// 长句切分后一行可能对应多个任务，任务按顺序编号
+		for _, part := range parts {
+			tasks = append(tasks, EdgeTTSTask{Index: len(tasks), Text: part, Speaker: speaker, Priority: priorities.ofLine(i)})
+		}
+	})
+	if err != nil {
+		return err
 	}
 
 	if len(tasks) == 0 {
-		return fmt.Errorf("没有有效的文本行需要处理")
+		return diagnostics.noValidLinesError(lineCount)
 	}
 
 	fmt.Printf("📊 文本处理统计: 总行数=%d, 空行=%d, 无效文本=%d, 有效任务=%d\n",
-		len(lines), emptyLineCount, invalidTextCount, len(tasks))
+		lineCount, emptyLineCount, invalidTextCount, len(tasks))
+	deduper.report()
+	limiter.report()
+	ets.textProcessor.ReportRedactions()
+
+	// 检查文本语言与语音是否匹配
+	ets.checkVoices()
+	ets.checkVoiceLanguage(tasks)
+
+	// 提前检查磁盘空间，避免合成到一半因磁盘已满失败
+	if err := ets.checkDiskSpace(tasks); err != nil {
+		return err
+	}
+
+	// 添加片头/片尾语任务
+	tasks = ets.withIntroOutroTasks(tasks)
 
 	// 并发处理任务
 	results, err := ets.processTTSTasksConcurrent(tasks)
@@ -181,6 +311,12 @@ func (ets *EdgeTTSService) ProcessInputFileConcurrent() error {
 		return results[i].Index < results[j].Index
 	})
 
+	// 合并前确认片段数量与有效句子数量一致
+	if err := ets.checkSegmentCount(tasks, results); err != nil {
+		return err
+	}
+	ets.rememberSegmentTexts(tasks, results)
+
 	// 收集所有音频文件
 	audioFiles := make([]string, 0, len(results))
 	for _, result := range results {
@@ -188,55 +324,56 @@ func (ets *EdgeTTSService) ProcessInputFileConcurrent() error {
 	}
 
 	// 合并音频文件
-	return ets.mergeAudioFiles(audioFiles)
+	return ets.mergeAudioFiles(withIntroOutroFiles(ets.config, audioFiles))
 }
 
-// readInputFile 读取输入文件
-func (ets *EdgeTTSService) readInputFile() ([]string, error) {
-	file, err := os.Open(ets.config.InputFile)
-	if err != nil {
-		return nil, fmt.Errorf("打开输入文件失败: %v", err)
-	}
-	defer file.Close()
-
-	var lines []string
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+// withIntroOutroTasks 在任务列表首尾添加片头/片尾语任务
+func (ets *EdgeTTSService) withIntroOutroTasks(tasks []EdgeTTSTask) []EdgeTTSTask {
+	intro, outro := introOutroTexts(ets.config)
+	if intro != "" {
+		tasks = append([]EdgeTTSTask{{Index: introTaskIndex, Text: intro}}, tasks...)
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("读取输入文件失败: %v", err)
+	if outro != "" {
+		outroIndex := 0
+		if len(tasks) > 0 {
+			outroIndex = tasks[len(tasks)-1].Index + 1
+		}
+		tasks = append(tasks, EdgeTTSTask{Index: outroIndex, Text: outro})
 	}
-
-	return lines, nil
+	return tasks
 }
 
-// processTTSTasksConcurrent 并发处理TTS任务
+// processTTSTasksConcurrent 并发处理TTS任务，结果边产生边消费，缓冲只与worker数量有关，与任务总数无关
 func (ets *EdgeTTSService) processTTSTasksConcurrent(tasks []EdgeTTSTask) ([]EdgeTTSResult, error) {
-	// 创建通道
-	taskChan := make(chan EdgeTTSTask, len(tasks))
-	resultChan := make(chan EdgeTTSResult, len(tasks))
+	ctx, stop := interruptContext()
+	defer stop()
 
-	// 将任务发送到通道
-	for _, task := range tasks {
-		taskChan <- task
-	}
-	close(taskChan)
+	// 任务按优先级出队
+	queue := enqueueTasks(tasks, func(task EdgeTTSTask) int { return task.Priority })
 
-	// 确定worker数量
-	workerCount := ets.config.Concurrent.MaxWorkers
-	if workerCount > len(tasks) {
-		workerCount = len(tasks)
+	// 按任务顺序实时写出到命名管道/stdout（如已配置）
+	order := make([]int, len(tasks))
+	for i, task := range tasks {
+		order[i] = task.Index
+	}
+	stream, err := newAudioStream(ets.config, order)
+	if err != nil {
+		return nil, err
 	}
 
+	// 确定worker数量（不超过任务数和goroutine上限）
+	workerCount := stageWorkerCount(ets.config, len(tasks))
+
 	fmt.Printf("启动 %d 个worker开始处理...\n", workerCount)
 
+	// 有界结果通道：收集端处理不过来时worker阻塞等待，而不是为全部任务预留缓冲
+	resultChan := make(chan EdgeTTSResult, resultBufferSize(workerCount))
+
 	// 启动workers
 	var wg sync.WaitGroup
 	for i := 0; i < workerCount; i++ {
 		wg.Add(1)
-		go ets.edgeTTSWorker(i, taskChan, resultChan, &wg)
+		go ets.edgeTTSWorker(ctx, i, queue, resultChan, &wg)
 	}
 
 	// 等待所有workers完成
@@ -245,46 +382,92 @@ func (ets *EdgeTTSService) processTTSTasksConcurrent(tasks []EdgeTTSTask) ([]Edg
 		close(resultChan)
 	}()
 
-	// 收集结果
+	// 流式消费结果：逐个写出流、统计，只保留成功片段供合并
 	var results []EdgeTTSResult
 	successCount := 0
 	failureCount := 0
+	skippedCount := 0
+	interruptedCount := 0
+	failures := NewFailureStats()
 
 	for result := range resultChan {
-		results = append(results, result)
-		if result.Error != nil {
+		if result.Error == nil {
+			stream.Send(result.Index, result.AudioFile)
+		} else {
+			stream.Send(result.Index, "")
+		}
+		if errors.Is(result.Error, context.Canceled) {
+			interruptedCount++
+		} else if result.Error == errBudgetExceeded {
+			skippedCount++
+		} else if result.Error != nil {
+			failures.Add(result.Error)
 			failureCount++
-			fmt.Printf("✗ 任务 %d 失败: %v\n", result.Index, result.Error)
+			Failf("✗ 任务 %d 失败: %v\n", result.Index, result.Error)
 		} else {
 			successCount++
 			fmt.Printf("✓ 任务 %d 完成: %s\n", result.Index, result.AudioFile)
+			results = append(results, result)
 		}
 	}
 
-	fmt.Printf("\n处理完成: 成功 %d, 失败 %d\n\n", successCount, failureCount)
+	if err := stream.Close(); err != nil {
+		Warnf("⚠️  流式输出未完整: %v\n", err)
+	}
+	ets.cache.report()
+
+	fmt.Printf("\n处理完成: 成功 %d, 失败 %d\n", successCount, failureCount)
+	failures.Print()
+	ets.budget.printSkipped(skippedCount)
+
+	// 按需写出指标快照
+	ets.metrics.setTasks(successCount, failureCount, skippedCount)
+	ets.metrics.setCache(ets.cache.counts())
+	writeMetrics(ets.config.MetricsFile, ets.metrics)
+	fmt.Println()
 
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("处理已中断（Ctrl+C），%d 个任务未完成", interruptedCount)
+	}
 	return results, nil
 }
 
 // edgeTTSWorker Edge TTS工作协程
-func (ets *EdgeTTSService) edgeTTSWorker(workerID int, taskChan <-chan EdgeTTSTask, resultChan chan<- EdgeTTSResult, wg *sync.WaitGroup) {
+func (ets *EdgeTTSService) edgeTTSWorker(ctx context.Context, workerID int, queue *taskQueue[EdgeTTSTask], resultChan chan<- EdgeTTSResult, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	for task := range taskChan {
+	for {
+		task, ok := queue.Pop()
+		if !ok {
+			return
+		}
+
+		// 已中断时不再提交新任务
+		if ctx.Err() != nil {
+			resultChan <- EdgeTTSResult{Index: task.Index, Error: ctx.Err()}
+			continue
+		}
+
+		// 超出时长预算后不再提交新任务
+		if ets.budget.Expired() {
+			resultChan <- EdgeTTSResult{Index: task.Index, Error: errBudgetExceeded}
+			continue
+		}
+
 		fmt.Printf("Worker %d 处理任务 %d: %s\n", workerID, task.Index, task.Text)
 
 		// 限制请求频率
-		err := ets.limiter.Wait(context.Background())
+		err := ets.limiter.Wait(ctx)
 		if err != nil {
 			resultChan <- EdgeTTSResult{
 				Index: task.Index,
-				Error: fmt.Errorf("等待速率限制失败: %v", err),
+				Error: fmt.Errorf("等待速率限制失败: %w", err),
 			}
 			continue
 		}
 
 		// 生成音频，带重试机制
-		audioFile, err := ets.generateAudioWithRetry(task.Text, task.Index, 3)
+		audioFile, err := ets.generateAudioWithRetry(ctx, task, 3)
 		resultChan <- EdgeTTSResult{
 			Index:     task.Index,
 			AudioFile: audioFile,
@@ -293,14 +476,26 @@ func (ets *EdgeTTSService) edgeTTSWorker(workerID int, taskChan <-chan EdgeTTSTa
 	}
 }
 
+// voiceOf 返回说话人使用的语音，说话人配置了语音时覆盖默认语音
+func (ets *EdgeTTSService) voiceOf(speakerName string) string {
+	voice := ets.config.EdgeTTS.Voice
+	if speaker, ok := ets.config.Speakers[speakerName]; ok && speaker.Voice != "" {
+		voice = speaker.Voice
+	}
+	if voice == "" {
+		voice = "zh-CN-XiaoyiNeural" // 默认中文女声
+	}
+	return voice
+}
+
 // generateAudioForText 为文本生成音频
-func (ets *EdgeTTSService) generateAudioForText(text string, index int) (string, error) {
-	ctx := context.Background()
+func (ets *EdgeTTSService) generateAudioForText(ctx context.Context, task EdgeTTSTask) (string, error) {
+	text, index := task.Text, task.Index
 
 	// 处理文本：去除特殊字符和格式
 	processedText := ets.textProcessor.ProcessText(text)
 	if strings.TrimSpace(processedText) == "" {
-		return "", fmt.Errorf("处理后的文本为空")
+		return "", errEmptyText
 	}
 
 	// 如果处理前后不同，显示处理效果
@@ -309,26 +504,29 @@ func (ets *EdgeTTSService) generateAudioForText(text string, index int) (string,
 	}
 
 	// 使用配置中的语音参数
-	voice := ets.config.EdgeTTS.Voice
-	if voice == "" {
-		voice = "zh-CN-XiaoyiNeural" // 默认中文女声
-	}
+	voice := ets.voiceOf(task.Speaker)
 
-	rate := ets.config.EdgeTTS.Rate
-	if rate == "" {
-		rate = "+0%" // 默认正常语速
-	}
+	// 生成文件名
+	filename := segmentFilename(index, "mp3")
+	audioPath := filepath.Join(ets.config.Audio.TempDir, filename)
 
-	volume := ets.config.EdgeTTS.Volume
-	if volume == "" {
-		volume = "+0%" // 默认正常音量
+	// 文本和语音参数未变的片段直接复用缓存
+	edge := ets.config.EdgeTTS
+	cacheKey := segmentCacheKey(ProviderEdge, voice, edge.Rate, EdgeVolume(ets.config), edge.Pitch, processedText)
+	if ets.cache.restore(cacheKey, "mp3", audioPath) {
+		fmt.Printf("  🗃️  复用缓存片段 %d\n", index)
+		return audioPath, nil
 	}
 
-	pitch := ets.config.EdgeTTS.Pitch
-	if pitch == "" {
-		pitch = "+0Hz" // 默认正常音调
+	if err := ets.synthesizeToFile(ctx, processedText, voice, audioPath); err != nil {
+		return "", err
 	}
+	ets.cache.store(cacheKey, "mp3", audioPath)
+	return audioPath, nil
+}
 
+// saveWithEdge 建立一次Edge TTS连接并把音频保存到文件
+func (ets *EdgeTTSService) saveWithEdge(ctx context.Context, processedText, voice, rate, volume, pitch, proxy, audioPath string) error {
 	// 创建Edge TTS通信实例
 	comm, err := communicate.NewCommunicate(
 		processedText,
@@ -336,40 +534,100 @@ func (ets *EdgeTTSService) generateAudioForText(text string, index int) (string,
 		rate,   // rate - 语速
 		volume, // volume - 音量
 		pitch,  // pitch - 音调
-		"",     // proxy
+		proxy,  // proxy
 		10,     // connectTimeout
 		60,     // receiveTimeout
 	)
 	if err != nil {
-		return "", fmt.Errorf("创建Edge TTS通信失败: %v", err)
+		return fmt.Errorf("创建Edge TTS通信失败: %v", err)
 	}
 
-	// 生成文件名
-	filename := fmt.Sprintf("audio_%03d.mp3", index)
-	audioPath := filepath.Join(ets.config.Audio.TempDir, filename)
+	// 保存音频文件（保留错误链，用于区分连接断开和文本被拒）
+	if err := comm.Save(ctx, audioPath, ""); err != nil {
+		return fmt.Errorf("保存音频文件失败: %w", withDiskFullHint(err))
+	}
+	return nil
+}
 
-	// 保存音频文件
-	err = comm.Save(ctx, audioPath, "")
+// SynthesizeSample 使用指定语音合成一段试听文本到输出路径
+func (ets *EdgeTTSService) SynthesizeSample(text, voice, outputPath string) error {
+	processedText := ets.textProcessor.ProcessText(text)
+	if strings.TrimSpace(processedText) == "" {
+		return errEmptyText
+	}
+	return writeFileAtomic(outputPath, func(path string) error {
+		return ets.synthesizeToFile(context.Background(), processedText, voice, path)
+	})
+}
+
+// synthesizeToFile 调用Edge TTS把已处理的文本合成到音频文件并验证，重连前的等待可被 ctx 取消
+func (ets *EdgeTTSService) synthesizeToFile(ctx context.Context, processedText, voice, audioPath string) error {
+	rate := ets.config.EdgeTTS.Rate
+	if rate == "" {
+		rate = "+0%" // 默认正常语速
+	}
+
+	volume := EdgeVolume(ets.config) // 统一音量标度或原生音量
+
+	pitch := ets.config.EdgeTTS.Pitch
+	if pitch == "" {
+		pitch = "+0Hz" // 默认正常音调
+	}
+
+	// 代理：config.network.proxy 或环境变量
+	proxy := ResolveProxy(ets.config)
+
+	// 每次连接只能推流一次，连接被断开时重新创建通信实例重连；文本被拒时不重连
+	var err error
+	for reconnect := 0; ; reconnect++ {
+		err = ets.saveWithEdge(ctx, processedText, voice, rate, volume, pitch, proxy, audioPath)
+		if err == nil || !isEdgeConnectionError(err) || reconnect >= edgeReconnectAttempts {
+			break
+		}
+
+		delay := time.Duration(reconnect+1) * edgeReconnectDelay
+		fmt.Printf("  🔌 Edge TTS连接被断开（%v），%v 后第 %d 次重连...\n", err, delay, reconnect+1)
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+	if isEdgeTextRejected(err) {
+		return fmt.Errorf("%w: %v", errEdgeTextRejected, err)
+	}
 	if err != nil {
-		return "", fmt.Errorf("保存音频文件失败: %v", err)
+		return err
 	}
 
 	// 验证生成的音频文件
 	if err := ets.validateAudioFile(audioPath); err != nil {
 		// 删除无效的音频文件
 		os.Remove(audioPath)
-		return "", fmt.Errorf("音频文件验证失败: %v", err)
+		return fmt.Errorf("%w: %v", errInvalidAudio, err)
 	}
 
-	return audioPath, nil
+	return nil
 }
 
-// generateAudioWithRetry 带重试机制的音频生成
-func (ets *EdgeTTSService) generateAudioWithRetry(text string, index int, maxRetries int) (string, error) {
+// generateAudioWithRetry 带重试机制的音频生成，每次尝试受 concurrent.task_timeout 限制，重试间的等待可被 ctx 取消
+func (ets *EdgeTTSService) generateAudioWithRetry(ctx context.Context, task EdgeTTSTask, maxRetries int) (string, error) {
 	var lastErr error
+	index := task.Index
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
-		audioPath, err := ets.generateAudioForText(text, index)
+		// 最后一次重试前降级为激进清洗后的文本，规避引擎不接受的残留字符
+		current := task
+		if attempt == maxRetries && attempt > 1 {
+			current = ets.degradeTask(task)
+		}
+
+		start := time.Now()
+		var audioPath string
+		err := withTaskTimeout(ctx, ets.config.Concurrent.TaskTimeout, func(ctx context.Context) error {
+			var err error
+			audioPath, err = ets.generateAudioForText(ctx, current)
+			return err
+		})
+		ets.metrics.observeRequest(attempt, time.Since(start), err)
 		if err == nil {
 			if attempt > 1 {
 				fmt.Printf("  ✓ 任务 %d 重试第 %d 次成功\n", index, attempt-1)
@@ -378,131 +636,166 @@ func (ets *EdgeTTSService) generateAudioWithRetry(text string, index int, maxRet
 		}
 
 		lastErr = err
-		fmt.Printf("  ✗ 任务 %d 第 %d 次尝试失败: %v\n", index, attempt, err)
+		Warnf("  ✗ 任务 %d 第 %d 次尝试失败: %v\n", index, attempt, err)
+
+		// 文本被拒时原样重试没有意义，直接进入最后一次降级文本尝试
+		if isEdgeTextRejected(err) && attempt < maxRetries-1 {
+			attempt = maxRetries - 1
+		}
 
 		if attempt < maxRetries {
 			// 等待后重试，递增等待时间
 			waitTime := time.Duration(attempt) * time.Second
 			fmt.Printf("  ⏳ 任务 %d 等待 %v 后重试...\n", index, waitTime)
-			time.Sleep(waitTime)
+			if err := sleepContext(ctx, waitTime); err != nil {
+				return "", err
+			}
 		}
 	}
 
-	return "", fmt.Errorf("任务 %d 经过 %d 次重试后仍然失败，最后错误: %v", index, maxRetries, lastErr)
+	return "", fmt.Errorf("任务 %d 经过 %d 次重试后仍然失败，最后错误: %w", index, maxRetries, lastErr)
 }
 
-// validateAudioFile 验证音频文件的有效性
-func (ets *EdgeTTSService) validateAudioFile(audioPath string) error {
-	// 检查文件是否存在
-	fileInfo, err := os.Stat(audioPath)
-	if err != nil {
-		return fmt.Errorf("音频文件不存在: %v", err)
+// checkSegmentCount 对比任务数与成功片段数，列出缺失的任务索引
+func (ets *EdgeTTSService) checkSegmentCount(tasks []EdgeTTSTask, results []EdgeTTSResult) error {
+	expected := make([]int, len(tasks))
+	for i, task := range tasks {
+		expected[i] = task.Index
 	}
-
-	// 检查文件大小（MP3文件通常至少几KB）
-	const minFileSize = 1024 // 最小1KB
-	if fileInfo.Size() < minFileSize {
-		return fmt.Errorf("音频文件过小 (%d bytes)，可能为空或损坏", fileInfo.Size())
+	produced := make([]int, 0, len(results))
+	for _, result := range results {
+		if result.Error == nil && result.AudioFile != "" {
+			produced = append(produced, result.Index)
+		}
 	}
+	return checkSegmentCount(ets.config, expected, produced, ets.budget.Expired())
+}
 
-	// 检查文件是否可读
-	file, err := os.Open(audioPath)
-	if err != nil {
-		return fmt.Errorf("无法打开音频文件: %v", err)
+// rememberSegmentTexts 记录每个成功片段对应的文本，供导出时间轴使用
+func (ets *EdgeTTSService) rememberSegmentTexts(tasks []EdgeTTSTask, results []EdgeTTSResult) {
+	textOf := make(map[int]string, len(tasks))
+	for _, task := range tasks {
+		textOf[task.Index] = task.Text
 	}
-	defer file.Close()
+	ets.segmentTexts = make(map[string]string, len(results))
+	for _, result := range results {
+		if result.Error == nil && result.AudioFile != "" {
+			ets.segmentTexts[result.AudioFile] = textOf[result.Index]
+		}
+	}
+}
 
-	// 读取文件头部，检查是否为有效的MP3文件
-	buffer := make([]byte, 10)
-	n, err := file.Read(buffer)
-	if err != nil || n < 3 {
-		return fmt.Errorf("无法读取音频文件头部")
+// checkDiskSpace 按任务文本估算片段总大小并检查磁盘可用空间（Edge输出固定为MP3）
+func (ets *EdgeTTSService) checkDiskSpace(tasks []EdgeTTSTask) error {
+	texts := make([]string, len(tasks))
+	for i, task := range tasks {
+		texts[i] = task.Text
 	}
+	return checkDiskSpace(ets.config, texts, mp3ByteRate)
+}
 
-	// 检查MP3文件头部标识
-	// MP3文件通常以ID3标签 (ID3) 或 MP3帧同步字 (0xFF 0xFB/0xFA/0xF3/0xF2) 开头
-	if n >= 3 && (string(buffer[:3]) == "ID3" ||
-		(buffer[0] == 0xFF && (buffer[1]&0xF0) == 0xF0)) {
-		fmt.Printf("  ✓ 音频文件验证通过: %s (%.2f KB)\n", audioPath, float64(fileInfo.Size())/1024)
-		return nil
+// checkVoiceLanguage 文本主要语言与默认语音不匹配时打印警告
+func (ets *EdgeTTSService) checkVoiceLanguage(tasks []EdgeTTSTask) {
+	texts := make([]string, len(tasks))
+	for i, task := range tasks {
+		texts[i] = task.Text
 	}
 
-	return fmt.Errorf("音频文件格式无效，可能不是有效的MP3文件")
+	voice := ets.config.EdgeTTS.Voice
+	if voice == "" {
+		voice = "zh-CN-XiaoyiNeural"
+	}
+	warnLanguageMismatch(ets.textProcessor, texts, voice, edgeVoiceLanguage(ets.voices, voice), ets.voices.suggestedVoice)
 }
 
-// mergeAudioFiles 合并音频文件
-func (ets *EdgeTTSService) mergeAudioFiles(audioFiles []string) error {
-	if len(audioFiles) == 0 {
-		return fmt.Errorf("没有音频文件需要合并")
+// checkVoices 用本地缓存的音色列表离线校验默认语音和说话人语音
+func (ets *EdgeTTSService) checkVoices() {
+	voices := []string{ets.config.EdgeTTS.Voice}
+	for _, speaker := range ets.config.Speakers {
+		voices = append(voices, speaker.Voice)
 	}
+	checkEdgeVoices(ets.voices, voices)
+}
 
-	fmt.Printf("开始合并 %d 个音频文件...\n", len(audioFiles))
+// degradeTask 返回使用降级文本的任务，文本无变化或清洗后为空时原样返回
+func (ets *EdgeTTSService) degradeTask(task EdgeTTSTask) EdgeTTSTask {
+	degraded := ets.textProcessor.DegradeText(task.Text)
+	if degraded == "" || degraded == task.Text {
+		return task
+	}
 
-	// 预先验证所有音频文件
-	validAudioFiles := []string{}
-	invalidCount := 0
+	fmt.Printf("  🔧 任务 %d 最后一次重试改用降级文本: %s\n", task.Index, degraded)
+	task.Text = degraded
+	return task
+}
 
-	for _, audioFile := range audioFiles {
-		if err := ets.validateAudioFile(audioFile); err != nil {
-			fmt.Printf("⚠️  跳过无效音频文件: %s, 原因: %v\n", audioFile, err)
-			invalidCount++
-			// 删除无效文件
-			os.Remove(audioFile)
-			continue
-		}
-		validAudioFiles = append(validAudioFiles, audioFile)
+// validateAudioFile 验证音频文件的有效性
+func (ets *EdgeTTSService) validateAudioFile(audioPath string) error {
+	// 检查文件是否存在
+	fileInfo, err := os.Stat(audioPath)
+	if err != nil {
+		return fmt.Errorf("音频文件不存在: %v", err)
 	}
 
-	if len(validAudioFiles) == 0 {
-		return fmt.Errorf("没有有效的音频文件可以合并")
+	// 检查文件大小（MP3文件通常至少几KB）
+	const minFileSize = 1024 // 最小1KB
+	if fileInfo.Size() < minFileSize {
+		return fmt.Errorf("音频文件过小 (%d bytes)，可能为空或损坏", fileInfo.Size())
 	}
 
-	if invalidCount > 0 {
-		fmt.Printf("📊 音频文件验证统计: 有效 %d, 无效 %d\n", len(validAudioFiles), invalidCount)
+	// 配置 audio.validate: size 时跳过文件头校验
+	if sizeOnlyValidation(ets.config) {
+		return nil
 	}
 
-	// 输出文件路径
-	outputPath := filepath.Join(ets.config.Audio.OutputDir, ets.config.Audio.FinalOutput)
-
-	// 创建输出文件
-	outputFile, err := os.Create(outputPath)
+	// 按文件头探测实际格式校验，不依赖配置的 codec
+	format, err := validateAudioHeader(audioPath, AudioFormatMP3, ets.config.Audio.StrictMP3Validation)
 	if err != nil {
-		return fmt.Errorf("创建输出文件失败: %v", err)
+		return err
 	}
-	defer outputFile.Close()
-
-	// 逐个读取并合并音频文件
-	for i, audioFile := range validAudioFiles {
-		fmt.Printf("合并文件 %d/%d: %s\n", i+1, len(validAudioFiles), audioFile)
+	fmt.Printf("  ✓ %s音频文件验证通过: %s (%.2f KB)\n", audioFormatLabel(format, AudioFormatMP3), audioPath, float64(fileInfo.Size())/1024)
+	return nil
+}
 
-		inputFile, err := os.Open(audioFile)
-		if err != nil {
-			return fmt.Errorf("打开音频文件失败 %s: %v", audioFile, err)
-		}
+// mergeAudioFiles 合并音频文件
+func (ets *EdgeTTSService) mergeAudioFiles(audioFiles []string) error {
+	return ets.recordOutputs(ets.mergeAudioFilesTo(audioFiles, filepath.Join(ets.config.Audio.OutputDir, ets.config.Audio.FinalOutput)))
+}
 
-		// 复制文件内容
-		_, err = outputFile.ReadFrom(inputFile)
-		inputFile.Close()
+// mergeAudioFilesTo 合并音频文件到指定输出路径，返回写出的文件
+func (ets *EdgeTTSService) mergeAudioFilesTo(audioFiles []string, outputPath string) ([]string, error) {
+	return mergeSegmentFiles(ets.config, ets.merger, audioFiles, outputPath, ets.validateAudioFile, ets.segmentTexts, ets.chapters)
+}
 
-		if err != nil {
-			return fmt.Errorf("复制音频文件失败 %s: %v", audioFile, err)
-		}
-	}
+// recordOutputs 记录合并写出的文件，原样返回合并错误
+func (ets *EdgeTTSService) recordOutputs(outputs []string, err error) error {
+	ets.outputs = append(ets.outputs, outputs...)
+	return err
+}
 
-	fmt.Printf("音频合并完成: %s\n", outputPath)
-	return nil
+// Outputs 返回本次处理实际写出的输出文件及其时间轴、校验和等附属文件
+func (ets *EdgeTTSService) Outputs() []string {
+	return ets.outputs
 }
 
-// ListEdgeVoices 列出可用的 Edge TTS 语音
-func ListEdgeVoices(languageFilter string) error {
-	ctx := context.Background()
+// Edge语音列表排序方式
+const (
+	VoiceSortLocale = "locale" // 按区域，同区域内按名称
+	VoiceSortName   = "name"   // 按音色名称
+	VoiceSortNeural = "neural" // Neural音色优先，其次按区域和名称
+)
 
-	fmt.Println("正在获取Edge TTS语音列表...")
+// ListEdgeVoices 列出可用的 Edge TTS 语音，refresh 为 true 时忽略本地缓存
+// sortBy 为空时保持接口返回的原始顺序
+func ListEdgeVoices(languageFilter string, refresh bool, proxy string, sortBy string) error {
+	if err := validateVoiceSort(sortBy); err != nil {
+		return err
+	}
 
-	// 获取语音列表
-	voiceList, err := voices.ListVoices(ctx, "")
+	// 获取语音列表（优先使用本地缓存）
+	voiceList, err := LoadEdgeVoices(refresh, proxy)
 	if err != nil {
-		return fmt.Errorf("获取语音列表失败: %v", err)
+		return err
 	}
 
 	// 过滤语音（如果指定了语言）
@@ -518,7 +811,7 @@ func ListEdgeVoices(languageFilter string) error {
 		}
 		fmt.Printf("\n找到 %d 个 '%s' 语言的语音:\n\n", len(filteredVoices), languageFilter)
 	} else {
-		filteredVoices = voiceList
+		filteredVoices = append(filteredVoices, voiceList...)
 		fmt.Printf("\n找到 %d 个可用语音:\n\n", len(filteredVoices))
 	}
 
@@ -526,30 +819,95 @@ func ListEdgeVoices(languageFilter string) error {
 		return fmt.Errorf("没有找到匹配的语音")
 	}
 
-	// 简化显示：只显示简短名称和区域
+	sortEdgeVoices(filteredVoices, sortBy)
+
+	// 简化显示：名称、区域、是否Neural/多语言，以及音色特点标签
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-	fmt.Fprintln(w, "音色\t区域")
-	fmt.Fprintln(w, "--------\t--------")
+	fmt.Fprintln(w, "音色\t区域\t类型\t采样率\t特点")
+	fmt.Fprintln(w, "--------\t--------\t--------\t--------\t--------")
 
 	for _, voice := range filteredVoices {
-		fmt.Fprintf(w, "%s\t%s\n", voice.ShortName, voice.Locale)
+		info := newEdgeVoiceInfo(voice)
+		personalities := strings.Join(voice.VoiceTag.VoicePersonalities, ", ")
+		if personalities == "" {
+			personalities = "-"
+		}
+		sampleRate := "-"
+		if info.SampleRate > 0 {
+			sampleRate = fmt.Sprintf("%dHz", info.SampleRate)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", voice.ShortName, voice.Locale, edgeVoiceKind(voice), sampleRate, personalities)
 	}
 	w.Flush()
 	fmt.Println()
+	fmt.Println("说明: Edge在线接口不支持 style/role（说话风格与角色扮演需使用Azure语音服务），\"特点\"列为音色自带的个性标签")
+	fmt.Println()
 
 	// 显示使用示例
 	if len(filteredVoices) > 0 {
 		exampleVoice := filteredVoices[0].ShortName
 		fmt.Printf("使用示例:\n")
 		fmt.Printf("  # 使用 %s 语音\n", exampleVoice)
-		fmt.Printf("  ./github.com/difyz9/markdown2tts edge -i input.txt --voice %s\n", exampleVoice)
+		fmt.Printf("  %s edge -i input.txt --voice %s\n", ProgramName, exampleVoice)
 		fmt.Printf("  # 调整语速和音量\n")
-		fmt.Printf("  ./github.com/difyz9/markdown2tts edge -i input.txt --voice %s --rate +20%% --volume +10%%\n\n", exampleVoice)
+		fmt.Printf("  %s edge -i input.txt --voice %s --rate +20%% --volume +10%%\n\n", ProgramName, exampleVoice)
 	}
 
 	return nil
 }
 
+// validateVoiceSort 检查语音列表排序方式
+func validateVoiceSort(sortBy string) error {
+	switch sortBy {
+	case "", VoiceSortLocale, VoiceSortName, VoiceSortNeural:
+		return nil
+	default:
+		return fmt.Errorf("未知的排序方式: %s（可选: %s, %s, %s）", sortBy, VoiceSortLocale, VoiceSortName, VoiceSortNeural)
+	}
+}
+
+// sortEdgeVoices 按指定方式对语音列表稳定排序
+func sortEdgeVoices(voices []types.Voice, sortBy string) {
+	byLocale := func(a, b types.Voice) bool {
+		if a.Locale != b.Locale {
+			return a.Locale < b.Locale
+		}
+		return a.ShortName < b.ShortName
+	}
+
+	switch sortBy {
+	case VoiceSortLocale:
+		sort.SliceStable(voices, func(i, j int) bool { return byLocale(voices[i], voices[j]) })
+	case VoiceSortName:
+		sort.SliceStable(voices, func(i, j int) bool { return voices[i].ShortName < voices[j].ShortName })
+	case VoiceSortNeural:
+		sort.SliceStable(voices, func(i, j int) bool {
+			ni, nj := isNeuralVoice(voices[i]), isNeuralVoice(voices[j])
+			if ni != nj {
+				return ni
+			}
+			return byLocale(voices[i], voices[j])
+		})
+	}
+}
+
+// isNeuralVoice 是否为Neural（神经网络）音色
+func isNeuralVoice(voice types.Voice) bool {
+	return strings.Contains(voice.ShortName, "Neural")
+}
+
+// edgeVoiceKind 返回音色类型标注，如 "Neural"、"Neural/多语言"
+func edgeVoiceKind(voice types.Voice) string {
+	kind := "标准"
+	if isNeuralVoice(voice) {
+		kind = "Neural"
+	}
+	if strings.Contains(voice.ShortName, "Multilingual") {
+		kind += "/多语言"
+	}
+	return kind
+}
+
 // getLanguageName 根据语言代码返回语言名称
 func getLanguageName(locale string) string {
 	languageMap := map[string]string{
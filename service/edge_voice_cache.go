@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/difyz9/edge-tts-go/pkg/types"
+	"github.com/difyz9/edge-tts-go/pkg/voices"
+)
+
+// edgeVoiceCacheTTL 语音列表缓存有效期
+const edgeVoiceCacheTTL = 7 * 24 * time.Hour
+
+// edgeVoiceCache 本地缓存的语音列表
+type edgeVoiceCache struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Voices    []edgeVoiceInfo `json:"voices"`
+}
+
+// edgeVoiceInfo 缓存的音色及其能力，离线时用于校验音色和判断语言
+// 字段与 types.Voice 平铺在同一层，旧版本写入的缓存仍可读取
+type edgeVoiceInfo struct {
+	types.Voice
+	SampleRate int `json:"sample_rate,omitempty"` // 输出采样率，由建议编码（如 audio-24khz-48kbitrate-mono-mp3）解析
+}
+
+// suggestedCodecRate 从建议编码中取出采样率，如 24khz
+var suggestedCodecRate = regexp.MustCompile(`(\d+)khz`)
+
+// newEdgeVoiceInfo 由接口返回的音色补全能力信息
+func newEdgeVoiceInfo(voice types.Voice) edgeVoiceInfo {
+	info := edgeVoiceInfo{Voice: voice}
+	if m := suggestedCodecRate.FindStringSubmatch(strings.ToLower(voice.SuggestedCodec)); m != nil {
+		khz, _ := strconv.Atoi(m[1])
+		info.SampleRate = khz * 1000
+	}
+	return info
+}
+
+// edgeVoiceCachePath 返回语音列表缓存文件路径（用户缓存目录下）
+func edgeVoiceCachePath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("获取缓存目录失败: %v", err)
+	}
+	return filepath.Join(cacheDir, "markdown2tts", "edge_voices.json"), nil
+}
+
+// LoadEdgeVoices 获取Edge TTS语音列表，优先读取未过期的本地缓存
+// refresh 为 true 时强制重新拉取；拉取失败时回退到过期缓存，便于离线使用
+func LoadEdgeVoices(refresh bool, proxy string) ([]types.Voice, error) {
+	cachePath, pathErr := edgeVoiceCachePath()
+
+	var cached *edgeVoiceCache
+	if pathErr == nil {
+		cached = readEdgeVoiceCache(cachePath)
+	}
+
+	if !refresh && cached != nil && time.Since(cached.FetchedAt) < edgeVoiceCacheTTL {
+		return cached.voiceList(), nil
+	}
+
+	fmt.Println("正在获取Edge TTS语音列表...")
+	voiceList, err := voices.ListVoices(context.Background(), proxy)
+	if err != nil {
+		if cached != nil {
+			Warnf("⚠️  获取语音列表失败，使用 %s 的本地缓存: %v\n", cached.FetchedAt.Format("2006-01-02 15:04"), err)
+			return cached.voiceList(), nil
+		}
+		return nil, fmt.Errorf("获取语音列表失败: %v", err)
+	}
+
+	if pathErr == nil {
+		if err := writeEdgeVoiceCache(cachePath, voiceList); err != nil {
+			Warnf("⚠️  写入语音列表缓存失败: %v\n", err)
+		}
+	}
+
+	return voiceList, nil
+}
+
+// readEdgeVoiceCache 读取缓存文件，文件不存在或损坏时返回nil
+func readEdgeVoiceCache(path string) *edgeVoiceCache {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var cache edgeVoiceCache
+	if err := json.Unmarshal(data, &cache); err != nil || len(cache.Voices) == 0 {
+		return nil
+	}
+	// 旧版本缓存没有能力字段，读取时补全
+	for i, voice := range cache.Voices {
+		if voice.SampleRate == 0 {
+			cache.Voices[i].SampleRate = newEdgeVoiceInfo(voice.Voice).SampleRate
+		}
+	}
+	return &cache
+}
+
+// voiceList 返回缓存中的音色列表
+func (c *edgeVoiceCache) voiceList() []types.Voice {
+	voiceList := make([]types.Voice, len(c.Voices))
+	for i, voice := range c.Voices {
+		voiceList[i] = voice.Voice
+	}
+	return voiceList
+}
+
+// cachedEdgeVoices 只读取本地缓存（忽略有效期，不联网），没有缓存时返回nil
+func cachedEdgeVoices() *edgeVoiceCache {
+	cachePath, err := edgeVoiceCachePath()
+	if err != nil {
+		return nil
+	}
+	return readEdgeVoiceCache(cachePath)
+}
+
+// find 按名称查找音色，ShortName 和完整名称均可匹配；没有缓存时返回false
+func (c *edgeVoiceCache) find(name string) (edgeVoiceInfo, bool) {
+	if c == nil {
+		return edgeVoiceInfo{}, false
+	}
+	for _, voice := range c.Voices {
+		if strings.EqualFold(voice.ShortName, name) || strings.EqualFold(voice.Name, name) {
+			return voice, true
+		}
+	}
+	return edgeVoiceInfo{}, false
+}
+
+// localeVoices 返回同一区域的音色名称，最多 limit 个
+func (c *edgeVoiceCache) localeVoices(locale string, limit int) []string {
+	var names []string
+	for _, voice := range c.Voices {
+		if strings.EqualFold(voice.Locale, locale) && len(names) < limit {
+			names = append(names, voice.ShortName)
+		}
+	}
+	return names
+}
+
+// writeEdgeVoiceCache 写入缓存文件
+func writeEdgeVoiceCache(path string, voiceList []types.Voice) error {
+	infos := make([]edgeVoiceInfo, len(voiceList))
+	for i, voice := range voiceList {
+		infos[i] = newEdgeVoiceInfo(voice)
+	}
+	data, err := json.Marshal(edgeVoiceCache{FetchedAt: time.Now(), Voices: infos})
+	if err != nil {
+		return err
+	}
+	if err := makeDirs(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// checkEdgeVoices 用本地缓存的音色列表离线校验音色名称，没有缓存时跳过
+// 缓存中找不到时只警告，缓存可能早于新上线的音色
+func checkEdgeVoices(cache *edgeVoiceCache, voices []string) {
+	if cache == nil {
+		return
+	}
+
+	checked := make(map[string]bool)
+	for _, voice := range voices {
+		if voice == "" || checked[voice] {
+			continue
+		}
+		checked[voice] = true
+		if _, ok := cache.find(voice); ok {
+			continue
+		}
+
+		Warnf("⚠️  本地音色列表（%s 缓存）中没有 %s，合成可能失败\n", cache.FetchedAt.Format("2006-01-02"), voice)
+		if similar := cache.localeVoices(edgeVoiceLocale(voice), 3); len(similar) > 0 {
+			fmt.Printf("   同区域可用音色: %s\n", strings.Join(similar, ", "))
+		}
+		fmt.Printf("   可运行 %s edge --list-all --refresh 更新音色列表\n", ProgramName)
+	}
+}
+
+// edgeVoiceLocale 取出音色名称中的区域，如 zh-CN-XiaoyiNeural → zh-CN
+func edgeVoiceLocale(voice string) string {
+	parts := strings.SplitN(voice, "-", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[0] + "-" + parts[1]
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/difyz9/edge-tts-go/pkg/types"
+)
+
+func TestEdgeVoiceLanguage(t *testing.T) {
+	cache := &edgeVoiceCache{Voices: []edgeVoiceInfo{
+		newEdgeVoiceInfo(types.Voice{Name: "Microsoft Server Speech Text to Speech Voice (en-US, AvaMultilingualNeural)", ShortName: "en-US-AvaMultilingualNeural", Locale: "en-US"}),
+		newEdgeVoiceInfo(types.Voice{ShortName: "Custom-Voice", Locale: "ja-JP"}),
+	}}
+	tests := []struct {
+		name  string
+		cache *edgeVoiceCache
+		voice string
+		want  string
+	}{
+		{"无缓存按名称", nil, "zh-CN-XiaoyiNeural", "zh"},
+		{"缓存中的区域", cache, "custom-voice", "ja"},
+		{"完整名称", cache, "Microsoft Server Speech Text to Speech Voice (en-US, AvaMultilingualNeural)", "en"},
+		{"缓存中没有", cache, "ko-KR-SunHiNeural", "ko"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := edgeVoiceLanguage(tt.cache, tt.voice); got != tt.want {
+				t.Errorf("edgeVoiceLanguage(%q) = %q, want %q", tt.voice, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSuggestedVoice(t *testing.T) {
+	cache := &edgeVoiceCache{Voices: []edgeVoiceInfo{{Voice: types.Voice{ShortName: "fr-FR-DeniseNeural", Locale: "fr-FR"}}}}
+	tests := []struct {
+		name  string
+		cache *edgeVoiceCache
+		lang  string
+		want  string
+	}{
+		{"内置推荐", nil, langChinese, edgeSuggestedVoices[langChinese]},
+		{"缓存中同语言", cache, "fr", "fr-FR-DeniseNeural"},
+		{"无缓存", nil, "fr", ""},
+		{"缓存中没有", cache, "de", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cache.suggestedVoice(tt.lang); got != tt.want {
+				t.Errorf("suggestedVoice(%q) = %q, want %q", tt.lang, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadEdgeVoiceCacheFillsSampleRate(t *testing.T) {
+	// 旧版本缓存没有 sample_rate 字段
+	path := filepath.Join(t.TempDir(), "edge_voices.json")
+	data := `{"fetched_at":"2025-01-01T00:00:00Z","voices":[{"ShortName":"zh-CN-XiaoxiaoNeural","Locale":"zh-CN","SuggestedCodec":"audio-24khz-48kbitrate-mono-mp3"}]}`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache := readEdgeVoiceCache(path)
+	if cache == nil {
+		t.Fatal("读取缓存失败")
+	}
+	if info, ok := cache.find("zh-CN-XiaoxiaoNeural"); !ok || info.SampleRate != edgeSampleRate {
+		t.Errorf("find = %+v, %v", info, ok)
+	}
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"strings"
+	"unicode"
+)
+
+// emojiRanges emoji基础字符的Unicode范围，移除emoji和"以emoji开头"判断共用这一张表
+// 修改范围时只改这里，两处行为始终一致
+var emojiRanges = [][2]rune{
+	{0x1F600, 0x1F64F}, // 表情符号和情感
+	{0x1F300, 0x1F5FF}, // 杂项符号和象形文字
+	{0x1F680, 0x1F6FF}, // 交通和地图符号
+	{0x1F1E0, 0x1F1FF}, // 区域指示符号（国旗）
+	{0x2600, 0x26FF},   // 杂项符号
+	{0x2700, 0x27BF},   // 装饰符号
+	{0x1F900, 0x1F9FF}, // 补充符号和象形文字
+	{0x1FA70, 0x1FAFF}, // 扩展符号和象形文字A
+	{0x1F000, 0x1F02F}, // 麻将牌
+	{0x1F0A0, 0x1F0FF}, // 扑克牌
+	{0x1F018, 0x1F270}, // 封闭字母数字补充和封闭表意文字补充
+	{0x238C, 0x2454},   // 杂项技术符号部分
+	{0x231A, 0x231B},   // 手表、沙漏
+	{0x23E9, 0x23FA},   // 媒体控制符号
+	{0x2B05, 0x2B07},   // 箭头
+	{0x2B1B, 0x2B1C},   // 黑白方块
+	{0x2B50, 0x2B50},   // 星星
+	{0x2B55, 0x2B55},   // 圆圈
+	{0x3030, 0x3030},   // 波浪线
+	{0x303D, 0x303D},   // 日文歌记号
+	{0x3297, 0x3297},   // 圈"祝"
+	{0x3299, 0x3299},   // 圈"秘"
+}
+
+// emojiComponentRanges 只在emoji组合序列中出现的修饰和连接字符
+var emojiComponentRanges = [][2]rune{
+	{0x200D, 0x200D},   // 零宽度连接符（ZWJ），连接家庭、职业等组合emoji
+	{0xFE00, 0xFE0F},   // 变体选择符，FE0F表示emoji样式
+	{0x1F3FB, 0x1F3FF}, // 肤色修饰符
+	{0x20D0, 0x20FF},   // 组合用符号，包括键帽 20E3
+	{0xE0020, 0xE007F}, // 标签字符，用于英格兰、苏格兰等地区旗帜
+}
+
+const (
+	zeroWidthJoiner    = 0x200D
+	emojiPresentation  = 0xFE0F
+	combiningKeycap    = 0x20E3
+	regionalIndicatorA = 0x1F1E6
+	regionalIndicatorZ = 0x1F1FF
+)
+
+// inRuneRanges 判断字符是否落在任一范围内
+func inRuneRanges(r rune, ranges [][2]rune) bool {
+	for _, rng := range ranges {
+		if r >= rng[0] && r <= rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmojiRune 判断字符是否为emoji基础字符
+func isEmojiRune(r rune) bool {
+	return inRuneRanges(r, emojiRanges)
+}
+
+// isEmojiComponent 判断字符是否为emoji修饰或连接字符
+func isEmojiComponent(r rune) bool {
+	return inRuneRanges(r, emojiComponentRanges)
+}
+
+// isRegionalIndicator 判断字符是否为区域指示符，两个一组表示国旗
+func isRegionalIndicator(r rune) bool {
+	return r >= regionalIndicatorA && r <= regionalIndicatorZ
+}
+
+// emojiSequenceLen 返回从 runes[i] 开始的完整emoji序列占用的字符数，不是emoji时返回0
+// 序列包括基础emoji及其后的修饰符、键帽序列（如 1️⃣）、成对的区域指示符（国旗，如 🇨🇳）以及用ZWJ连接的组合emoji（如 👨‍👩‍👧‍👦）
+func emojiSequenceLen(runes []rune, i int) int {
+	start := i
+	if i >= len(runes) {
+		return 0
+	}
+
+	r := runes[i]
+	next := func(offset int) rune {
+		if i+offset < len(runes) {
+			return runes[i+offset]
+		}
+		return 0
+	}
+
+	switch {
+	case (r >= '0' && r <= '9') || r == '#' || r == '*':
+		// 键帽序列：数字或#*，可选FE0F，后跟20E3
+		if next(1) == combiningKeycap {
+			i += 2
+		} else if next(1) == emojiPresentation && next(2) == combiningKeycap {
+			i += 3
+		} else {
+			return 0
+		}
+	case isRegionalIndicator(r) && isRegionalIndicator(next(1)):
+		i += 2
+	case isEmojiRune(r):
+		i++
+	case unicode.IsSymbol(r) && next(1) == emojiPresentation:
+		// 默认文本样式的符号加FE0F显示为emoji，如 ©️ ™️
+		i++
+	default:
+		return 0
+	}
+
+	for i < len(runes) {
+		switch {
+		case runes[i] == zeroWidthJoiner:
+			// ZWJ后面必须是另一个emoji，才属于同一个组合序列
+			n := emojiSequenceLen(runes, i+1)
+			if n == 0 {
+				return i - start
+			}
+			i += 1 + n
+		case isEmojiComponent(runes[i]):
+			i++
+		default:
+			return i - start
+		}
+	}
+	return i - start
+}
+
+// emojiDescriptions describe 模式下常见emoji的中文读法，键已去掉变体选择符，❤ 与 ❤️ 读法相同
+var emojiDescriptions = stripEmojiPresentationKeys(map[string]string{
+	"🚀":  "火箭",
+	"❤️": "红心",
+	"💖":  "爱心",
+	"💯":  "满分",
+	"👍":  "点赞",
+	"👎":  "点踩",
+	"👌":  "OK",
+	"✨":  "闪亮",
+	"🌟":  "亮星",
+	"🔥":  "火焰",
+	"💡":  "灯泡",
+	"🎉":  "庆祝",
+	"🎊":  "彩带",
+	"🎈":  "气球",
+	"🎁":  "礼物",
+	"📝":  "记录",
+	"📋":  "清单",
+	"📊":  "图表",
+	"📈":  "上升",
+	"📉":  "下降",
+	"💼":  "公文包",
+	"🔨":  "锤子",
+	"⚡":  "闪电",
+	"🌈":  "彩虹",
+	"☀️": "太阳",
+	"🌙":  "月亮",
+	"⭐":  "星星",
+	"🌍":  "地球",
+	"🚨":  "警报",
+	"⚠️": "警告",
+	"❌":  "错误",
+	"✅":  "正确",
+	"✔️": "勾选",
+	"❓":  "疑问",
+	"❗":  "感叹",
+	"💰":  "金钱",
+	"💸":  "花钱",
+	"🎯":  "目标",
+	"🔍":  "搜索",
+	"📱":  "手机",
+	"💻":  "电脑",
+	"🖥️": "显示器",
+	"⌚":  "手表",
+	"📷":  "相机",
+	"🔊":  "音量",
+	"🔇":  "静音",
+	"📢":  "喇叭",
+	"📣":  "扩音器",
+	"🔔":  "铃铛",
+	"🔕":  "静音",
+	"📚":  "书籍",
+	"📖":  "打开书",
+	"📄":  "文档",
+	"📃":  "页面",
+	"📑":  "书签",
+	"🗂️": "文件夹",
+	"📂":  "文件夹",
+	"📁":  "文件夹",
+	"🔗":  "链接",
+	"📎":  "回形针",
+	"✂️": "剪刀",
+	"📐":  "三角尺",
+	"📏":  "直尺",
+	"🎨":  "调色板",
+	"🖌️": "画笔",
+	"🖍️": "蜡笔",
+	"🖊️": "钢笔",
+	"✏️": "铅笔",
+	"🏆":  "奖杯",
+	"🥇":  "金牌",
+	"🥈":  "银牌",
+	"🥉":  "铜牌",
+	"🎖️": "勋章",
+	"🏅":  "奖章",
+	"🎗️": "丝带",
+	"🎀":  "蝴蝶结",
+	"👑":  "皇冠",
+	"💎":  "钻石",
+	"🔑":  "钥匙",
+	"🗝️": "钥匙",
+	"🔒":  "锁定",
+	"🔓":  "解锁",
+	"🔐":  "加密",
+	"🔏":  "密码锁",
+	"🛡️": "盾牌",
+	"⚔️": "剑",
+	"🏹":  "弓箭",
+	"🎮":  "游戏",
+	"🕹️": "操纵杆",
+	"🎲":  "骰子",
+	"🧩":  "拼图",
+	"🎪":  "马戏团",
+	"🎭":  "面具",
+	"🎬":  "电影",
+	"🎤":  "麦克风",
+	"🎧":  "耳机",
+	"🎵":  "音符",
+	"🎶":  "音乐",
+	"🎼":  "乐谱",
+	"🔈":  "扬声器",
+	"🔉":  "音量",
+	"📻":  "收音机",
+	"📺":  "电视",
+	"📸":  "快照",
+	"📹":  "摄像",
+	"📽️": "放映机",
+	"🎥":  "摄影机",
+	"📞":  "电话",
+	"☎️": "电话",
+	"📟":  "传呼机",
+	"📠":  "传真",
+	"📧":  "邮件",
+	"📨":  "邮件",
+	"📩":  "邮件",
+	"📪":  "邮箱",
+	"📫":  "邮箱",
+	"📬":  "邮箱",
+	"📭":  "邮箱",
+	"📮":  "邮筒",
+	"🗳️": "投票箱",
+	"✉️": "信封",
+	"📜":  "卷轴",
+	"📅":  "日历",
+	"📆":  "日历",
+	"🗓️": "日历",
+	"📇":  "名片",
+	"🗃️": "文件盒",
+	"🗄️": "文件柜",
+	"🗑️": "垃圾桶",
+	"⌛":  "沙漏",
+	"⏳":  "沙漏",
+	"⏰":  "闹钟",
+	"⏱️": "秒表",
+	"⏲️": "定时器",
+	"🕐":  "一点",
+	"🕑":  "二点",
+	"🕒":  "三点",
+	"🕓":  "四点",
+	"🕔":  "五点",
+	"🕕":  "六点",
+	"🕖":  "七点",
+	"🕗":  "八点",
+	"🕘":  "九点",
+	"🕙":  "十点",
+	"🕚":  "十一点",
+	"🕛":  "十二点",
+})
+
+// stripEmojiPresentationKeys 去掉映射表键中的变体选择符 FE0F，查表时序列也按同样方式处理
+func stripEmojiPresentationKeys(table map[string]string) map[string]string {
+	out := make(map[string]string, len(table))
+	for emoji, description := range table {
+		out[stripEmojiPresentation(emoji)] = description
+	}
+	return out
+}
+
+// stripEmojiPresentation 去掉变体选择符 FE0F
+func stripEmojiPresentation(text string) string {
+	return strings.ReplaceAll(text, string(rune(emojiPresentation)), "")
+}
+
+// describeEmojis 把映射表中的emoji替换为中文描述，未收录的emoji和孤立的修饰、连接字符移除
+func describeEmojis(text string) string {
+	runes := []rune(text)
+	var sb strings.Builder
+	for i := 0; i < len(runes); {
+		if n := emojiSequenceLen(runes, i); n > 0 {
+			sb.WriteString(emojiDescriptions[stripEmojiPresentation(string(runes[i:i+n]))])
+			i += n
+			continue
+		}
+		if !isEmojiComponent(runes[i]) {
+			sb.WriteRune(runes[i])
+		}
+		i++
+	}
+	return sb.String()
+}
+
+// removeEmojis 移除文本中的emoji，组合序列整体移除，孤立的修饰和连接字符也一并移除
+func removeEmojis(text string) string {
+	runes := []rune(text)
+	out := make([]rune, 0, len(runes))
+	for i := 0; i < len(runes); {
+		if n := emojiSequenceLen(runes, i); n > 0 {
+			i += n
+			continue
+		}
+		if !isEmojiComponent(runes[i]) {
+			out = append(out, runes[i])
+		}
+		i++
+	}
+	return string(out)
+}
+
+// startsWithEmojiSequence 判断文本开头是否为完整的emoji序列
+// 与 removeEmojis 一样先忽略孤立的修饰和连接字符，只有被移除的是真正的emoji时才算以emoji开头
+// 因此开头只有零宽连接符或变体选择符的文本不会被整行跳过
+func startsWithEmojiSequence(text string) bool {
+	runes := []rune(text)
+	i := 0
+	for i < len(runes) && isEmojiComponent(runes[i]) {
+		i++
+	}
+	return emojiSequenceLen(runes, i) > 0
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRemoveEmojis(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"单个emoji", "出发🚀", "出发"},
+		{"ZWJ家庭", "👨‍👩‍👧‍👦家庭", "家庭"},
+		{"肤色修饰", "👍🏽好", "好"},
+		{"键帽序列", "1️⃣第一", "第一"},
+		{"末尾键帽", "文字#️⃣", "文字"},
+		{"旗帜", "🇨🇳中国", "中国"},
+		{"标签序列旗帜", "🏴\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F旗", "旗"},
+		{"变体选择符", "我爱❤️Go", "我爱Go"},
+		{"孤立的零宽连接符", "‍开头", "开头"},
+		{"普通数字和井号保留", "1. 第一 #标签", "1. 第一 #标签"},
+		{"版权符号保留", "©版权", "©版权"},
+		{"中文标点保留", "你好，世界！", "你好，世界！"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := removeEmojis(tt.input); got != tt.want {
+				t.Errorf("removeEmojis(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEmojiSequenceLen(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int
+	}{
+		{"🚀a", 1},
+		{"❤️a", 2},
+		{"👍🏽a", 2},
+		{"1️⃣a", 3},
+		{"👨‍👩‍👧a", 5},
+		{"🇨🇳a", 2},
+		{"1a", 0},
+		{"中", 0},
+	}
+	for _, tt := range tests {
+		if got := emojiSequenceLen([]rune(tt.input), 0); got != tt.want {
+			t.Errorf("emojiSequenceLen(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestStartsWithEmoji(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"🚀 出发", true},
+		{"  👨‍👩‍👧‍👦 家庭", true},
+		{"1️⃣ 第一步", true},
+		{"🇨🇳 中国", true},
+		{"‍🚀 连接符之后是emoji", true},
+		{"‍开头只有连接符", false},
+		{"️变体选择符", false},
+		{"1. 第一步", false},
+		{"©版权所有", false},
+		{"正文🚀", false},
+	}
+	tp := NewTextProcessor()
+	for _, tt := range tests {
+		if got := tp.startsWithEmoji(tt.input); got != tt.want {
+			t.Errorf("startsWithEmoji(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+		// 以emoji开头时，移除emoji后开头的内容应被删掉
+		text := strings.TrimSpace(tt.input)
+		if removed := []rune(removeEmojis(text)); tt.want && len(removed) > 0 && []rune(text)[0] == removed[0] {
+			t.Errorf("%q: 判断为以emoji开头，但 removeEmojis 未移除开头", tt.input)
+		}
+	}
+}
+
+func TestFilterReasonLeadingEmoji(t *testing.T) {
+	tests := []struct {
+		mode  string
+		input string
+		want  string
+	}{
+		{EmojiModeRemove, "🚀 出发", FilterReasonEmoji},
+		{EmojiModeRemove, "‍开头只有连接符", ""},
+		{EmojiModeDescribe, "🚀 出发", ""},
+		{EmojiModeKeep, "🚀 出发", ""},
+	}
+	for _, inputType := range []string{InputTypeMarkdown, InputTypePlain} {
+		for _, tt := range tests {
+			tp := NewTextProcessor()
+			if err := tp.SetInputType(inputType); err != nil {
+				t.Fatal(err)
+			}
+			if err := tp.SetEmojiMode(tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			if got := tp.FilterReason(tt.input); got != tt.want {
+				t.Errorf("%s/%s: FilterReason(%q) = %q, want %q", inputType, tt.mode, tt.input, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestEmojiMode(t *testing.T) {
+	tests := []struct {
+		mode  string
+		input string
+		want  string
+	}{
+		{EmojiModeRemove, "我爱❤️Go🚀", "我爱 Go"},
+		{EmojiModeDescribe, "我爱❤️Go🚀", "我爱红心 Go 火箭"},
+		{EmojiModeKeep, "我爱❤️Go🚀", "我爱❤️Go🚀"},
+		{"", "我爱❤️Go🚀", "我爱 Go"},
+		// 不带变体选择符的 ❤ 与 ❤️ 读法相同
+		{EmojiModeDescribe, "我爱❤Go", "我爱红心 Go"},
+		// 未收录的emoji和孤立的连接字符移除
+		{EmojiModeDescribe, "看🦩‍", "看"},
+	}
+	// emoji处理与输入类型无关，纯文本输入的结果与Markdown输入一致
+	for _, inputType := range []string{InputTypeMarkdown, InputTypePlain} {
+		for _, tt := range tests {
+			t.Run(inputType+"/"+tt.mode+"/"+tt.input, func(t *testing.T) {
+				tp := NewTextProcessor()
+				if err := tp.SetInputType(inputType); err != nil {
+					t.Fatal(err)
+				}
+				if err := tp.SetEmojiMode(tt.mode); err != nil {
+					t.Fatal(err)
+				}
+				if got := tp.ProcessText(tt.input); got != tt.want {
+					t.Errorf("ProcessText(%q) = %q, want %q", tt.input, got, tt.want)
+				}
+			})
+		}
+	}
+}
+
+func TestSetEmojiModeInvalid(t *testing.T) {
+	tp := NewTextProcessor()
+	if err := tp.SetEmojiMode("speak"); err == nil {
+		t.Error("未知的emoji_mode应返回错误")
+	}
+}
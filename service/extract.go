@@ -0,0 +1,57 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ExtractSentences 只做文本提取、清洗和分句，返回实际送去合成的句子，不调用TTS
+// smart 为 true 时按智能Markdown模式解析，否则逐行清洗并去掉被过滤的行
+func (tp *TextProcessor) ExtractSentences(path string, smart bool) ([]string, error) {
+	if smart {
+		return tp.ProcessMarkdownFile(path)
+	}
+
+	lines, err := tp.PreviewTextFile(path)
+	if err != nil {
+		return nil, err
+	}
+	sentences := make([]string, 0, len(lines))
+	for _, line := range lines {
+		if !line.Skipped {
+			sentences = append(sentences, line.Processed)
+		}
+	}
+	return sentences, nil
+}
+
+// WriteSentences 把句子逐行写入 w，句子内的换行替换为空格，保证一句一行
+func WriteSentences(w io.Writer, sentences []string) error {
+	for _, sentence := range sentences {
+		line := strings.Join(strings.Fields(sentence), " ")
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSentencesFile 把句子逐行写入文件，先写临时文件再替换，失败时不破坏已有文件
+func WriteSentencesFile(path string, sentences []string) error {
+	err := writeFileAtomic(path, func(tmpPath string) error {
+		file, err := createFile(tmpPath)
+		if err != nil {
+			return err
+		}
+		if err := WriteSentences(file, sentences); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	})
+	if err != nil {
+		return fmt.Errorf("写入文本文件失败: %v", err)
+	}
+	return nil
+}
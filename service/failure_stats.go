@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"syscall"
+
+	edgeerrors "github.com/difyz9/edge-tts-go/pkg/errors"
+	"github.com/gorilla/websocket"
+	sdkerrors "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+// 合成失败的错误类型，在出错处用 %w 包装，失败统计据此分类
+var (
+	errTTSTaskTimeout = errors.New("TTS任务超时")
+	errInvalidAudio   = errors.New("音频文件验证失败")
+	errDownloadFailed = errors.New("下载音频失败")
+	errEmptyText      = errors.New("处理后的文本为空")
+)
+
+// failureCategory 失败原因分类及其判断条件
+type failureCategory struct {
+	Name  string
+	Match func(err error) bool
+}
+
+// failureCategories 按顺序匹配，靠前的分类优先
+// 腾讯云按SDK返回的错误码分类，其余按出错处包装的错误类型分类
+var failureCategories = []failureCategory{
+	{Name: "限流", Match: func(err error) bool {
+		return hasTencentCode(err, "RequestLimitExceeded", "LimitExceeded")
+	}},
+	{Name: "鉴权", Match: func(err error) bool {
+		return hasTencentCode(err, "AuthFailure", "UnauthorizedOperation")
+	}},
+	{Name: "网络超时", Match: isTimeoutError},
+	{Name: "音频校验失败", Match: func(err error) bool { return errors.Is(err, errInvalidAudio) }},
+	{Name: "下载失败", Match: func(err error) bool { return errors.Is(err, errDownloadFailed) }},
+	{Name: "文本为空", Match: func(err error) bool {
+		return errors.Is(err, errEmptyText) || hasTencentCode(err, "InvalidParameterValue.TextEmpty")
+	}},
+	{Name: "网络错误", Match: func(err error) bool {
+		return isNetworkError(err) || hasTencentCode(err, "ClientError.NetworkError")
+	}},
+	{Name: "合成任务失败", Match: func(err error) bool {
+		return errors.Is(err, errTTSTaskFailed) || errors.Is(err, errEdgeTextRejected) || errors.Is(err, edgeerrors.ErrNoAudioReceived)
+	}},
+}
+
+// failureOther 无法归类的失败
+const failureOther = "其他"
+
+// classifyFailure 返回错误的分类名称
+func classifyFailure(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	for _, category := range failureCategories {
+		if category.Match(err) {
+			return category.Name
+		}
+	}
+	return failureOther
+}
+
+// hasTencentCode 判断错误链中的腾讯云SDK错误码是否为指定的错误码或其子错误码（如 AuthFailure.SignatureExpire）
+func hasTencentCode(err error, codes ...string) bool {
+	var sdkErr *sdkerrors.TencentCloudSDKError
+	if !errors.As(err, &sdkErr) {
+		return false
+	}
+	code := sdkErr.GetCode()
+	for _, c := range codes {
+		if code == c || strings.HasPrefix(code, c+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// isTimeoutError 判断是否为超时：ctx 超时、网络读写超时或任务轮询超时
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, errTTSTaskTimeout) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// isNetworkError 判断是否为连接层面的错误：连接被拒绝/重置、DNS失败、连接意外关闭、WebSocket断开
+// context 的超时和取消也实现了 net.Error，需要先排除
+func isNetworkError(err error) bool {
+	if err == nil || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, websocket.ErrBadHandshake) || errors.Is(err, edgeerrors.ErrWebSocketError) {
+		return true
+	}
+	var netErr net.Error
+	var closeErr *websocket.CloseError
+	return errors.As(err, &netErr) || errors.As(err, &closeErr)
+}
+
+// FailureStats 按错误类型聚合的失败统计
+type FailureStats struct {
+	counts map[string]int
+}
+
+// NewFailureStats 创建失败统计
+func NewFailureStats() *FailureStats {
+	return &FailureStats{counts: make(map[string]int)}
+}
+
+// Add 记录一次失败
+func (fs *FailureStats) Add(err error) {
+	if err == nil {
+		return
+	}
+	fs.counts[classifyFailure(err)]++
+}
+
+// Summary 返回失败统计摘要，如 "30 次限流、2 次网络超时"，按次数从多到少排列
+func (fs *FailureStats) Summary() string {
+	if len(fs.counts) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(fs.counts))
+	for name := range fs.counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if fs.counts[names[i]] != fs.counts[names[j]] {
+			return fs.counts[names[i]] > fs.counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = fmt.Sprintf("%d 次%s", fs.counts[name], name)
+	}
+	return strings.Join(parts, "、")
+}
+
+// Print 打印失败原因统计，没有失败时不输出
+func (fs *FailureStats) Print() {
+	if summary := fs.Summary(); summary != "" {
+		Warnf("📉 失败原因统计: %s\n", summary)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	edgeerrors "github.com/difyz9/edge-tts-go/pkg/errors"
+	"github.com/gorilla/websocket"
+	sdkerrors "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+// timeoutError 模拟网络读写超时
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "read tcp: i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyFailure(t *testing.T) {
+	sdk := func(code string) error {
+		return fmt.Errorf("调用腾讯云TTS失败: %w", sdkerrors.NewTencentCloudSDKError(code, "message", "req-id"))
+	}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"限流", sdk("RequestLimitExceeded"), "限流"},
+		{"限流子错误码", sdk("LimitExceeded.AccessLimit"), "限流"},
+		{"鉴权", sdk("AuthFailure.SignatureExpire"), "鉴权"},
+		{"无权限", sdk("UnauthorizedOperation"), "鉴权"},
+		{"SDK网络错误", sdk("ClientError.NetworkError"), "网络错误"},
+		{"SDK网络错误且ctx超时", withContextError(expiredContext(t), sdk("ClientError.NetworkError")), "网络超时"},
+		{"ctx超时", fmt.Errorf("任务 1 经过 3 次重试后仍然失败，最后错误: %w", context.DeadlineExceeded), "网络超时"},
+		{"读写超时", &net.OpError{Op: "read", Err: timeoutError{}}, "网络超时"},
+		{"轮询超时", fmt.Errorf("%w，任务ID: 1", errTTSTaskTimeout), "网络超时"},
+		{"音频校验", fmt.Errorf("%w: 音频文件过小", errInvalidAudio), "音频校验失败"},
+		{"下载", fmt.Errorf("%w，状态码: 500", errDownloadFailed), "下载失败"},
+		{"下载超时优先按超时统计", fmt.Errorf("%w: %w", errDownloadFailed, &net.OpError{Op: "read", Err: timeoutError{}}), "网络超时"},
+		{"文本为空", errEmptyText, "文本为空"},
+		{"连接重置", fmt.Errorf("保存音频文件失败: %w", &net.OpError{Op: "read", Err: syscall.ECONNRESET}), "网络错误"},
+		{"连接意外关闭", io.ErrUnexpectedEOF, "网络错误"},
+		{"WebSocket关闭", &websocket.CloseError{Code: websocket.CloseAbnormalClosure}, "网络错误"},
+		{"Edge WebSocket错误", edgeerrors.NewWebSocketError("read failed"), "网络错误"},
+		{"任务失败", fmt.Errorf("%w: 合成失败", errTTSTaskFailed), "合成任务失败"},
+		{"Edge未返回音频", edgeerrors.NewNoAudioReceivedError("no audio"), "合成任务失败"},
+		// 只凭文本不再归类
+		{"文本含关键字", errors.New("写入 timeout.log 失败: EOF"), failureOther},
+		{"限速等待被取消", fmt.Errorf("等待速率限制失败: %w", context.Canceled), failureOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifyFailure(tt.err); got != tt.want {
+				t.Errorf("classifyFailure(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// expiredContext 返回已超时的 ctx
+func expiredContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 0)
+	t.Cleanup(cancel)
+	<-ctx.Done()
+	return ctx
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// edgeSampleRate Edge TTS输出MP3的采样率
+const edgeSampleRate = 24000
+
+// synthesisFallback 一组备选合成参数，provider 为 edge 时带有按备选语速创建的Edge服务
+type synthesisFallback struct {
+	model.FallbackConfig
+	edge *EdgeTTSService
+}
+
+// newSynthesisFallbacks 校验 concurrent.fallbacks 并创建备选参数组，无效的组警告后忽略
+func newSynthesisFallbacks(config *model.Config) []synthesisFallback {
+	var fallbacks []synthesisFallback
+	for i, fc := range config.Concurrent.Fallbacks {
+		fb := synthesisFallback{FallbackConfig: fc}
+		fb.Provider = strings.ToLower(strings.TrimSpace(fc.Provider))
+		switch fb.Provider {
+		case "", ProviderTencent:
+			fb.Provider = ProviderTencent
+			if fc.VoiceType != 0 {
+				if err := checkTencentVoiceParams(fc.VoiceType, config.TTS.SampleRate, config.TTS.EmotionCategory); err != nil {
+					Warnf("⚠️  忽略第 %d 组备选参数: %v\n", i+1, err)
+					continue
+				}
+			}
+		case ProviderEdge:
+			// Edge只输出24kHz的MP3，与其他格式的片段无法合并，采样率不同时需要ffmpeg重采样
+			if codec := strings.ToLower(config.TTS.Codec); codec != "" && codec != "mp3" {
+				Warnf("⚠️  忽略第 %d 组备选参数: Edge TTS只输出MP3，与 codec=%s 的片段无法合并\n", i+1, config.TTS.Codec)
+				continue
+			}
+			if !IsFFmpegAvailable() {
+				Warnf("⚠️  忽略第 %d 组备选参数: Edge TTS输出 %d Hz，需要ffmpeg重采样为 %d Hz 才能与其他片段合并\n", i+1, edgeSampleRate, tencentSampleRate(config))
+				continue
+			}
+			edgeConfig := *config
+			if fc.Rate != "" {
+				edgeConfig.EdgeTTS.Rate = fc.Rate
+			}
+			fb.edge = NewEdgeTTSService(&edgeConfig)
+		default:
+			Warnf("⚠️  忽略第 %d 组备选参数: 未知的TTS服务: %s（可选: tencent, edge）\n", i+1, fc.Provider)
+			continue
+		}
+		fallbacks = append(fallbacks, fb)
+	}
+	return fallbacks
+}
+
+// describe 返回备选参数的简短描述，用于日志
+func (fb synthesisFallback) describe() string {
+	parts := []string{fb.Provider}
+	if fb.VoiceType != 0 {
+		parts = append(parts, fmt.Sprintf("音色=%d", fb.VoiceType))
+	}
+	if fb.Speed != 0 {
+		parts = append(parts, fmt.Sprintf("语速=%g", fb.Speed))
+	}
+	if fb.Voice != "" {
+		parts = append(parts, "语音="+fb.Voice)
+	}
+	if fb.Rate != "" {
+		parts = append(parts, "语速="+fb.Rate)
+	}
+	return strings.Join(parts, " ")
+}
+
+// retryWithFallbacks 由worker池对最终失败的任务按备选参数逐组各试一次，某组成功即不再尝试后续组
+// 全部失败的任务保留原来的错误，便于按失败原因统计
+func (cas *ConcurrentAudioService) retryWithFallbacks(ctx context.Context, tasks []TTSTask, failed []TTSResult) []TTSResult {
+	byIndex := make(map[int]TTSTask, len(tasks))
+	for _, task := range tasks {
+		byIndex[task.Index] = task
+	}
+
+	numWorkers := stageWorkerCount(cas.config, len(failed))
+	fmt.Printf("\n🔁 使用 %d 组备选参数重试 %d 个失败任务（%d 个worker）...\n", len(cas.fallbacks), len(failed), numWorkers)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// 每个worker只写入自己取到的下标，无需加锁
+			for i := range jobs {
+				task := byIndex[failed[i].Index]
+				if audioFile, ok := cas.tryFallbacks(ctx, task); ok {
+					failed[i] = TTSResult{Index: task.Index, AudioFile: audioFile}
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range failed {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return failed
+}
+
+// tryFallbacks 按顺序尝试各组备选参数，成功时记录断点续传进度并返回音频片段路径
+// 备选参数合成的片段与主参数不同，不写入片段缓存
+func (cas *ConcurrentAudioService) tryFallbacks(ctx context.Context, task TTSTask) (string, bool) {
+	for n, fb := range cas.fallbacks {
+		if ctx.Err() != nil {
+			return "", false
+		}
+
+		var audioFile string
+		start := time.Now()
+		err := withTaskTimeout(ctx, cas.config.Concurrent.TaskTimeout, func(ctx context.Context) error {
+			var err error
+			audioFile, err = cas.synthesizeFallback(ctx, task, fb)
+			return err
+		})
+		// 备选参数的尝试计为重试
+		cas.metrics.observeRequest(n+2, time.Since(start), err)
+		if err == nil {
+			fmt.Printf("  ✓ 任务 %d 使用备选参数（%s）合成成功\n", task.Index, fb.describe())
+			cas.progress.record(task.Index, cas.segmentCacheKey(task), audioFile)
+			return audioFile, true
+		}
+		Failf("  ✗ 任务 %d 备选参数（%s）失败: %v\n", task.Index, fb.describe(), err)
+	}
+	return "", false
+}
+
+// synthesizeFallback 使用一组备选参数合成任务，返回验证过的音频片段路径
+func (cas *ConcurrentAudioService) synthesizeFallback(ctx context.Context, task TTSTask, fb synthesisFallback) (string, error) {
+	if fb.edge != nil {
+		voice := fb.Voice
+		if voice == "" {
+			voice = fb.edge.voiceOf(task.Speaker)
+		}
+		text := task.Text
+		if cas.textProcessor.ssmlText(text) {
+			text = ssmlVisibleText(text)
+		}
+		audioFile := filepath.Join(cas.config.Audio.TempDir, segmentFilename(task.Index, "mp3"))
+		if err := fb.edge.synthesizeToFile(ctx, text, voice, audioFile); err != nil {
+			return "", err
+		}
+		if err := matchSampleRate(audioFile, tencentSampleRate(cas.config)); err != nil {
+			os.Remove(audioFile)
+			return "", err
+		}
+		return audioFile, nil
+	}
+
+	voiceType := cas.voiceTypeOf(task)
+	if fb.VoiceType != 0 {
+		voiceType = fb.VoiceType
+	}
+	req := cas.newTTSRequest(task.Text, voiceType)
+	if fb.Speed != 0 {
+		req.Speed = fb.Speed
+	}
+
+	if !useRealtime(cas.mode, task.Text) {
+		audioURL, _, err := cas.synthesizeRequest(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		return cas.downloadAndValidate(audioURL, task.Index)
+	}
+
+	audioFile := filepath.Join(cas.config.Audio.TempDir, segmentFilename(task.Index, cas.config.TTS.Codec))
+	if err := cas.limiters.realtime.Wait(ctx); err != nil {
+		return "", fmt.Errorf("等待速率限制失败: %w", err)
+	}
+	if err := cas.ttsService.SynthesizeRealtimeContext(ctx, req, audioFile); err != nil {
+		return "", err
+	}
+	if err := cas.validateAudioFile(audioFile); err != nil {
+		os.Remove(audioFile)
+		return "", fmt.Errorf("%w: %v", errInvalidAudio, err)
+	}
+	return audioFile, nil
+}
+
+// tencentSampleRate 返回腾讯云片段的采样率，备选服务的片段需要与之一致才能合并
+func tencentSampleRate(config *model.Config) int64 {
+	if config.TTS.SampleRate > 0 {
+		return config.TTS.SampleRate
+	}
+	return defaultTencentSampleRate
+}
+
+// matchSampleRate 音频采样率与 target 不同时用ffmpeg重采样，替换原文件
+func matchSampleRate(path string, target int64) error {
+	rate, err := detectSampleRate(path)
+	if err != nil {
+		return fmt.Errorf("%w: 无法识别采样率: %v", errInvalidAudio, err)
+	}
+	if int64(rate) == target {
+		return nil
+	}
+	err = writeFileAtomic(path, func(tmp string) error {
+		return runFFmpeg("-y", "-loglevel", "error", "-i", path, "-ar", strconv.FormatInt(target, 10), tmp)
+	})
+	if err != nil {
+		return fmt.Errorf("重采样为 %d Hz 失败: %v", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// stubFFmpeg 在 PATH 中放入一个假的ffmpeg脚本，调用参数逐行写入返回的文件
+func stubFFmpeg(t *testing.T, script string) string {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	content := "#!/bin/sh\nfor a in \"$@\"; do echo \"$a\" >> '" + argsFile + "'; done\n" + script + "\n"
+	if err := os.WriteFile(filepath.Join(dir, ffmpegBinary), []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return argsFile
+}
+
+func TestNewSynthesisFallbacks(t *testing.T) {
+	tests := []struct {
+		name      string
+		codec     string
+		ffmpeg    bool
+		fallbacks []model.FallbackConfig
+		want      []string
+	}{
+		{"默认腾讯云", "", false, []model.FallbackConfig{{Speed: 0.9}}, []string{ProviderTencent}},
+		{"Edge需要ffmpeg重采样", "mp3", false, []model.FallbackConfig{{Provider: "edge"}}, nil},
+		{"Edge可重采样", "mp3", true, []model.FallbackConfig{{Provider: " Edge "}}, []string{ProviderEdge}},
+		{"Edge不支持wav", "wav", true, []model.FallbackConfig{{Provider: "edge"}}, nil},
+		{"未知服务", "", true, []model.FallbackConfig{{Provider: "azure"}, {Provider: "tencent"}}, []string{ProviderTencent}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.ffmpeg {
+				stubFFmpeg(t, "")
+			} else {
+				t.Setenv("PATH", t.TempDir())
+			}
+			var config model.Config
+			config.TTS.Codec = tt.codec
+			config.Concurrent.Fallbacks = tt.fallbacks
+
+			var got []string
+			for _, fb := range newSynthesisFallbacks(&config) {
+				got = append(got, fb.Provider)
+				if (fb.edge != nil) != (fb.Provider == ProviderEdge) {
+					t.Errorf("%s: edge服务 = %v", fb.Provider, fb.edge)
+				}
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("备选服务 = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchSampleRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     uint32
+		target   int64
+		resample bool
+	}{
+		{"采样率一致", 16000, 16000, false},
+		{"Edge 24kHz重采样", edgeSampleRate, defaultTencentSampleRate, true},
+		{"8kHz目标", edgeSampleRate, 8000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 假ffmpeg把最后一个参数（输出文件）写成固定内容
+			argsFile := stubFFmpeg(t, `for last; do :; done; printf resampled > "$last"`)
+			path := filepath.Join(t.TempDir(), "segment_0001.wav")
+			wav := buildWAV(wavFormat{audioFormat: 1, channels: 1, sampleRate: tt.rate, bitsPerSample: 16}, make([]byte, 64))
+			if err := os.WriteFile(path, wav, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := matchSampleRate(path, tt.target); err != nil {
+				t.Fatal(err)
+			}
+
+			data, _ := os.ReadFile(path)
+			args, _ := os.ReadFile(argsFile)
+			if !tt.resample {
+				if len(args) != 0 || !bytes.Equal(data, wav) {
+					t.Errorf("采样率一致时不应调用ffmpeg: %q", args)
+				}
+				return
+			}
+			if string(data) != "resampled" {
+				t.Errorf("文件未被替换: %q", data)
+			}
+			want := "-ar\n" + strconv.FormatInt(tt.target, 10) + "\n"
+			if !strings.Contains(string(args), "-i\n"+path+"\n") || !strings.Contains(string(args), want) {
+				t.Errorf("ffmpeg参数 = %q", args)
+			}
+		})
+	}
+}
+
+func TestMatchSampleRateFailure(t *testing.T) {
+	stubFFmpeg(t, "exit 1")
+	path := filepath.Join(t.TempDir(), "segment_0001.wav")
+	wav := buildWAV(wavFormat{audioFormat: 1, channels: 1, sampleRate: edgeSampleRate, bitsPerSample: 16}, make([]byte, 64))
+	if err := os.WriteFile(path, wav, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := matchSampleRate(path, defaultTencentSampleRate); err == nil {
+		t.Fatal("ffmpeg失败时应返回错误")
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, wav) {
+		t.Error("重采样失败时原文件被修改")
+	}
+}
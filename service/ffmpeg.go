@@ -0,0 +1,41 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ffmpegBinary ffmpeg可执行文件名
+const ffmpegBinary = "ffmpeg"
+
+// IsFFmpegAvailable 检查系统中是否安装了ffmpeg
+func IsFFmpegAvailable() bool {
+	_, err := exec.LookPath(ffmpegBinary)
+	return err == nil
+}
+
+// runFFmpeg 执行ffmpeg命令，失败时返回包含stderr输出的错误
+func runFFmpeg(args ...string) error {
+	path, err := exec.LookPath(ffmpegBinary)
+	if err != nil {
+		return fmt.Errorf("未找到ffmpeg，请先安装: %v", err)
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		output := strings.TrimSpace(stderr.String())
+		// 只保留最后几行，ffmpeg的错误信息通常在末尾
+		lines := strings.Split(output, "\n")
+		if len(lines) > 5 {
+			lines = lines[len(lines)-5:]
+		}
+		return fmt.Errorf("ffmpeg执行失败: %v\n%s", err, strings.Join(lines, "\n"))
+	}
+
+	return nil
+}
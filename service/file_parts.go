@@ -0,0 +1,133 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 语速估算：正常语速下每秒约朗读的汉字数和英文单词数
+const (
+	hanCharsPerSecond    = 4.5
+	latinWordsPerSecond  = 2.5
+	sentencePauseSeconds = 0.3
+)
+
+// estimateSpeechDuration 按字数粗略估算一段文本的朗读时长
+func estimateSpeechDuration(text string) time.Duration {
+	hanChars := 0
+	latinWords := 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			hanChars++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				latinWords++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+
+	seconds := float64(hanChars)/hanCharsPerSecond + float64(latinWords)/latinWordsPerSecond + sentencePauseSeconds
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// partItem 参与分文件的一个音频片段
+type partItem struct {
+	File     string
+	Section  int
+	Duration time.Duration
+}
+
+// planFileParts 按预估时长把片段分成多个输出文件，每个文件不超过 limit
+// 超出时优先在当前文件内最后一个章节边界处切分（该边界之前已累计至少一半时长），否则在句子边界切分
+func planFileParts(items []partItem, limit time.Duration) [][]string {
+	var parts [][]string
+	var current []partItem
+	var elapsed time.Duration
+
+	flush := func(items []partItem) {
+		if len(items) == 0 {
+			return
+		}
+		files := make([]string, len(items))
+		for i, item := range items {
+			files[i] = item.File
+		}
+		parts = append(parts, files)
+	}
+
+	for _, item := range items {
+		if len(current) > 0 && elapsed+item.Duration > limit {
+			cut := len(current)
+			var before time.Duration
+			for i := len(current) - 1; i > 0; i-- {
+				if current[i].Section != current[i-1].Section {
+					for _, prev := range current[:i] {
+						before += prev.Duration
+					}
+					if before >= limit/2 {
+						cut = i
+					}
+					break
+				}
+			}
+
+			flush(current[:cut])
+			current = append([]partItem(nil), current[cut:]...)
+			elapsed = 0
+			for _, rest := range current {
+				elapsed += rest.Duration
+			}
+		}
+
+		current = append(current, item)
+		elapsed += item.Duration
+	}
+	flush(current)
+
+	return parts
+}
+
+// partOutputPath 返回第 index 个分段文件（从1开始）的输出路径，如 merged_part1.mp3
+func partOutputPath(outputDir, finalOutput string, index int) string {
+	ext := filepath.Ext(finalOutput)
+	if ext == "" {
+		ext = ".mp3"
+	}
+	stem := strings.TrimSuffix(filepath.Base(finalOutput), filepath.Ext(finalOutput))
+	return filepath.Join(outputDir, fmt.Sprintf("%s_part%d%s", stem, index, ext))
+}
+
+// mergeFileParts 按 audio.max_file_duration 把片段合并为多个编号输出文件，返回所有分段写出的文件
+// 片头/片尾音频与片头/片尾语一致，只加在第一个和最后一个分段
+func mergeFileParts(config *model.Config, items []partItem, merge func(audioFiles []string, outputPath string) ([]string, error)) ([]string, error) {
+	parts := planFileParts(items, config.Audio.MaxFileDuration)
+	if len(parts) == 1 {
+		return merge(withIntroOutroFiles(config, parts[0]), filepath.Join(config.Audio.OutputDir, config.Audio.FinalOutput))
+	}
+
+	fmt.Printf("✂️  按单文件最长 %v 切分为 %d 个输出文件\n", config.Audio.MaxFileDuration, len(parts))
+	var outputs []string
+	for i, files := range parts {
+		outputPath := partOutputPath(config.Audio.OutputDir, config.Audio.FinalOutput, i+1)
+		fmt.Printf("\n📦 合并分段 %d/%d: %s\n", i+1, len(parts), filepath.Base(outputPath))
+		written, err := merge(withIntroOutroFilesAt(config, files, i == 0, i == len(parts)-1), outputPath)
+		outputs = append(outputs, written...)
+		if err != nil {
+			return outputs, fmt.Errorf("合并分段 %d 失败: %v", i+1, err)
+		}
+	}
+
+	fmt.Printf("✅ 分段输出完成: 共 %d 个文件\n", len(parts))
+	return outputs, nil
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+func TestMergeFilePartsOutputs(t *testing.T) {
+	var config model.Config
+	config.Audio.OutputDir = "out"
+	config.Audio.FinalOutput = "book.mp3"
+	config.Audio.MaxFileDuration = time.Minute
+	items := []partItem{
+		{File: "a.mp3", Duration: 40 * time.Second},
+		{File: "b.mp3", Duration: 40 * time.Second},
+	}
+
+	// 每个分段写出音频和校验和两个文件
+	merge := func(audioFiles []string, outputPath string) ([]string, error) {
+		return []string{outputPath, ChecksumPath(outputPath)}, nil
+	}
+	outputs, err := mergeFileParts(&config, items, merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	part1 := filepath.Join("out", "book_part1.mp3")
+	part2 := filepath.Join("out", "book_part2.mp3")
+	want := []string{part1, ChecksumPath(part1), part2, ChecksumPath(part2)}
+	if strings.Join(outputs, ",") != strings.Join(want, ",") {
+		t.Errorf("outputs = %v, want %v", outputs, want)
+	}
+}
+
+func TestMergeFilePartsIntroOutro(t *testing.T) {
+	var config model.Config
+	config.Audio.OutputDir = "out"
+	config.Audio.FinalOutput = "book.mp3"
+	config.Audio.IntroFile = "intro.mp3"
+	config.Audio.OutroFile = "outro.mp3"
+	config.Audio.MaxFileDuration = time.Minute
+	items := []partItem{
+		{File: "a.mp3", Duration: 40 * time.Second},
+		{File: "b.mp3", Duration: 40 * time.Second},
+		{File: "c.mp3", Duration: 40 * time.Second},
+	}
+
+	var merged []string
+	merge := func(audioFiles []string, outputPath string) ([]string, error) {
+		merged = append(merged, strings.Join(audioFiles, ","))
+		return []string{outputPath}, nil
+	}
+	if _, err := mergeFileParts(&config, items, merge); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"intro.mp3,a.mp3", "b.mp3", "c.mp3,outro.mp3"}
+	if strings.Join(merged, " | ") != strings.Join(want, " | ") {
+		t.Errorf("分段合并输入 = %v, want %v", merged, want)
+	}
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 文本被过滤的原因
+const (
+	FilterReasonEmpty          = "空行"
+	FilterReasonEmoji          = "以emoji开头"
+	FilterReasonURL            = "纯URL或邮箱"
+	FilterReasonMarkdown       = "Markdown标记行（标题/表格/代码块/图片等）"
+	FilterReasonTooShort       = "单个无意义字符"
+	FilterReasonNoContent      = "不含文字或数字"
+	FilterReasonProcessedEmpty = "清洗后为空"
+)
+
+// filterReasonOrder 诊断输出中各过滤原因的顺序
+var filterReasonOrder = []string{
+	FilterReasonEmpty,
+	FilterReasonEmoji,
+	FilterReasonURL,
+	FilterReasonMarkdown,
+	FilterReasonTooShort,
+	FilterReasonNoContent,
+	FilterReasonProcessedEmpty,
+	FilterReasonBelowMinChars,
+	FilterReasonAboveMaxChars,
+}
+
+// maxFilterSamples 诊断中展示的被过滤行样例数
+const maxFilterSamples = 5
+
+// maxFilterSampleRunes 样例行超过该长度时截断显示
+const maxFilterSampleRunes = 40
+
+// filteredLine 被过滤行的样例
+type filteredLine struct {
+	Line   int
+	Text   string
+	Reason string
+}
+
+// filterDiagnostics 记录逐行模式下被过滤的行，全部被过滤时输出诊断
+type filterDiagnostics struct {
+	counts  map[string]int
+	samples []filteredLine
+}
+
+func newFilterDiagnostics() *filterDiagnostics {
+	return &filterDiagnostics{counts: make(map[string]int)}
+}
+
+// record 记录一行被过滤，index为从0开始的行号；空行只计数不作为样例
+func (d *filterDiagnostics) record(index int, text, reason string) {
+	d.counts[reason]++
+	if reason == FilterReasonEmpty || len(d.samples) >= maxFilterSamples {
+		return
+	}
+	d.samples = append(d.samples, filteredLine{Line: index + 1, Text: strings.TrimSpace(text), Reason: reason})
+}
+
+// noValidLinesError 打印总行数、各类被过滤行数和样例，并返回说明错误
+func (d *filterDiagnostics) noValidLinesError(lineCount int) error {
+	fmt.Printf("🔍 输入文件共 %d 行，全部被文本清洗过滤：\n", lineCount)
+	for _, reason := range filterReasonOrder {
+		if count := d.counts[reason]; count > 0 {
+			fmt.Printf("   - %s: %d 行\n", reason, count)
+		}
+	}
+
+	if len(d.samples) > 0 {
+		fmt.Println("📝 被过滤行样例：")
+		for _, sample := range d.samples {
+			text := []rune(sample.Text)
+			if len(text) > maxFilterSampleRunes {
+				text = append(text[:maxFilterSampleRunes], []rune("...")...)
+			}
+			fmt.Printf("   第 %d 行 [%s]: %s\n", sample.Line, sample.Reason, string(text))
+		}
+	}
+
+	if lineCount == 0 {
+		return fmt.Errorf("输入文件为空，没有有效的文本行需要处理")
+	}
+	return fmt.Errorf("没有有效的文本行需要处理（%d 行全部被过滤，详见上方诊断）", lineCount)
+}
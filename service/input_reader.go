@@ -0,0 +1,143 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// markdownChunkSize Markdown增量处理时单个块的目标大小
+const markdownChunkSize = 256 * 1024
+
+// forEachInputLine 逐行流式读取输入文件，返回总行数
+// 不限制单行长度，避免 bufio.Scanner 遇到超长行时报错
+func forEachInputLine(path string, fn func(index int, line string)) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("打开输入文件失败: %v", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	index := 0
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			fn(index, strings.TrimRight(line, "\r\n"))
+			index++
+		}
+		if err == io.EOF {
+			return index, nil
+		}
+		if err != nil {
+			return index, fmt.Errorf("读取输入文件失败: %v", err)
+		}
+	}
+}
+
+// markdownBlockState 逐行跟踪代码块、公式块和HTML注释的边界，避免在这些结构内部切分
+type markdownBlockState struct {
+	inFence     bool
+	fenceMarker string
+	inMath      bool
+	inComment   bool
+}
+
+// update 根据当前行（已去除首尾空白）更新状态，返回该行是否位于代码块内（含围栏行）
+func (s *markdownBlockState) update(trimmed string) bool {
+	switch {
+	case s.inFence:
+		if strings.HasPrefix(trimmed, s.fenceMarker) {
+			s.inFence = false
+		}
+		return true
+	case s.inComment:
+		if strings.Contains(trimmed, "-->") {
+			s.inComment = false
+		}
+	case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+		s.inFence = true
+		s.fenceMarker = trimmed[:3]
+		return true
+	case strings.LastIndex(trimmed, "<!--") > strings.LastIndex(trimmed, "-->"):
+		s.inComment = true
+	case strings.Count(trimmed, "$$")%2 == 1:
+		s.inMath = !s.inMath
+	}
+	return false
+}
+
+// insideBlock 当前是否处于不可切分的结构内部
+func (s *markdownBlockState) insideBlock() bool {
+	return s.inFence || s.inMath || s.inComment
+}
+
+// forEachMarkdownChunk 按文档结构把Markdown切成块并依次回调
+// 只在代码块、公式块和HTML注释之外的空行处切分，保证每个块都是完整的Markdown块级结构
+// fn 返回错误时停止读取并返回该错误
+func forEachMarkdownChunk(r io.Reader, fn func(chunk string) error) error {
+	reader := bufio.NewReader(skipFrontMatter(r))
+	var chunk strings.Builder
+	var state markdownBlockState
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			trimmed := strings.TrimSpace(line)
+			state.update(trimmed)
+
+			chunk.WriteString(line)
+
+			if trimmed == "" && !state.insideBlock() && chunk.Len() >= markdownChunkSize {
+				if err := fn(chunk.String()); err != nil {
+					return err
+				}
+				chunk.Reset()
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取Markdown文件失败: %v", err)
+		}
+	}
+
+	if chunk.Len() > 0 {
+		return fn(chunk.String())
+	}
+	return nil
+}
+
+// maxFrontMatterLines front-matter的最大行数，超过仍未闭合则视为普通内容
+const maxFrontMatterLines = 200
+
+// skipFrontMatter 跳过文档开头的YAML（---）或TOML（+++）front-matter
+// 未找到闭合行时原样保留全部内容（开头的 --- 可能只是分隔线）
+func skipFrontMatter(r io.Reader) io.Reader {
+	reader := bufio.NewReader(r)
+
+	first, err := reader.ReadString('\n')
+	delimiter := strings.TrimSpace(strings.TrimPrefix(first, "\uFEFF"))
+	if err != nil || (delimiter != "---" && delimiter != "+++") {
+		return io.MultiReader(strings.NewReader(first), reader)
+	}
+
+	consumed := []string{first}
+	for i := 0; i < maxFrontMatterLines; i++ {
+		line, err := reader.ReadString('\n')
+		consumed = append(consumed, line)
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == delimiter || (delimiter == "---" && trimmed == "...") {
+			return reader // 丢弃front-matter
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	return io.MultiReader(strings.NewReader(strings.Join(consumed, "")), reader)
+}
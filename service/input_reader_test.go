@@ -0,0 +1,88 @@
+package service
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestSkipFrontMatter(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"YAML", "---\ntitle: 标题\ntags: [a]\n---\n正文\n", "正文\n"},
+		{"YAML以...结束", "---\ntitle: 标题\n...\n正文\n", "正文\n"},
+		{"TOML", "+++\ntitle = \"标题\"\n+++\n正文\n", "正文\n"},
+		{"BOM", "\uFEFF---\ntitle: 标题\n---\n正文\n", "正文\n"},
+		{"未闭合的分隔线保留", "---\n正文\n", "---\n正文\n"},
+		{"不在开头", "正文\n---\ntitle: a\n---\n", "正文\n---\ntitle: a\n---\n"},
+		{"TOML不接受...", "+++\na = 1\n...\n", "+++\na = 1\n...\n"},
+		{"超过行数上限", "---\n" + strings.Repeat("a: 1\n", maxFrontMatterLines+1) + "---\n正文\n", "---\n" + strings.Repeat("a: 1\n", maxFrontMatterLines+1) + "---\n正文\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(skipFrontMatter(strings.NewReader(tt.input)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("skipFrontMatter(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownBlockState(t *testing.T) {
+	tests := []struct {
+		name   string
+		lines  []string
+		inside []bool // 每行处理后是否仍在不可切分的结构内
+	}{
+		{"代码块", []string{"```go", "", "```", ""}, []bool{true, true, false, false}},
+		{"波浪线围栏", []string{"~~~", "```", "~~~"}, []bool{true, true, false}},
+		{"公式块", []string{"$$", "x^2", "$$"}, []bool{true, true, false}},
+		{"单行公式", []string{"$$x$$", ""}, []bool{false, false}},
+		{"跨行注释", []string{"<!-- 注释", "", "结束 -->", ""}, []bool{true, true, false, false}},
+		{"单行注释", []string{"<!-- 注释 -->", ""}, []bool{false, false}},
+		{"注释中的围栏", []string{"<!--", "```", "-->", ""}, []bool{true, true, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var state markdownBlockState
+			for i, line := range tt.lines {
+				state.update(line)
+				if got := state.insideBlock(); got != tt.inside[i] {
+					t.Errorf("第 %d 行 %q 后 insideBlock = %v, want %v", i+1, line, got, tt.inside[i])
+				}
+			}
+		})
+	}
+}
+
+func TestForEachMarkdownSectionSkipsCommentsAndFrontMatter(t *testing.T) {
+	input := "---\ntitle: 文档\n---\n# 第一章\n正文一\n<!--\n# 被注释的标题\n-->\n```\n# 代码中的标题\n```\n## 第二章\n正文二\n"
+	var titles []string
+	err := forEachMarkdownSection(strings.NewReader(input), func(title, body string) error {
+		titles = append(titles, title)
+		if strings.Contains(body, "title: 文档") {
+			t.Errorf("front-matter 未剔除: %q", body)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(titles, ","); got != "第一章,第二章" {
+		t.Errorf("章节标题 = %q", got)
+	}
+}
+
+func TestProcessMarkdownDocumentStripsHTMLComments(t *testing.T) {
+	tp := NewTextProcessor()
+	got := strings.Join(tp.ProcessMarkdownDocument("正文一。<!-- 行内注释 -->\n\n<!--\n多行\n注释\n-->\n\n正文二。\n"), "\n")
+	if strings.Contains(got, "注释") || !strings.Contains(got, "正文二") {
+		t.Errorf("ProcessMarkdownDocument = %q", got)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// introTaskIndex 片头语任务的索引，保证按索引排序后位于最前
+const introTaskIndex = -1
+
+// segmentFilename 生成音频片段文件名
+func segmentFilename(index int, codec string) string {
+	if index == introTaskIndex {
+		return fmt.Sprintf("audio_intro.%s", codec)
+	}
+	return fmt.Sprintf("audio_%03d.%s", index, codec)
+}
+
+// introOutroTexts 返回配置的片头/片尾语（已去除首尾空白）
+func introOutroTexts(config *model.Config) (intro, outro string) {
+	return strings.TrimSpace(config.Audio.IntroText), strings.TrimSpace(config.Audio.OutroText)
+}
+
+// withIntroOutroFiles 在音频文件列表首尾拼接配置的片头/片尾音频文件
+func withIntroOutroFiles(config *model.Config, audioFiles []string) []string {
+	return withIntroOutroFilesAt(config, audioFiles, true, true)
+}
+
+// withIntroOutroFilesAt 多文件输出时使用：与片头/片尾语一样，片头音频只拼在第一个输出文件（first）之前，片尾音频只拼在最后一个输出文件（last）之后
+func withIntroOutroFilesAt(config *model.Config, audioFiles []string, first, last bool) []string {
+	intro, outro := "", ""
+	if first {
+		intro = config.Audio.IntroFile
+	}
+	if last {
+		outro = config.Audio.OutroFile
+	}
+	if intro == "" && outro == "" {
+		return audioFiles
+	}
+
+	result := make([]string, 0, len(audioFiles)+2)
+	if intro != "" {
+		fmt.Printf("🎬 添加片头音频: %s\n", intro)
+		result = append(result, intro)
+	}
+	result = append(result, audioFiles...)
+	if outro != "" {
+		fmt.Printf("🎬 添加片尾音频: %s\n", outro)
+		result = append(result, outro)
+	}
+	return result
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// segmentsDirName 保留片段的输出子目录
+const segmentsDirName = "segments"
+
+// keepSegments 把有效的合成片段按顺序拷贝到输出目录的 segments/ 子目录
+// 片段文件名本身带有序号（audio_001.mp3），片头/片尾等外部文件不拷贝
+func keepSegments(config *model.Config, audioFiles []string) {
+	if !config.Audio.KeepSegments {
+		return
+	}
+
+	segmentsDir := filepath.Join(config.Audio.OutputDir, segmentsDirName)
+	if err := makeDirs(segmentsDir); err != nil {
+		Warnf("⚠️  创建片段目录失败: %v\n", err)
+		return
+	}
+
+	tempDir := filepath.Clean(config.Audio.TempDir)
+	copied := 0
+	for _, audioFile := range audioFiles {
+		if filepath.Dir(filepath.Clean(audioFile)) != tempDir {
+			continue
+		}
+		target := filepath.Join(segmentsDir, filepath.Base(audioFile))
+		if err := copySegment(audioFile, target); err != nil {
+			Warnf("⚠️  保留片段失败: %s, 原因: %v\n", audioFile, err)
+			continue
+		}
+		copied++
+	}
+
+	fmt.Printf("📁 已保留 %d 个音频片段到: %s\n", copied, segmentsDir)
+}
+
+// copySegment 拷贝单个片段文件
+func copySegment(src, dst string) error {
+	input, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("打开片段失败: %v", err)
+	}
+	defer input.Close()
+
+	output, err := createFile(dst)
+	if err != nil {
+		return fmt.Errorf("创建片段文件失败: %v", err)
+	}
+	defer output.Close()
+
+	if _, err := copyBuffered(output, input); err != nil {
+		return fmt.Errorf("拷贝片段失败: %v", err)
+	}
+	return nil
+}
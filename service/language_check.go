@@ -0,0 +1,134 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// 检测的主要语言
+const (
+	langChinese  = "zh"
+	langEnglish  = "en"
+	langJapanese = "ja"
+	langKorean   = "ko"
+)
+
+// languageNames 语言代码对应的中文名称
+var languageNames = map[string]string{
+	langChinese:  "中文",
+	langEnglish:  "英文",
+	langJapanese: "日文",
+	langKorean:   "韩文",
+}
+
+// languageSampleRunes 语言检测最多统计的字符数，长文档只看开头部分即可
+const languageSampleRunes = 20000
+
+// latinLettersPerUnit 英文按字母计数时折算的权重：约3个字母的信息量相当于1个汉字，
+// 避免中文技术文档里的英文术语被误判为英文
+const latinLettersPerUnit = 3.0
+
+// languageMismatchRatio 主要语言占比达到该阈值且与音色语言不同时才警告
+const languageMismatchRatio = 0.6
+
+// DetectLanguage 统计文本的主要语言，返回语言代码和该语言的加权占比
+// 中文按汉字计数，英文按字母折算；出现假名时汉字计入日文
+func (tp *TextProcessor) DetectLanguage(texts []string) (string, float64) {
+	counts := make(map[string]float64)
+	sampled := 0
+
+	for _, text := range texts {
+		for _, r := range text {
+			switch {
+			case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+				counts[langJapanese]++
+			case unicode.Is(unicode.Hangul, r):
+				counts[langKorean]++
+			case tp.isChinese(r):
+				counts[langChinese]++
+			case tp.isEnglish(r):
+				counts[langEnglish] += 1 / latinLettersPerUnit
+			default:
+				continue
+			}
+			sampled++
+		}
+		if sampled >= languageSampleRunes {
+			break
+		}
+	}
+
+	// 日文混用汉字，假名占比明显时把汉字归入日文
+	if counts[langJapanese] > 0 && counts[langJapanese]*10 >= counts[langChinese] {
+		counts[langJapanese] += counts[langChinese]
+		counts[langChinese] = 0
+	}
+
+	if sampled == 0 {
+		return "", 0
+	}
+
+	dominant := ""
+	total := 0.0
+	for _, lang := range []string{langChinese, langEnglish, langJapanese, langKorean} {
+		total += counts[lang]
+		if counts[lang] > counts[dominant] {
+			dominant = lang
+		}
+	}
+	return dominant, counts[dominant] / total
+}
+
+// warnLanguageMismatch 文本主要语言与音色语言不一致时打印警告和建议音色，不中断处理
+func warnLanguageMismatch(tp *TextProcessor, texts []string, voice, voiceLang string, suggest func(lang string) string) {
+	lang, ratio := tp.DetectLanguage(texts)
+	if lang == "" || voiceLang == "" || lang == voiceLang || ratio < languageMismatchRatio {
+		return
+	}
+
+	Warnf("⚠️  文本主要为%s（约 %.0f%%），但所选音色 %s 为%s音色，合成效果可能较差\n",
+		languageNames[lang], ratio*100, voice, languageNames[voiceLang])
+	if suggestion := suggest(lang); suggestion != "" {
+		fmt.Printf("   建议改用%s音色: %s\n", languageNames[lang], suggestion)
+	}
+}
+
+// edgeVoiceLanguage 返回Edge语音的语言代码：优先使用本地音色缓存中的区域，否则从名称（如 zh-CN-XiaoyiNeural）中取出
+func edgeVoiceLanguage(cache *edgeVoiceCache, voice string) string {
+	locale := voice
+	if info, ok := cache.find(voice); ok && info.Locale != "" {
+		locale = info.Locale
+	}
+	lang, _, _ := strings.Cut(locale, "-")
+	return strings.ToLower(lang)
+}
+
+// suggestedVoice 返回语言的推荐Edge语音，没有内置推荐时从本地音色缓存中选取同语言的音色
+func (c *edgeVoiceCache) suggestedVoice(lang string) string {
+	if voice, ok := edgeSuggestedVoices[lang]; ok {
+		return voice
+	}
+	if c != nil {
+		for _, voice := range c.Voices {
+			if strings.HasPrefix(strings.ToLower(voice.Locale), lang+"-") {
+				return voice.ShortName
+			}
+		}
+	}
+	return ""
+}
+
+// edgeSuggestedVoices 各语言推荐的Edge语音
+var edgeSuggestedVoices = map[string]string{
+	langChinese:  "zh-CN-XiaoxiaoNeural",
+	langEnglish:  "en-US-AriaNeural",
+	langJapanese: "ja-JP-NanamiNeural",
+	langKorean:   "ko-KR-SunHiNeural",
+}
+
+// tencentSuggestedVoices 各语言推荐的腾讯云音色
+var tencentSuggestedVoices = map[string]string{
+	langChinese: "101001（智瑜）",
+	langEnglish: "101051（WeRose）",
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 超过 max_chars 的句子处理策略
+const (
+	LongTextSplit = "split" // 在标点处切分为多句（默认）
+	LongTextSkip  = "skip"  // 整句跳过，适合过滤乱码或误粘贴的长行
+)
+
+// 不满足长度限制的过滤原因
+const (
+	FilterReasonBelowMinChars = "短于 min_chars"
+	FilterReasonAboveMaxChars = "超过 max_chars"
+)
+
+// 切分长句时优先使用的断点：句末标点优先，其次是逗号等句中停顿
+const (
+	strongBreakRunes = "。！？；.!?;"
+	weakBreakRunes   = "，,、：:"
+)
+
+// lengthLimiter 按 min_chars/max_chars 过滤或切分句子，并保证每句不超过provider单次请求的最大字数
+type lengthLimiter struct {
+	minChars      int
+	maxChars      int
+	policy        string
+	maxTextLength int // provider单次请求的最大字数，0表示不限制
+	short         int // 过短跳过的句子数
+	skipped       int // 过长跳过的句子数
+	split         int // 过长切分的句子数
+	providerSplit int // 超过provider上限切分的句子数
+}
+
+// newLengthLimiter 按配置和provider单次请求的最大字数创建长度限制器，都未设置时返回nil，nil限制器原样保留所有句子
+// 所有合成路径在预处理阶段都经过限制器，超长句子在发送前就被切分，不会因超出provider上限被拒
+func newLengthLimiter(config *model.Config, maxTextLength int) *lengthLimiter {
+	minChars, maxChars := config.Markdown.MinChars, config.Markdown.MaxChars
+	if minChars <= 0 && maxChars <= 0 && maxTextLength <= 0 {
+		return nil
+	}
+	if maxChars > 0 && minChars > maxChars {
+		Warnf("警告: min_chars(%d) 大于 max_chars(%d)，忽略 min_chars\n", minChars, maxChars)
+		minChars = 0
+	}
+
+	policy := strings.ToLower(strings.TrimSpace(config.Markdown.LongTextPolicy))
+	switch policy {
+	case "":
+		policy = LongTextSplit
+	case LongTextSplit, LongTextSkip:
+	default:
+		Warnf("警告: 未知的长句处理策略: %s（可选: split, skip），将切分长句\n", policy)
+		policy = LongTextSplit
+	}
+	return &lengthLimiter{minChars: minChars, maxChars: maxChars, policy: policy, maxTextLength: maxTextLength}
+}
+
+// fit 返回满足长度限制的句子：过短或按策略跳过时返回过滤原因，过长且策略为split时返回切分后的多句
+// 超过provider上限的句子总是切分，与 long_text_policy 无关
+func (l *lengthLimiter) fit(text string) ([]string, string) {
+	if l == nil {
+		return []string{text}, ""
+	}
+
+	// SSML文本切分会破坏标签结构，只按朗读的文字检查最短长度，不切分
+	if ssmlTagRegex.MatchString(text) {
+		if l.minChars > 0 && len([]rune(strings.TrimSpace(ssmlVisibleText(text)))) < l.minChars {
+			l.short++
+			return nil, FilterReasonBelowMinChars
+		}
+		return []string{text}, ""
+	}
+
+	parts, reason := l.fitConfigured(text)
+	if reason != "" || l.maxTextLength <= 0 {
+		return parts, reason
+	}
+
+	fitted := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if len([]rune(strings.TrimSpace(part))) > l.maxTextLength {
+			l.providerSplit++
+			fitted = append(fitted, splitByLength(part, l.maxTextLength)...)
+			continue
+		}
+		fitted = append(fitted, part)
+	}
+	return fitted, ""
+}
+
+// fitConfigured 按 min_chars/max_chars 过滤或切分句子
+func (l *lengthLimiter) fitConfigured(text string) ([]string, string) {
+	length := len([]rune(strings.TrimSpace(text)))
+	switch {
+	case l.minChars > 0 && length < l.minChars:
+		l.short++
+		return nil, FilterReasonBelowMinChars
+	case l.maxChars > 0 && length > l.maxChars:
+		if l.policy == LongTextSkip {
+			l.skipped++
+			return nil, FilterReasonAboveMaxChars
+		}
+		l.split++
+		return splitByLength(text, l.maxChars), ""
+	default:
+		return []string{text}, ""
+	}
+}
+
+// report 打印长度过滤统计
+func (l *lengthLimiter) report() {
+	if l == nil || l.short+l.skipped+l.split+l.providerSplit == 0 {
+		return
+	}
+
+	var parts []string
+	if l.short > 0 {
+		parts = append(parts, fmt.Sprintf("短于 %d 字跳过 %d 句", l.minChars, l.short))
+	}
+	if l.skipped > 0 {
+		parts = append(parts, fmt.Sprintf("超过 %d 字跳过 %d 句", l.maxChars, l.skipped))
+	}
+	if l.split > 0 {
+		parts = append(parts, fmt.Sprintf("超过 %d 字切分 %d 句", l.maxChars, l.split))
+	}
+	if l.providerSplit > 0 {
+		parts = append(parts, fmt.Sprintf("超过单次请求上限 %d 字切分 %d 句", l.maxTextLength, l.providerSplit))
+	}
+	fmt.Printf("📏 长度过滤: %s\n", strings.Join(parts, "，"))
+}
+
+// splitByLength 把文本切分为不超过 maxChars 字的片段，优先在句末标点处断开，其次是逗号和空格，都没有时硬切
+func splitByLength(text string, maxChars int) []string {
+	var parts []string
+	runes := []rune(strings.TrimSpace(text))
+
+	for len(runes) > maxChars {
+		cut := bestBreak(runes[:maxChars+1])
+		if cut <= 0 {
+			cut = maxChars
+		}
+		if part := strings.TrimSpace(string(runes[:cut])); part != "" {
+			parts = append(parts, part)
+		}
+		runes = []rune(strings.TrimLeftFunc(string(runes[cut:]), unicode.IsSpace))
+	}
+	if part := strings.TrimSpace(string(runes)); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
+
+// bestBreak 在窗口内从后往前查找断点，返回切分位置，找不到时返回0
+// 窗口比上限多1个字，因此标点最晚只能位于倒数第二个字，空格可以位于最后一个字
+func bestBreak(window []rune) int {
+	for _, set := range []string{strongBreakRunes, weakBreakRunes} {
+		for i := len(window) - 2; i > 0; i-- {
+			if strings.ContainsRune(set, window[i]) {
+				return i + 1
+			}
+		}
+	}
+	for i := len(window) - 1; i > 0; i-- {
+		if unicode.IsSpace(window[i]) {
+			return i
+		}
+	}
+	return 0
+}
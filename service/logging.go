@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// 输出级别，开启日志文件时按 --log-level 过滤
+const (
+	LogLevelInfo = iota
+	LogLevelWarn
+	LogLevelError
+)
+
+// logLevelTags 带级别输出的行首标记，由日志转发识别后去掉，不会出现在终端
+var logLevelTags = map[int]string{
+	LogLevelWarn:  "\x01W",
+	LogLevelError: "\x01E",
+}
+
+// tagLogLevels 是否给带级别的输出加行首标记，只在开启日志文件时打开
+var tagLogLevels atomic.Bool
+
+// SetLogLevelTags 开启或关闭行首的级别标记
+func SetLogLevelTags(enabled bool) {
+	tagLogLevels.Store(enabled)
+}
+
+// ParseLogLevelTag 识别并去掉行首的级别标记，没有标记的行为普通信息
+func ParseLogLevelTag(line string) (int, string) {
+	for level, tag := range logLevelTags {
+		if strings.HasPrefix(line, tag) {
+			return level, line[len(tag):]
+		}
+	}
+	return LogLevelInfo, line
+}
+
+// Warnf 打印警告：处理可以继续，但结果可能不符合预期
+func Warnf(format string, args ...interface{}) {
+	printLevel(LogLevelWarn, fmt.Sprintf(format, args...))
+}
+
+// Failf 打印错误：任务失败或命令无法完成
+func Failf(format string, args ...interface{}) {
+	printLevel(LogLevelError, fmt.Sprintf(format, args...))
+}
+
+// printLevel 写到标准输出，需要时给每个非空行加上级别标记
+func printLevel(level int, text string) {
+	if tagLogLevels.Load() {
+		lines := strings.SplitAfter(text, "\n")
+		for i, line := range lines {
+			if strings.TrimSpace(line) != "" {
+				lines[i] = logLevelTags[level] + line
+			}
+		}
+		text = strings.Join(lines, "")
+	}
+	fmt.Print(text)
+}
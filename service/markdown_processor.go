@@ -2,41 +2,164 @@ package service
 
 import (
 	"bytes"
+	"fmt"
+	"net/url"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/russross/blackfriday/v2"
 )
 
+// defaultMarkdownExtensions 默认启用的blackfriday解析扩展
+const defaultMarkdownExtensions = blackfriday.CommonExtensions |
+	blackfriday.AutoHeadingIDs |
+	blackfriday.Footnotes
+
+// markdownExtensionNames 可在 markdown.extensions 中开关的扩展名称
+var markdownExtensionNames = map[string]blackfriday.Extensions{
+	"common":                     blackfriday.CommonExtensions,
+	"no_intra_emphasis":          blackfriday.NoIntraEmphasis,
+	"tables":                     blackfriday.Tables,
+	"fenced_code":                blackfriday.FencedCode,
+	"autolink":                   blackfriday.Autolink,
+	"strikethrough":              blackfriday.Strikethrough,
+	"lax_html_blocks":            blackfriday.LaxHTMLBlocks,
+	"space_headings":             blackfriday.SpaceHeadings,
+	"hard_line_break":            blackfriday.HardLineBreak,
+	"tab_size_eight":             blackfriday.TabSizeEight,
+	"footnotes":                  blackfriday.Footnotes,
+	"no_empty_line_before_block": blackfriday.NoEmptyLineBeforeBlock,
+	"heading_ids":                blackfriday.HeadingIDs,
+	"titleblock":                 blackfriday.Titleblock,
+	"auto_heading_ids":           blackfriday.AutoHeadingIDs,
+	"backslash_line_break":       blackfriday.BackslashLineBreak,
+	"definition_lists":           blackfriday.DefinitionLists,
+}
+
 // MarkdownProcessor 专门处理Markdown文档的处理器
 type MarkdownProcessor struct {
-	preserveLinks bool
-	removeImages  bool
+	linkMode     string
+	removeImages bool
+	readImageAlt bool
+	stripTOC     bool
+	urlMode      string
+	ssml         bool // SSML模式：保留SSML标签
+	extensions   blackfriday.Extensions
 }
 
 // NewMarkdownProcessor 创建新的Markdown处理器
 func NewMarkdownProcessor() *MarkdownProcessor {
 	return &MarkdownProcessor{
-		preserveLinks: true, // 保留链接文本
-		removeImages:  true, // 移除图片
+		linkMode:     LinkModeText, // 保留链接文本
+		removeImages: true,         // 移除图片
+		readImageAlt: false,
+		extensions:   defaultMarkdownExtensions,
+	}
+}
+
+// SetLinkMode 设置链接处理模式（text/domain/remove），空字符串表示默认的text
+func (mp *MarkdownProcessor) SetLinkMode(mode string) {
+	if mode == "" {
+		mode = LinkModeText
+	}
+	mp.linkMode = mode
+}
+
+// SetURLMode 设置裸URL处理模式（remove/domain），domain 模式下自动链接保留URL，由文本处理读作域名
+func (mp *MarkdownProcessor) SetURLMode(mode string) {
+	mp.urlMode = mode
+}
+
+// SetExtensions 在默认扩展的基础上按顺序开关解析扩展
+// "name" 或 "+name" 开启，"-name" 关闭，"none" 清空全部扩展；出错时保持原设置
+func (mp *MarkdownProcessor) SetExtensions(names []string) error {
+	extensions := defaultMarkdownExtensions
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "none" {
+			extensions = blackfriday.NoExtensions
+			continue
+		}
+
+		enable := !strings.HasPrefix(name, "-")
+		flag, ok := markdownExtensionNames[strings.TrimLeft(name, "+-")]
+		if !ok {
+			return fmt.Errorf("未知的Markdown扩展: %s（可选: %s）", name, markdownExtensionList())
+		}
+		if enable {
+			extensions |= flag
+		} else {
+			extensions &^= flag
+		}
+	}
+
+	mp.extensions = extensions
+	return nil
+}
+
+// markdownExtensionList 返回排序后的扩展名称列表
+func markdownExtensionList() string {
+	names := make([]string, 0, len(markdownExtensionNames)+1)
+	for name := range markdownExtensionNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(append(names, "none"), ", ")
+}
+
+// SetReadImageAlt 设置是否朗读图片的alt描述文本
+func (mp *MarkdownProcessor) SetReadImageAlt(enabled bool) {
+	mp.readImageAlt = enabled
+}
+
+// SetStripTOC 设置是否剔除目录（由本文档锚点链接组成的列表，以及 [TOC] 占位标记）
+func (mp *MarkdownProcessor) SetStripTOC(enabled bool) {
+	mp.stripTOC = enabled
+}
+
+// textDefinitionRegex 形如 [注]: 说明 的行，blackfriday 会把它当作链接定义整行丢掉
+var textDefinitionRegex = regexp.MustCompile(`^[ \t]{0,3}\[[^\]^\n][^\]\n]*\]:`)
+
+// escapeTextDefinitions 转义目标不像链接的"定义"行，让说明文本照常朗读，代码块内不处理
+func escapeTextDefinitions(markdown string) string {
+	lines := strings.Split(markdown, "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || !textDefinitionRegex.MatchString(line) || linkDefinitionRegex.MatchString(line) {
+			continue
+		}
+		bracket := strings.Index(line, "[")
+		lines[i] = line[:bracket] + "\\" + line[bracket:]
 	}
+	return strings.Join(lines, "\n")
 }
 
 // ExtractTextForTTS 从Markdown文档中提取适合TTS的纯文本
 func (mp *MarkdownProcessor) ExtractTextForTTS(markdown string) string {
 	// 使用 blackfriday 解析 Markdown
-	doc := blackfriday.New(blackfriday.WithExtensions(
-		blackfriday.CommonExtensions |
-			blackfriday.AutoHeadingIDs |
-			blackfriday.Footnotes,
-	)).Parse([]byte(markdown))
+	markdown = escapeTextDefinitions(markdown)
+	doc := blackfriday.New(blackfriday.WithExtensions(mp.extensions)).Parse([]byte(markdown))
 
 	// 创建自定义渲染器来提取纯文本
 	renderer := &TTSRenderer{
-		preserveLinks: mp.preserveLinks,
-		removeImages:  mp.removeImages,
-		buffer:        &bytes.Buffer{},
+		linkMode:     mp.linkMode,
+		removeImages: mp.removeImages,
+		readImageAlt: mp.readImageAlt,
+		stripTOC:     mp.stripTOC,
+		urlMode:      mp.urlMode,
+		ssml:         mp.ssml,
+		buffer:       getTextBuffer(),
 	}
+	defer putTextBuffer(renderer.buffer)
 
 	// 遍历AST并提取文本
 	doc.Walk(func(node *blackfriday.Node, entering bool) blackfriday.WalkStatus {
@@ -53,11 +176,15 @@ func (mp *MarkdownProcessor) ExtractTextForTTS(markdown string) string {
 
 // TTSRenderer 自定义渲染器，专门用于提取适合TTS的文本
 type TTSRenderer struct {
-	preserveLinks bool
-	removeImages  bool
-	buffer        *bytes.Buffer
-	inImage       bool
-	linkText      string
+	linkMode     string
+	removeImages bool
+	readImageAlt bool
+	stripTOC     bool
+	urlMode      string
+	ssml         bool
+	buffer       *bytes.Buffer
+	inImage      bool
+	linkText     string
 }
 
 // RenderNode 处理AST节点
@@ -78,6 +205,12 @@ func (r *TTSRenderer) RenderNode(node *blackfriday.Node, entering bool) blackfri
 		return blackfriday.SkipChildren
 
 	case blackfriday.HTMLBlock, blackfriday.HTMLSpan:
+		// SSML模式下SSML标签原样保留，交给分句和文本处理
+		if entering && r.ssml && isSSMLMarkup(string(node.Literal)) {
+			r.buffer.Write(node.Literal)
+			r.buffer.WriteString(" ")
+			return blackfriday.SkipChildren
+		}
 		// 跳过HTML块，但可能需要提取内容
 		if entering && r.shouldExtractHTMLContent(node) {
 			content := r.extractHTMLContent(string(node.Literal))
@@ -89,6 +222,18 @@ func (r *TTSRenderer) RenderNode(node *blackfriday.Node, entering bool) blackfri
 		return blackfriday.SkipChildren
 
 	case blackfriday.Image:
+		// 可选朗读图片的alt描述，对无障碍用途很重要
+		if r.readImageAlt {
+			if entering {
+				if alt := r.imageAltText(node); alt != "" {
+					// 以句号结尾，使alt描述在分句时独立成句
+					r.buffer.WriteString(imageAltPrefix + alt + "。")
+					r.buffer.WriteString(" ")
+				}
+			}
+			return blackfriday.SkipChildren
+		}
+
 		// 处理图片
 		if r.removeImages {
 			return blackfriday.SkipChildren
@@ -105,8 +250,15 @@ func (r *TTSRenderer) RenderNode(node *blackfriday.Node, entering bool) blackfri
 		if entering {
 			r.linkText = ""
 		} else {
-			if r.preserveLinks && r.linkText != "" {
-				r.buffer.WriteString(r.linkText)
+			destination := string(node.LinkData.Destination)
+			// 正文中的裸URL被解析为自动链接，读作域名时保留URL交给文本处理
+			if r.urlMode == URLModeDomain && strings.TrimSpace(r.linkText) == destination {
+				r.buffer.WriteString(destination)
+				r.buffer.WriteString(" ")
+				return blackfriday.GoToNext
+			}
+			if spoken := spokenLink(r.linkMode, r.linkText, destination); spoken != "" {
+				r.buffer.WriteString(spoken)
 				r.buffer.WriteString(" ")
 			}
 		}
@@ -133,11 +285,18 @@ func (r *TTSRenderer) RenderNode(node *blackfriday.Node, entering bool) blackfri
 
 	case blackfriday.Paragraph:
 		// 段落处理
+		if entering && r.stripTOC && isTOCMarker(node) {
+			return blackfriday.SkipChildren
+		}
 		if !entering {
 			r.buffer.WriteString("\n")
 		}
 
 	case blackfriday.List, blackfriday.Item:
+		// 剔除目录：避免把一串章节标题重复朗读一遍
+		if entering && r.stripTOC && node.Type == blackfriday.List && isTOCList(node) {
+			return blackfriday.SkipChildren
+		}
 		// 列表处理
 		if !entering {
 			r.buffer.WriteString("\n")
@@ -157,6 +316,51 @@ func (r *TTSRenderer) RenderNode(node *blackfriday.Node, entering bool) blackfri
 	return blackfriday.GoToNext
 }
 
+// spokenLink 按链接模式返回链接的朗读文本
+func spokenLink(mode, text, destination string) string {
+	text = strings.TrimSpace(text)
+	switch mode {
+	case LinkModeRemove:
+		return ""
+	case LinkModeDomain:
+		domain := linkDomain(destination)
+		if domain == "" {
+			return text
+		}
+		// 自动链接的文本就是URL本身，只读域名
+		if text == "" || text == destination {
+			return linkDomainPrefix + domain
+		}
+		return text + "，" + linkDomainPrefix + domain
+	default:
+		return text
+	}
+}
+
+// linkDomain 提取链接的域名（去掉 www. 前缀），站内相对链接和邮箱返回空
+func linkDomain(destination string) string {
+	if strings.HasPrefix(strings.ToLower(destination), "www.") {
+		destination = "http://" + destination
+	}
+	u, err := url.Parse(destination)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// imageAltText 提取图片节点的alt文本（图片的子节点即为alt内容）
+func (r *TTSRenderer) imageAltText(node *blackfriday.Node) string {
+	var alt strings.Builder
+	node.Walk(func(child *blackfriday.Node, entering bool) blackfriday.WalkStatus {
+		if entering && child.Type == blackfriday.Text {
+			alt.Write(child.Literal)
+		}
+		return blackfriday.GoToNext
+	})
+	return strings.TrimSpace(alt.String())
+}
+
 // shouldExtractHTMLContent 判断是否应该提取HTML内容
 func (r *TTSRenderer) shouldExtractHTMLContent(node *blackfriday.Node) bool {
 	content := string(node.Literal)
@@ -220,22 +424,29 @@ func (mp *MarkdownProcessor) SplitIntoSentences(text string) []string {
 			continue
 		}
 
+		// SSML标签内可能含有 . 等符号，且不能在元素内部切分
+		if mp.ssml && ssmlTagRegex.MatchString(paragraph) {
+			sentences = append(sentences, splitSSMLSentences(paragraph)...)
+			continue
+		}
+
 		// 保护常见的技术术语，避免在其中分割
 		protected := paragraph
 
 		// 暂时替换常见的技术模式，避免在这些地方分割
-		protectedPatterns := map[string]string{
-			".New()":  "NEWMETHOD",
-			".Load()": "LOADMETHOD",
-			".Call()": "CALLMETHOD",
-			".com/":   "DOTCOM",
-			".org/":   "DOTORG",
-			".net/":   "DOTNET",
-			".go":     "DOTGO",
+		// 使用有序列表，保证替换和恢复顺序固定
+		protectedPatterns := [][2]string{
+			{".New()", "NEWMETHOD"},
+			{".Load()", "LOADMETHOD"},
+			{".Call()", "CALLMETHOD"},
+			{".com/", "DOTCOM"},
+			{".org/", "DOTORG"},
+			{".net/", "DOTNET"},
+			{".go", "DOTGO"},
 		}
 
-		for pattern, replacement := range protectedPatterns {
-			protected = strings.ReplaceAll(protected, pattern, replacement)
+		for _, pattern := range protectedPatterns {
+			protected = strings.ReplaceAll(protected, pattern[0], pattern[1])
 		}
 
 		// 现在可以安全地按句号分割（只对中文句号和英文句号结尾）
@@ -251,8 +462,8 @@ func (mp *MarkdownProcessor) SplitIntoSentences(text string) []string {
 				}
 
 				// 恢复保护的模式
-				for pattern, replacement := range protectedPatterns {
-					part = strings.ReplaceAll(part, replacement, pattern)
+				for _, pattern := range protectedPatterns {
+					part = strings.ReplaceAll(part, pattern[1], pattern[0])
 				}
 
 				// 加回标点符号（除了最后一部分）
@@ -264,8 +475,8 @@ func (mp *MarkdownProcessor) SplitIntoSentences(text string) []string {
 			}
 		} else {
 			// 恢复保护的模式
-			for pattern, replacement := range protectedPatterns {
-				paragraph = strings.ReplaceAll(paragraph, replacement, pattern)
+			for _, pattern := range protectedPatterns {
+				paragraph = strings.ReplaceAll(paragraph, pattern[1], pattern[0])
 			}
 			sentences = append(sentences, paragraph)
 		}
@@ -0,0 +1,49 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// markdownProgressInterval 解析超大Markdown时进度输出的最短间隔
+const markdownProgressInterval = 2 * time.Second
+
+// markdownProgress 解析Markdown句子阶段的进度反馈，文档较小时不输出
+// 并行预处理的worker会同时调用 add，因此由互斥锁保护
+type markdownProgress struct {
+	mu       sync.Mutex
+	start    time.Time
+	last     time.Time
+	count    int
+	reported bool
+}
+
+func newMarkdownProgress() *markdownProgress {
+	now := time.Now()
+	return &markdownProgress{start: now, last: now}
+}
+
+// add 记录已处理一句，距上次输出超过间隔时打印进度；nil时不做任何事
+func (p *markdownProgress) add() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.count++
+	if now := time.Now(); now.Sub(p.last) >= markdownProgressInterval {
+		p.last = now
+		p.reported = true
+		fmt.Printf("⏳ 正在解析Markdown: 已处理 %d 句...\n", p.count)
+	}
+}
+
+// finish 输出过进度时打印解析汇总
+func (p *markdownProgress) finish() {
+	if p == nil || !p.reported {
+		return
+	}
+	fmt.Printf("📄 Markdown解析完成: 共处理 %d 句，用时 %v\n", p.count, time.Since(p.start).Round(time.Millisecond))
+}
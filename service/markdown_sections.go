@@ -0,0 +1,205 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// splitHeadingRegex 分章使用的标题（一级和二级ATX标题）
+var splitHeadingRegex = regexp.MustCompile(`^#{1,2}\s+(.+?)\s*#*\s*$`)
+
+// unsafeFilenameRegex 文件名中不允许的字符
+var unsafeFilenameRegex = regexp.MustCompile(`[\\/:*?"<>|\x00-\x1f]+`)
+
+// maxSectionTitleRunes 文件名中标题部分的最大长度
+const maxSectionTitleRunes = 50
+
+// MarkdownSection 按标题切分的章节
+type MarkdownSection struct {
+	Title     string   // 章节标题，首个标题之前的内容为空
+	Sentences []string // 章节内适合TTS的句子
+}
+
+// ProcessMarkdownSections 按一级/二级标题把Markdown文件切分为章节，并分别提取句子
+func (tp *TextProcessor) ProcessMarkdownSections(path string) ([]MarkdownSection, error) {
+	return tp.ProcessMarkdownSectionsContext(context.Background(), path)
+}
+
+// ProcessMarkdownSectionsContext 同 ProcessMarkdownSections，超大文件时输出解析进度，context取消时中止
+func (tp *TextProcessor) ProcessMarkdownSectionsContext(ctx context.Context, path string) ([]MarkdownSection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开Markdown文件失败: %v", err)
+	}
+	defer file.Close()
+
+	return tp.processMarkdownSections(ctx, file)
+}
+
+// processMarkdownSections 从 r 流式读取Markdown，按一级/二级标题切分章节并提取句子
+func (tp *TextProcessor) processMarkdownSections(ctx context.Context, r io.Reader) ([]MarkdownSection, error) {
+	progress := newMarkdownProgress()
+	var sections []MarkdownSection
+	err := forEachMarkdownSection(r, func(title, body string) error {
+		sentences, err := tp.processMarkdownDocument(ctx, body, progress)
+		if len(sentences) > 0 {
+			sections = append(sections, MarkdownSection{Title: title, Sentences: sentences})
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	progress.finish()
+
+	return sections, nil
+}
+
+// forEachMarkdownSection 流式读取Markdown，在代码块和HTML注释之外的一级/二级标题处切分章节
+// fn 返回错误时停止读取并返回该错误
+func forEachMarkdownSection(r io.Reader, fn func(title, body string) error) error {
+	reader := bufio.NewReader(skipFrontMatter(r))
+	var body strings.Builder
+	var state markdownBlockState
+	title := ""
+
+	for {
+		line, err := reader.ReadString('\n')
+		if line != "" {
+			trimmed := strings.TrimSpace(line)
+
+			wasInComment := state.inComment
+			if inFence := state.update(trimmed); !inFence && !wasInComment {
+				if match := splitHeadingRegex.FindStringSubmatch(trimmed); match != nil {
+					if body.Len() > 0 {
+						if err := fn(title, body.String()); err != nil {
+							return err
+						}
+						body.Reset()
+					}
+					title = match[1]
+				}
+			}
+
+			body.WriteString(line)
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取Markdown文件失败: %v", err)
+		}
+	}
+
+	if body.Len() > 0 {
+		return fn(title, body.String())
+	}
+	return nil
+}
+
+// sectionFilename 生成章节音频文件名：序号_清洗后的标题.扩展名，序号保证不重名
+func sectionFilename(index int, title, ext string) string {
+	name := unsafeFilenameRegex.ReplaceAllString(title, "")
+	name = strings.Join(strings.Fields(name), "_")
+	name = strings.Trim(name, "._")
+
+	if runes := []rune(name); len(runes) > maxSectionTitleRunes {
+		name = string(runes[:maxSectionTitleRunes])
+	}
+	if name == "" {
+		name = "section"
+	}
+
+	return fmt.Sprintf("%03d_%s%s", index, name, ext)
+}
+
+// sectionOutputPath 返回第 index 个章节（从1开始）的输出路径，扩展名与 final_output 一致
+func sectionOutputPath(outputDir, finalOutput string, index int, title string) string {
+	ext := filepath.Ext(finalOutput)
+	if ext == "" {
+		ext = ".mp3"
+	}
+	return filepath.Join(outputDir, sectionFilename(index, title, ext))
+}
+
+// loadMarkdownSections 读取Markdown文件：分章模式按标题切分，否则整篇作为一个章节
+// 解析期间按 Ctrl+C 可中止，避免超大文档解析时只能强制结束进程；同一遍读取中收集优先行的文本
+func loadMarkdownSections(tp *TextProcessor, path string, split bool, priorities *taskPriorities) ([]MarkdownSection, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开Markdown文件失败: %v", err)
+	}
+	defer file.Close()
+
+	if split {
+		return tp.processMarkdownSections(ctx, priorities.observe(file))
+	}
+
+	sentences, err := tp.processMarkdownReader(ctx, priorities.observe(file))
+	if err != nil || len(sentences) == 0 {
+		return nil, err
+	}
+	return []MarkdownSection{{Sentences: sentences}}, nil
+}
+
+// sectionOfTask 返回任务所属章节：片头语归入第一章，片尾语等未登记的任务归入最后一章
+func sectionOfTask(sectionOf map[int]int, index, sectionCount int) int {
+	if section, ok := sectionOf[index]; ok {
+		return section
+	}
+	if index == introTaskIndex {
+		return 0
+	}
+	return sectionCount - 1
+}
+
+// mergeSections 分章模式下按章节合并音频，每章输出一个以标题命名的文件，返回所有章节写出的文件
+// 片头/片尾音频与片头/片尾语一致，只加在第一个和最后一个有音频的章节
+func mergeSections(config *model.Config, sections []MarkdownSection, sectionFiles map[int][]string, merge func(audioFiles []string, outputPath string) ([]string, error)) ([]string, error) {
+	firstSection, lastSection := -1, -1
+	for i := range sections {
+		if len(sectionFiles[i]) > 0 {
+			if firstSection < 0 {
+				firstSection = i
+			}
+			lastSection = i
+		}
+	}
+
+	var outputs []string
+	merged := 0
+	for i, section := range sections {
+		files := sectionFiles[i]
+		if len(files) == 0 {
+			Warnf("⚠️  章节 %d「%s」没有可用的音频，跳过\n", i+1, section.Title)
+			continue
+		}
+
+		outputPath := sectionOutputPath(config.Audio.OutputDir, config.Audio.FinalOutput, i+1, section.Title)
+		fmt.Printf("\n📑 合并章节 %d/%d: %s\n", i+1, len(sections), filepath.Base(outputPath))
+		written, err := merge(withIntroOutroFilesAt(config, files, i == firstSection, i == lastSection), outputPath)
+		outputs = append(outputs, written...)
+		if err != nil {
+			return outputs, fmt.Errorf("合并章节 %d 失败: %v", i+1, err)
+		}
+		merged++
+	}
+
+	if merged == 0 {
+		return nil, fmt.Errorf("没有成功生成任何章节音频")
+	}
+	fmt.Printf("✅ 分章输出完成: 共 %d 个章节文件\n", merged)
+	return outputs, nil
+}
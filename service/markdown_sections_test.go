@@ -0,0 +1,38 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+func TestMergeSectionsIntroOutro(t *testing.T) {
+	var config model.Config
+	config.Audio.OutputDir = "out"
+	config.Audio.FinalOutput = "book.mp3"
+	config.Audio.IntroFile = "intro.mp3"
+	config.Audio.OutroFile = "outro.mp3"
+
+	// 第一章没有音频时片头加在第二章，最后一章没有音频时片尾加在倒数第二章
+	sections := []MarkdownSection{{Title: "序"}, {Title: "一"}, {Title: "二"}, {Title: "三"}, {Title: "后记"}}
+	sectionFiles := map[int][]string{1: {"a.mp3"}, 2: {"b.mp3"}, 3: {"c.mp3"}}
+
+	var merged []string
+	merge := func(audioFiles []string, outputPath string) ([]string, error) {
+		merged = append(merged, strings.Join(audioFiles, ","))
+		return []string{outputPath}, nil
+	}
+	outputs, err := mergeSections(&config, sections, sectionFiles, merge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"intro.mp3,a.mp3", "b.mp3", "c.mp3,outro.mp3"}
+	if strings.Join(merged, " | ") != strings.Join(want, " | ") {
+		t.Errorf("章节合并输入 = %v, want %v", merged, want)
+	}
+	if len(outputs) != 3 {
+		t.Errorf("outputs = %v", outputs)
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+)
+
+// minTOCItems 识别为目录所需的最少列表项数，单个锚点链接列表项不视为目录
+const minTOCItems = 2
+
+// tocMarkerRegex 目录占位标记，如 [TOC]、[[_TOC_]]、[[toc]]
+var tocMarkerRegex = regexp.MustCompile(`(?i)^\[{1,2}_?toc_?\]{1,2}$`)
+
+// tocNumberingRegex 目录项链接前允许的编号，如 "1.2 "
+var tocNumberingRegex = regexp.MustCompile(`^[\d.\s]*$`)
+
+// isTOCList 判断列表是否为目录：至少 minTOCItems 项，每项都只有一个指向本文档锚点的链接，嵌套的子列表也是目录
+func isTOCList(list *blackfriday.Node) bool {
+	return tocListItems(list) >= minTOCItems
+}
+
+// tocListItems 返回目录列表的项数，列表中有非目录项时返回0
+func tocListItems(list *blackfriday.Node) int {
+	items := 0
+	for item := list.FirstChild; item != nil; item = item.Next {
+		if item.Type != blackfriday.Item || !isTOCItem(item) {
+			return 0
+		}
+		items++
+	}
+	return items
+}
+
+// isTOCItem 列表项只包含一个锚点链接段落，以及可选的目录子列表
+func isTOCItem(item *blackfriday.Node) bool {
+	links := 0
+	for child := item.FirstChild; child != nil; child = child.Next {
+		switch child.Type {
+		case blackfriday.Paragraph:
+			if !isAnchorLinkParagraph(child) {
+				return false
+			}
+			links++
+		case blackfriday.List:
+			if tocListItems(child) == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return links == 1
+}
+
+// isAnchorLinkParagraph 段落只包含一个指向本文档锚点（#开头）的链接，链接外只允许编号和空白
+func isAnchorLinkParagraph(paragraph *blackfriday.Node) bool {
+	links := 0
+	for child := paragraph.FirstChild; child != nil; child = child.Next {
+		switch child.Type {
+		case blackfriday.Link:
+			if !strings.HasPrefix(string(child.LinkData.Destination), "#") {
+				return false
+			}
+			links++
+		case blackfriday.Text:
+			if !tocNumberingRegex.Match(child.Literal) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return links == 1
+}
+
+// isTOCMarker 段落是否为单独一行的目录占位标记
+// blackfriday 会把 [[_TOC_]] 中的 _TOC_ 解析为强调，按原文还原后再匹配
+func isTOCMarker(paragraph *blackfriday.Node) bool {
+	source, ok := inlineSource(paragraph)
+	return ok && tocMarkerRegex.MatchString(strings.TrimSpace(source))
+}
+
+// inlineSource 还原只由文本和强调组成的行内内容的原文，含其他节点（链接、代码等）时返回false
+func inlineSource(node *blackfriday.Node) (string, bool) {
+	var b strings.Builder
+	for child := node.FirstChild; child != nil; child = child.Next {
+		var delim string
+		switch child.Type {
+		case blackfriday.Text:
+			b.Write(child.Literal)
+			continue
+		case blackfriday.Emph:
+			delim = "_"
+		case blackfriday.Strong:
+			delim = "__"
+		default:
+			return "", false
+		}
+		inner, ok := inlineSource(child)
+		if !ok {
+			return "", false
+		}
+		b.WriteString(delim + inner + delim)
+	}
+	return b.String(), true
+}
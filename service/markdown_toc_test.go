@@ -0,0 +1,48 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripTOC(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		removed string
+	}{
+		{"方括号标记", "[TOC]\n\n正文。", "TOC"},
+		{"GitLab标记", "[[_TOC_]]\n\n正文。", "TOC"},
+		{"小写双括号", "[[toc]]\n\n正文。", "toc"},
+		{"锚点目录", "- [简介](#简介)\n- [安装](#安装)\n    - [1.1 依赖](#依赖)\n\n正文。", "安装"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mp := NewMarkdownProcessor()
+			mp.SetStripTOC(true)
+			got := mp.ExtractTextForTTS(tt.input)
+			if strings.Contains(got, tt.removed) || !strings.Contains(got, "正文") {
+				t.Errorf("ExtractTextForTTS(%q) = %q", tt.input, got)
+			}
+		})
+	}
+}
+
+func TestStripTOCKeepsContent(t *testing.T) {
+	tests := []struct {
+		input string
+		keep  string
+	}{
+		{"这是 _TOC_ 的说明", "TOC"},
+		{"[[_TOC_]] 之后还有文字", "之后还有文字"},
+		{"- [外部链接](https://example.com)\n- [另一个](https://example.org)", "另一个"},
+		{"- [简介](#简介)", "简介"},
+	}
+	for _, tt := range tests {
+		mp := NewMarkdownProcessor()
+		mp.SetStripTOC(true)
+		if got := mp.ExtractTextForTTS(tt.input); !strings.Contains(got, tt.keep) {
+			t.Errorf("ExtractTextForTTS(%q) = %q, want 包含 %q", tt.input, got, tt.keep)
+		}
+	}
+}
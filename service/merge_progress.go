@@ -0,0 +1,69 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// mergeProgressMinBytes 合并总大小达到该值时才显示字节级进度，小文件合并很快无需刷屏
+const mergeProgressMinBytes = 32 << 20
+
+// mergeProgressStep 进度输出的百分比步长
+const mergeProgressStep = 10
+
+// mergeProgress 按已写字节/总字节显示合并进度
+type mergeProgress struct {
+	total    int64
+	written  int64
+	reported int
+}
+
+// newMergeProgress 统计待合并文件的总大小，无法读取大小的文件不计入
+func newMergeProgress(files []string) *mergeProgress {
+	var total int64
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			total += info.Size()
+		}
+	}
+	return &mergeProgress{total: total}
+}
+
+// skip 跳过未合并的文件，从总大小中扣除
+func (p *mergeProgress) skip(file string) {
+	if info, err := os.Stat(file); err == nil {
+		p.total -= info.Size()
+	}
+}
+
+// copy 把 src 复制到 dst 并累计进度
+func (p *mergeProgress) copy(dst io.Writer, src io.Reader) (int64, error) {
+	return copyBuffered(&mergeProgressWriter{dst: dst, progress: p}, src)
+}
+
+// add 累计已写字节，每跨过一个百分比步长打印一次
+func (p *mergeProgress) add(n int) {
+	p.written += int64(n)
+	if p.total < mergeProgressMinBytes {
+		return
+	}
+
+	percent := int(min(p.written*100/max(p.total, 1), 100))
+	if percent/mergeProgressStep > p.reported/mergeProgressStep {
+		p.reported = percent
+		fmt.Printf("📦 合并进度: %d%% (%.1f/%.1f MB)\n", percent, float64(p.written)/(1<<20), float64(p.total)/(1<<20))
+	}
+}
+
+// mergeProgressWriter 统计写入字节数的Writer
+type mergeProgressWriter struct {
+	dst      io.Writer
+	progress *mergeProgress
+}
+
+func (w *mergeProgressWriter) Write(b []byte) (int, error) {
+	n, err := w.dst.Write(b)
+	w.progress.add(n)
+	return n, err
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricsPrefix Prometheus指标名前缀
+const metricsPrefix = "markdown2tts"
+
+// SynthesisMetrics 合成过程的运行指标，worker并发更新
+// 请求失败按 FailureStats 的分类聚合，与处理结束时打印的失败原因统计一致
+type SynthesisMetrics struct {
+	mu        sync.Mutex
+	provider  string
+	started   time.Time
+	requests  int           // 合成请求次数（含重试）
+	failed    int           // 失败的合成请求次数
+	retries   int           // 重试次数
+	latency   time.Duration // 所有合成请求的累计耗时
+	failures  *FailureStats // 失败请求的原因分类
+	succeeded int           // 成功的任务数
+	taskFail  int           // 最终失败的任务数
+	skipped   int           // 因时长预算未处理的任务数
+	cacheHits int           // 从片段缓存复用的任务数
+	cached    int           // 新写入片段缓存的任务数
+}
+
+// newSynthesisMetrics 创建指标，从当前时刻开始计时
+func newSynthesisMetrics(provider string) *SynthesisMetrics {
+	return &SynthesisMetrics{provider: provider, started: time.Now(), failures: NewFailureStats()}
+}
+
+// observeRequest 记录一次合成请求的耗时和结果，attempt 从1开始，大于1表示重试
+func (m *SynthesisMetrics) observeRequest(attempt int, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests++
+	m.latency += latency
+	if attempt > 1 {
+		m.retries++
+	}
+	if err != nil {
+		m.failed++
+		m.failures.Add(err)
+	}
+}
+
+// setTasks 记录任务的最终结果
+func (m *SynthesisMetrics) setTasks(succeeded, failed, skipped int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.succeeded, m.taskFail, m.skipped = succeeded, failed, skipped
+}
+
+// setCache 记录片段缓存的复用和写入数
+func (m *SynthesisMetrics) setCache(hits, stores int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.cacheHits, m.cached = hits, stores
+}
+
+// metricsSnapshot 指标快照，JSON输出格式
+type metricsSnapshot struct {
+	Provider          string         `json:"provider"`
+	StartedAt         time.Time      `json:"started_at"`
+	DurationSeconds   float64        `json:"duration_seconds"`
+	Requests          int            `json:"requests"`
+	RequestsFailed    int            `json:"requests_failed"`
+	Retries           int            `json:"retries"`
+	AvgLatencySeconds float64        `json:"avg_latency_seconds"`
+	TasksSucceeded    int            `json:"tasks_succeeded"`
+	TasksFailed       int            `json:"tasks_failed"`
+	TasksSkipped      int            `json:"tasks_skipped"`
+	CacheHits         int            `json:"cache_hits"`
+	CacheStores       int            `json:"cache_stores"`
+	Failures          map[string]int `json:"failures,omitempty"`
+}
+
+// snapshot 返回当前指标快照
+func (m *SynthesisMetrics) snapshot() metricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := metricsSnapshot{
+		Provider:        m.provider,
+		StartedAt:       m.started,
+		DurationSeconds: roundSeconds(time.Since(m.started)),
+		Requests:        m.requests,
+		RequestsFailed:  m.failed,
+		Retries:         m.retries,
+		TasksSucceeded:  m.succeeded,
+		TasksFailed:     m.taskFail,
+		TasksSkipped:    m.skipped,
+		CacheHits:       m.cacheHits,
+		CacheStores:     m.cached,
+	}
+	if m.requests > 0 {
+		s.AvgLatencySeconds = roundSeconds(m.latency / time.Duration(m.requests))
+	}
+	if len(m.failures.counts) > 0 {
+		s.Failures = make(map[string]int, len(m.failures.counts))
+		for name, count := range m.failures.counts {
+			s.Failures[name] = count
+		}
+	}
+	return s
+}
+
+// WriteFile 把指标快照写入文件：.prom 扩展名输出Prometheus文本格式（供node_exporter textfile采集），其他输出JSON
+// 先写临时文件再重命名，采集方不会读到写了一半的文件
+func (m *SynthesisMetrics) WriteFile(path string) error {
+	s := m.snapshot()
+
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), ".prom") {
+		data = s.prometheus()
+	} else {
+		var err error
+		if data, err = json.MarshalIndent(s, "", "  "); err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	}
+
+	return writeFileAtomic(path, func(tmp string) error {
+		return writeFile(tmp, data)
+	})
+}
+
+// writeMetrics 按配置写出指标快照，失败时只打印警告
+func writeMetrics(path string, m *SynthesisMetrics) {
+	if path == "" || m == nil {
+		return
+	}
+	if err := m.WriteFile(path); err != nil {
+		Warnf("⚠️  写入指标快照失败: %v\n", err)
+		return
+	}
+	fmt.Printf("📈 已写入指标快照: %s\n", path)
+}
+
+// prometheus 输出Prometheus文本格式
+func (s metricsSnapshot) prometheus() []byte {
+	var buf bytes.Buffer
+	label := fmt.Sprintf(`provider=%q`, s.Provider)
+
+	metric := func(name, kind, help string, samples ...string) {
+		fmt.Fprintf(&buf, "# HELP %s_%s %s\n# TYPE %s_%s %s\n", metricsPrefix, name, help, metricsPrefix, name, kind)
+		for _, sample := range samples {
+			fmt.Fprintf(&buf, "%s_%s%s\n", metricsPrefix, name, sample)
+		}
+	}
+
+	metric("synthesis_requests_total", "counter", "Synthesis requests including retries.",
+		fmt.Sprintf("{%s} %d", label, s.Requests))
+	metric("synthesis_request_failures_total", "counter", "Failed synthesis requests.",
+		fmt.Sprintf("{%s} %d", label, s.RequestsFailed))
+	metric("synthesis_retries_total", "counter", "Synthesis retries.",
+		fmt.Sprintf("{%s} %d", label, s.Retries))
+	metric("synthesis_request_latency_seconds_avg", "gauge", "Average synthesis request latency.",
+		fmt.Sprintf("{%s} %g", label, s.AvgLatencySeconds))
+	metric("tasks_total", "gauge", "Sentences by final status.",
+		fmt.Sprintf(`{%s,status="succeeded"} %d`, label, s.TasksSucceeded),
+		fmt.Sprintf(`{%s,status="failed"} %d`, label, s.TasksFailed),
+		fmt.Sprintf(`{%s,status="skipped"} %d`, label, s.TasksSkipped))
+	metric("segment_cache_total", "gauge", "Segments restored from or stored to the segment cache.",
+		fmt.Sprintf(`{%s,result="hit"} %d`, label, s.CacheHits),
+		fmt.Sprintf(`{%s,result="store"} %d`, label, s.CacheStores))
+
+	names := make([]string, 0, len(s.Failures))
+	for name := range s.Failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	samples := make([]string, len(names))
+	for i, name := range names {
+		samples[i] = fmt.Sprintf(`{%s,category=%q} %d`, label, name, s.Failures[name])
+	}
+	metric("synthesis_failures_by_category", "gauge", "Failed synthesis requests by category.", samples...)
+
+	metric("run_duration_seconds", "gauge", "Wall time of the run.",
+		fmt.Sprintf("{%s} %g", label, s.DurationSeconds))
+	metric("run_start_timestamp_seconds", "gauge", "Unix time when the run started.",
+		fmt.Sprintf("{%s} %d", label, s.StartedAt.Unix()))
+	return buf.Bytes()
+}
@@ -0,0 +1,88 @@
+package service
+
+import (
+	"fmt"
+	"os"
+)
+
+// mp3Layer3Bitrates Layer III 比特率表（kbps），索引0为free、15为无效
+var mp3Layer3Bitrates = map[bool][16]int{
+	true:  {0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0}, // MPEG1
+	false: {0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},     // MPEG2/2.5
+}
+
+// 严格校验阈值：有效帧需至少覆盖音频数据的90%，且不少于minMP3Frames帧
+const (
+	minMP3FrameCoverage = 0.9
+	minMP3Frames        = 10
+)
+
+// mp3FrameLength 解析Layer III帧头，返回整帧字节数，不是有效帧头时返回0
+func mp3FrameLength(header []byte) int {
+	if len(header) < 4 || header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return 0
+	}
+
+	version := (header[1] >> 3) & 0x03
+	layer := (header[1] >> 1) & 0x03
+	bitrateIndex := header[2] >> 4
+	rateIndex := (header[2] >> 2) & 0x03
+	padding := int((header[2] >> 1) & 0x01)
+
+	rates, ok := mp3SampleRates[version]
+	if !ok || layer != 1 || rateIndex == 3 {
+		return 0
+	}
+	mpeg1 := version == 3
+	bitrate := mp3Layer3Bitrates[mpeg1][bitrateIndex] * 1000
+	if bitrate == 0 {
+		return 0
+	}
+
+	samplesPerFrame := 1152
+	if !mpeg1 {
+		samplesPerFrame = 576
+	}
+	return samplesPerFrame/8*bitrate/rates[rateIndex] + padding
+}
+
+// validateMP3Frames 严格校验：遍历全文件的MP3帧，统计能连续解析的帧所覆盖的字节比例
+// 头部正常但中间被截断或混入其他数据的文件会因覆盖率过低而判为损坏
+func validateMP3Frames(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取音频文件失败: %v", err)
+	}
+
+	// 跳过开头的ID3v2标签和结尾的ID3v1标签
+	start := 0
+	if len(data) >= 10 && string(data[0:3]) == "ID3" {
+		start = 10 + (int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f))
+	}
+	end := len(data)
+	if end-start >= 128 && string(data[end-128:end-125]) == "TAG" {
+		end -= 128
+	}
+	if start >= end {
+		return fmt.Errorf("MP3文件不包含音频数据")
+	}
+
+	frames := 0
+	covered := 0
+	for offset := start; offset+4 <= end; {
+		length := mp3FrameLength(data[offset : offset+4])
+		if length == 0 || offset+length > end {
+			offset++ // 失去同步，逐字节寻找下一个帧头
+			continue
+		}
+		frames++
+		covered += length
+		offset += length
+	}
+
+	coverage := float64(covered) / float64(end-start)
+	if frames < minMP3Frames || coverage < minMP3FrameCoverage {
+		return fmt.Errorf("MP3帧校验失败: 有效帧 %d 个，覆盖 %.0f%% 的音频数据，文件可能已损坏", frames, coverage*100)
+	}
+	return nil
+}
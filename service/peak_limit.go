@@ -0,0 +1,78 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 合并结果的峰值限制方式
+const (
+	PeakLimitOff      = "off"      // 不处理（默认）
+	PeakLimitLimit    = "limit"    // 软限幅：只压住超过上限的瞬时峰值
+	PeakLimitCompress = "compress" // 先轻度压缩拉近片段间的响度差，再限幅
+)
+
+// defaultPeakCeiling 默认峰值上限（dBFS），留出余量避免编码后削波
+const defaultPeakCeiling = -1.0
+
+// peakLimitMode 返回配置的峰值限制方式，未知取值时警告并关闭
+func peakLimitMode(config *model.Config) string {
+	mode := strings.ToLower(strings.TrimSpace(config.Audio.PeakLimit))
+	switch mode {
+	case "", PeakLimitOff:
+		return PeakLimitOff
+	case PeakLimitLimit, PeakLimitCompress:
+		return mode
+	default:
+		Warnf("警告: 未知的峰值限制方式: %s（可选: off, limit, compress），将不做处理\n", mode)
+		return PeakLimitOff
+	}
+}
+
+// peakLimitFilter 构造ffmpeg滤镜：alimiter 把峰值限制在 ceiling 以下，compress 模式前置 acompressor
+func peakLimitFilter(mode string, ceiling float64) string {
+	// alimiter 的 limit 为线性幅度，取值范围 0.0625-1
+	limit := math.Max(0.0625, math.Min(1, math.Pow(10, ceiling/20)))
+	limiter := fmt.Sprintf("alimiter=limit=%.4f:attack=5:release=50:level=disabled", limit)
+	if mode == PeakLimitCompress {
+		return "acompressor=threshold=-18dB:ratio=3:attack=10:release=200:makeup=2," + limiter
+	}
+	return limiter
+}
+
+// withPeakLimit 按配置在 merge 之后对合并结果做峰值限制，保证输出没有削波
+// 需要ffmpeg，未安装时警告并直接输出合并结果
+func withPeakLimit(config *model.Config, merge func(path string) error) func(path string) error {
+	mode := peakLimitMode(config)
+	if mode == PeakLimitOff {
+		return merge
+	}
+	if !IsFFmpegAvailable() {
+		Warnf("⚠️  峰值限制需要ffmpeg，未找到ffmpeg，跳过峰值限制\n")
+		return merge
+	}
+
+	ceiling := config.Audio.PeakCeiling
+	if ceiling >= 0 {
+		ceiling = defaultPeakCeiling
+	}
+
+	return func(path string) error {
+		raw := filepath.Join(config.Audio.TempDir, "unlimited_"+filepath.Base(path))
+		if err := merge(raw); err != nil {
+			return err
+		}
+		defer os.Remove(raw)
+
+		fmt.Printf("🎚️  峰值限制（%s，上限 %.1f dBFS）...\n", mode, ceiling)
+		if err := runFFmpeg("-y", "-loglevel", "error", "-i", raw, "-af", peakLimitFilter(mode, ceiling), "-map_metadata", "0", path); err != nil {
+			return fmt.Errorf("峰值限制失败: %v", err)
+		}
+		return nil
+	}
+}
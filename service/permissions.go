@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 创建目录和文件时使用的权限，可通过 config.permissions 覆盖
+// 实际权限仍受进程 umask 影响
+var (
+	dirPerm  os.FileMode = 0755
+	filePerm os.FileMode = 0644
+)
+
+// earlyFiles 读取配置前就已创建的文件（如日志文件），应用配置的权限时一并修改
+var earlyFiles []string
+
+// ApplyPermissions 按配置设置目录/文件权限，未配置时保持默认值
+func ApplyPermissions(config *model.Config) error {
+	if mode := config.Permissions.DirMode; mode != "" {
+		perm, err := parseFileMode(mode)
+		if err != nil {
+			return fmt.Errorf("无效的目录权限 dir_mode: %v", err)
+		}
+		dirPerm = perm
+	}
+	if mode := config.Permissions.FileMode; mode != "" {
+		perm, err := parseFileMode(mode)
+		if err != nil {
+			return fmt.Errorf("无效的文件权限 file_mode: %v", err)
+		}
+		filePerm = perm
+		for _, path := range earlyFiles {
+			if err := os.Chmod(path, filePerm); err != nil {
+				return fmt.Errorf("修改文件权限失败: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseFileMode 解析八进制权限字符串，如 "0750"
+func parseFileMode(mode string) (os.FileMode, error) {
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > 0777 {
+		return 0, fmt.Errorf("%q 不是有效的八进制权限（如 0750）", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// makeDirs 按配置的目录权限递归创建目录
+func makeDirs(path string) error {
+	return os.MkdirAll(path, dirPerm)
+}
+
+// createFile 按配置的文件权限创建（或截断）文件
+func createFile(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, filePerm)
+}
+
+// writeFile 按配置的文件权限写入文件
+func writeFile(path string, data []byte) error {
+	return os.WriteFile(path, data, filePerm)
+}
+
+// writeTempFile 在 dir 下创建名称唯一的临时文件并写入 data，返回文件路径
+// 临时文件用完即删，保留 CreateTemp 的0600权限；同一目录下并发运行的多个进程互不覆盖
+func writeTempFile(dir, pattern string, data []byte) (string, error) {
+	file, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// OpenLogFile 以追加方式打开日志文件，新建时按配置的文件权限创建
+// 日志文件在读取配置前打开，配置的权限在 ApplyPermissions 时补上
+func OpenLogFile(path string) (*os.File, error) {
+	_, statErr := os.Stat(path)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, filePerm)
+	if err == nil && os.IsNotExist(statErr) {
+		earlyFiles = append(earlyFiles, path)
+	}
+	return file, err
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+func TestOpenLogFilePermissions(t *testing.T) {
+	defer func(perm os.FileMode) { filePerm, earlyFiles = perm, nil }(filePerm)
+	defer syscall.Umask(syscall.Umask(0))
+
+	dir := t.TempDir()
+	created := filepath.Join(dir, "new.log")
+	existing := filepath.Join(dir, "old.log")
+	if err := os.WriteFile(existing, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{created, existing} {
+		file, err := OpenLogFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Close()
+	}
+
+	var config model.Config
+	config.Permissions.FileMode = "0600"
+	if err := ApplyPermissions(&config); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]os.FileMode{created: 0600, existing: 0644}
+	for path, want := range tests {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s: 权限 %o, want %o", filepath.Base(path), got, want)
+		}
+	}
+}
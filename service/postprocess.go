@@ -0,0 +1,147 @@
+package service
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 音频后处理步骤，按 audio.postprocess 中的顺序执行
+const (
+	PostprocessResample    = "resample"     // 片段：统一采样率（需要ffmpeg）
+	PostprocessTrimSilence = "trim_silence" // 片段：裁剪首尾静音（无ffmpeg时仅支持WAV）
+	PostprocessNormalize   = "normalize"    // 片段：响度归一化到 loudness_target（需要ffmpeg）
+	PostprocessLimit       = "limit"        // 合并结果：软限幅（需要ffmpeg）
+	PostprocessCompress    = "compress"     // 合并结果：轻度压缩后限幅（需要ffmpeg）
+)
+
+// postprocessSegmentSteps 在合并前逐个片段执行的步骤，其余步骤作用于合并结果
+var postprocessSegmentSteps = map[string]bool{
+	PostprocessResample:    true,
+	PostprocessTrimSilence: true,
+	PostprocessNormalize:   true,
+}
+
+// defaultLoudnessTarget 默认响度目标（LUFS）
+const defaultLoudnessTarget = -16.0
+
+// postprocessSteps 返回后处理步骤
+// 未配置 audio.postprocess 时由 resample、trim_silence、peak_limit 等开关推导，保持原有顺序：重采样 → 裁剪静音 → 峰值限制
+func postprocessSteps(config *model.Config, warn bool) []string {
+	if len(config.Audio.Postprocess) == 0 {
+		var steps []string
+		if config.Audio.Resample {
+			steps = append(steps, PostprocessResample)
+		}
+		if config.Audio.TrimSilence {
+			steps = append(steps, PostprocessTrimSilence)
+		}
+		if mode := strings.ToLower(strings.TrimSpace(config.Audio.PeakLimit)); mode == PeakLimitLimit || mode == PeakLimitCompress {
+			steps = append(steps, mode)
+		} else if warn {
+			peakLimitMode(config) // 对未知取值打印警告
+		}
+		return steps
+	}
+
+	var steps []string
+	for _, step := range config.Audio.Postprocess {
+		step = strings.ToLower(strings.TrimSpace(step))
+		switch step {
+		case PostprocessResample, PostprocessTrimSilence, PostprocessNormalize, PostprocessLimit, PostprocessCompress:
+			steps = append(steps, step)
+		default:
+			if warn {
+				Warnf("警告: 未知的音频后处理步骤: %s（可选: resample, trim_silence, normalize, limit, compress），已忽略\n", step)
+			}
+		}
+	}
+	return steps
+}
+
+// postprocessSegments 合并前按顺序对片段执行后处理，返回处理后的文件列表
+// 未配置重采样步骤时仍检查采样率是否一致并给出警告
+func postprocessSegments(config *model.Config, audioFiles []string) []string {
+	steps := postprocessSteps(config, true)
+
+	resample := false
+	for _, step := range steps {
+		resample = resample || step == PostprocessResample
+	}
+	if !resample {
+		check := *config
+		if len(config.Audio.Postprocess) > 0 {
+			check.Audio.Resample = false // 管道中没有 resample 时只检查不重采样
+		}
+		audioFiles = unifySampleRates(&check, audioFiles)
+	}
+
+	for _, step := range steps {
+		if !postprocessSegmentSteps[step] {
+			continue
+		}
+
+		cfg := *config
+		switch step {
+		case PostprocessResample:
+			cfg.Audio.Resample = true
+			audioFiles = unifySampleRates(&cfg, audioFiles)
+		case PostprocessTrimSilence:
+			cfg.Audio.TrimSilence = true
+			audioFiles = trimSilence(&cfg, audioFiles)
+		case PostprocessNormalize:
+			audioFiles = normalizeLoudness(&cfg, audioFiles)
+		}
+	}
+	return audioFiles
+}
+
+// withPostprocess 按顺序在 merge 之后对合并结果执行后处理
+func withPostprocess(config *model.Config, merge func(path string) error) func(path string) error {
+	for _, step := range postprocessSteps(config, false) {
+		if postprocessSegmentSteps[step] {
+			continue
+		}
+
+		cfg := *config
+		cfg.Audio.PeakLimit = step
+		merge = withPeakLimit(&cfg, merge)
+	}
+	return merge
+}
+
+// normalizeLoudness 用ffmpeg loudnorm 把每个片段归一化到相同响度，减少句与句之间的音量跳变
+func normalizeLoudness(config *model.Config, audioFiles []string) []string {
+	if !IsFFmpegAvailable() {
+		Warnf("⚠️  响度归一化需要ffmpeg，未找到ffmpeg，跳过归一化\n")
+		return audioFiles
+	}
+
+	target := config.Audio.LoudnessTarget
+	if target >= 0 {
+		target = defaultLoudnessTarget
+	}
+	filter := fmt.Sprintf("loudnorm=I=%g:TP=-1.5:LRA=11", target)
+
+	fmt.Printf("🔊 归一化 %d 个音频片段的响度（目标 %.0f LUFS）...\n", len(audioFiles), target)
+	result := make([]string, len(audioFiles))
+	for i, file := range audioFiles {
+		result[i] = file
+		output := filepath.Join(config.Audio.TempDir, "normalized_"+filepath.Base(file))
+
+		args := []string{"-y", "-loglevel", "error", "-i", file, "-af", filter}
+		// loudnorm 内部会升采样到192kHz，输出保持原采样率
+		if rate, err := detectSampleRate(file); err == nil {
+			args = append(args, "-ar", strconv.Itoa(rate))
+		}
+		if err := runFFmpeg(append(args, output)...); err != nil {
+			Warnf("⚠️  响度归一化失败，保留原文件: %s, 错误: %v\n", file, err)
+			continue
+		}
+		result[i] = output
+	}
+	return result
+}
@@ -0,0 +1,4 @@
+package service
+
+// ProgramName 可执行文件名，命令帮助、示例输入文件和使用提示统一引用，避免写出错误的命令
+const ProgramName = "markdown2tts"
@@ -0,0 +1,25 @@
+package service
+
+import (
+	"path/filepath"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// TTS服务提供方名称
+const (
+	ProviderTencent = "tencent" // 腾讯云TTS
+	ProviderEdge    = "edge"    // Microsoft Edge TTS
+)
+
+// ApplyProviderDirs 按配置为指定provider切换到独立的输出/临时子目录
+// 未配置该provider的子目录时保持原目录不变
+func ApplyProviderDirs(config *model.Config, provider string) {
+	subdir, ok := config.Audio.ProviderSubdirs[provider]
+	if !ok || subdir == "" {
+		return
+	}
+
+	config.Audio.OutputDir = filepath.Join(config.Audio.OutputDir, subdir)
+	config.Audio.TempDir = filepath.Join(config.Audio.TempDir, subdir)
+}
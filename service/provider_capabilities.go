@@ -0,0 +1,92 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ProviderCapabilities TTS服务提供方支持的能力，上层据此决定启用哪些合成路径和请求参数
+type ProviderCapabilities struct {
+	SSML          bool     // 是否接受SSML标记文本
+	Streaming     bool     // 是否边合成边返回音频（无需等待整段完成）
+	Emotion       bool     // 是否支持情感类型和强度参数（具体还取决于音色）
+	Batch         bool     // 是否为异步任务接口，适合一次提交长文本
+	MaxTextLength int      // 单次请求的最大字数，0表示不限制
+	Codecs        []string // 支持的输出编码
+	SynthesisQPS  int      // 每个合成接口（创建任务、实时合成各自计数）每秒的默认配额，0表示未声明
+	StatusQPS     int      // 任务状态查询每秒的默认配额，与合成请求分开计数，0表示没有状态查询
+}
+
+// providerCapabilities 各provider的能力声明
+var providerCapabilities = map[string]ProviderCapabilities{
+	// 腾讯云长文本语音合成：异步任务，单次最多10万字符，多情感音色支持情感参数
+	// 创建任务、实时合成与状态查询按接口分别计算QPS，默认各20次/秒
+	ProviderTencent: {
+		SSML:          true,
+		Streaming:     false,
+		Emotion:       true,
+		Batch:         true,
+		MaxTextLength: 100000,
+		Codecs:        []string{"mp3", "wav", "pcm"},
+		SynthesisQPS:  20,
+		StatusQPS:     20,
+	},
+	// Edge在线接口：websocket边合成边返回，SSML由客户端生成且不支持 style/role，超长文本由客户端按字节切分
+	ProviderEdge: {
+		SSML:          false,
+		Streaming:     true,
+		Emotion:       false,
+		Batch:         false,
+		MaxTextLength: 0,
+		Codecs:        []string{"mp3"},
+	},
+}
+
+// CapabilitiesOf 返回指定provider的能力声明，未知provider返回false
+func CapabilitiesOf(provider string) (ProviderCapabilities, bool) {
+	caps, ok := providerCapabilities[provider]
+	return caps, ok
+}
+
+// SupportsCodec 判断是否支持指定的输出编码
+func (c ProviderCapabilities) SupportsCodec(codec string) bool {
+	for _, supported := range c.Codecs {
+		if supported == codec {
+			return true
+		}
+	}
+	return false
+}
+
+// checkProviderCodec 开始合成前确认provider支持配置的输出编码，避免每个请求都被服务端拒绝
+func checkProviderCodec(caps ProviderCapabilities, provider, codec string) error {
+	codec = strings.ToLower(strings.TrimSpace(codec))
+	if codec == "" || caps.SupportsCodec(codec) {
+		return nil
+	}
+	return fmt.Errorf("%s 不支持输出编码 %s（可选: %s）", provider, codec, strings.Join(caps.Codecs, ", "))
+}
+
+// Capabilities 返回腾讯云TTS的能力声明
+func (cas *ConcurrentAudioService) Capabilities() ProviderCapabilities {
+	return providerCapabilities[ProviderTencent]
+}
+
+// maxTextLength 返回单次请求的最大字数：始终使用实时接口时受实时接口的字数限制
+// auto 模式下超过实时接口上限的句子改走异步任务，因此仍按异步任务的上限切分
+func (cas *ConcurrentAudioService) maxTextLength() int {
+	if cas.mode == TencentModeRealtime {
+		return realtimeMaxChars
+	}
+	return cas.Capabilities().MaxTextLength
+}
+
+// Capabilities 返回腾讯云TTS的能力声明
+func (ams *AudioMergeService) Capabilities() ProviderCapabilities {
+	return providerCapabilities[ProviderTencent]
+}
+
+// Capabilities 返回Edge TTS的能力声明
+func (ets *EdgeTTSService) Capabilities() ProviderCapabilities {
+	return providerCapabilities[ProviderEdge]
+}
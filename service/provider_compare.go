@@ -0,0 +1,236 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 对比音频中各段之间的分隔方式
+const (
+	CompareSeparatorVoice   = "voice"   // 每段前由该provider朗读来源名称（默认）
+	CompareSeparatorSilence = "silence" // 段间插入静音（需要ffmpeg）
+	CompareSeparatorNone    = "none"    // 直接拼接
+)
+
+// compareSilenceDuration 静音分隔的时长
+const compareSilenceDuration = 800 * time.Millisecond
+
+// compareProviders 参与对比的provider及其朗读名称，按拼接顺序排列
+var compareProviders = []struct {
+	Name  string
+	Label string
+}{
+	{ProviderTencent, "腾讯云"},
+	{ProviderEdge, "Edge"},
+}
+
+// CompareOptions 对比模式参数
+type CompareOptions struct {
+	Sentences  int    // 参与对比的句子数（取输入的前N句）
+	Separator  string // 分隔方式: voice/silence/none
+	OutputPath string // 对比音频输出路径，清单写到同名的 .manifest.json
+}
+
+// compareEntry 清单中的一段语音，时间单位为秒
+type compareEntry struct {
+	Index    int     `json:"index"`
+	Sentence int     `json:"sentence"`
+	Provider string  `json:"provider"`
+	Voice    string  `json:"voice"`
+	Text     string  `json:"text"`
+	Start    float64 `json:"start"`
+	End      float64 `json:"end"`
+	File     string  `json:"file"`
+}
+
+// comparePart 拼接顺序中的一个文件，entry 为空表示分隔段
+type comparePart struct {
+	file  string
+	entry *compareEntry
+}
+
+// CompareProviders 对输入的前N句分别用腾讯云和Edge合成，按句交替拼接为一个对比音频，并写出标注每段来源的清单
+func CompareProviders(config *model.Config, ttsService *TTSService, opts CompareOptions) error {
+	separator := strings.ToLower(strings.TrimSpace(opts.Separator))
+	switch separator {
+	case "":
+		separator = CompareSeparatorVoice
+	case CompareSeparatorVoice, CompareSeparatorNone:
+	case CompareSeparatorSilence:
+		if !IsFFmpegAvailable() {
+			return fmt.Errorf("静音分隔需要ffmpeg，请安装ffmpeg或改用 --separator voice")
+		}
+	default:
+		return fmt.Errorf("未知的分隔方式: %s（可选: voice, silence, none）", opts.Separator)
+	}
+	if opts.Sentences <= 0 {
+		return fmt.Errorf("对比句子数必须大于0")
+	}
+
+	// 两个provider统一输出MP3，采样率不一致时自动重采样，保证拼接后可以正常播放
+	cfg := *config
+	cfg.TTS.Codec = "mp3"
+	cfg.Audio.Resample = true
+	cfg.Audio.TempDir = filepath.Join(config.Audio.TempDir, "compare")
+	if err := makeDirs(cfg.Audio.TempDir); err != nil {
+		return fmt.Errorf("创建临时目录失败: %v", err)
+	}
+
+	sentences, err := loadCompareSentences(&cfg, opts.Sentences)
+	if err != nil {
+		return err
+	}
+
+	edgeVoice := cfg.EdgeTTS.Voice
+	if edgeVoice == "" {
+		edgeVoice = "zh-CN-XiaoyiNeural"
+	}
+	tencentService := NewConcurrentAudioService(&cfg, ttsService)
+	edgeService := NewEdgeTTSService(&cfg)
+	synthesize := map[string]func(text, path string) error{
+		ProviderTencent: func(text, path string) error {
+			return tencentService.SynthesizeSample(text, cfg.TTS.VoiceType, path)
+		},
+		ProviderEdge: func(text, path string) error {
+			return edgeService.SynthesizeSample(text, edgeVoice, path)
+		},
+	}
+	voices := map[string]string{ProviderTencent: fmt.Sprint(cfg.TTS.VoiceType), ProviderEdge: edgeVoice}
+
+	fmt.Printf("🆚 对比 %d 句: 腾讯云(%s) vs Edge(%s)，分隔方式: %s\n\n", len(sentences), voices[ProviderTencent], edgeVoice, separator)
+
+	// 准备分隔段：朗读来源名称或静音
+	separators := make(map[string]string)
+	for _, p := range compareProviders {
+		switch separator {
+		case CompareSeparatorVoice:
+			path := filepath.Join(cfg.Audio.TempDir, "label_"+p.Name+".mp3")
+			if err := synthesize[p.Name](p.Label, path); err != nil {
+				Warnf("⚠️  合成 %s 来源提示失败，该来源将不带语音提示: %v\n", p.Label, err)
+				continue
+			}
+			separators[p.Name] = path
+		case CompareSeparatorSilence:
+			path := filepath.Join(cfg.Audio.TempDir, "silence.mp3")
+			if err := runFFmpeg("-y", "-loglevel", "error", "-f", "lavfi", "-i", "anullsrc=r=24000:cl=mono",
+				"-t", fmt.Sprintf("%.1f", compareSilenceDuration.Seconds()), path); err != nil {
+				return fmt.Errorf("生成静音分隔失败: %v", err)
+			}
+			separators[p.Name] = path
+		}
+	}
+
+	// 按句交替合成两个provider的音频
+	var parts []comparePart
+	failed := 0
+	for i, sentence := range sentences {
+		for _, p := range compareProviders {
+			path := filepath.Join(cfg.Audio.TempDir, fmt.Sprintf("compare_%03d_%s.mp3", i+1, p.Name))
+			if err := synthesize[p.Name](sentence, path); err != nil {
+				failed++
+				Failf("✗ 第 %d 句 %s 合成失败: %v\n", i+1, p.Label, err)
+				continue
+			}
+			fmt.Printf("✓ 第 %d 句 %s: %s\n", i+1, p.Label, sentence)
+
+			if sep, ok := separators[p.Name]; ok {
+				parts = append(parts, comparePart{file: sep})
+			}
+			parts = append(parts, comparePart{file: path, entry: &compareEntry{
+				Sentence: i + 1,
+				Provider: p.Name,
+				Voice:    voices[p.Name],
+				Text:     sentence,
+				File:     path,
+			}})
+		}
+	}
+	if failed == len(sentences)*len(compareProviders) {
+		return fmt.Errorf("所有对比片段均合成失败")
+	}
+
+	files := make([]string, len(parts))
+	for i, part := range parts {
+		files[i] = part.file
+	}
+	files = unifySampleRates(&cfg, files)
+
+	if err := NewAudioMergeOnlyService().MergeAudioFiles(files, opts.OutputPath); err != nil {
+		return err
+	}
+
+	return writeCompareManifest(opts.OutputPath, parts, files)
+}
+
+// loadCompareSentences 读取输入的前N句：Markdown文件按智能解析切句，其他按行读取并清洗
+func loadCompareSentences(config *model.Config, limit int) ([]string, error) {
+	tp := NewTextProcessorFromConfig(config)
+
+	var sentences []string
+	ext := strings.ToLower(filepath.Ext(config.InputFile))
+	if ext == ".md" || ext == ".markdown" {
+		all, err := tp.ProcessMarkdownFile(config.InputFile)
+		if err != nil {
+			return nil, err
+		}
+		sentences = all[:min(limit, len(all))]
+	} else {
+		_, err := forEachInputLine(config.InputFile, func(i int, line string) {
+			if len(sentences) >= limit || tp.FilterReason(line) != "" {
+				return
+			}
+			if text := tp.ProcessText(line); text != "" {
+				sentences = append(sentences, text)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sentences) == 0 {
+		return nil, fmt.Errorf("输入文件 %s 中没有可对比的有效句子", config.InputFile)
+	}
+	return sentences, nil
+}
+
+// writeCompareManifest 按实际拼接的文件时长写出清单，标注每段语音的来源
+func writeCompareManifest(outputPath string, parts []comparePart, files []string) error {
+	var entries []compareEntry
+	var offset time.Duration
+	for i, part := range parts {
+		duration, err := measureAudioDuration(files[i])
+		if err != nil && part.entry != nil {
+			duration = estimateSpeechDuration(part.entry.Text)
+		}
+		if part.entry != nil {
+			entry := *part.entry
+			entry.Index = len(entries) + 1
+			entry.Start = roundSeconds(offset)
+			entry.End = roundSeconds(offset + duration)
+			entries = append(entries, entry)
+		}
+		offset += duration
+	}
+
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"audio":    outputPath,
+		"duration": roundSeconds(offset),
+		"segments": entries,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成对比清单失败: %v", err)
+	}
+
+	manifestPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".manifest.json"
+	if err := writeFile(manifestPath, append(data, '\n')); err != nil {
+		return fmt.Errorf("写入对比清单失败: %v", err)
+	}
+	fmt.Printf("\n🆚 对比音频: %s\n📋 来源清单: %s（%d 段）\n", outputPath, manifestPath, len(entries))
+	return nil
+}
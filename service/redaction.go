@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// redactRule 预编译的脱敏规则
+type redactRule struct {
+	name    string
+	pattern *regexp.Regexp
+	replace string
+}
+
+// redactor 在朗读前按规则替换敏感信息（密钥、手机号等），记录每条规则的命中次数
+// Markdown文档按句并发处理，命中计数需要加锁
+type redactor struct {
+	rules   []redactRule
+	mu      sync.Mutex
+	hits    []int
+	invalid error // 规则无效时不朗读任何文本，避免敏感信息原样读出
+}
+
+// newRedactor 编译脱敏规则，未配置规则时返回nil，nil脱敏器原样返回文本
+func newRedactor(rules []model.RedactRule) (*redactor, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	r := &redactor{hits: make([]int, len(rules))}
+	for i, rule := range rules {
+		if rule.Pattern == "" {
+			return nil, fmt.Errorf("第 %d 条脱敏规则缺少 pattern", i+1)
+		}
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 条脱敏规则的正则无效: %v", i+1, err)
+		}
+		name := rule.Name
+		if name == "" {
+			name = rule.Pattern
+		}
+		r.rules = append(r.rules, redactRule{name: name, pattern: pattern, replace: rule.Replace})
+	}
+	return r, nil
+}
+
+// apply 依次应用脱敏规则，替换文本支持 $1 等分组引用
+// 每条规则只匹配一遍，替换的同时计数；ReplaceAllStringFunc 拿不到分组，无法展开 $1
+func (r *redactor) apply(text string) string {
+	if r == nil {
+		return text
+	}
+	if r.invalid != nil {
+		return ""
+	}
+
+	for i, rule := range r.rules {
+		matches := rule.pattern.FindAllStringSubmatchIndex(text, -1)
+		if len(matches) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range matches {
+			b.WriteString(text[last:m[0]])
+			b.Write(rule.pattern.ExpandString(nil, rule.replace, text, m))
+			last = m[1]
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+
+		r.mu.Lock()
+		r.hits[i] += len(matches)
+		r.mu.Unlock()
+	}
+	return text
+}
+
+// report 打印脱敏命中统计
+func (r *redactor) report() {
+	if r == nil {
+		return
+	}
+
+	if r.invalid != nil {
+		Failf("❌ 脱敏规则无效，所有文本均已丢弃: %v\n", r.invalid)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var parts []string
+	for i, rule := range r.rules {
+		if r.hits[i] > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d 处", rule.name, r.hits[i]))
+		}
+	}
+	if len(parts) > 0 {
+		fmt.Printf("🔒 脱敏替换: %s\n", strings.Join(parts, "，"))
+	}
+}
+
+// SetRedactRules 设置脱敏规则（正则 → 替换文本），在文本处理的最开始应用
+// 规则无效时返回错误，并让处理器丢弃所有文本，不会在未脱敏的情况下继续朗读
+func (tp *TextProcessor) SetRedactRules(rules []model.RedactRule) error {
+	r, err := newRedactor(rules)
+	if err != nil {
+		tp.redactor = &redactor{invalid: err}
+		return err
+	}
+	tp.redactor = r
+	return nil
+}
+
+// validateRedactRules 检查脱敏规则能否编译，加载配置时调用，规则无效时直接报错退出
+func validateRedactRules(rules []model.RedactRule) error {
+	_, err := newRedactor(rules)
+	return err
+}
+
+// ReportRedactions 打印脱敏命中统计，未配置规则或没有命中时不输出
+func (tp *TextProcessor) ReportRedactions() {
+	tp.redactor.report()
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+func TestRedactorApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    []model.RedactRule
+		input    string
+		want     string
+		wantHits []int
+	}{
+		{
+			name:     "替换并计数",
+			rules:    []model.RedactRule{{Pattern: `1[3-9]\d{9}`, Replace: "手机号"}},
+			input:    "联系13800138000或13900139000",
+			want:     "联系手机号或手机号",
+			wantHits: []int{2},
+		},
+		{
+			name:     "分组引用",
+			rules:    []model.RedactRule{{Pattern: `(sk)-\w+`, Replace: "$1 密钥"}},
+			input:    "key=sk-abc123",
+			want:     "key=sk 密钥",
+			wantHits: []int{1},
+		},
+		{
+			name:     "锚点按原文匹配",
+			rules:    []model.RedactRule{{Pattern: `^AK\w+`, Replace: "密钥"}},
+			input:    "AKID1 AKID2",
+			want:     "密钥 AKID2",
+			wantHits: []int{1},
+		},
+		{
+			name: "多条规则依次应用",
+			rules: []model.RedactRule{
+				{Pattern: `\d{4}`, Replace: "####"},
+				{Pattern: `#+`, Replace: "数字"},
+			},
+			input:    "编号1234",
+			want:     "编号数字",
+			wantHits: []int{1, 1},
+		},
+		{
+			name:     "没有命中",
+			rules:    []model.RedactRule{{Pattern: `secret`, Replace: "x"}},
+			input:    "普通文本",
+			want:     "普通文本",
+			wantHits: []int{0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newRedactor(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.apply(tt.input); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			for i, want := range tt.wantHits {
+				if r.hits[i] != want {
+					t.Errorf("规则 %d 命中 %d 次, want %d", i+1, r.hits[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidRedactRuleFailsClosed(t *testing.T) {
+	rules := []model.RedactRule{{Pattern: `(unclosed`, Replace: "x"}}
+
+	tp := NewTextProcessor()
+	if err := tp.SetRedactRules(rules); err == nil {
+		t.Fatal("期望无效正则返回错误")
+	}
+	if got := tp.ProcessText("密钥 AKID123"); got != "" {
+		t.Errorf("规则无效时仍输出了文本: %q", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	data := "markdown:\n  redact:\n    - pattern: \"(unclosed\"\n      replace: x\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfigFile(path); err == nil || !strings.Contains(err.Error(), "redact") {
+		t.Errorf("加载配置应因脱敏规则无效而失败, got %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/difyz9/markdown2tts/model"
+	"golang.org/x/time/rate"
+)
+
+// requestLimiters 按provider配额分别限制各接口的请求，避免轮询挤占合成配额触发限流
+// 创建任务（CreateTtsTask）与实时合成（TextToVoice）是两个接口，QPS各自计算，分别限速
+type requestLimiters struct {
+	create   *rate.Limiter // 创建长文本合成任务（含重试）
+	realtime *rate.Limiter // 实时合成（含重试）
+	status   *rate.Limiter // 任务状态查询（轮询、刷新过期URL）
+}
+
+// newRequestLimiters 按配置和provider配额创建各接口的限速器
+// rate_limit 分别限制两个合成接口，超过provider配额时按配额限速；status_rate_limit 未设置时按provider的状态查询配额
+func newRequestLimiters(config *model.Config, caps ProviderCapabilities) requestLimiters {
+	synthesis := config.Concurrent.RateLimit
+	if quota := caps.SynthesisQPS; quota > 0 && (synthesis <= 0 || synthesis > quota) {
+		if synthesis > quota {
+			Warnf("⚠️  rate_limit=%d 超过合成接口配额 %d次/秒，按配额限速\n", synthesis, quota)
+		}
+		synthesis = quota
+	}
+
+	status := config.Concurrent.StatusRateLimit
+	if status <= 0 {
+		status = caps.StatusQPS
+	}
+	return requestLimiters{
+		create:   newRateLimiter(synthesis),
+		realtime: newRateLimiter(synthesis),
+		status:   newRateLimiter(status),
+	}
+}
+
+// newRateLimiter 创建每秒最多 perSecond 次请求的限速器，perSecond 不大于0时不限速
+func newRateLimiter(perSecond int) *rate.Limiter {
+	if perSecond <= 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(perSecond), perSecond)
+}
+
+// describe 返回用于日志的限速说明
+func (l requestLimiters) describe() string {
+	return fmt.Sprintf("创建任务 %s, 实时合成 %s, 状态查询 %s", describeRate(l.create), describeRate(l.realtime), describeRate(l.status))
+}
+
+// describeRate 返回限速器的每秒请求数说明
+func describeRate(limiter *rate.Limiter) string {
+	if limiter.Limit() == rate.Inf {
+		return "不限"
+	}
+	return fmt.Sprintf("%g次/秒", float64(limiter.Limit()))
+}
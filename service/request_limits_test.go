@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/difyz9/markdown2tts/model"
+	"golang.org/x/time/rate"
+)
+
+func TestNewRequestLimiters(t *testing.T) {
+	tencent := providerCapabilities[ProviderTencent]
+	tests := []struct {
+		name       string
+		rateLimit  int
+		statusRate int
+		caps       ProviderCapabilities
+		synthesis  rate.Limit
+		status     rate.Limit
+	}{
+		{"按配置限速", 5, 0, tencent, 5, 20},
+		{"超过配额按配额", 50, 0, tencent, 20, 20},
+		{"未配置按配额", 0, 0, tencent, 20, 20},
+		{"状态查询单独配置", 5, 2, tencent, 5, 2},
+		{"未声明配额不限速", 0, 0, ProviderCapabilities{}, rate.Inf, rate.Inf},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var config model.Config
+			config.Concurrent.RateLimit = tt.rateLimit
+			config.Concurrent.StatusRateLimit = tt.statusRate
+			l := newRequestLimiters(&config, tt.caps)
+			if l.create == l.realtime {
+				t.Fatal("创建任务与实时合成应使用各自的限速器")
+			}
+			if l.create.Limit() != tt.synthesis || l.realtime.Limit() != tt.synthesis || l.status.Limit() != tt.status {
+				t.Errorf("限速 = %v/%v/%v, want %v/%v/%v", l.create.Limit(), l.realtime.Limit(), l.status.Limit(), tt.synthesis, tt.synthesis, tt.status)
+			}
+		})
+	}
+}
+
+func TestLimiterErrorKeepsContext(t *testing.T) {
+	var config model.Config
+	config.Concurrent.RateLimit = 1
+	cas := &ConcurrentAudioService{config: &config, limiters: newRequestLimiters(&config, providerCapabilities[ProviderTencent])}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"创建任务", func() error { _, err := cas.createTTSTask(ctx, &model.TTSRequest{Text: "你好"}); return err }},
+		{"实时合成", func() error { return cas.synthesizeRealtime(ctx, TTSTask{Text: "你好"}, "") }},
+		{"状态查询", func() error { _, err := cas.waitForTTSCompletion(ctx, "task-id"); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, context.Canceled) {
+				t.Errorf("err = %v, want context.Canceled", err)
+			}
+		})
+	}
+}
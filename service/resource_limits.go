@@ -0,0 +1,68 @@
+package service
+
+import (
+	"fmt"
+	"runtime"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// ApplyResourceLimits 应用CPU相关的资源限制（GOMAXPROCS）
+func ApplyResourceLimits(config *model.Config) {
+	if config.Concurrent.MaxProcs > 0 {
+		previous := runtime.GOMAXPROCS(config.Concurrent.MaxProcs)
+		fmt.Printf("⚙️  GOMAXPROCS: %d → %d\n", previous, config.Concurrent.MaxProcs)
+	}
+}
+
+// stageWorkerCount 计算单级流水线的worker数量：不超过任务数和goroutine上限，至少为1
+func stageWorkerCount(config *model.Config, taskCount int) int {
+	count := clampWorkers(config.Concurrent.MaxWorkers, taskCount)
+	if limit := config.Concurrent.MaxGoroutines; limit > 0 && count > limit {
+		count = limit
+	}
+	return count
+}
+
+// pipelineWorkerCounts 计算两级流水线（合成+下载）的worker数量
+// 两级合计超过goroutine上限时按比例缩减，每级至少保留1个worker
+func pipelineWorkerCounts(config *model.Config, taskCount int) (synth, download int) {
+	synth = clampWorkers(config.Concurrent.MaxWorkers, taskCount)
+
+	download = config.Concurrent.DownloadWorkers
+	if download <= 0 {
+		download = config.Concurrent.MaxWorkers
+	}
+	download = clampWorkers(download, taskCount)
+
+	limit := config.Concurrent.MaxGoroutines
+	if limit > 0 && synth+download > limit {
+		total := synth + download
+		synth = synth * limit / total
+		if synth < 1 {
+			synth = 1
+		}
+		download = limit - synth
+		if download < 1 {
+			download = 1
+		}
+	}
+
+	return synth, download
+}
+
+// resultBufferSize 结果通道的缓冲大小：每个worker最多积压2个结果，峰值内存不随任务总数增长
+func resultBufferSize(workers int) int {
+	return workers * 2
+}
+
+// clampWorkers 把worker数量限制在 [1, taskCount] 范围内
+func clampWorkers(workers, taskCount int) int {
+	if workers > taskCount {
+		workers = taskCount
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	return workers
+}
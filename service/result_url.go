@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errAudioURLExpired 音频URL的签名已过期，需要重新查询任务获取新的URL
+var errAudioURLExpired = errors.New("音频URL已过期")
+
+// audioURLExpiryMargin 签名剩余有效期不足该值时提前刷新URL，避免下载到一半过期
+const audioURLExpiryMargin = 30 * time.Second
+
+// audioURLExpiry 从签名URL中解析过期时间
+// 腾讯云COS签名为 q-sign-time=<开始>;<结束>，其他预签名URL常用 Expires=<时间戳>
+func audioURLExpiry(audioURL string) (time.Time, bool) {
+	parsed, err := url.Parse(audioURL)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// q-sign-time 中含分号，url.Query 会丢弃这类参数，这里逐个解析
+	var end string
+	for _, pair := range strings.Split(parsed.RawQuery, "&") {
+		key, value, _ := strings.Cut(pair, "=")
+		value, _ = url.QueryUnescape(value)
+		switch key {
+		case "q-sign-time":
+			if _, after, ok := strings.Cut(value, ";"); ok {
+				end = after
+			}
+		case "Expires":
+			if end == "" {
+				end = value
+			}
+		}
+	}
+
+	seconds, err := strconv.ParseInt(end, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// audioURLExpired 判断URL签名是否已过期（或即将过期），无法解析过期时间时返回false
+func audioURLExpired(audioURL string) bool {
+	expiry, ok := audioURLExpiry(audioURL)
+	return ok && time.Now().Add(audioURLExpiryMargin).After(expiry)
+}
+
+// isExpiredURLStatus 签名过期时对象存储返回403，结合URL中的过期时间判断；无法解析过期时间时403也按过期处理
+func isExpiredURLStatus(audioURL string, statusCode int) bool {
+	if statusCode != http.StatusForbidden {
+		return false
+	}
+	if _, ok := audioURLExpiry(audioURL); ok {
+		return audioURLExpired(audioURL)
+	}
+	return true
+}
+
+// refreshAudioURL 重新查询已完成的任务获取新的音频URL，不重新合成
+func (cas *ConcurrentAudioService) refreshAudioURL(ctx context.Context, taskID string, index int) (string, error) {
+	if taskID == "" {
+		return "", fmt.Errorf("缺少任务ID，无法刷新音频URL")
+	}
+
+	fmt.Printf("  🔁 任务 %d 的音频URL已过期，重新查询任务 %s 获取新的URL...\n", index, taskID)
+	audioURL, err := cas.waitForTTSCompletion(ctx, taskID)
+	if err != nil {
+		return "", fmt.Errorf("刷新音频URL失败: %w", err)
+	}
+	return audioURL, nil
+}
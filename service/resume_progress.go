@@ -0,0 +1,152 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// progressFileName 断点续传的进度清单，保存在临时目录
+const progressFileName = "progress.json"
+
+// progressSaveInterval 进度清单的最短写盘间隔，任务很多时避免每完成一个片段就重写整个清单
+const progressSaveInterval = 2 * time.Second
+
+// progressEntry 一个已完成任务的记录
+type progressEntry struct {
+	File     string `json:"file"`      // 生成的音频片段
+	TextHash string `json:"text_hash"` // 文本及音色参数的哈希，变化时需要重新合成
+}
+
+// progressManifest progress.json 的内容，按任务索引记录
+type progressManifest struct {
+	Segments map[int]progressEntry `json:"segments"`
+}
+
+// resumeProgress 记录已完成的任务，--resume 重跑时跳过文本未变且片段仍存在的任务
+// 不开启 resume 时也记录进度，中途失败后可以用 --resume 接着处理
+type resumeProgress struct {
+	path    string
+	resume  bool
+	mu      sync.Mutex
+	entries map[int]progressEntry
+	dirty   bool
+	saved   time.Time
+	reused  int
+}
+
+// newResumeProgress 按配置创建进度清单并读取上次运行留下的记录，只有开启 concurrent.resume 时才复用
+// 不开启时保留已有记录，避免试听等其他运行覆盖掉中断任务的进度
+func newResumeProgress(config *model.Config) *resumeProgress {
+	p := &resumeProgress{
+		path:    filepath.Join(config.Audio.TempDir, progressFileName),
+		resume:  config.Concurrent.Resume,
+		entries: make(map[int]progressEntry),
+	}
+
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		if p.resume && !os.IsNotExist(err) {
+			Warnf("⚠️  读取进度清单失败，将从头处理: %v\n", err)
+		}
+		return p
+	}
+	var manifest progressManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		if p.resume {
+			Warnf("⚠️  进度清单格式无效，将从头处理: %v\n", err)
+		}
+		return p
+	}
+	if manifest.Segments != nil {
+		p.entries = manifest.Segments
+	}
+	if p.resume {
+		fmt.Printf("⏯️  断点续传: 进度清单记录了 %d 个已完成片段（%s）\n", len(p.entries), p.path)
+	}
+	return p
+}
+
+// reuse 开启 resume 时判断任务能否复用上次生成的片段：文本哈希一致，且片段文件存在并通过校验
+// 文本变化或片段被删除时删除记录，任务重新合成
+func (p *resumeProgress) reuse(index int, hash, audioFile string, validate func(path string) error) bool {
+	if p == nil || !p.resume {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	entry, ok := p.entries[index]
+	if !ok {
+		return false
+	}
+	if entry.TextHash != hash || entry.File != audioFile || validate(audioFile) != nil {
+		delete(p.entries, index)
+		p.dirty = true
+		return false
+	}
+	p.reused++
+	return true
+}
+
+// record 记录完成的任务，距上次写盘超过 progressSaveInterval 时写出清单
+func (p *resumeProgress) record(index int, hash, audioFile string) {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries[index] = progressEntry{File: audioFile, TextHash: hash}
+	p.dirty = true
+	if time.Since(p.saved) >= progressSaveInterval {
+		p.save()
+	}
+}
+
+// flush 写出尚未保存的记录，处理结束（包括中断）时调用
+func (p *resumeProgress) flush() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.dirty {
+		p.save()
+	}
+}
+
+// save 写出进度清单，调用方需持有锁；写入失败只警告，不影响合成
+func (p *resumeProgress) save() {
+	data, err := json.MarshalIndent(progressManifest{Segments: p.entries}, "", "  ")
+	if err == nil {
+		err = writeFileAtomic(p.path, func(path string) error {
+			return writeFile(path, append(data, '\n'))
+		})
+	}
+	if err != nil {
+		Warnf("⚠️  写入进度清单失败: %v\n", err)
+		return
+	}
+	p.dirty = false
+	p.saved = time.Now()
+}
+
+// report 打印断点续传复用的片段数
+func (p *resumeProgress) report() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.reused > 0 {
+		fmt.Printf("⏯️  断点续传: 跳过 %d 个已完成的任务\n", p.reused)
+	}
+}
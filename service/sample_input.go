@@ -0,0 +1,87 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// defaultSampleLanguage 默认示例语言
+const defaultSampleLanguage = "zh"
+
+// sampleInputTemplates 按语言区分的示例输入文件模板
+// 可用变量: {{.Program}} 可执行文件名，{{.Input}} 示例文件路径
+// .txt 默认按纯文本处理，模板中不放Markdown格式示例，避免 ** 等符号被原样朗读
+var sampleInputTemplates = map[string]string{
+	"zh": `欢迎使用TTS语音合成应用！
+
+这是一个功能强大的文本转语音工具。
+支持腾讯云TTS和Microsoft Edge TTS两种引擎。
+Edge TTS完全免费，无需API密钥。
+
+中英文混合示例：
+AI Agent可以automatically处理various任务。
+
+符号测试：！@#$%^&*()
+括号测试：（中文括号）和(English brackets)
+
+请编辑此文件，添加您要转换的文本内容。
+每行文本将被转换为一个音频片段，最后自动合并。
+Markdown文件请使用 .md 扩展名，标题、加粗和链接等格式会自动处理。
+
+开始使用：
+1. 免费版本：{{.Program}} edge -i {{.Input}}
+2. 腾讯云版本：{{.Program}} tts -i {{.Input}}
+`,
+	"en": `Welcome to the text-to-speech app!
+
+This tool turns text into natural sounding speech.
+It supports both Tencent Cloud TTS and Microsoft Edge TTS.
+Edge TTS is free and needs no API key.
+
+Symbol test: ! @ # $ % ^ & * ( )
+Bracket test: (round brackets) and [square brackets]
+
+Edit this file and add the text you want to convert.
+Each line becomes one audio segment, and all segments are merged at the end.
+For Markdown input use the .md extension, headings, bold text and links are handled automatically.
+
+Getting started:
+1. Free: {{.Program}} edge -i {{.Input}} --voice en-US-AriaNeural
+2. Tencent Cloud: {{.Program}} tts -i {{.Input}}
+`,
+}
+
+// SampleLanguages 返回支持的示例语言，按名称排序
+func SampleLanguages() []string {
+	langs := make([]string, 0, len(sampleInputTemplates))
+	for lang := range sampleInputTemplates {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// renderSampleInput 按语言渲染示例输入文件内容
+func renderSampleInput(lang, inputPath string) (string, error) {
+	text, ok := sampleInputTemplates[lang]
+	if !ok {
+		return "", fmt.Errorf("不支持的示例语言: %s（可选: %s）", lang, strings.Join(SampleLanguages(), ", "))
+	}
+
+	tmpl, err := template.New("sample_" + lang).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("解析示例模板失败: %v", err)
+	}
+
+	var sb strings.Builder
+	err = tmpl.Execute(&sb, struct {
+		Program string
+		Input   string
+	}{ProgramName, inputPath})
+	if err != nil {
+		return "", fmt.Errorf("生成示例内容失败: %v", err)
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// mp3SampleRates MPEG版本 → 采样率索引表（MPEG1、MPEG2、MPEG2.5）
+var mp3SampleRates = map[byte][3]int{
+	3: {44100, 48000, 32000}, // MPEG1
+	2: {22050, 24000, 16000}, // MPEG2
+	0: {11025, 12000, 8000},  // MPEG2.5
+}
+
+// mp3HeaderScanLimit 查找首个MP3帧头时最多读取的字节数（不含ID3标签）
+const mp3HeaderScanLimit = 64 * 1024
+
+// detectSampleRate 检测音频文件采样率，支持MP3和WAV，其他格式返回错误
+func detectSampleRate(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	header := make([]byte, 10)
+	if _, err := io.ReadFull(file, header); err != nil {
+		return 0, fmt.Errorf("文件过短: %v", err)
+	}
+
+	// WAV: RIFF....WAVE，采样率位于第24字节
+	if string(header[0:4]) == "RIFF" {
+		wavHeader := make([]byte, 28)
+		if _, err := file.ReadAt(wavHeader, 0); err != nil {
+			return 0, fmt.Errorf("读取WAV头失败: %v", err)
+		}
+		return int(binary.LittleEndian.Uint32(wavHeader[24:28])), nil
+	}
+
+	// 跳过ID3v2标签（大小为syncsafe整数）
+	offset := int64(0)
+	if string(header[0:3]) == "ID3" {
+		size := int64(header[6]&0x7f)<<21 | int64(header[7]&0x7f)<<14 | int64(header[8]&0x7f)<<7 | int64(header[9]&0x7f)
+		offset = 10 + size
+	}
+
+	buf := make([]byte, mp3HeaderScanLimit)
+	n, err := file.ReadAt(buf, offset)
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	buf = buf[:n]
+
+	// 查找帧同步字 11位全1
+	for i := 0; i+3 < len(buf); i++ {
+		if buf[i] != 0xFF || buf[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+		version := (buf[i+1] >> 3) & 0x03
+		layer := (buf[i+1] >> 1) & 0x03
+		rateIndex := (buf[i+2] >> 2) & 0x03
+		rates, ok := mp3SampleRates[version]
+		if !ok || layer == 0 || rateIndex == 3 {
+			continue
+		}
+		return rates[rateIndex], nil
+	}
+
+	return 0, fmt.Errorf("未识别的音频格式")
+}
+
+// unifySampleRates 合并前检查所有片段采样率是否一致
+// 目标采样率为 audio.sample_rate，未配置时取片段中最常见的采样率；
+// 开启 audio.resample 且安装了ffmpeg时把不一致的片段重采样，否则只打印警告
+func unifySampleRates(config *model.Config, audioFiles []string) []string {
+	rates := make(map[string]int, len(audioFiles))
+	counts := make(map[int]int)
+	for _, file := range audioFiles {
+		rate, err := detectSampleRate(file)
+		if err != nil {
+			continue // 无法识别的格式不参与检查
+		}
+		rates[file] = rate
+		counts[rate]++
+	}
+
+	target := config.Audio.SampleRate
+	if target <= 0 {
+		target = mostCommonRate(counts)
+	}
+	if target <= 0 {
+		return audioFiles
+	}
+
+	var mismatched []string
+	for _, file := range audioFiles {
+		if rate, ok := rates[file]; ok && rate != target {
+			mismatched = append(mismatched, file)
+		}
+	}
+	if len(mismatched) == 0 {
+		return audioFiles
+	}
+
+	if !config.Audio.Resample || !IsFFmpegAvailable() {
+		Warnf("⚠️  %d 个音频片段的采样率与 %d Hz 不一致（如 %s 为 %d Hz），合并后文件属性可能混乱\n",
+			len(mismatched), target, filepath.Base(mismatched[0]), rates[mismatched[0]])
+		if config.Audio.Resample {
+			fmt.Println("   未找到ffmpeg，无法重采样")
+		} else {
+			fmt.Println("   可设置 audio.resample: true（需要ffmpeg）自动统一采样率")
+		}
+		return audioFiles
+	}
+
+	fmt.Printf("🔄 重采样 %d 个音频片段到 %d Hz...\n", len(mismatched), target)
+	replaced := make(map[string]string, len(mismatched))
+	for _, file := range mismatched {
+		output := filepath.Join(config.Audio.TempDir, "resampled_"+filepath.Base(file))
+		if err := runFFmpeg("-y", "-loglevel", "error", "-i", file, "-ar", strconv.Itoa(target), output); err != nil {
+			Warnf("⚠️  重采样失败，保留原文件: %s, 错误: %v\n", file, err)
+			continue
+		}
+		replaced[file] = output
+	}
+
+	result := make([]string, len(audioFiles))
+	for i, file := range audioFiles {
+		if output, ok := replaced[file]; ok {
+			result[i] = output
+		} else {
+			result[i] = file
+		}
+	}
+	return result
+}
+
+// mostCommonRate 返回出现次数最多的采样率，次数相同时取较高的采样率
+func mostCommonRate(counts map[int]int) int {
+	rates := make([]int, 0, len(counts))
+	for rate := range counts {
+		rates = append(rates, rate)
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if counts[rates[i]] != counts[rates[j]] {
+			return counts[rates[i]] > counts[rates[j]]
+		}
+		return rates[i] > rates[j]
+	})
+	if len(rates) == 0 {
+		return 0
+	}
+	return rates[0]
+}
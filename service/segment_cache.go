@@ -0,0 +1,106 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// segmentCache 按文本和音色参数缓存已合成的片段，重复合成同一文档时只合成变化的句子
+// worker并发读写，计数需要加锁
+type segmentCache struct {
+	dir    string
+	mu     sync.Mutex
+	hits   int
+	stores int
+}
+
+// newSegmentCache 按配置创建片段缓存，未设置 audio.cache_dir 时返回nil，nil缓存不命中也不保存
+func newSegmentCache(config *model.Config) *segmentCache {
+	if config.Audio.CacheDir == "" {
+		return nil
+	}
+	if err := makeDirs(config.Audio.CacheDir); err != nil {
+		Warnf("⚠️  创建片段缓存目录失败，不使用缓存: %v\n", err)
+		return nil
+	}
+	return &segmentCache{dir: config.Audio.CacheDir}
+}
+
+// segmentCacheKey 由provider、影响音频结果的参数和文本计算缓存键
+func segmentCacheKey(provider string, params ...interface{}) string {
+	parts := make([]string, 0, len(params)+1)
+	parts = append(parts, provider)
+	for _, param := range params {
+		parts = append(parts, fmt.Sprint(param))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// path 返回缓存键对应的缓存文件
+func (c *segmentCache) path(key, ext string) string {
+	return filepath.Join(c.dir, key+"."+ext)
+}
+
+// restore 缓存命中时把片段复制到 dest 并返回true
+func (c *segmentCache) restore(key, ext, dest string) bool {
+	if c == nil {
+		return false
+	}
+	if err := copySegment(c.path(key, ext), dest); err != nil {
+		return false
+	}
+
+	c.mu.Lock()
+	c.hits++
+	c.mu.Unlock()
+	return true
+}
+
+// store 把合成成功的片段保存到缓存，失败时只打印警告
+func (c *segmentCache) store(key, ext, src string) {
+	if c == nil {
+		return
+	}
+	err := writeFileAtomic(c.path(key, ext), func(tmp string) error {
+		return copySegment(src, tmp)
+	})
+	if err != nil {
+		Warnf("⚠️  保存片段缓存失败: %v\n", err)
+		return
+	}
+
+	c.mu.Lock()
+	c.stores++
+	c.mu.Unlock()
+}
+
+// counts 返回复用和新写入缓存的片段数
+func (c *segmentCache) counts() (hits, stores int) {
+	if c == nil {
+		return 0, 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.stores
+}
+
+// report 打印缓存命中统计
+func (c *segmentCache) report() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.hits+c.stores > 0 {
+		fmt.Printf("🗃️  片段缓存: 复用 %d 个，新合成 %d 个（%s）\n", c.hits, c.stores, c.dir)
+	}
+}
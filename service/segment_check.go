@@ -0,0 +1,60 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// maxMissingIndicesShown 缺失片段警告中最多列出的任务索引数
+const maxMissingIndicesShown = 20
+
+// checkSegmentCount 合并前对比有效句子数与成功片段数，缺失比例超过 missing_segment_ratio 时显著警告，
+// 配置 abort_on_missing 时中止合并；因处理时长预算跳过的任务属于预期内缺失，只警告不中止
+func checkSegmentCount(config *model.Config, expected, produced []int, budgetExpired bool) error {
+	done := make(map[int]bool, len(produced))
+	for _, index := range produced {
+		done[index] = true
+	}
+
+	var missing []int
+	for _, index := range expected {
+		if !done[index] {
+			missing = append(missing, index)
+		}
+	}
+	if len(missing) == 0 || len(expected) == 0 {
+		return nil
+	}
+
+	ratio := float64(len(missing)) / float64(len(expected))
+	if ratio <= config.Audio.MissingSegmentRatio {
+		return nil
+	}
+
+	shown := missing
+	if len(shown) > maxMissingIndicesShown {
+		shown = shown[:maxMissingIndicesShown]
+	}
+	indices := make([]string, len(shown))
+	for i, index := range shown {
+		indices[i] = fmt.Sprint(index)
+	}
+	list := strings.Join(indices, ", ")
+	if len(missing) > len(shown) {
+		list += fmt.Sprintf(" 等共 %d 个", len(missing))
+	}
+
+	fmt.Println()
+	Warnf("⚠️⚠️⚠️  音频片段不完整  ⚠️⚠️⚠️\n")
+	Warnf("   有效句子 %d 个，成功片段 %d 个，缺失 %d 个（%.1f%%），最终音频将比预期短\n",
+		len(expected), len(expected)-len(missing), len(missing), ratio*100)
+	Warnf("   缺失的任务索引: %s\n", list)
+
+	if config.Audio.AbortOnMissing && !budgetExpired {
+		return fmt.Errorf("缺失 %d 个音频片段，已按 abort_on_missing 配置中止合并", len(missing))
+	}
+	fmt.Println()
+	return nil
+}
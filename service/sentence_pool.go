@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+)
+
+// minSentencesPerWorker 每个预处理worker至少分到的句子数，句子太少时串行处理更快
+const minSentencesPerWorker = 64
+
+// processSentences 对句子逐个执行 ProcessText 和有效性检查，按原顺序返回可合成的句子
+// 句子较多时使用与 GOMAXPROCS 相同数量的worker并行处理，结果按下标归并以保持顺序
+func (tp *TextProcessor) processSentences(ctx context.Context, sentences []string, progress *markdownProgress) ([]string, error) {
+	workers := min(runtime.GOMAXPROCS(0), len(sentences)/minSentencesPerWorker)
+	if workers <= 1 {
+		var processedSentences []string
+		for _, sentence := range sentences {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("Markdown解析已取消: %v", err)
+			}
+			if processed := tp.processSentence(sentence); processed != "" {
+				processedSentences = append(processedSentences, processed)
+			}
+			progress.add()
+		}
+		return processedSentences, nil
+	}
+
+	// 发送所有句子下标到通道
+	indexChan := make(chan int, len(sentences))
+	for i := range sentences {
+		indexChan <- i
+	}
+	close(indexChan)
+
+	results := make([]string, len(sentences))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexChan {
+				if ctx.Err() != nil {
+					return
+				}
+				results[i] = tp.processSentence(sentences[i])
+				progress.add()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("Markdown解析已取消: %v", err)
+	}
+
+	var processedSentences []string
+	for _, processed := range results {
+		if processed != "" {
+			processedSentences = append(processedSentences, processed)
+		}
+	}
+	return processedSentences, nil
+}
+
+// processSentence 处理单个句子，不适合合成时返回空字符串
+func (tp *TextProcessor) processSentence(sentence string) string {
+	if sentence == "" {
+		return ""
+	}
+
+	// 使用现有的文本处理逻辑
+	processed := tp.ProcessText(sentence)
+	if processed != "" && tp.IsValidTextForTTS(processed) {
+		return processed
+	}
+	return ""
+}
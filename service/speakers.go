@@ -0,0 +1,95 @@
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// speakerSegment 按说话人切分后的文本片段，Speaker 为空表示使用默认音色
+type speakerSegment struct {
+	Speaker string
+	Text    string
+}
+
+// speakerMatcher 识别对话脚本中的说话人前缀（如 "A: 你好"）
+// 只识别 config.speakers 中配置过的说话人，避免把 "注意：" 之类的普通文本误判为说话人
+type speakerMatcher struct {
+	prefixRegex *regexp.Regexp // 行首前缀
+	inlineRegex *regexp.Regexp // 句中前缀（Markdown段落中多行会被合并）
+}
+
+// newSpeakerMatcher 根据配置创建说话人识别器，未配置说话人时返回nil
+func newSpeakerMatcher(speakers map[string]model.SpeakerConfig) *speakerMatcher {
+	if len(speakers) == 0 {
+		return nil
+	}
+
+	// 长名字优先，避免 "A" 抢先匹配 "AB"
+	names := make([]string, 0, len(speakers))
+	for name := range speakers {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if len(names[i]) != len(names[j]) {
+			return len(names[i]) > len(names[j])
+		}
+		return names[i] < names[j]
+	})
+
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	alternatives := strings.Join(quoted, "|")
+
+	return &speakerMatcher{
+		prefixRegex: regexp.MustCompile(`^\s*(` + alternatives + `)\s*[:：]\s*`),
+		inlineRegex: regexp.MustCompile(`(?:^|\s)(` + alternatives + `)\s*[:：]\s*`),
+	}
+}
+
+// parseLine 识别行首的说话人前缀，返回说话人和去掉前缀后的文本
+func (sm *speakerMatcher) parseLine(line string) (string, string) {
+	if sm == nil {
+		return "", line
+	}
+
+	loc := sm.prefixRegex.FindStringSubmatchIndex(line)
+	if loc == nil {
+		return "", line
+	}
+	return line[loc[2]:loc[3]], line[loc[1]:]
+}
+
+// split 按文本中出现的说话人前缀切分，前缀之前的文本使用默认音色
+func (sm *speakerMatcher) split(text string) []speakerSegment {
+	if sm == nil {
+		return []speakerSegment{{Text: text}}
+	}
+
+	matches := sm.inlineRegex.FindAllStringSubmatchIndex(text, -1)
+	if len(matches) == 0 {
+		return []speakerSegment{{Text: text}}
+	}
+
+	var segments []speakerSegment
+	appendSegment := func(speaker, segmentText string) {
+		if segmentText = strings.TrimSpace(segmentText); segmentText != "" {
+			segments = append(segments, speakerSegment{Speaker: speaker, Text: segmentText})
+		}
+	}
+
+	appendSegment("", text[:matches[0][0]])
+	for i, loc := range matches {
+		end := len(text)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		appendSegment(text[loc[2]:loc[3]], text[loc[1]:end])
+	}
+
+	return segments
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// ssmlTagRegex SSML标签，如 <speak>、<break time="500ms"/>、<phoneme alphabet="py" ph="zhong4">
+var ssmlTagRegex = regexp.MustCompile(htmlTagPattern)
+
+// ssmlTagPartsRegex 拆出标签的结束标记、元素名和自闭合标记
+var ssmlTagPartsRegex = regexp.MustCompile(`^<(/?)([a-zA-Z][\w:-]*)[^<>]*?(/?)>$`)
+
+// ssmlElements 腾讯云支持的SSML元素，Markdown中只有这些标签按SSML原样保留，其他HTML仍按HTML处理
+var ssmlElements = map[string]bool{
+	"speak":   true,
+	"break":   true,
+	"phoneme": true,
+	"say-as":  true,
+	"sub":     true,
+	"prosody": true,
+	"p":       true,
+	"s":       true,
+}
+
+// ssmlSentenceEndRegex 句末标点，与 SplitIntoSentences 的分句规则一致
+var ssmlSentenceEndRegex = regexp.MustCompile(`[。！？]|[.!?](?:\s|$)`)
+
+// ssmlAmpRegex & 及其后可能的实体名，已经是实体（如 &amp;）的不再转义
+var ssmlAmpRegex = regexp.MustCompile(`&([a-zA-Z0-9#]+;)?`)
+
+// IsSSML 判断文本是否为SSML：以 <speak> 标签开头
+func IsSSML(text string) bool {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if !strings.HasPrefix(text, "<speak") {
+		return false
+	}
+	rest := text[len("<speak"):]
+	return strings.HasPrefix(rest, ">") || strings.HasPrefix(rest, " ")
+}
+
+// mapSSMLText 只对标签之间的文本调用 fn，标签原样保留
+func mapSSMLText(text string, fn func(string) string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range ssmlTagRegex.FindAllStringIndex(text, -1) {
+		if loc[0] > last {
+			sb.WriteString(fn(text[last:loc[0]]))
+		}
+		sb.WriteString(text[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	if last < len(text) {
+		sb.WriteString(fn(text[last:]))
+	}
+	return sb.String()
+}
+
+// escapeSSMLText 转义标签之间文本中的 & < >，否则SSML不是合法的XML
+func escapeSSMLText(text string) string {
+	text = ssmlAmpRegex.ReplaceAllStringFunc(text, func(match string) string {
+		if match == "&" {
+			return "&amp;"
+		}
+		return match
+	})
+	return strings.NewReplacer("<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// ssmlVisibleText 去掉标签后实际朗读的文本，用于按字数判断合成方式
+func ssmlVisibleText(text string) string {
+	return ssmlTagRegex.ReplaceAllString(text, "")
+}
+
+// parseSSMLTag 解析标签，返回小写元素名、是否为结束标签、是否自闭合；注释等非元素标签 ok 为 false
+func parseSSMLTag(tag string) (name string, closing, selfClosing, ok bool) {
+	m := ssmlTagPartsRegex.FindStringSubmatch(tag)
+	if m == nil {
+		return "", false, false, false
+	}
+	return strings.ToLower(m[2]), m[1] == "/", m[3] == "/", true
+}
+
+// isSSMLMarkup 判断HTML片段中的标签是否全部为SSML元素
+func isSSMLMarkup(html string) bool {
+	tags := ssmlTagRegex.FindAllString(html, -1)
+	if len(tags) == 0 {
+		return false
+	}
+	for _, tag := range tags {
+		name, _, _, ok := parseSSMLTag(tag)
+		if !ok || !ssmlElements[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// balanceSSML 补全SSML结构，保证发送的是合法的单根文档：
+// 丢弃与最近未闭合元素不匹配的结束标签，补上未闭合的元素，
+// 去掉文本中间的 <speak> 标签后统一用一个 <speak> 根元素包住（保留第一个 <speak> 的属性）
+func balanceSSML(text string) string {
+	root := "<speak>"
+	var sb strings.Builder
+	var open []string
+	foundRoot := false
+	last := 0
+	for _, loc := range ssmlTagRegex.FindAllStringIndex(text, -1) {
+		sb.WriteString(text[last:loc[0]])
+		last = loc[1]
+		tag := text[loc[0]:loc[1]]
+
+		name, closing, selfClosing, ok := parseSSMLTag(tag)
+		switch {
+		case ok && name == "speak":
+			if !closing && !foundRoot {
+				root, foundRoot = tag, true
+			}
+		case !ok || selfClosing:
+			sb.WriteString(tag)
+		case closing:
+			if n := len(open); n > 0 && open[n-1] == name {
+				open = open[:n-1]
+				sb.WriteString(tag)
+			}
+		default:
+			open = append(open, name)
+			sb.WriteString(tag)
+		}
+	}
+	sb.WriteString(text[last:])
+	for i := len(open) - 1; i >= 0; i-- {
+		sb.WriteString("</" + open[i] + ">")
+	}
+	return root + strings.TrimSpace(sb.String()) + "</speak>"
+}
+
+// splitSSMLSentences 按句末标点切分含SSML标签的段落：不在未闭合的元素内部切分，
+// 句末之后只剩标签（如段尾的 <break/>）时并入前一句；<speak> 根标签去掉，合成时统一补上
+func splitSSMLSentences(paragraph string) []string {
+	var sentences []string
+	var current strings.Builder
+	depth := 0
+
+	flush := func() {
+		part := strings.TrimSpace(current.String())
+		current.Reset()
+		if part == "" {
+			return
+		}
+		if strings.TrimSpace(ssmlVisibleText(part)) == "" && len(sentences) > 0 {
+			sentences[len(sentences)-1] += part
+			return
+		}
+		sentences = append(sentences, part)
+	}
+
+	writeText := func(text string) {
+		if depth > 0 {
+			current.WriteString(text)
+			return
+		}
+		for {
+			loc := ssmlSentenceEndRegex.FindStringIndex(text)
+			if loc == nil {
+				current.WriteString(text)
+				return
+			}
+			current.WriteString(strings.TrimRight(text[:loc[1]], " \t\n"))
+			flush()
+			text = text[loc[1]:]
+		}
+	}
+
+	last := 0
+	for _, loc := range ssmlTagRegex.FindAllStringIndex(paragraph, -1) {
+		writeText(paragraph[last:loc[0]])
+		last = loc[1]
+		tag := paragraph[loc[0]:loc[1]]
+
+		name, closing, selfClosing, ok := parseSSMLTag(tag)
+		if ok && name == "speak" {
+			continue
+		}
+		if ok && !selfClosing {
+			if closing {
+				if depth > 0 {
+					depth--
+				}
+			} else {
+				depth++
+			}
+		}
+		current.WriteString(tag)
+	}
+	writeText(paragraph[last:])
+	flush()
+	return sentences
+}
+
+// SetSSML 设置SSML模式：开启后包含标签的文本只清洗标签之间的内容，
+// 不移除标签，也不把 < > 等符号替换为文字，便于使用 <break time="500ms"/> 等标记
+func (tp *TextProcessor) SetSSML(enabled bool) {
+	tp.ssml = enabled
+	tp.markdownProcessor.SetSSML(enabled)
+}
+
+// SetSSML 设置SSML模式：开启后Markdown中的SSML标签原样保留，分句时不在元素内部切分
+func (mp *MarkdownProcessor) SetSSML(enabled bool) {
+	mp.ssml = enabled
+}
+
+// ssmlText 判断文本是否按SSML处理：开启SSML模式且包含标签
+func (tp *TextProcessor) ssmlText(text string) bool {
+	return tp.ssml && ssmlTagRegex.MatchString(text)
+}
+
+// newTencentTextProcessor 按腾讯云音色的语言创建文本处理器，并按 tts.ssml 开启SSML模式
+func newTencentTextProcessor(config *model.Config) *TextProcessor {
+	tp := newTextProcessorForVoice(config, tencentVoiceLanguage(config.TTS.VoiceType))
+	tp.SetSSML(config.TTS.SSML)
+	return tp
+}
+
+// tencentRequestText 返回发送给腾讯云的文本：应用发音替换表；
+// 开启SSML或文本以 <speak> 开头时只替换标签之间的文本，并补全未闭合的元素和 <speak> 根元素
+func tencentRequestText(req *model.TTSRequest) string {
+	if !req.EnableSSML && !IsSSML(req.Text) {
+		return applyPronunciations(req.Text, req.Pronunciations)
+	}
+
+	text := mapSSMLText(req.Text, func(s string) string {
+		return applyPronunciations(s, req.Pronunciations)
+	})
+	return balanceSSML(text)
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// wellFormedXML 判断文本是否为结构完整的XML
+func wellFormedXML(text string) bool {
+	decoder := xml.NewDecoder(strings.NewReader(text))
+	for {
+		if _, err := decoder.Token(); err != nil {
+			return err == io.EOF
+		}
+	}
+}
+
+func TestProcessMarkdownFileSSML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "句间停顿",
+			input: `第一句。<break time="500ms"/>第二句。`,
+			want:  []string{"第一句。", `<break time="500ms"/>第二句。`},
+		},
+		{
+			name:  "段尾停顿并入前一句",
+			input: `第一句。第二句。<break time="1s"/>`,
+			want:  []string{"第一句。", `第二句。<break time="1s"/>`},
+		},
+		{
+			name:  "元素内部不切分",
+			input: `开头。<prosody rate="slow">慢一。慢二。</prosody>结尾。`,
+			want:  []string{"开头。", `<prosody rate="slow">慢一。慢二。</prosody>结尾。`},
+		},
+		{
+			name:  "根标签去掉",
+			input: `<speak>甲。<say-as interpret-as="digits">123</say-as>乙。</speak>`,
+			want:  []string{"甲。", `<say-as interpret-as="digits">123</say-as>乙。`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "doc.md")
+			if err := os.WriteFile(path, []byte(tt.input+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			tp := NewTextProcessor()
+			tp.SetSSML(true)
+			got, err := tp.ProcessMarkdownFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessSpecialSymbolsKeepsSSMLTags(t *testing.T) {
+	tp := NewTextProcessor()
+	tp.SetSSML(true)
+	for _, tag := range []string{`<break time="500ms"/>`, `<phoneme alphabet="py" ph="zhong4">`, `<say-as interpret-as="digits">`} {
+		got := tp.processSpecialSymbols("价格 + 税" + tag + "结束")
+		if !strings.Contains(got, tag) {
+			t.Errorf("标签被改写: %q", got)
+		}
+		if !strings.Contains(got, "加") {
+			t.Errorf("标签外的符号未处理: %q", got)
+		}
+	}
+}
+
+func TestTencentRequestTextSSML(t *testing.T) {
+	tests := []struct {
+		name string
+		req  model.TTSRequest
+		want string
+	}{
+		{
+			name: "缺少结束根标签",
+			req:  model.TTSRequest{Text: "<speak>甲。"},
+			want: "<speak>甲。</speak>",
+		},
+		{
+			name: "补上根元素",
+			req:  model.TTSRequest{Text: `甲<break time="500ms"/>乙`, EnableSSML: true},
+			want: `<speak>甲<break time="500ms"/>乙</speak>`,
+		},
+		{
+			name: "补全未闭合元素并丢弃多余结束标签",
+			req:  model.TTSRequest{Text: `<prosody rate="slow">慢</say-as>`, EnableSSML: true},
+			want: `<speak><prosody rate="slow">慢</prosody></speak>`,
+		},
+		{
+			name: "保留根元素属性",
+			req:  model.TTSRequest{Text: `<speak version="1.0">甲</speak>乙`},
+			want: `<speak version="1.0">甲乙</speak>`,
+		},
+		{
+			name: "发音替换只作用于标签之间",
+			req:  model.TTSRequest{Text: `<sub alias="重庆">重庆</sub>`, EnableSSML: true, Pronunciations: map[string]string{"重庆": "崇庆"}},
+			want: `<speak><sub alias="重庆">崇庆</sub></speak>`,
+		},
+		{
+			name: "未开启SSML的普通文本原样发送",
+			req:  model.TTSRequest{Text: "a < b"},
+			want: "a < b",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tencentRequestText(&tt.req)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if tt.req.EnableSSML || IsSSML(tt.req.Text) {
+				if !wellFormedXML(got) {
+					t.Errorf("结果不是合法的XML: %q", got)
+				}
+			}
+		})
+	}
+}
+
+func TestIsSSML(t *testing.T) {
+	tests := map[string]bool{
+		"<speak>甲</speak>":           true,
+		"  <SPEAK version=\"1.0\">甲": true,
+		"<speaker>甲</speaker>":       false,
+		"甲<speak>乙</speak>":          false,
+		`<break time="500ms"/>甲`:     false,
+	}
+	for text, want := range tests {
+		if got := IsSSML(text); got != want {
+			t.Errorf("IsSSML(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// symbolReading 符号及其读法，读法为空表示删除该符号
+type symbolReading struct {
+	symbol  string
+	reading string
+}
+
+// symbolRule 预编译的符号替换规则
+type symbolRule struct {
+	symbolReading
+	pattern *regexp.Regexp // 符号前后必须是空白或字符串边界
+}
+
+// builtinSymbolTables 内置的符号读法表，按替换顺序排列，未收录的语言使用中文表
+var builtinSymbolTables = map[string][]symbolReading{
+	langChinese: {
+		{"@", "at"},
+		{"#", ""},
+		{"$", "美元"},
+		{"%", "百分号"},
+		{"^", ""},
+		{"&", ""},
+		{"*", ""},
+		{"+", "加"},
+		{"=", "等于"},
+		{"|", ""},
+		{"~", ""},
+		{"`", ""},
+		{"<", "小于"},
+		{">", "大于"},
+		{"[", "左方括号"},
+		{"]", "右方括号"},
+		{"{", "左大括号"},
+		{"}", "右大括号"},
+	},
+	langEnglish: {
+		{"@", "at"},
+		{"#", ""},
+		{"$", "dollars"},
+		{"%", "percent"},
+		{"^", ""},
+		{"&", "and"},
+		{"*", ""},
+		{"+", "plus"},
+		{"=", "equals"},
+		{"|", ""},
+		{"~", ""},
+		{"`", ""},
+		{"<", "less than"},
+		{">", "greater than"},
+		{"[", "left bracket"},
+		{"]", "right bracket"},
+		{"{", "left brace"},
+		{"}", "right brace"},
+	},
+}
+
+// loadSymbolFile 读取外置符号读法表（YAML/JSON/TOML，按扩展名识别），格式为 语言 → 符号 → 读法，如：
+//
+//	en:
+//	  "$": "dollar"
+//	  "€": "euro"
+func loadSymbolFile(path string) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取符号读法表失败: %v", err)
+	}
+
+	var tables map[string]map[string]string
+	if err := decodeByFormat(ConfigFormatOf(path), data, &tables); err != nil {
+		return nil, fmt.Errorf("解析符号读法表 %s 失败: %v", path, err)
+	}
+
+	normalized := make(map[string]map[string]string, len(tables))
+	for lang, table := range tables {
+		normalized[strings.ToLower(strings.TrimSpace(lang))] = table
+	}
+	return normalized, nil
+}
+
+// buildSymbolRules 以内置表为基础应用覆盖项，生成按顺序的替换规则
+// 覆盖项中已有的符号替换读法，新增的符号按字典序追加在后面
+func buildSymbolRules(lang string, overrides map[string]string) []symbolRule {
+	base, ok := builtinSymbolTables[lang]
+	if !ok {
+		base = builtinSymbolTables[langChinese]
+	}
+
+	readings := make([]symbolReading, 0, len(base)+len(overrides))
+	known := make(map[string]bool, len(base))
+	for _, entry := range base {
+		if reading, ok := overrides[entry.symbol]; ok {
+			entry.reading = reading
+		}
+		readings = append(readings, entry)
+		known[entry.symbol] = true
+	}
+
+	var extra []string
+	for symbol := range overrides {
+		if symbol != "" && !known[symbol] {
+			extra = append(extra, symbol)
+		}
+	}
+	sort.Strings(extra)
+	for _, symbol := range extra {
+		readings = append(readings, symbolReading{symbol: symbol, reading: overrides[symbol]})
+	}
+
+	rules := make([]symbolRule, len(readings))
+	for i, entry := range readings {
+		rules[i] = symbolRule{
+			symbolReading: entry,
+			pattern:       regexp.MustCompile(`(\s|^)` + regexp.QuoteMeta(entry.symbol) + `(\s|$)`),
+		}
+	}
+	return rules
+}
+
+// SetSymbolLanguage 按语言选择符号读法表（zh/en，其他语言使用中文表），同时应用外置表中该语言的覆盖项
+func (tp *TextProcessor) SetSymbolLanguage(lang string) {
+	tp.symbolLanguage = strings.ToLower(strings.TrimSpace(lang))
+	if tp.symbolLanguage == "" {
+		tp.symbolLanguage = langChinese
+	}
+	tp.symbolRules = buildSymbolRules(tp.symbolLanguage, tp.symbolOverrides[tp.symbolLanguage])
+}
+
+// SetSymbolOverrides 设置外置符号读法表（语言 → 符号 → 读法），并按当前语言重建替换规则
+func (tp *TextProcessor) SetSymbolOverrides(overrides map[string]map[string]string) {
+	tp.symbolOverrides = overrides
+	tp.SetSymbolLanguage(tp.symbolLanguage)
+}
+
+// hasSymbolTable 判断语言是否有内置表或外置表
+func (tp *TextProcessor) hasSymbolTable(lang string) bool {
+	_, builtin := builtinSymbolTables[lang]
+	_, external := tp.symbolOverrides[lang]
+	return builtin || external
+}
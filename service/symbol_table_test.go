@@ -0,0 +1,109 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildSymbolRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		lang      string
+		overrides map[string]string
+		symbol    string
+		want      string
+	}{
+		{"中文表", langChinese, nil, "+", "加"},
+		{"英文表", langEnglish, nil, "+", "plus"},
+		{"未收录语言用中文表", "ja", nil, "=", "等于"},
+		{"覆盖读法", langEnglish, map[string]string{"$": "dollar"}, "$", "dollar"},
+		{"覆盖为删除", langChinese, map[string]string{"+": ""}, "+", ""},
+		{"新增符号", langEnglish, map[string]string{"€": "euro"}, "€", "euro"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := buildSymbolRules(tt.lang, tt.overrides)
+			for _, rule := range rules {
+				if rule.symbol == tt.symbol {
+					if rule.reading != tt.want {
+						t.Errorf("%s 读作 %q, want %q", tt.symbol, rule.reading, tt.want)
+					}
+					return
+				}
+			}
+			t.Errorf("没有 %s 的规则", tt.symbol)
+		})
+	}
+}
+
+func TestBuildSymbolRulesOrder(t *testing.T) {
+	// 内置符号保持原顺序，新增符号按字典序追加
+	rules := buildSymbolRules(langEnglish, map[string]string{"€": "euro", "£": "pound", "@": "at sign"})
+	base := builtinSymbolTables[langEnglish]
+	if len(rules) != len(base)+2 {
+		t.Fatalf("规则数 = %d, want %d", len(rules), len(base)+2)
+	}
+	for i, entry := range base {
+		if rules[i].symbol != entry.symbol {
+			t.Errorf("第 %d 条规则 = %q, want %q", i, rules[i].symbol, entry.symbol)
+		}
+	}
+	if rules[len(base)].symbol != "£" || rules[len(base)+1].symbol != "€" {
+		t.Errorf("新增符号顺序 = %q, %q", rules[len(base)].symbol, rules[len(base)+1].symbol)
+	}
+}
+
+func TestProcessSpecialSymbolsByLanguage(t *testing.T) {
+	tests := []struct {
+		lang      string
+		overrides map[string]map[string]string
+		input     string
+		want      string
+	}{
+		{langChinese, nil, "a + b = c", "a 加 b 等于 c"},
+		{langEnglish, nil, "a + b = c", "a plus b equals c"},
+		{langEnglish, map[string]map[string]string{"en": {"+": "and"}}, "a + b", "a and b"},
+		{langChinese, nil, "a+b", "a+b"},
+	}
+	for _, tt := range tests {
+		tp := NewTextProcessor()
+		tp.SetSymbolOverrides(tt.overrides)
+		tp.SetSymbolLanguage(tt.lang)
+		if got := tp.processSpecialSymbols(tt.input); got != tt.want {
+			t.Errorf("%s: processSpecialSymbols(%q) = %q, want %q", tt.lang, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestLoadSymbolFile(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{"YAML", "symbols.yaml", "EN:\n  \"$\": dollar\n", false},
+		{"JSON", "symbols.json", `{" en ": {"$": "dollar"}}`, false},
+		{"格式错误", "bad.yaml", "en: [\n", true},
+		{"文件不存在", "missing.yaml", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.file)
+			if tt.content != "" {
+				if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			tables, err := loadSymbolFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadSymbolFile err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tables["en"]["$"] != "dollar" {
+				t.Errorf("tables = %v", tables)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"bytes"
+	"container/heap"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 任务优先级，数值越大越先合成
+const (
+	TaskPriorityNormal = 0
+	TaskPriorityHigh   = 1
+)
+
+// taskQueue 按优先级出队的任务队列：优先级高的先出队，同优先级按入队顺序
+// 只改变合成的先后，结果合并前仍按 Index 排序，不影响最终顺序
+type taskQueue[T any] struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	items  taskHeap[T]
+	seq    int
+	closed bool
+}
+
+// queuedTask 队列中的任务
+type queuedTask[T any] struct {
+	task     T
+	priority int
+	seq      int // 入队序号，同优先级按入队顺序出队
+}
+
+// taskHeap 实现 heap.Interface
+type taskHeap[T any] []queuedTask[T]
+
+func (h taskHeap[T]) Len() int { return len(h) }
+func (h taskHeap[T]) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h taskHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap[T]) Push(x any)   { *h = append(*h, x.(queuedTask[T])) }
+func (h *taskHeap[T]) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+// newTaskQueue 创建任务队列
+func newTaskQueue[T any]() *taskQueue[T] {
+	q := &taskQueue[T]{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// Push 加入任务，处理过程中也可以加入高优先任务插队
+func (q *taskQueue[T]) Push(task T, priority int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	heap.Push(&q.items, queuedTask[T]{task: task, priority: priority, seq: q.seq})
+	q.seq++
+	q.cond.Signal()
+}
+
+// Pop 取出优先级最高的任务，队列为空时等待；队列已关闭且为空时返回false
+func (q *taskQueue[T]) Pop() (T, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.items) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.items) == 0 {
+		var zero T
+		return zero, false
+	}
+	return heap.Pop(&q.items).(queuedTask[T]).task, true
+}
+
+// Close 关闭队列：不再接受新任务，已有任务取完后 Pop 返回false
+func (q *taskQueue[T]) Close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// enqueueTasks 把全部任务按优先级放入队列后关闭队列，有高优先任务时打印数量
+func enqueueTasks[T any](tasks []T, priority func(T) int) *taskQueue[T] {
+	queue := newTaskQueue[T]()
+	high := 0
+	for _, task := range tasks {
+		p := priority(task)
+		if p > TaskPriorityNormal {
+			high++
+		}
+		queue.Push(task, p)
+	}
+	queue.Close()
+	if high > 0 {
+		fmt.Printf("⏫ %d 个任务优先合成\n", high)
+	}
+	return queue
+}
+
+// taskPriorities 根据 concurrent.priority_lines 判断任务的优先级
+type taskPriorities struct {
+	lines map[int]bool // 优先的行号（从0开始）
+	texts []string     // 优先行的文本（只保留文字和数字），用于匹配智能Markdown模式下的句子
+}
+
+// newTaskPriorities 读取优先行，未配置时返回nil
+// 智能Markdown模式下优先行的文本由 observe 在流式读取输入时收集
+func newTaskPriorities(config *model.Config) *taskPriorities {
+	if len(config.Concurrent.PriorityLines) == 0 {
+		return nil
+	}
+
+	p := &taskPriorities{lines: make(map[int]bool)}
+	for _, line := range config.Concurrent.PriorityLines {
+		if line > 0 {
+			p.lines[line-1] = true
+		}
+	}
+
+	return p
+}
+
+// observe 包装输入文件的读取：内容原样透传，同时按行号收集优先行的文本
+// 智能Markdown模式下句子不带行号，按这些文本匹配优先行
+func (p *taskPriorities) observe(r io.Reader) io.Reader {
+	if p == nil || len(p.lines) == 0 {
+		return r
+	}
+	return &priorityLineReader{r: r, p: p}
+}
+
+// collect 记录第 index 行（从0开始）的文本，只保留优先行
+func (p *taskPriorities) collect(index int, line string) {
+	if !p.lines[index] {
+		return
+	}
+	if text := priorityMatchText(line); text != "" {
+		p.texts = append(p.texts, text)
+	}
+}
+
+// priorityLineReader 透传读取的同时逐行统计行号，只缓存当前优先行的内容
+type priorityLineReader struct {
+	r     io.Reader
+	p     *taskPriorities
+	index int    // 当前行号（从0开始）
+	line  []byte // 当前优先行已读到的内容
+}
+
+func (lr *priorityLineReader) Read(buf []byte) (int, error) {
+	n, err := lr.r.Read(buf)
+	data := buf[:n]
+	for len(data) > 0 {
+		end := bytes.IndexByte(data, '\n')
+		if end < 0 {
+			if lr.p.lines[lr.index] {
+				lr.line = append(lr.line, data...)
+			}
+			break
+		}
+		if lr.p.lines[lr.index] {
+			lr.line = append(lr.line, data[:end]...)
+			lr.p.collect(lr.index, string(lr.line))
+			lr.line = lr.line[:0]
+		}
+		lr.index++
+		data = data[end+1:]
+	}
+	if err == io.EOF && len(lr.line) > 0 {
+		lr.p.collect(lr.index, string(lr.line))
+		lr.line = nil
+	}
+	return n, err
+}
+
+// ofLine 返回第 index 行（从0开始）生成的任务的优先级
+func (p *taskPriorities) ofLine(index int) int {
+	if p != nil && p.lines[index] {
+		return TaskPriorityHigh
+	}
+	return TaskPriorityNormal
+}
+
+// ofText 返回句子的优先级：句子出自优先行时为高优先
+func (p *taskPriorities) ofText(sentence string) int {
+	if p == nil {
+		return TaskPriorityNormal
+	}
+	text := priorityMatchText(sentence)
+	if text == "" {
+		return TaskPriorityNormal
+	}
+	for _, line := range p.texts {
+		if strings.Contains(line, text) {
+			return TaskPriorityHigh
+		}
+	}
+	return TaskPriorityNormal
+}
+
+// priorityMatchText 只保留文字和数字，去掉Markdown标记和标点后再比较
+func priorityMatchText(text string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, text)
+}
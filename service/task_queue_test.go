@@ -0,0 +1,34 @@
+package service
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+func TestTaskPrioritiesObserve(t *testing.T) {
+	var config model.Config
+	config.Concurrent.PriorityLines = []int{2, 4}
+	p := newTaskPriorities(&config)
+
+	input := "# 标题\n**第二行**改过了\r\n第三行\n最后一行没有换行"
+	// 逐字节读取，覆盖一行跨多次 Read 的情况
+	data, err := io.ReadAll(p.observe(iotest.OneByteReader(strings.NewReader(input))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != input {
+		t.Errorf("透传内容 = %q", data)
+	}
+
+	want := []string{"第二行改过了", "最后一行没有换行"}
+	if strings.Join(p.texts, ",") != strings.Join(want, ",") {
+		t.Errorf("优先行文本 = %q, want %q", p.texts, want)
+	}
+	if p.ofText("第二行改过了。") != TaskPriorityHigh || p.ofText("第三行") != TaskPriorityNormal {
+		t.Error("按文本匹配优先行错误")
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/regions"
+	tts "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tts/v20190823"
+)
+
+// tencentRegions 腾讯云支持的地域列表
+var tencentRegions = []string{
+	regions.Beijing, regions.Shanghai, regions.Guangzhou, regions.GuangzhouOpen,
+	regions.Chengdu, regions.Chongqing, regions.Nanjing, regions.HongKong,
+	regions.ShanghaiFSI, regions.ShenzhenFSI,
+	regions.Singapore, regions.Bangkok, regions.Jakarta, regions.Mumbai,
+	regions.Seoul, regions.Tokyo,
+	regions.Frankfurt, regions.Moscow,
+	regions.Ashburn, regions.SiliconValley, regions.Toronto, regions.SaoPaulo,
+}
+
+// ValidateTencentRegion 校验地域是否为腾讯云支持的地域，留空时由SDK使用默认地域
+func ValidateTencentRegion(region string) error {
+	if region == "" {
+		return nil
+	}
+	for _, r := range tencentRegions {
+		if r == region {
+			return nil
+		}
+	}
+	return fmt.Errorf("不支持的腾讯云地域: %s（可选: %s）", region, strings.Join(tencentRegions, ", "))
+}
+
+// tencentClientOptions 决定SDK客户端配置的参数，相同参数的客户端在进程内复用
+type tencentClientOptions struct {
+	secretID   string
+	secretKey  string
+	region     string
+	proxy      string
+	timeout    time.Duration
+	maxRetries int
+}
+
+var (
+	tencentClientsMu sync.Mutex
+	tencentClients   = make(map[tencentClientOptions]*tts.Client)
+)
+
+// tencentClient 返回指定参数的SDK客户端，已创建过时直接复用
+func tencentClient(opts tencentClientOptions) (*tts.Client, error) {
+	tencentClientsMu.Lock()
+	defer tencentClientsMu.Unlock()
+
+	if client, ok := tencentClients[opts]; ok {
+		return client, nil
+	}
+
+	// 实例化一个认证对象
+	credential := common.NewCredential(opts.secretID, opts.secretKey)
+
+	// 实例化一个客户端配置对象
+	cpf := profile.NewClientProfile()
+	cpf.HttpProfile.Endpoint = "tts.tencentcloudapi.com"
+	cpf.HttpProfile.Proxy = opts.proxy
+	if opts.timeout > 0 {
+		// SDK的请求超时以秒为单位，不足1秒按1秒计
+		cpf.HttpProfile.ReqTimeout = int(math.Ceil(opts.timeout.Seconds()))
+	}
+	if opts.maxRetries > 0 {
+		cpf.NetworkFailureMaxRetries = opts.maxRetries
+		cpf.NetworkFailureRetryDuration = profile.ExponentialBackoff
+		cpf.RateLimitExceededMaxRetries = opts.maxRetries
+		cpf.RateLimitExceededRetryDuration = profile.ExponentialBackoff
+		// 不开启 UnsafeRetryOnConnectionFailure：CreateTtsTask 不是幂等请求，请求发出后连接失败时重发会重复创建并计费任务，
+		// 这类失败交给 retryTask 按任务重试，计入指标和日志
+	}
+
+	// 实例化要请求产品的client对象
+	client, err := tts.NewClient(credential, opts.region, cpf)
+	if err != nil {
+		return nil, err
+	}
+	tencentClients[opts] = client
+	return client, nil
+}
+
+// NewTTSServiceFromConfig 按配置创建腾讯云TTS服务，校验地域并应用超时与SDK重试设置
+func NewTTSServiceFromConfig(config *model.Config) (*TTSService, error) {
+	tc := config.TencentCloud
+	if err := ValidateTencentRegion(tc.Region); err != nil {
+		return nil, err
+	}
+	if tc.Timeout < 0 || tc.MaxRetries < 0 {
+		return nil, fmt.Errorf("tencent_cloud.timeout 与 tencent_cloud.max_retries 不能为负数")
+	}
+
+	client, err := tencentClient(tencentClientOptions{
+		secretID:   tc.SecretID,
+		secretKey:  tc.SecretKey,
+		region:     tc.Region,
+		proxy:      ResolveProxy(config),
+		timeout:    tc.Timeout,
+		maxRetries: tc.MaxRetries,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建腾讯云TTS客户端失败: %v", err)
+	}
+	return &TTSService{client: client}, nil
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/difyz9/markdown2tts/model"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+)
+
+// 腾讯云密钥来源
+const (
+	CredentialSourceFlag   = "命令行参数"
+	CredentialSourceEnv    = "环境变量 TENCENTCLOUD_SECRET_ID/TENCENTCLOUD_SECRET_KEY"
+	CredentialSourceFile   = "凭证文件"
+	CredentialSourceConfig = "配置文件"
+)
+
+// 配置模板中的占位密钥，视为未配置
+const (
+	placeholderSecretID  = "your_secret_id"
+	placeholderSecretKey = "your_secret_key"
+)
+
+// ResolveTencentCredentials 按 命令行 > 环境变量 > 凭证文件 > 配置文件 的优先级确定腾讯云密钥并写回config，返回密钥来源
+// 凭证文件为 TENCENTCLOUD_CREDENTIALS_FILE 指定的路径，默认 ~/.tencentcloud/credentials 的 [default] 段
+func ResolveTencentCredentials(config *model.Config, flagSecretID, flagSecretKey string) (string, error) {
+	tc := &config.TencentCloud
+
+	if flagSecretID != "" || flagSecretKey != "" {
+		if flagSecretID == "" || flagSecretKey == "" {
+			return "", fmt.Errorf("--secret-id 和 --secret-key 需要同时指定")
+		}
+		tc.SecretID, tc.SecretKey = flagSecretID, flagSecretKey
+		return CredentialSourceFlag, nil
+	}
+
+	if credential, err := common.DefaultEnvProvider().GetCredential(); err == nil {
+		tc.SecretID, tc.SecretKey = credential.GetSecretId(), credential.GetSecretKey()
+		return CredentialSourceEnv, nil
+	}
+
+	credential, err := common.DefaultProfileProvider().GetCredential()
+	if err == nil {
+		tc.SecretID, tc.SecretKey = credential.GetSecretId(), credential.GetSecretKey()
+		return CredentialSourceFile, nil
+	}
+
+	path, custom := os.LookupEnv(common.EnvCredentialFile)
+	if !custom {
+		path = defaultCredentialsPath()
+	}
+	if _, statErr := os.Stat(path); custom || (path != "" && statErr == nil) {
+		// 凭证文件存在但无法读取时提示原因，继续使用配置文件中的密钥
+		Warnf("⚠️  读取腾讯云凭证文件 %s 失败: %v\n", path, err)
+	}
+
+	if tc.SecretID == "" || tc.SecretKey == "" || tc.SecretID == placeholderSecretID || tc.SecretKey == placeholderSecretKey {
+		return "", fmt.Errorf("未找到腾讯云SecretID和SecretKey：请通过 --secret-id/--secret-key、环境变量 TENCENTCLOUD_SECRET_ID/TENCENTCLOUD_SECRET_KEY、凭证文件 ~/.tencentcloud/credentials 或配置文件设置")
+	}
+	return CredentialSourceConfig, nil
+}
+
+// defaultCredentialsPath 默认凭证文件路径 ~/.tencentcloud/credentials，无法确定主目录时返回空
+func defaultCredentialsPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".tencentcloud", "credentials")
+}
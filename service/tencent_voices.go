@@ -0,0 +1,167 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// tencentVoice 腾讯云精品音色
+type tencentVoice struct {
+	ID          int64
+	Name        string // 中文名称，如 智琪
+	Alias       string // 拼音别名，如 zhiqi
+	Description string
+	Language    string   // 音色语言：zh 或 en
+	Gender      string   // 性别：female 或 male
+	Emotions    []string // 支持的情感类型，为空表示不支持情感参数
+	SampleRates []int64  // 支持的采样率
+}
+
+// 音色性别
+const (
+	voiceFemale = "female"
+	voiceMale   = "male"
+)
+
+// tencentSampleRates 精品音色支持的采样率
+var tencentSampleRates = []int64{8000, 16000}
+
+// tencentEmotions 多情感音色支持的情感类型
+var tencentEmotions = []string{"neutral", "sad", "happy", "angry", "fear", "news", "story", "radio", "poetry", "call"}
+
+// tencentVoices 内置的腾讯云音色元数据，用于别名解析和参数校验
+var tencentVoices = []tencentVoice{
+	{ID: 101001, Name: "智瑜", Alias: "zhiyu", Description: "情感女声", Language: "zh", Gender: voiceFemale, Emotions: tencentEmotions, SampleRates: tencentSampleRates},
+	{ID: 101002, Name: "智聆", Alias: "zhiling", Description: "通用女声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101003, Name: "智美", Alias: "zhimei", Description: "客服女声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101004, Name: "智云", Alias: "zhiyun", Description: "通用男声", Language: "zh", Gender: voiceMale, SampleRates: tencentSampleRates},
+	{ID: 101005, Name: "智莉", Alias: "zhili", Description: "通用女声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101006, Name: "智言", Alias: "zhiyan", Description: "助手女声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101007, Name: "智娜", Alias: "zhina", Description: "客服女声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101008, Name: "智琪", Alias: "zhiqi", Description: "客服女声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101009, Name: "智芸", Alias: "zhiyun2", Description: "知性女声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101010, Name: "智华", Alias: "zhihua", Description: "通用男声", Language: "zh", Gender: voiceMale, SampleRates: tencentSampleRates},
+	{ID: 101011, Name: "智燕", Alias: "zhiyan2", Description: "新闻女声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101012, Name: "智丹", Alias: "zhidan", Description: "新闻女声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101013, Name: "智辉", Alias: "zhihui", Description: "新闻男声", Language: "zh", Gender: voiceMale, SampleRates: tencentSampleRates},
+	{ID: 101014, Name: "智宁", Alias: "zhining", Description: "新闻男声", Language: "zh", Gender: voiceMale, SampleRates: tencentSampleRates},
+	{ID: 101015, Name: "智萌", Alias: "zhimeng", Description: "男童声", Language: "zh", Gender: voiceMale, SampleRates: tencentSampleRates},
+	{ID: 101016, Name: "智甜", Alias: "zhitian", Description: "女童声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101017, Name: "智蓉", Alias: "zhirong", Description: "情感女声", Language: "zh", Gender: voiceFemale, Emotions: tencentEmotions, SampleRates: tencentSampleRates},
+	{ID: 101018, Name: "智靖", Alias: "zhijing", Description: "情感男声", Language: "zh", Gender: voiceMale, Emotions: tencentEmotions, SampleRates: tencentSampleRates},
+	{ID: 101019, Name: "智彤", Alias: "zhitong", Description: "粤语女声", Language: "zh", Gender: voiceFemale, SampleRates: tencentSampleRates},
+	{ID: 101050, Name: "WeJack", Alias: "wejack", Description: "英文男声", Language: "en", Gender: voiceMale, SampleRates: tencentSampleRates},
+	{ID: 101051, Name: "WeRose", Alias: "werose", Description: "英文女声", Language: "en", Gender: voiceFemale, SampleRates: tencentSampleRates},
+}
+
+// findTencentVoice 按ID查找内置音色，未收录时返回false
+func findTencentVoice(voiceType int64) (tencentVoice, bool) {
+	for _, v := range tencentVoices {
+		if v.ID == voiceType {
+			return v, true
+		}
+	}
+	return tencentVoice{}, false
+}
+
+// checkTencentVoiceParams 校验音色是否支持请求的采样率和情感参数，未收录的音色不校验
+// 不兼容的参数会被服务端拒绝且错误信息不明确，这里提前给出可选值
+func checkTencentVoiceParams(voiceType, sampleRate int64, emotionCategory string) error {
+	voice, ok := findTencentVoice(voiceType)
+	if !ok {
+		return nil
+	}
+
+	label := fmt.Sprintf("音色 %s(%d)", voice.Name, voice.ID)
+	if sampleRate != 0 && !containsInt64(voice.SampleRates, sampleRate) {
+		rates := make([]string, len(voice.SampleRates))
+		for i, rate := range voice.SampleRates {
+			rates[i] = strconv.FormatInt(rate, 10)
+		}
+		return fmt.Errorf("%s 不支持采样率 %d（可选: %s）", label, sampleRate, strings.Join(rates, ", "))
+	}
+
+	if emotionCategory == "" {
+		return nil
+	}
+	if len(voice.Emotions) == 0 {
+		return fmt.Errorf("%s 不是多情感音色，不支持情感类型 %s，请删除 tts.emotion_category 或改用多情感音色（%s）", label, emotionCategory, tencentEmotionVoices())
+	}
+	for _, emotion := range voice.Emotions {
+		if emotion == emotionCategory {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s 不支持情感类型 %s（可选: %s）", label, emotionCategory, strings.Join(voice.Emotions, ", "))
+}
+
+// tencentEmotionVoices 返回支持情感参数的音色列表，如 "zhiyu(智瑜 101001)"
+func tencentEmotionVoices() string {
+	var voices []string
+	for _, v := range tencentVoices {
+		if len(v.Emotions) > 0 {
+			voices = append(voices, fmt.Sprintf("%s(%s %d)", v.Alias, v.Name, v.ID))
+		}
+	}
+	return strings.Join(voices, ", ")
+}
+
+// containsInt64 判断切片是否包含指定值
+func containsInt64(values []int64, target int64) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// tencentVoiceLanguage 返回音色的语言，未收录的音色视为中文
+func tencentVoiceLanguage(voiceType int64) string {
+	if v, ok := findTencentVoice(voiceType); ok {
+		return v.Language
+	}
+	return langChinese
+}
+
+// ResolveTencentVoice 把音色名称解析为VoiceType：支持数字ID、拼音别名和中文名称（不区分大小写）
+func ResolveTencentVoice(voice string) (int64, error) {
+	voice = strings.TrimSpace(voice)
+	if id, err := strconv.ParseInt(voice, 10, 64); err == nil {
+		return id, nil
+	}
+
+	for _, v := range tencentVoices {
+		if strings.EqualFold(voice, v.Alias) || voice == v.Name {
+			return v.ID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("未知的腾讯云音色: %q，可用别名: %s", voice, tencentVoiceAliases())
+}
+
+// ApplyTencentVoice 按 config.tts.voice 设置音色ID，未配置名称时保持 voice_type
+func ApplyTencentVoice(config *model.Config) error {
+	if config.TTS.Voice == "" {
+		return nil
+	}
+
+	id, err := ResolveTencentVoice(config.TTS.Voice)
+	if err != nil {
+		return err
+	}
+	config.TTS.VoiceType = id
+	return nil
+}
+
+// tencentVoiceAliases 返回可用别名列表，如 "zhiqi(智琪 101008)"
+func tencentVoiceAliases() string {
+	aliases := make([]string, len(tencentVoices))
+	for i, v := range tencentVoices {
+		aliases[i] = fmt.Sprintf("%s(%s %d)", v.Alias, v.Name, v.ID)
+	}
+	return strings.Join(aliases, ", ")
+}
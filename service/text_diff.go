@@ -0,0 +1,172 @@
+package service
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ANSI颜色：删除为红色删除线，新增为绿色
+const (
+	ansiDelete = "\x1b[31;9m"
+	ansiInsert = "\x1b[32m"
+	ansiReset  = "\x1b[0m"
+)
+
+// maxDiffRunes 逐字diff的最大长度，超长文本直接按整行替换显示，避免O(n*m)开销
+const maxDiffRunes = 2000
+
+// diffOp 差异片段类型
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffDelete
+	diffInsert
+)
+
+// diffSegment 连续的同类差异片段
+type diffSegment struct {
+	Op   diffOp
+	Text string
+}
+
+// diffRunes 基于最长公共子序列计算两段文本的逐字差异
+func diffRunes(before, after string) []diffSegment {
+	a, b := []rune(before), []rune(after)
+	if len(a) > maxDiffRunes || len(b) > maxDiffRunes {
+		return []diffSegment{{Op: diffDelete, Text: before}, {Op: diffInsert, Text: after}}
+	}
+
+	// lcs[i][j] 为 a[i:] 与 b[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var segments []diffSegment
+	appendRune := func(op diffOp, r rune) {
+		if n := len(segments); n > 0 && segments[n-1].Op == op {
+			segments[n-1].Text += string(r)
+			return
+		}
+		segments = append(segments, diffSegment{Op: op, Text: string(r)})
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			appendRune(diffEqual, a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			appendRune(diffDelete, a[i])
+			i++
+		default:
+			appendRune(diffInsert, b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		appendRune(diffDelete, a[i])
+	}
+	for ; j < len(b); j++ {
+		appendRune(diffInsert, b[j])
+	}
+
+	return segments
+}
+
+// FormatTextDiff 格式化文本差异：color 为 true 时用ANSI颜色高亮，否则用 [-删除-]{+新增+} 标记
+func FormatTextDiff(before, after string, color bool) string {
+	var sb strings.Builder
+	for _, segment := range diffRunes(before, after) {
+		switch segment.Op {
+		case diffEqual:
+			sb.WriteString(segment.Text)
+		case diffDelete:
+			if color {
+				sb.WriteString(ansiDelete + segment.Text + ansiReset)
+			} else {
+				sb.WriteString("[-" + segment.Text + "-]")
+			}
+		case diffInsert:
+			if color {
+				sb.WriteString(ansiInsert + segment.Text + ansiReset)
+			} else {
+				sb.WriteString("{+" + segment.Text + "+}")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// IsColorTerminal 判断标准输出是否为支持颜色的终端（遵循 NO_COLOR 约定）
+func IsColorTerminal() bool {
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// PreviewLine 单行预览结果
+type PreviewLine struct {
+	Number    int    // 行号（从1开始）
+	Original  string // 原文
+	Processed string // 处理后文本
+	Skipped   bool   // 是否被过滤，不参与合成
+}
+
+// PreviewTextFile 按逐行模式预览文本清洗结果
+func (tp *TextProcessor) PreviewTextFile(path string) ([]PreviewLine, error) {
+	var lines []PreviewLine
+	_, err := forEachInputLine(path, func(index int, line string) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			return
+		}
+
+		preview := PreviewLine{Number: index + 1, Original: trimmed}
+		if tp.IsValidTextForTTS(trimmed) {
+			preview.Processed = tp.ProcessText(trimmed)
+		}
+		preview.Skipped = preview.Processed == ""
+		lines = append(lines, preview)
+	})
+	return lines, err
+}
+
+// PrintPreview 输出预览结果：被过滤的行标记为跳过，有改动的行显示高亮diff
+func PrintPreview(w io.Writer, lines []PreviewLine, color bool) {
+	changed, skipped := 0, 0
+	for _, line := range lines {
+		switch {
+		case line.Skipped:
+			skipped++
+			fmt.Fprintf(w, "%4d ⏭️  跳过: %s\n", line.Number, line.Original)
+		case line.Processed != line.Original:
+			changed++
+			fmt.Fprintf(w, "%4d ✏️  %s\n", line.Number, FormatTextDiff(line.Original, line.Processed, color))
+		default:
+			fmt.Fprintf(w, "%4d ✓  %s\n", line.Number, line.Original)
+		}
+	}
+	fmt.Fprintf(w, "\n📊 预览统计: 共 %d 行, 有改动 %d 行, 跳过 %d 行\n", len(lines), changed, skipped)
+}
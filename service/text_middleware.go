@@ -0,0 +1,190 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TextMiddleware 文本处理中间件：接收上一步的结果，返回处理后的文本
+type TextMiddleware func(text string) string
+
+// 标准文本处理步骤名称，按默认顺序排列
+// 脱敏固定在最前面执行，不属于可重排的处理链，text_pipeline 中可以写出但位置和是否列出都不影响
+const (
+	TextStepRedact        = "redact"         // 脱敏替换
+	TextStepNonSpeech     = "non_speech"     // Markdown输入：移除代码块、表格、图片、链接、公式等不朗读的内容
+	TextStepEscape        = "escape"         // Markdown输入：处理转义字符
+	TextStepMarkdown      = "markdown"       // 去除Markdown格式字符
+	TextStepEmoji         = "emoji"          // 按 emoji_mode 移除、描述或保留emoji（纯文本输入同样执行）
+	TextStepSymbols       = "symbols"        // 特殊符号替换为读法
+	TextStepWhitespace    = "whitespace"     // 规范化空白字符
+	TextStepMixedLanguage = "mixed_language" // 中英文边界插入空格
+	TextStepBrackets      = "brackets"       // 括号补充说明处理
+)
+
+// standardTextSteps 标准处理链的默认顺序（脱敏之后）
+var standardTextSteps = []string{
+	TextStepNonSpeech, TextStepEscape, TextStepMarkdown,
+	TextStepEmoji, TextStepSymbols, TextStepWhitespace, TextStepMixedLanguage, TextStepBrackets,
+}
+
+// namedMiddleware 带名称的中间件，名称用于增删和重排
+type namedMiddleware struct {
+	name string
+	fn   TextMiddleware
+}
+
+// standardMiddlewares 组装标准处理链
+// 各步骤在执行时读取开关，构造后再调用 SetInputType、SetMixedLanguageSpacing 等设置仍然生效
+func (tp *TextProcessor) standardMiddlewares() []namedMiddleware {
+	return []namedMiddleware{
+		{TextStepNonSpeech, func(text string) string {
+			if tp.plainText {
+				return text
+			}
+			return tp.removeNonSpeechElements(text)
+		}},
+		// 转义字符需要在Markdown格式处理之前处理
+		{TextStepEscape, func(text string) string {
+			if tp.plainText {
+				return text
+			}
+			return tp.processEscapeCharacters(text)
+		}},
+		{TextStepMarkdown, func(text string) string {
+			if !tp.preserveMarkdown {
+				return text
+			}
+			return tp.processMarkdownFormatting(text)
+		}},
+		{TextStepEmoji, tp.processEmojis},
+		{TextStepSymbols, func(text string) string {
+			if !tp.handleSpecialSymbols {
+				return text
+			}
+			return tp.processSpecialSymbols(text)
+		}},
+		{TextStepWhitespace, func(text string) string {
+			if !tp.normalizeWhitespace {
+				return text
+			}
+			return tp.normalizeWhitespaceText(text)
+		}},
+		{TextStepMixedLanguage, func(text string) string {
+			if !tp.mixedLanguageSpacing {
+				return text
+			}
+			return tp.processMixedLanguageText(text)
+		}},
+		{TextStepBrackets, tp.processBrackets},
+	}
+}
+
+// Middlewares 返回当前处理链的步骤名称，第一项总是固定执行的脱敏步骤
+func (tp *TextProcessor) Middlewares() []string {
+	names := []string{TextStepRedact}
+	for _, m := range tp.middlewares {
+		names = append(names, m.name)
+	}
+	return names
+}
+
+// Use 在处理链末尾追加中间件，同名中间件已存在时替换它并保持原位置
+func (tp *TextProcessor) Use(name string, fn TextMiddleware) {
+	if i := tp.middlewareIndex(name); i >= 0 {
+		tp.middlewares[i].fn = fn
+		return
+	}
+	tp.middlewares = append(tp.middlewares, namedMiddleware{name, fn})
+}
+
+// InsertBefore 在指定步骤之前插入中间件
+func (tp *TextProcessor) InsertBefore(step, name string, fn TextMiddleware) error {
+	return tp.insertMiddleware(step, 0, name, fn)
+}
+
+// InsertAfter 在指定步骤之后插入中间件
+func (tp *TextProcessor) InsertAfter(step, name string, fn TextMiddleware) error {
+	return tp.insertMiddleware(step, 1, name, fn)
+}
+
+// insertMiddleware 在 step 的位置加 offset 处插入中间件
+func (tp *TextProcessor) insertMiddleware(step string, offset int, name string, fn TextMiddleware) error {
+	if name == TextStepRedact || tp.middlewareIndex(name) >= 0 {
+		return fmt.Errorf("文本处理步骤已存在: %s", name)
+	}
+	i := tp.middlewareIndex(step)
+	if step == TextStepRedact {
+		// 脱敏固定最先执行，只能在其后（处理链最前面）插入
+		if offset == 0 {
+			return fmt.Errorf("脱敏步骤总是最先执行，不能在 %s 之前插入", TextStepRedact)
+		}
+		i = -1
+	} else if i < 0 {
+		return fmt.Errorf("未知的文本处理步骤: %s（当前: %s）", step, strings.Join(tp.Middlewares(), ", "))
+	}
+
+	i += offset
+	tp.middlewares = append(tp.middlewares, namedMiddleware{})
+	copy(tp.middlewares[i+1:], tp.middlewares[i:])
+	tp.middlewares[i] = namedMiddleware{name, fn}
+	return nil
+}
+
+// RemoveMiddleware 从处理链中移除指定步骤，步骤不存在时返回false
+func (tp *TextProcessor) RemoveMiddleware(name string) bool {
+	i := tp.middlewareIndex(name)
+	if i < 0 {
+		return false
+	}
+	tp.middlewares = append(tp.middlewares[:i], tp.middlewares[i+1:]...)
+	return true
+}
+
+// SetMiddlewareOrder 按给定顺序重排处理链，未列出的步骤被移除，空列表恢复标准处理链
+// 脱敏总在最前面执行，列表中的 redact 会被忽略，避免漏写或放在后面导致敏感内容被朗读
+func (tp *TextProcessor) SetMiddlewareOrder(names []string) error {
+	if len(names) == 0 {
+		tp.middlewares = tp.standardMiddlewares()
+		return nil
+	}
+
+	// 自定义中间件和标准步骤都可以参与排序
+	available := make(map[string]namedMiddleware)
+	for _, m := range tp.standardMiddlewares() {
+		available[m.name] = m
+	}
+	for _, m := range tp.middlewares {
+		available[m.name] = m
+	}
+
+	ordered := make([]namedMiddleware, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == TextStepRedact {
+			continue
+		}
+		m, ok := available[name]
+		if !ok {
+			return fmt.Errorf("未知的文本处理步骤: %s（可选: %s）", name, strings.Join(append([]string{TextStepRedact}, standardTextSteps...), ", "))
+		}
+		if seen[name] {
+			return fmt.Errorf("文本处理步骤重复: %s", name)
+		}
+		seen[name] = true
+		ordered = append(ordered, m)
+	}
+	tp.middlewares = ordered
+	return nil
+}
+
+// middlewareIndex 返回步骤在处理链中的位置，不存在时返回-1
+func (tp *TextProcessor) middlewareIndex(name string) int {
+	for i, m := range tp.middlewares {
+		if m.name == name {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+func TestRedactAlwaysRunsFirst(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline []string
+	}{
+		{"默认处理链", nil},
+		{"未列出redact", []string{TextStepMarkdown, TextStepWhitespace}},
+		{"redact放在最后", []string{TextStepSymbols, TextStepWhitespace, TextStepRedact}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := NewTextProcessor()
+			// 规则按原文匹配 "key=" 前缀，若符号步骤先执行，"=" 会被改写为读法导致漏匹配
+			if err := tp.SetRedactRules([]model.RedactRule{{Pattern: `key = sk-\w+`, Replace: "密钥已隐藏"}}); err != nil {
+				t.Fatal(err)
+			}
+			if err := tp.SetMiddlewareOrder(tt.pipeline); err != nil {
+				t.Fatal(err)
+			}
+			if got := tp.ProcessText("配置 key = sk-abc123 即可"); strings.Contains(got, "abc123") || !strings.Contains(got, "密钥已隐藏") {
+				t.Errorf("ProcessText = %q，敏感内容未脱敏", got)
+			}
+			if names := tp.Middlewares(); names[0] != TextStepRedact || strings.Count(strings.Join(names, ","), TextStepRedact) != 1 {
+				t.Errorf("Middlewares = %v", names)
+			}
+		})
+	}
+}
+
+func TestSetMiddlewareOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline []string
+		want     string
+		wantErr  bool
+	}{
+		{"默认顺序", nil, "redact,non_speech,escape,markdown,emoji,symbols,whitespace,mixed_language,brackets", false},
+		{"重排", []string{"whitespace", "markdown"}, "redact,whitespace,markdown", false},
+		{"忽略redact", []string{"redact", "brackets"}, "redact,brackets", false},
+		{"未知步骤", []string{"markdown", "spell"}, "", true},
+		{"重复步骤", []string{"markdown", "markdown"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := NewTextProcessor()
+			err := tp.SetMiddlewareOrder(tt.pipeline)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SetMiddlewareOrder(%v) err = %v, wantErr %v", tt.pipeline, err, tt.wantErr)
+			}
+			if !tt.wantErr && strings.Join(tp.Middlewares(), ",") != tt.want {
+				t.Errorf("Middlewares = %v, want %s", tp.Middlewares(), tt.want)
+			}
+		})
+	}
+}
+
+func TestInsertMiddleware(t *testing.T) {
+	upper := func(text string) string { return strings.ToUpper(text) }
+	tests := []struct {
+		name    string
+		insert  func(tp *TextProcessor) error
+		first   string
+		wantErr bool
+	}{
+		{"插在redact之后", func(tp *TextProcessor) error { return tp.InsertAfter(TextStepRedact, "upper", upper) }, "upper", false},
+		{"插在redact之前", func(tp *TextProcessor) error { return tp.InsertBefore(TextStepRedact, "upper", upper) }, "", true},
+		{"插在标准步骤之前", func(tp *TextProcessor) error { return tp.InsertBefore(TextStepNonSpeech, "upper", upper) }, "upper", false},
+		{"名称与redact相同", func(tp *TextProcessor) error { return tp.InsertAfter(TextStepMarkdown, TextStepRedact, upper) }, "", true},
+		{"未知步骤", func(tp *TextProcessor) error { return tp.InsertAfter("spell", "upper", upper) }, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tp := NewTextProcessor()
+			err := tt.insert(tp)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && tp.Middlewares()[1] != tt.first {
+				t.Errorf("Middlewares = %v", tp.Middlewares())
+			}
+		})
+	}
+}
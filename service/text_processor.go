@@ -1,6 +1,12 @@
 package service
 
 import (
+	"context"
+	"fmt"
+	"github.com/difyz9/markdown2tts/model"
+	"io"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"unicode"
@@ -12,78 +18,258 @@ type TextProcessor struct {
 	preserveMarkdown     bool
 	normalizeWhitespace  bool
 	handleSpecialSymbols bool
-	markdownProcessor    *MarkdownProcessor // 新增：专业的Markdown处理器
+	mixedLanguageSpacing bool                         // 是否在中英文边界插入空格
+	plainText            bool                         // 纯文本输入：不做Markdown去格式，* # | 等按正文保留
+	readImageAlt         bool                         // 是否朗读图片alt描述
+	mathMode             string                       // 数学公式处理模式
+	bracketMode          string                       // 括号补充说明处理模式
+	linkMode             string                       // 链接处理模式
+	urlMode              string                       // 裸URL处理模式
+	emojiMode            string                       // emoji处理模式
+	shortWords           map[string]bool              // 允许朗读的短词白名单
+	symbolLanguage       string                       // 符号读法表的语言
+	symbolOverrides      map[string]map[string]string // 外置符号读法表：语言 → 符号 → 读法
+	symbolRules          []symbolRule                 // 当前语言的符号替换规则
+	redactor             *redactor                    // 脱敏规则，nil表示不脱敏
+	ssml                 bool                         // SSML模式：只清洗标签之间的文本，保留标签
+	middlewares          []namedMiddleware            // 文本处理链，ProcessText 按顺序执行
+	markdownProcessor    *MarkdownProcessor           // 新增：专业的Markdown处理器
 }
 
+// imageAltPrefix 朗读图片alt描述时使用的前缀
+const imageAltPrefix = "图片："
+
+// 数学公式处理模式
+const (
+	MathModeKeep        = "keep"        // 保持原样（默认）
+	MathModeRemove      = "remove"      // 整体移除
+	MathModePlaceholder = "placeholder" // 替换为"（公式）"占位
+)
+
+// 括号补充说明处理模式（书名号《》内容始终原样保留）
+const (
+	BracketModePause  = "pause"  // 前后插入逗号停顿（默认）
+	BracketModeKeep   = "keep"   // 保持原样
+	BracketModeRemove = "remove" // 不朗读括号内的补充说明
+)
+
+// 链接处理模式
+const (
+	LinkModeText   = "text"   // 只朗读链接文本（默认）
+	LinkModeDomain = "domain" // 朗读链接文本并附上域名，如"文档，链接到 example.com"
+	LinkModeRemove = "remove" // 链接整体不朗读
+)
+
+// 裸URL处理模式
+const (
+	URLModeRemove = "remove" // 整条删除（默认）
+	URLModeDomain = "domain" // 去掉协议和路径，读作域名，如"example 点 com"
+)
+
+// emoji处理模式
+const (
+	EmojiModeRemove   = "remove"   // 移除，以emoji开头的行整行跳过（默认）
+	EmojiModeDescribe = "describe" // 已知emoji读作中文描述（如 🚀 读作"火箭"），未知的移除
+	EmojiModeKeep     = "keep"     // 保持原样，交给TTS引擎处理
+)
+
+// urlDotReadings 朗读域名时 . 的读法，未收录的语言读作"点"
+var urlDotReadings = map[string]string{
+	langChinese: "点",
+	langEnglish: "dot",
+}
+
+// linkDomainPrefix 朗读链接域名时使用的前缀
+const linkDomainPrefix = "链接到 "
+
+// 输入类型，决定清洗力度
+const (
+	InputTypeAuto     = "auto"     // 按输入文件扩展名选择（默认）
+	InputTypeMarkdown = "markdown" // Markdown：去除格式标记、读出特殊符号
+	InputTypePlain    = "plain"    // 纯文本：保留 * # | 等字符，不做Markdown处理
+)
+
+// htmlCommentRegex HTML注释 <!-- ... -->
+var htmlCommentRegex = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// mathPlaceholder 公式占位读法
+const mathPlaceholder = "（公式）"
+
 // NewTextProcessor 创建新的文本处理器
 func NewTextProcessor() *TextProcessor {
-	return &TextProcessor{
+	tp := &TextProcessor{
 		preserveMarkdown:     true,
 		normalizeWhitespace:  true,
 		handleSpecialSymbols: true,
+		mixedLanguageSpacing: true,
+		emojiMode:            EmojiModeRemove,
 		markdownProcessor:    NewMarkdownProcessor(), // 初始化Markdown处理器
 	}
+	tp.middlewares = tp.standardMiddlewares()
+	tp.SetSymbolLanguage(langChinese)
+	return tp
 }
 
-// ProcessText 处理文本，优化TTS语音合成效果
-func (tp *TextProcessor) ProcessText(text string) string {
-	if text == "" {
-		return text
-	}
+// NewTextProcessorFromConfig 根据配置创建文本处理器
+func NewTextProcessorFromConfig(config *model.Config) *TextProcessor {
+	return newTextProcessorForVoice(config, "")
+}
 
-	// 1. 移除Markdown中不需要语音合成的内容（代码块、表格、图片、链接等）
-	text = tp.removeNonSpeechElements(text)
+// newTextProcessorForVoice 根据配置创建文本处理器，symbol_language 未配置时按音色语言选择符号读法表
+func newTextProcessorForVoice(config *model.Config, voiceLang string) *TextProcessor {
+	tp := NewTextProcessor()
+	if config == nil {
+		return tp
+	}
 
-	// 2. 处理转义字符（需要在Markdown处理之前）
-	text = tp.processEscapeCharacters(text)
+	if err := tp.SetInputType(ResolveInputType(config)); err != nil {
+		Warnf("警告: %v，将按Markdown处理\n", err)
+	}
+	tp.SetReadImageAlt(config.Markdown.ReadImageAlt)
+	tp.SetStripTOC(config.Markdown.StripTOC)
+	tp.SetMixedLanguageSpacing(!config.Markdown.DisableMixedSpacing)
+	if err := tp.SetMathMode(config.Markdown.MathMode); err != nil {
+		Warnf("警告: %v，将保持公式原样\n", err)
+	}
+	if err := tp.SetBracketMode(config.Markdown.BracketMode); err != nil {
+		Warnf("警告: %v，将在括号前后停顿\n", err)
+	}
+	if err := tp.SetLinkMode(config.Markdown.LinkMode); err != nil {
+		Warnf("警告: %v，将只朗读链接文本\n", err)
+	}
+	if err := tp.SetURLMode(config.Markdown.URLMode); err != nil {
+		Warnf("警告: %v，将删除裸URL\n", err)
+	}
+	if err := tp.SetEmojiMode(config.Markdown.EmojiMode); err != nil {
+		Warnf("警告: %v，将移除emoji\n", err)
+	}
+	tp.SetShortWords(config.Markdown.ShortWords)
+	if err := tp.SetRedactRules(config.Markdown.Redact); err != nil {
+		Failf("错误: %v，为避免读出敏感信息将不朗读任何文本\n", err)
+	}
+	if err := tp.SetMarkdownExtensions(config.Markdown.Extensions); err != nil {
+		Warnf("警告: %v，将使用默认Markdown扩展\n", err)
+	}
+	if err := tp.SetMiddlewareOrder(config.Markdown.TextPipeline); err != nil {
+		Warnf("警告: %v，将使用默认文本处理顺序\n", err)
+	}
 
-	// 3. 处理Markdown格式字符
-	if tp.preserveMarkdown {
-		text = tp.processMarkdownFormatting(text)
+	if config.Markdown.SymbolFile != "" {
+		overrides, err := loadSymbolFile(config.Markdown.SymbolFile)
+		if err != nil {
+			Warnf("警告: %v，将使用内置符号读法\n", err)
+		} else {
+			tp.SetSymbolOverrides(overrides)
+		}
+	}
+	symbolLang := strings.ToLower(strings.TrimSpace(config.Markdown.SymbolLanguage))
+	if symbolLang == "" || symbolLang == "auto" {
+		symbolLang = voiceLang
+	} else if !tp.hasSymbolTable(symbolLang) {
+		Warnf("警告: 没有 %s 的符号读法表，将使用中文读法\n", symbolLang)
 	}
+	tp.SetSymbolLanguage(symbolLang)
+	return tp
+}
 
-	// 4. 处理特殊符号
-	if tp.handleSpecialSymbols {
-		text = tp.processSpecialSymbols(text)
+// ResolveInputType 返回实际使用的输入类型：显式配置优先，auto时 .txt 视为纯文本，其他按Markdown处理
+func ResolveInputType(config *model.Config) string {
+	inputType := strings.ToLower(strings.TrimSpace(config.Markdown.InputType))
+	if inputType != "" && inputType != InputTypeAuto {
+		return inputType
+	}
+	if strings.EqualFold(filepath.Ext(config.InputFile), ".txt") {
+		return InputTypePlain
 	}
+	return InputTypeMarkdown
+}
 
-	// 5. 规范化空白字符
-	if tp.normalizeWhitespace {
-		text = tp.normalizeWhitespaceText(text)
+// ProcessText 处理文本，优化TTS语音合成效果
+func (tp *TextProcessor) ProcessText(text string) string {
+	if text == "" {
+		return text
 	}
 
-	// 6. 处理中英文混合文本
-	text = tp.processMixedLanguageText(text)
+	// SSML模式下标签不经过处理链，避免被当作HTML移除或把 < > 读成文字
+	if tp.ssmlText(text) {
+		text = strings.TrimSpace(mapSSMLText(text, func(s string) string {
+			return escapeSSMLText(tp.runMiddlewares(s))
+		}))
+		if strings.TrimSpace(ssmlVisibleText(text)) == "" {
+			return ""
+		}
+		return text
+	}
+	return tp.runMiddlewares(text)
+}
 
-	// 7. 处理各种类型的括号
-	text = tp.processBrackets(text)
+// runMiddlewares 先脱敏，再按顺序执行文本处理链
+func (tp *TextProcessor) runMiddlewares(text string) string {
+	// 脱敏在其他处理之前应用，避免敏感内容被拆分或改写后漏匹配；处理链如何重排都不能跳过
+	text = tp.redactor.apply(text)
 
+	// 默认处理链：移除不朗读的内容 → 转义字符 → Markdown格式 → emoji → 特殊符号 → 空白 → 中英文混排 → 括号
+	for _, m := range tp.middlewares {
+		text = m.fn(text)
+	}
 	return text
 }
 
 // ProcessMarkdownDocument 使用专业Markdown解析器处理整个文档
 func (tp *TextProcessor) ProcessMarkdownDocument(markdown string) []string {
+	sentences, _ := tp.processMarkdownDocument(context.Background(), markdown, nil)
+	return sentences
+}
+
+// processMarkdownDocument 处理Markdown文档，逐句记录进度，context取消时中止
+func (tp *TextProcessor) processMarkdownDocument(ctx context.Context, markdown string, progress *markdownProgress) ([]string, error) {
+	// HTML注释不应朗读，可能跨行，需在解析前整体剔除
+	markdown = htmlCommentRegex.ReplaceAllString(markdown, "")
+
+	// 公式可能跨行，需在解析和分句前整体处理
+	markdown = tp.processMath(markdown)
+
 	// 使用专业的Markdown处理器提取纯文本
 	extractedText := tp.markdownProcessor.ExtractTextForTTS(markdown)
 
 	// 分割成适合TTS的句子
 	sentences := tp.markdownProcessor.SplitIntoSentences(extractedText)
 
-	// 对每个句子进行进一步的文本处理
-	var processedSentences []string
-	for _, sentence := range sentences {
-		if sentence == "" {
-			continue
-		}
+	// 对每个句子进行进一步的文本处理（句子较多时并行）
+	return tp.processSentences(ctx, sentences, progress)
+}
 
-		// 使用现有的文本处理逻辑
-		processed := tp.ProcessText(sentence)
-		if processed != "" && tp.IsValidTextForTTS(processed) {
-			processedSentences = append(processedSentences, processed)
-		}
+// ProcessMarkdownFile 流式读取Markdown文件，按块增量处理，降低超大文件的峰值内存
+func (tp *TextProcessor) ProcessMarkdownFile(path string) ([]string, error) {
+	return tp.ProcessMarkdownFileContext(context.Background(), path)
+}
+
+// ProcessMarkdownFileContext 同 ProcessMarkdownFile，超大文件时输出解析进度，context取消时中止
+func (tp *TextProcessor) ProcessMarkdownFileContext(ctx context.Context, path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开Markdown文件失败: %v", err)
 	}
+	defer file.Close()
 
-	return processedSentences
+	return tp.processMarkdownReader(ctx, file)
+}
+
+// processMarkdownReader 从 r 流式读取Markdown，按块增量提取适合TTS的句子
+func (tp *TextProcessor) processMarkdownReader(ctx context.Context, r io.Reader) ([]string, error) {
+	progress := newMarkdownProgress()
+	var sentences []string
+	err := forEachMarkdownChunk(r, func(chunk string) error {
+		chunkSentences, err := tp.processMarkdownDocument(ctx, chunk, progress)
+		sentences = append(sentences, chunkSentences...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	progress.finish()
+
+	return sentences, nil
 }
 
 // removeNonSpeechElements 移除Markdown中不需要语音合成的元素
@@ -91,6 +277,9 @@ func (tp *TextProcessor) removeNonSpeechElements(text string) string {
 	// 1. 移除代码块（``` 或 ~~~ 包围的内容）
 	text = tp.removeCodeBlocks(text)
 
+	// 处理数学公式（需在符号处理之前，避免 $ 读成"美元"）
+	text = tp.processMath(text)
+
 	// 2. 移除表格
 	text = tp.removeTables(text)
 
@@ -127,6 +316,39 @@ func (tp *TextProcessor) removeCodeBlocks(text string) string {
 	return text
 }
 
+// processMath 按配置移除数学公式或替换为占位读法
+func (tp *TextProcessor) processMath(text string) string {
+	if tp.mathMode == "" || tp.mathMode == MathModeKeep {
+		return text
+	}
+
+	replacement := ""
+	if tp.mathMode == MathModePlaceholder {
+		replacement = mathPlaceholder
+	}
+
+	// 块级公式 $$...$$
+	blockMathRegex := regexp.MustCompile(`(?s)\$\$.+?\$\$`)
+	text = blockMathRegex.ReplaceAllString(text, replacement)
+
+	// 行内公式 $...$：内容首尾不能是空白，避免误伤"$5 和 $10"这类价格
+	inlineMathRegex := regexp.MustCompile(`\$(?:[^\s$]|[^\s$][^$\n]*?[^\s$])\$`)
+	var result strings.Builder
+	last := 0
+	for _, loc := range inlineMathRegex.FindAllStringIndex(text, -1) {
+		// 结束符后紧跟数字时更可能是价格，如"$5和$10"
+		if loc[1] < len(text) && text[loc[1]] >= '0' && text[loc[1]] <= '9' {
+			continue
+		}
+		result.WriteString(text[last:loc[0]])
+		result.WriteString(replacement)
+		last = loc[1]
+	}
+	result.WriteString(text[last:])
+
+	return result.String()
+}
+
 // removeTables 移除Markdown表格
 func (tp *TextProcessor) removeTables(text string) string {
 	// 移除Markdown表格（包含 | 分隔符的行）
@@ -167,7 +389,18 @@ func (tp *TextProcessor) removeTables(text string) string {
 func (tp *TextProcessor) removeImages(text string) string {
 	// 移除Markdown图片格式 ![alt](url) 或 ![alt](url "title")
 	imageRegex := regexp.MustCompile(`!\[([^\]]*)\]\([^)]+\)`)
-	text = imageRegex.ReplaceAllString(text, "")
+	if tp.readImageAlt {
+		// 保留alt描述用于朗读
+		text = imageRegex.ReplaceAllStringFunc(text, func(match string) string {
+			alt := strings.TrimSpace(imageRegex.FindStringSubmatch(match)[1])
+			if alt == "" {
+				return ""
+			}
+			return imageAltPrefix + alt + "。"
+		})
+	} else {
+		text = imageRegex.ReplaceAllString(text, "")
+	}
 
 	// 移除HTML img标签
 	htmlImageRegex := regexp.MustCompile(`(?i)<img[^>]*>`)
@@ -176,15 +409,37 @@ func (tp *TextProcessor) removeImages(text string) string {
 	return text
 }
 
+// linkDefinitionRegex 引用式链接定义行：目标必须像链接（带协议、以 / . # 开头或用 <> 包围），可带标题
+// "[注]: 这里是说明" 之类的普通文本不算定义，仍然朗读；脚注定义 [^1]: 由脚注处理
+var linkDefinitionRegex = regexp.MustCompile(`(?m)^[ \t]{0,3}\[[^\]^\n][^\]\n]*\]:[ \t]*` +
+	`(?:<[^>\n]*>|[a-zA-Z][a-zA-Z0-9+.-]*://\S+|mailto:\S+|www\.\S+|\.{0,2}/\S*|#\S*)` +
+	`(?:[ \t]+(?:"[^"\n]*"|'[^'\n]*'|\([^)\n]*\)))?[ \t]*$`)
+
 // processLinks 处理链接（保留链接文本，移除URL）
 func (tp *TextProcessor) processLinks(text string) string {
-	// 处理Markdown链接格式 [text](url)，保留text部分
-	linkRegex := regexp.MustCompile(`\[([^\]]+)\]\([^)]+\)`)
-	text = linkRegex.ReplaceAllString(text, "$1")
+	// 处理脚注：定义行 [^1]: 说明 保留说明文本，正文中的引用标记 [^1] 移除
+	footnoteDefRegex := regexp.MustCompile(`(?m)^\s{0,3}\[\^[^\]]+\]:\s*`)
+	text = footnoteDefRegex.ReplaceAllString(text, "")
+	footnoteRefRegex := regexp.MustCompile(`\[\^[^\]]+\]`)
+	text = footnoteRefRegex.ReplaceAllString(text, "")
+
+	// 移除引用式链接定义行 [1]: http://example.com "title"
+	text = linkDefinitionRegex.ReplaceAllString(text, "")
+
+	// 还原引用式链接 [text][id] 和 [text][] 为 text
+	refLinkRegex := regexp.MustCompile(`\[([^\]]+)\]\[[^\]]*\]`)
+	text = refLinkRegex.ReplaceAllString(text, "$1")
+
+	// 处理Markdown链接格式 [text](url)，按链接模式保留text部分
+	linkRegex := regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)[^)]*\)`)
+	text = linkRegex.ReplaceAllStringFunc(text, func(match string) string {
+		parts := linkRegex.FindStringSubmatch(match)
+		return spokenLink(tp.linkMode, parts[1], parts[2])
+	})
 
-	// 移除纯URL（http://、https://、ftp://、www.）
+	// 移除纯URL（http://、https://、ftp://、www.），url_mode 为 domain 时读作域名
 	urlRegex := regexp.MustCompile(`https?://[^\s]+|ftp://[^\s]+|www\.[^\s]+`)
-	text = urlRegex.ReplaceAllString(text, "")
+	text = urlRegex.ReplaceAllStringFunc(text, tp.spokenURL)
 
 	// 移除邮箱地址
 	emailRegex := regexp.MustCompile(`[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}`)
@@ -193,10 +448,14 @@ func (tp *TextProcessor) processLinks(text string) string {
 	return text
 }
 
+// htmlTagPattern HTML标签（含注释和声明）：尖括号后必须是标签名、/ 或 !
+// 对话中"<好的！>"、公式中"a < b > c"这类尖括号不是标签，不能被移除
+const htmlTagPattern = `</?[a-zA-Z!][^<>]*>`
+
 // removeHTMLTags 移除HTML标签
 func (tp *TextProcessor) removeHTMLTags(text string) string {
 	// 移除HTML标签但保留内容
-	htmlTagRegex := regexp.MustCompile(`<[^>]*>`)
+	htmlTagRegex := regexp.MustCompile(htmlTagPattern)
 	text = htmlTagRegex.ReplaceAllString(text, "")
 
 	// 移除HTML实体
@@ -339,85 +598,66 @@ func (tp *TextProcessor) processMarkdownFormatting(text string) string {
 	return text
 }
 
+// escapeCharacterReplacer 转义字符替换表
+var escapeCharacterReplacer = strings.NewReplacer(
+	`\*`, "*", // 转义的星号
+	`\\`, "\\", // 转义的反斜杠
+	`\n`, " ", // 换行符转为空格
+	`\t`, " ", // 制表符转为空格
+	`\r`, "", // 回车符删除
+	`\"`, "\"", // 转义的双引号
+	`\'`, "'", // 转义的单引号
+	`\&`, "&", // 转义的&符号
+	`\#`, "#", // 转义的#符号
+	`\%`, "%", // 转义的%符号
+	`\$`, "$", // 转义的$符号
+	`\@`, "@", // 转义的@符号
+	`\!`, "!", // 转义的!符号
+	`\?`, "?", // 转义的?符号
+	`\+`, "+", // 转义的+符号
+	`\=`, "=", // 转义的=符号
+	`\-`, "-", // 转义的-符号
+	`\_`, "_", // 转义的下划线
+	`\^`, "^", // 转义的^符号
+	`\~`, "~", // 转义的~符号
+	`\|`, "|", // 转义的|符号
+	`\>`, ">", // 转义的>符号
+	`\<`, "<", // 转义的<符号
+	`\{`, "{", // 转义的{符号
+	`\}`, "}", // 转义的}符号
+	`\[`, "[", // 转义的[符号
+	`\]`, "]", // 转义的]符号
+	`\(`, "(", // 转义的(符号
+	`\)`, ")", // 转义的)符号
+)
+
 // processEscapeCharacters 处理转义字符
 func (tp *TextProcessor) processEscapeCharacters(text string) string {
-	// 处理常见的转义序列
-	replacements := map[string]string{
-		`\*`: "*",  // 转义的星号
-		`\\`: "\\", // 转义的反斜杠
-		`\n`: " ",  // 换行符转为空格
-		`\t`: " ",  // 制表符转为空格
-		`\r`: "",   // 回车符删除
-		`\"`: "\"", // 转义的双引号
-		`\'`: "'",  // 转义的单引号
-		`\&`: "&",  // 转义的&符号
-		`\#`: "#",  // 转义的#符号
-		`\%`: "%",  // 转义的%符号
-		`\$`: "$",  // 转义的$符号
-		`\@`: "@",  // 转义的@符号
-		`\!`: "!",  // 转义的!符号
-		`\?`: "?",  // 转义的?符号
-		`\+`: "+",  // 转义的+符号
-		`\=`: "=",  // 转义的=符号
-		`\-`: "-",  // 转义的-符号
-		`\_`: "_",  // 转义的下划线
-		`\^`: "^",  // 转义的^符号
-		`\~`: "~",  // 转义的~符号
-		`\|`: "|",  // 转义的|符号
-		`\>`: ">",  // 转义的>符号
-		`\<`: "<",  // 转义的<符号
-		`\{`: "{",  // 转义的{符号
-		`\}`: "}",  // 转义的}符号
-		`\[`: "[",  // 转义的[符号
-		`\]`: "]",  // 转义的]符号
-		`\(`: "(",  // 转义的(符号
-		`\)`: ")",  // 转义的)符号
-
-	}
-
-	for escaped, unescaped := range replacements {
-		text = strings.ReplaceAll(text, escaped, unescaped)
-	}
-
-	return text
+	// 处理常见的转义序列（单次扫描替换，结果与遍历顺序无关）
+	return escapeCharacterReplacer.Replace(text)
 }
 
-// processSpecialSymbols 处理特殊符号
+// markdownMarkupSymbols Markdown格式标记符号，纯文本输入中按正文保留
+var markdownMarkupSymbols = map[string]bool{"*": true, "#": true, "|": true, "_": true, "~": true}
+
+// processSpecialSymbols 处理特殊符号（emoji在单独的 emoji 步骤中处理）
 func (tp *TextProcessor) processSpecialSymbols(text string) string {
-	// 首先处理emoji符号
-	text = tp.processRemoveEmojis(text)
-
-	// 为一些特殊符号添加适当的语音停顿或读法
-	// 只有当符号独立存在且不在常见上下文中时才替换
-	symbolReplacements := map[string]string{
-		"@": "at",
-		"#": "",
-		"$": "美元",
-		"%": "百分号",
-		"^": "",
-		"&": "",
-		"*": "",
-		"+": "加",
-		"=": "等于",
-		"|": "",
-		"~": "",
-		"`": "",
-
-		"<": "小于",
-		">": "大于",
-		"[": "左方括号",
-		"]": "右方括号",
-		"{": "左大括号",
-		"}": "右大括号",
-	}
-
-	// 只替换独立的符号，避免破坏有意义的文本
-	for symbol, replacement := range symbolReplacements {
+	// SSML模式下只处理标签之间的文本，标签属性中的 = " 等不读成文字
+	if tp.ssmlText(text) {
+		return mapSSMLText(text, tp.processSpecialSymbols)
+	}
+
+	// 为一些特殊符号添加适当的语音停顿或读法（读法表按语言选择，可通过 symbol_file 外置覆盖）
+	// 只有当符号独立存在且不在常见上下文中时才替换，规则有序，保证替换顺序固定
+	for _, rule := range tp.symbolRules {
+		symbol, replacement := rule.symbol, rule.reading
+		// 纯文本中的 * # | 等按正文保留，不当作Markdown标记删除
+		if tp.plainText && markdownMarkupSymbols[symbol] {
+			continue
+		}
 		// 更精确的匹配：符号前后必须是空格、标点或字符串边界
 		// 但要避免替换有意义的组合，如邮箱、网址、价格等
-		pattern := `(\s|^)` + regexp.QuoteMeta(symbol) + `(\s|$)`
-		regex := regexp.MustCompile(pattern)
-		text = regex.ReplaceAllStringFunc(text, func(match string) string {
+		text = rule.pattern.ReplaceAllStringFunc(text, func(match string) string {
 			// 检查是否在特殊上下文中（如邮箱、网址、价格等）
 			if tp.isInSpecialContext(text, symbol, match) {
 				return match // 保持原样
@@ -467,7 +707,8 @@ func (tp *TextProcessor) normalizeWhitespaceText(text string) string {
 
 // processMixedLanguageText 处理中英文混合文本
 func (tp *TextProcessor) processMixedLanguageText(text string) string {
-	var result strings.Builder
+	result := getTextBuffer()
+	defer putTextBuffer(result)
 	runes := []rune(text)
 
 	for i, r := range runes {
@@ -495,30 +736,45 @@ func (tp *TextProcessor) processMixedLanguageText(text string) string {
 	return result.String()
 }
 
-// processBrackets 处理各种类型的括号
+// bracketNoteRegex 中文圆括号、英文圆括号、中文方头括号内的补充说明，括号须成对且不含嵌套
+// 英文圆括号只在内容含有汉字时才算补充说明，f(x)、(1)、API(v2) 等原样保留
+var bracketNoteRegex = regexp.MustCompile(`（([^（）]+)）|\(([^()]+)\)|【([^【】]+)】`)
+
+// duplicatePauseRegex 插入停顿后与原有标点相邻的多余逗号
+var duplicatePauseRegex = regexp.MustCompile(`，\s*([，。！？；：、,.!?;:])`)
+
+// processBrackets 按括号模式处理补充说明，默认前后插入逗号停顿，如"张三（经理）说"读作"张三，经理，说"
+// 书名号《》和引号属于正文内容，不做处理
 func (tp *TextProcessor) processBrackets(text string) string {
-	// 处理括号内容，为TTS添加适当的语调标记
-	bracketPatterns := map[string][2]string{
-		`（([^）]+）)`:    {"（", "）"},   // 中文括号
-		`\(([^)]+)\)`:  {"(", ")"},   // 英文括号
-		`【([^】]+】)`:    {"【", "】"},   // 中文方括号
-		`\[([^\]]+)\]`: {"[", "]"},   // 英文方括号
-		`《([^》]+》)`:    {"《", "》"},   // 中文书名号
-		`"([^"]+")`:    {"\"", "\""}, // 中文双引号
-		`'([^']+')`:    {"'", "'"},   // 中文单引号
-		`"([^"]+)"`:    {"\"", "\""}, // 英文双引号
-		`'([^']+)'`:    {"'", "'"},   // 英文单引号
-	}
-
-	for pattern := range bracketPatterns {
-		regex := regexp.MustCompile(pattern)
-		// 保持括号内容不变，只是确保括号周围有适当的停顿
-		text = regex.ReplaceAllStringFunc(text, func(match string) string {
-			return match // 保持原样，让TTS自然处理
-		})
+	if tp.bracketMode == BracketModeKeep || !bracketNoteRegex.MatchString(text) {
+		return text
 	}
 
-	return text
+	text = bracketNoteRegex.ReplaceAllStringFunc(text, func(match string) string {
+		if strings.HasPrefix(match, "(") && !strings.ContainsFunc(match, tp.isChinese) {
+			return match
+		}
+		if tp.bracketMode == BracketModeRemove {
+			return ""
+		}
+
+		var note string
+		for _, group := range bracketNoteRegex.FindStringSubmatch(match)[1:] {
+			if group != "" {
+				note = strings.TrimSpace(group)
+				break
+			}
+		}
+		if note == "" {
+			return ""
+		}
+		return "，" + note + "，"
+	})
+
+	// 清理与原有标点重复的停顿，以及句首句尾多余的逗号
+	text = duplicatePauseRegex.ReplaceAllString(text, "$1")
+	text = strings.ReplaceAll(text, "，，", "，")
+	return strings.TrimSuffix(strings.TrimPrefix(text, "，"), "，")
 }
 
 // isChinese 判断是否为中文字符
@@ -531,60 +787,110 @@ func (tp *TextProcessor) isEnglish(r rune) bool {
 	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
 }
 
+// degradedPunctuation 降级清洗时保留的标点
+const degradedPunctuation = "，。！？、；：,.!?;:"
+
+// DegradeText 激进清洗文本：只保留字母、数字、中文和常用标点，用于合成失败后的降级重试
+func (tp *TextProcessor) DegradeText(text string) string {
+	var builder strings.Builder
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			builder.WriteRune(r)
+		case strings.ContainsRune(degradedPunctuation, r):
+			builder.WriteRune(r)
+		default:
+			builder.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
 // IsValidTextForTTS 检查文本是否适合TTS处理
 func (tp *TextProcessor) IsValidTextForTTS(text string) bool {
+	return tp.FilterReason(text) == ""
+}
+
+// FilterReason 返回文本被过滤的原因，文本可以合成时返回空字符串
+func (tp *TextProcessor) FilterReason(text string) string {
 	text = strings.TrimSpace(text)
 
 	// 空文本
 	if text == "" {
-		return false
+		return FilterReasonEmpty
 	}
 
-	// 检查是否以emoji开头，如果是则跳过不参与语音合成
-	if tp.startsWithEmoji(text) {
-		return false
+	// 检查是否以emoji开头，如果是则跳过不参与语音合成（emoji读作描述或保留时不跳过）
+	if tp.emojiMode == EmojiModeRemove && tp.startsWithEmoji(text) {
+		return FilterReasonEmoji
+	}
+
+	// 检查是否为纯URL或邮箱
+	if tp.isPureURL(text) {
+		return FilterReasonURL
+	}
+
+	// 代码块、表格、图片、链接定义和纯标记行只在Markdown输入中过滤
+	if !tp.plainText && tp.isMarkdownOnlyLine(text) {
+		return FilterReasonMarkdown
 	}
 
-	// 检查是否为代码块
+	// 太短的文本（少于2个字符）：单个汉字、数字或白名单中的词仍视为有效
+	if len([]rune(text)) < 2 {
+		if tp.isAllowedShortWord(text) {
+			return ""
+		}
+		return FilterReasonTooShort
+	}
+
+	// 检查是否包含有效内容（至少有一个字母、数字或中文字符）
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || tp.isChinese(r) {
+			return ""
+		}
+	}
+
+	return FilterReasonNoContent
+}
+
+// isMarkdownOnlyLine 检查是否为不需要朗读的Markdown结构行
+func (tp *TextProcessor) isMarkdownOnlyLine(text string) bool {
+	// 代码块
 	if tp.isCodeBlock(text) {
-		return false
+		return true
 	}
 
-	// 检查是否为表格行
+	// 表格行
 	if tp.isTableRow(text) || tp.isTableSeparator(text) {
-		return false
+		return true
 	}
 
-	// 检查是否为图片
-	if tp.isImage(text) {
-		return false
+	// 图片（开启alt朗读时由removeImages处理）
+	if !tp.readImageAlt && tp.isImage(text) {
+		return true
 	}
 
-	// 检查是否为纯URL或邮箱
-	if tp.isPureURL(text) {
-		return false
+	// 引用式链接定义行（如 [1]: http://...）
+	if tp.isLinkDefinition(text) {
+		return true
 	}
 
 	// 纯标记行（如 ###、**、-----）
-	if tp.isPureMarkupLine(text) {
-		return false
-	}
+	return tp.isPureMarkupLine(text)
+}
 
-	// 太短的文本（少于2个字符）
-	if len([]rune(text)) < 2 {
-		return false
+// isAllowedShortWord 判断单字符文本是否可以朗读
+// 列表/对话中的"是"、"好"、"1"是有效内容，单个无意义符号仍然过滤
+func (tp *TextProcessor) isAllowedShortWord(text string) bool {
+	if tp.shortWords[text] {
+		return true
 	}
-
-	// 检查是否包含有效内容（至少有一个字母、数字或中文字符）
-	hasValidContent := false
 	for _, r := range text {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) || tp.isChinese(r) {
-			hasValidContent = true
-			break
+		if !tp.isChinese(r) && !unicode.IsDigit(r) {
+			return false
 		}
 	}
-
-	return hasValidContent
+	return text != ""
 }
 
 // isCodeBlock 检查是否为代码块
@@ -675,33 +981,36 @@ func (tp *TextProcessor) isPureURL(text string) bool {
 	return false
 }
 
+// isLinkDefinition 检查是否为引用式链接定义行（脚注定义 [^1]: 除外，其说明文本需要朗读）
+func (tp *TextProcessor) isLinkDefinition(text string) bool {
+	return linkDefinitionRegex.MatchString(strings.TrimSpace(text))
+}
+
 // isPureMarkupLine 检查是否为纯标记行
 func (tp *TextProcessor) isPureMarkupLine(text string) bool {
 	text = strings.TrimSpace(text)
 
 	// 检查各种标记格式
 	markupPatterns := []string{
-		`^#+\s*$`,                   // 纯井号
-		`^\*+\s*$`,                  // 纯星号
-		`^-+\s*$`,                   // 纯破折号
-		`^=+\s*$`,                   // 纯等号
-		`^_+\s*$`,                   // 纯下划线
-		`^#+[^a-zA-Z\p{Han}]*$`,     // 井号加非字母内容
-		`^\*{3,}[^a-zA-Z\p{Han}]*$`, // 三个或更多星号加非字母内容
-		`^-{3,}[^a-zA-Z\p{Han}]*$`,  // 三个或更多破折号加非字母内容
-		`^##.*$`,                    // 以 ## 开头的行（Markdown 标题）
-		`^\*\*\(.*$`,                // 以 **( 开头的行（格式化说明）
-		`^---.*$`,                   // 以 --- 开头的行（分割线）
-		`^-----.*$`,                 // 以 ----- 开头的行（分割线）
-		`^\|[-:|\\s]+\|$`,           // 表格分隔符行
-		`^>\s*$`,                    // 空引用块
-		`^[-*+]\s*$`,                // 空列表项
-		`^\d+\.\s*$`,                // 空有序列表项
-		`^[-*+]\s*\[[\sx]\]\s*$`,    // 空任务列表项
-		`^\s*` + "`" + `{3}\s*$`,    // 代码块开始/结束标记
-		`^\s*~{3}\s*$`,              // 代码块开始/结束标记（波浪号）
-		`^<!--.*-->$`,               // HTML注释
-		`^<[^>]+>\s*$`,              // 单独的HTML标签
+		`^#+\s*$`,                           // 纯井号
+		`^\*+\s*$`,                          // 纯星号
+		`^-+\s*$`,                           // 纯破折号
+		`^=+\s*$`,                           // 纯等号
+		`^_+\s*$`,                           // 纯下划线
+		`^#+[^a-zA-Z\p{Han}]*$`,             // 井号加非字母内容
+		`^\*{3,}[^a-zA-Z\p{Han}]*$`,         // 三个或更多星号加非字母内容
+		`^-{3,}[^a-zA-Z\p{Han}]*$`,          // 三个或更多破折号加非字母内容
+		`^##.*$`,                            // 以 ## 开头的行（Markdown 标题）
+		`^\*\*\([^)]*\)?[^a-zA-Z\p{Han}]*$`, // 整行的 **(...) 格式化说明，括号后有文字时保留
+		`^\|[-:|\\s]+\|$`,                   // 表格分隔符行
+		`^>\s*$`,                            // 空引用块
+		`^[-*+]\s*$`,                        // 空列表项
+		`^\d+\.\s*$`,                        // 空有序列表项
+		`^[-*+]\s*\[[\sx]\]\s*$`,            // 空任务列表项
+		`^\s*` + "`" + `{3}\s*$`,            // 代码块开始/结束标记
+		`^\s*~{3}\s*$`,                      // 代码块开始/结束标记（波浪号）
+		`^<!--.*-->$`,                       // HTML注释
+		`^(` + htmlTagPattern + `)+\s*$`,    // 单独的HTML标签
 	}
 
 	for _, pattern := range markupPatterns {
@@ -720,253 +1029,159 @@ func (tp *TextProcessor) SetOptions(preserveMarkdown, normalizeWhitespaceOpt, ha
 	tp.handleSpecialSymbols = handleSpecialSymbols
 }
 
-// processRemoveEmojis 处理emoji符号，将其完全移除不参与语音合成
-func (tp *TextProcessor) processRemoveEmojis(text string) string {
-	// 使用正则表达式移除所有emoji符号
-	// 这个正则表达式匹配大部分Unicode emoji范围
-	emojiRegex := regexp.MustCompile(`[\x{1F600}-\x{1F64F}]|[\x{1F300}-\x{1F5FF}]|[\x{1F680}-\x{1F6FF}]|[\x{1F1E0}-\x{1F1FF}]|[\x{2600}-\x{26FF}]|[\x{2700}-\x{27BF}]|[\x{1F900}-\x{1F9FF}]|[\x{1F018}-\x{1F270}]|[\x{238C}-\x{2454}]|[\x{20D0}-\x{20FF}]|[\x{FE0F}]`)
-	text = emojiRegex.ReplaceAllString(text, "")
+// SetInputType 按输入类型调整清洗力度：plain 不做Markdown去格式和符号读法替换
+func (tp *TextProcessor) SetInputType(inputType string) error {
+	switch inputType {
+	case "", InputTypeAuto, InputTypeMarkdown:
+		tp.plainText = false
+		tp.preserveMarkdown = true
+		tp.handleSpecialSymbols = true
+		return nil
+	case InputTypePlain:
+		// 符号读法照常处理，只跳过 * # | _ ~ 这类Markdown标记符号
+		tp.plainText = true
+		tp.preserveMarkdown = false
+		tp.handleSpecialSymbols = true
+		return nil
+	default:
+		return fmt.Errorf("未知的输入类型: %s（可选: auto, markdown, plain）", inputType)
+	}
+}
 
-	// 移除变体选择器（Variation Selectors）- 用于emoji样式
-	variationSelectors := regexp.MustCompile(`[\x{FE00}-\x{FE0F}]`)
-	text = variationSelectors.ReplaceAllString(text, "")
+// SetMixedLanguageSpacing 设置是否在中英文边界插入空格（默认开启）
+func (tp *TextProcessor) SetMixedLanguageSpacing(enabled bool) {
+	tp.mixedLanguageSpacing = enabled
+}
 
-	// 移除零宽度连接符（Zero Width Joiner）- 用于组合emoji
-	zwj := regexp.MustCompile(`\x{200D}`)
-	text = zwj.ReplaceAllString(text, "")
+// SetReadImageAlt 设置是否朗读图片的alt描述文本
+func (tp *TextProcessor) SetReadImageAlt(enabled bool) {
+	tp.readImageAlt = enabled
+	tp.markdownProcessor.SetReadImageAlt(enabled)
+}
 
-	// 移除更多emoji范围
-	moreEmojis := regexp.MustCompile(`[\x{1F170}-\x{1F251}]|[\x{1F004}\x{1F0CF}]|[\x{1F18E}]|[\x{3030}\x{303D}]|[\x{3297}\x{3299}]|[\x{1F201}-\x{1F202}]|[\x{1F21A}\x{1F22F}]|[\x{1F232}-\x{1F236}]|[\x{1F238}-\x{1F23A}]|[\x{1F250}-\x{1F251}]`)
-	text = moreEmojis.ReplaceAllString(text, "")
+// SetStripTOC 设置是否剔除Markdown中的目录区块
+func (tp *TextProcessor) SetStripTOC(enabled bool) {
+	tp.markdownProcessor.SetStripTOC(enabled)
+}
 
-	// 移除表情符号修饰符（Skin tone modifiers）
-	skinToneModifiers := regexp.MustCompile(`[\x{1F3FB}-\x{1F3FF}]`)
-	text = skinToneModifiers.ReplaceAllString(text, "")
+// SetMathMode 设置数学公式处理模式（keep/remove/placeholder）
+func (tp *TextProcessor) SetMathMode(mode string) error {
+	switch mode {
+	case "", MathModeKeep, MathModeRemove, MathModePlaceholder:
+		tp.mathMode = mode
+		return nil
+	default:
+		return fmt.Errorf("未知的公式处理模式: %s（可选: keep, remove, placeholder）", mode)
+	}
+}
 
-	return text
+// SetBracketMode 设置括号补充说明的处理模式：pause（默认）/keep/remove
+func (tp *TextProcessor) SetBracketMode(mode string) error {
+	switch mode {
+	case "", BracketModePause, BracketModeKeep, BracketModeRemove:
+		tp.bracketMode = mode
+		return nil
+	default:
+		return fmt.Errorf("未知的括号处理模式: %s（可选: pause, keep, remove）", mode)
+	}
 }
 
-//
-//// processEmojis 处理emoji符号，将其转换为对应的中文描述或移除
-//func (tp *TextProcessor) processEmojis(text string) string {
-//	// 常见emoji符号映射表
-//	emojiReplacements := map[string]string{
-//		"🚀": "火箭",
-//		"❤️": "红心",
-//		"💖": "爱心",
-//		"💯": "满分",
-//		"👍": "点赞",
-//		"👎": "点踩",
-//		"👌": "OK",
-//		"✨": "闪亮",
-//		"🌟": "亮星",
-//		"🔥": "火焰",
-//		"💡": "灯泡",
-//		"🎉": "庆祝",
-//		"🎊": "彩带",
-//		"🎈": "气球",
-//		"🎁": "礼物",
-//		"📝": "记录",
-//		"📋": "清单",
-//		"📊": "图表",
-//		"📈": "上升",
-//		"📉": "下降",
-//		"💼": "公文包",
-//		"🔨": "锤子",
-//		"⚡": "闪电",
-//		"🌈": "彩虹",
-//		"☀️": "太阳",
-//		"🌙": "月亮",
-//		"⭐": "星星",
-//		"🌍": "地球",
-//		"🚨": "警报",
-//		"⚠️": "警告",
-//		"❌": "错误",
-//		"✅": "正确",
-//		"✔️": "勾选",
-//		"❓": "疑问",
-//		"❗": "感叹",
-//		"💰": "金钱",
-//		"💸": "花钱",
-//		"🎯": "目标",
-//		"🔍": "搜索",
-//		"📱": "手机",
-//		"💻": "电脑",
-//		"🖥️": "显示器",
-//		"⌚": "手表",
-//		"📷": "相机",
-//		"🔊": "音量",
-//		"🔇": "静音",
-//		"📢": "喇叭",
-//		"📣": "扩音器",
-//		"🔔": "铃铛",
-//		"🔕": "静音",
-//		"📚": "书籍",
-//		"📖": "打开书",
-//		"📄": "文档",
-//		"📃": "页面",
-//		"📑": "书签",
-//		"🗂️": "文件夹",
-//		"📂": "文件夹",
-//		"📁": "文件夹",
-//		"🔗": "链接",
-//		"📎": "回形针",
-//		"✂️": "剪刀",
-//		"📐": "三角尺",
-//		"📏": "直尺",
-//		"🎨": "调色板",
-//		"🖌️": "画笔",
-//		"🖍️": "蜡笔",
-//		"🖊️": "钢笔",
-//		"✏️": "铅笔",
-//		"📝": "记录",
-//		"🏆": "奖杯",
-//		"🥇": "金牌",
-//		"🥈": "银牌",
-//		"🥉": "铜牌",
-//		"🎖️": "勋章",
-//		"🏅": "奖章",
-//		"🎗️": "丝带",
-//		"🎀": "蝴蝶结",
-//		"👑": "皇冠",
-//		"💎": "钻石",
-//		"🔑": "钥匙",
-//		"🗝️": "钥匙",
-//		"🔒": "锁定",
-//		"🔓": "解锁",
-//		"🔐": "加密",
-//		"🔏": "密码锁",
-//		"🛡️": "盾牌",
-//		"⚔️": "剑",
-//		"🏹": "弓箭",
-//		"🎮": "游戏",
-//		"🕹️": "操纵杆",
-//		"🎲": "骰子",
-//		"🧩": "拼图",
-//		"🎪": "马戏团",
-//		"🎭": "面具",
-//		"🎨": "艺术",
-//		"🎬": "电影",
-//		"🎤": "麦克风",
-//		"🎧": "耳机",
-//		"🎵": "音符",
-//		"🎶": "音乐",
-//		"🎼": "乐谱",
-//		"🔈": "扬声器",
-//		"🔉": "音量",
-//		"📻": "收音机",
-//		"📺": "电视",
-//		"📸": "快照",
-//		"📹": "摄像",
-//		"📽️": "放映机",
-//		"🎥": "摄影机",
-//		"📞": "电话",
-//		"☎️": "电话",
-//		"📟": "传呼机",
-//		"📠": "传真",
-//		"📧": "邮件",
-//		"📨": "邮件",
-//		"📩": "邮件",
-//		"📪": "邮箱",
-//		"📫": "邮箱",
-//		"📬": "邮箱",
-//		"📭": "邮箱",
-//		"📮": "邮筒",
-//		"🗳️": "投票箱",
-//		"✉️": "信封",
-//		"📜": "卷轴",
-//		"📋": "剪贴板",
-//		"📅": "日历",
-//		"📆": "日历",
-//		"🗓️": "日历",
-//		"📇": "名片",
-//		"🗃️": "文件盒",
-//		"🗄️": "文件柜",
-//		"🗑️": "垃圾桶",
-//		"📊": "柱状图",
-//		"📈": "趋势向上",
-//		"📉": "趋势向下",
-//		"📊": "图表",
-//		"⌛": "沙漏",
-//		"⏳": "沙漏",
-//		"⏰": "闹钟",
-//		"⏱️": "秒表",
-//		"⏲️": "定时器",
-//		"🕐": "一点",
-//		"🕑": "二点",
-//		"🕒": "三点",
-//		"🕓": "四点",
-//		"🕔": "五点",
-//		"🕕": "六点",
-//		"🕖": "七点",
-//		"🕗": "八点",
-//		"🕘": "九点",
-//		"🕙": "十点",
-//		"🕚": "十一点",
-//		"🕛": "十二点",
-//	}
-//
-//	// 精确匹配emoji符号并替换
-//	for emoji, replacement := range emojiReplacements {
-//		text = strings.ReplaceAll(text, emoji, replacement)
-//	}
-//
-//	// 使用正则表达式移除其他未映射的emoji符号
-//	// 这个正则表达式匹配大部分Unicode emoji范围
-//	emojiRegex := regexp.MustCompile(`[\x{1F600}-\x{1F64F}]|[\x{1F300}-\x{1F5FF}]|[\x{1F680}-\x{1F6FF}]|[\x{1F1E0}-\x{1F1FF}]|[\x{2600}-\x{26FF}]|[\x{2700}-\x{27BF}]|[\x{1F900}-\x{1F9FF}]|[\x{1F018}-\x{1F270}]|[\x{238C}-\x{2454}]|[\x{20D0}-\x{20FF}]|[\x{FE0F}]`)
-//	text = emojiRegex.ReplaceAllString(text, "")
-//
-//	return text
-//}
-
-// startsWithEmoji 检查文本是否以emoji开头
-func (tp *TextProcessor) startsWithEmoji(text string) bool {
-	text = strings.TrimSpace(text)
-	if text == "" {
-		return false
+// SetMarkdownExtensions 在默认扩展基础上开关Markdown解析扩展，如 ["-autolink", "hard_line_break"]
+func (tp *TextProcessor) SetMarkdownExtensions(names []string) error {
+	return tp.markdownProcessor.SetExtensions(names)
+}
+
+// SetURLMode 设置裸URL处理模式：remove（默认）/domain
+func (tp *TextProcessor) SetURLMode(mode string) error {
+	switch mode {
+	case "", URLModeRemove, URLModeDomain:
+		tp.urlMode = mode
+		tp.markdownProcessor.SetURLMode(mode)
+		return nil
+	default:
+		return fmt.Errorf("未知的URL处理模式: %s（可选: remove, domain）", mode)
 	}
+}
 
-	// 获取第一个字符（rune）
-	runes := []rune(text)
-	if len(runes) == 0 {
-		return false
+// spokenURL 返回裸URL的朗读文本：默认删除；domain 模式下读作域名，域名中的 . 按符号语言读作"点"或"dot"
+// 读作域名时URL后紧跟的中文或标点不属于URL，原样保留
+func (tp *TextProcessor) spokenURL(match string) string {
+	end := len(match)
+	for i, r := range match {
+		if r > unicode.MaxASCII {
+			end = i
+			break
+		}
+	}
+	if tp.urlMode != URLModeDomain {
+		return ""
 	}
 
-	firstRune := runes[0]
-
-	// 检查第一个字符是否在emoji的Unicode范围内
-	// 这些范围涵盖了大部分常见的emoji符号
-	emojiRanges := [][2]rune{
-		{0x1F600, 0x1F64F}, // 表情符号和情感
-		{0x1F300, 0x1F5FF}, // 杂项符号和象形文字
-		{0x1F680, 0x1F6FF}, // 交通和地图符号
-		{0x1F1E0, 0x1F1FF}, // 区域指示符号（国旗）
-		{0x2600, 0x26FF},   // 杂项符号
-		{0x2700, 0x27BF},   // 装饰符号
-		{0x1F900, 0x1F9FF}, // 补充符号和象形文字
-		{0x1F018, 0x1F270}, // 封闭字母数字补充
-		{0x238C, 0x2454},   // 杂项技术符号部分
-		{0x1F170, 0x1F251}, // 封闭字母数字补充
-		{0x1F004, 0x1F0CF}, // 麻将和扑克牌
-		{0x1F18E, 0x1F18E}, // 负方形AB
-		{0x3030, 0x303D},   // 日文标点
-		{0x3297, 0x3299},   // 表意文字描述符
-		{0x1F201, 0x1F202}, // 封闭表意文字补充
-		{0x1F21A, 0x1F22F}, // 封闭表意文字补充
-		{0x1F232, 0x1F236}, // 封闭表意文字补充
-		{0x1F238, 0x1F23A}, // 封闭表意文字补充
-		{0x1F250, 0x1F251}, // 封闭表意文字补充
-		{0x1F3FB, 0x1F3FF}, // 肤色修饰符
-		{0xFE0F, 0xFE0F},   // 变体选择符16（emoji变体）
-		{0x200D, 0x200D},   // 零宽度连接符
-	}
-
-	// 检查第一个字符是否在任何emoji范围内
-	for _, emojiRange := range emojiRanges {
-		if firstRune >= emojiRange[0] && firstRune <= emojiRange[1] {
-			return true
+	address := strings.TrimRight(match[:end], ".,;:!?)]}'\"")
+	suffix := match[len(address):]
+	domain := linkDomain(address)
+	if domain == "" {
+		return suffix
+	}
+
+	dot, ok := urlDotReadings[tp.symbolLanguage]
+	if !ok {
+		dot = urlDotReadings[langChinese]
+	}
+	return strings.ReplaceAll(domain, ".", " "+dot+" ") + suffix
+}
+
+// SetLinkMode 设置链接处理模式：text（默认）/domain/remove
+func (tp *TextProcessor) SetLinkMode(mode string) error {
+	switch mode {
+	case "", LinkModeText, LinkModeDomain, LinkModeRemove:
+		tp.linkMode = mode
+		tp.markdownProcessor.SetLinkMode(mode)
+		return nil
+	default:
+		return fmt.Errorf("未知的链接处理模式: %s（可选: text, domain, remove）", mode)
+	}
+}
+
+// SetEmojiMode 设置emoji处理模式：remove（默认）/describe/keep
+func (tp *TextProcessor) SetEmojiMode(mode string) error {
+	switch mode {
+	case "":
+		tp.emojiMode = EmojiModeRemove
+		return nil
+	case EmojiModeRemove, EmojiModeDescribe, EmojiModeKeep:
+		tp.emojiMode = mode
+		return nil
+	default:
+		return fmt.Errorf("未知的emoji处理模式: %s（可选: remove, describe, keep）", mode)
+	}
+}
+
+// SetShortWords 设置短词白名单，白名单中的单字符文本（如 "A"）不会被过滤
+func (tp *TextProcessor) SetShortWords(words []string) {
+	tp.shortWords = make(map[string]bool, len(words))
+	for _, word := range words {
+		if word = strings.TrimSpace(word); word != "" {
+			tp.shortWords[word] = true
 		}
 	}
+}
+
+// processEmojis 按 emoji_mode 处理emoji符号：默认完全移除不参与语音合成，
+// describe 模式把映射表中的emoji读作中文描述（如 🚀 读作"火箭"）、其余移除，keep 模式保持原样
+// 按完整序列处理，ZWJ组合emoji（如 👨‍👩‍👧‍👦）、肤色修饰和键帽序列不会残留码点
+func (tp *TextProcessor) processEmojis(text string) string {
+	switch tp.emojiMode {
+	case EmojiModeKeep:
+		return text
+	case EmojiModeDescribe:
+		return describeEmojis(text)
+	default:
+		return removeEmojis(text)
+	}
+}
 
-	return false
+// startsWithEmoji 检查文本是否以emoji开头，判断规则与 removeEmojis 一致
+func (tp *TextProcessor) startsWithEmoji(text string) bool {
+	return startsWithEmojiSequence(strings.TrimSpace(text))
 }
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsLinkDefinition(t *testing.T) {
+	tests := map[string]bool{
+		"[1]: http://example.com":               true,
+		`[docs]: https://example.com/a "文档"`:    true,
+		"[logo]: <图片 地址.png>":                   true,
+		"  [home]: /index.html 'Home'":          true,
+		"[top]: #目录":                            true,
+		"[local]: ./guide.md (指南)":              true,
+		"[mail]: mailto:a@example.com":          true,
+		"[site]: www.example.com":               true,
+		"[注]: 这里是一段说明":                          false,
+		"[TODO]: 补充测试用例":                        false,
+		"[x]: note: 需要朗读":                       false,
+		"[1]: http://example.com 后面还有正文":        false,
+		"[^1]: 脚注说明":                            false,
+		"正文 [1]: http://example.com":            false,
+		"[a]: https://example.com \"标题\" 多余的文字": false,
+	}
+	tp := NewTextProcessor()
+	for text, want := range tests {
+		if got := tp.isLinkDefinition(text); got != want {
+			t.Errorf("isLinkDefinition(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestProcessLinksKeepsNonURLDefinitions(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"[文档][1]\n[1]: https://example.com", "文档\n"},
+		{"[注]: 这里是一段说明", "[注]: 这里是一段说明"},
+		{"[标题][]\n[标题]: <./a.md> \"A\"", "标题\n"},
+	}
+	tp := NewTextProcessor()
+	for _, tt := range tests {
+		if got := tp.processLinks(tt.input); got != tt.want {
+			t.Errorf("processLinks(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestProcessMarkdownDocumentLinkDefinitions(t *testing.T) {
+	tp := NewTextProcessor()
+	got := tp.ProcessMarkdownDocument("正文一。\n\n[注]: 这里是一段说明。\n\n[1]: http://example.com\n\n```\n[x]: y\n```\n")
+	if !strings.Contains(strings.Join(got, "\n"), "这里是一段说明") {
+		t.Errorf("说明文本被当作链接定义丢弃: %q", got)
+	}
+	for _, s := range got {
+		if strings.Contains(s, "example.com") {
+			t.Errorf("链接定义被朗读: %q", got)
+		}
+	}
+}
+
+func TestProcessBrackets(t *testing.T) {
+	tests := []struct {
+		mode  string
+		input string
+		want  string
+	}{
+		{BracketModePause, "张三（经理）说", "张三，经理，说"},
+		{BracketModePause, "张三(经理)说", "张三，经理，说"},
+		{BracketModePause, "见【注释】。", "见，注释。"},
+		{BracketModePause, "调用f(x)返回", "调用f(x)返回"},
+		{BracketModePause, "步骤(1)完成", "步骤(1)完成"},
+		{BracketModePause, "使用API(v2)接口", "使用API(v2)接口"},
+		{BracketModePause, "(nested (a))", "(nested (a))"},
+		{BracketModeRemove, "张三（经理）说", "张三说"},
+		{BracketModeRemove, "调用f(x)返回", "调用f(x)返回"},
+		{BracketModeRemove, "张三(经理)说", "张三说"},
+		{BracketModeKeep, "张三（经理）说", "张三（经理）说"},
+	}
+	for _, tt := range tests {
+		tp := NewTextProcessor()
+		if err := tp.SetBracketMode(tt.mode); err != nil {
+			t.Fatal(err)
+		}
+		if got := tp.processBrackets(tt.input); got != tt.want {
+			t.Errorf("%s: processBrackets(%q) = %q, want %q", tt.mode, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestFilterReasonShortWords(t *testing.T) {
+	tests := []struct {
+		text       string
+		shortWords []string
+		want       string
+	}{
+		{"是", nil, ""},
+		{"好", nil, ""},
+		{"1", nil, ""},
+		{" 7 ", nil, ""},
+		{"A", nil, FilterReasonTooShort},
+		{"A", []string{" A "}, ""},
+		{"、", nil, FilterReasonTooShort},
+		{"。", []string{"A"}, FilterReasonTooShort},
+		{"OK", nil, ""},
+	}
+	for _, tt := range tests {
+		tp := NewTextProcessor()
+		tp.SetShortWords(tt.shortWords)
+		if got := tp.FilterReason(tt.text); got != tt.want {
+			t.Errorf("FilterReason(%q), short_words=%v = %q, want %q", tt.text, tt.shortWords, got, tt.want)
+		}
+	}
+}
+
+func TestMixedLanguageSpacing(t *testing.T) {
+	tests := []struct {
+		input    string
+		want     string
+		disabled string
+	}{
+		{"使用Go语言", "使用 Go 语言", "使用Go语言"},
+		{"已有 Go 空格", "已有 Go 空格", "已有 Go 空格"},
+		// 只在汉字与英文字母之间加空格，数字与汉字之间不加
+		{"版本v2发布", "版本 v2发布", "版本v2发布"},
+		{"纯中文", "纯中文", "纯中文"},
+	}
+	for _, tt := range tests {
+		for _, enabled := range []bool{true, false} {
+			tp := NewTextProcessor()
+			tp.SetMixedLanguageSpacing(enabled)
+			want := tt.want
+			if !enabled {
+				want = tt.disabled
+			}
+			if got := tp.ProcessText(tt.input); got != want {
+				t.Errorf("spacing=%v: ProcessText(%q) = %q, want %q", enabled, tt.input, got, want)
+			}
+		}
+	}
+}
+
+func TestDialogueMarkupKept(t *testing.T) {
+	tests := []struct {
+		input  string
+		reason string
+		want   string // 未过滤时清洗结果应包含的文本
+	}{
+		{"<好的！>", "", "<好的！>"},
+		{"-- 你说什么？", "", "-- 你说什么？"},
+		{"**(旁白)** 他走了", "", "他走了"},
+		{"<p>正文</p>", "", "正文"},
+		{"<br>", FilterReasonMarkdown, ""},
+		{"<div><span>", FilterReasonMarkdown, ""},
+		{"**(注)**", FilterReasonMarkdown, ""},
+		{"---", FilterReasonMarkdown, ""},
+	}
+	tp := NewTextProcessor()
+	for _, tt := range tests {
+		if got := tp.FilterReason(tt.input); got != tt.reason {
+			t.Errorf("FilterReason(%q) = %q, want %q", tt.input, got, tt.reason)
+		}
+		// 被过滤的行不再检查清洗结果
+		if got := tp.ProcessText(tt.input); tt.reason == "" && !strings.Contains(got, tt.want) {
+			t.Errorf("ProcessText(%q) = %q, want 包含 %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestRemoveHTMLTags(t *testing.T) {
+	tests := map[string]string{
+		"<b>加粗</b>文字":         "加粗文字",
+		"<好的！>":               "<好的！>",
+		"a < b > c":           "a < b > c",
+		"<!-- 注释 -->正文":       "正文",
+		"<img src=\"a.png\">": "",
+	}
+	tp := NewTextProcessor()
+	for input, want := range tests {
+		if got := tp.removeHTMLTags(input); got != want {
+			t.Errorf("removeHTMLTags(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestPlainInputSymbols(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"评分 * 5 # 3 | 完成", "评分 * 5 # 3 | 完成"},
+		{"a_b ~ c", "a_b ~ c"},
+		{"我爱❤️Go🚀", "我爱 Go"},
+		{"价格 + 税 = 总价", "价格 加 税 等于 总价"},
+	}
+	tp := NewTextProcessor()
+	if err := tp.SetInputType(InputTypePlain); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		if got := tp.ProcessText(tt.input); got != tt.want {
+			t.Errorf("plain: ProcessText(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
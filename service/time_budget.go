@@ -0,0 +1,36 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errBudgetExceeded 超出处理时长预算后未提交的任务
+var errBudgetExceeded = errors.New("已超出处理时长预算，任务未提交")
+
+// timeBudget 处理时长预算，零值表示不限制
+type timeBudget struct {
+	limit    time.Duration
+	deadline time.Time
+}
+
+// newTimeBudget 从当前时刻开始计时，limit<=0 时不限制
+func newTimeBudget(limit time.Duration) *timeBudget {
+	if limit <= 0 {
+		return &timeBudget{}
+	}
+	return &timeBudget{limit: limit, deadline: time.Now().Add(limit)}
+}
+
+// Expired 是否已超出预算
+func (tb *timeBudget) Expired() bool {
+	return tb.limit > 0 && time.Now().After(tb.deadline)
+}
+
+// printSkipped 打印因超时而未处理的任务数
+func (tb *timeBudget) printSkipped(skipped int) {
+	if skipped > 0 {
+		fmt.Printf("⏱️  已达到处理时长预算 %v，剩余 %d 句因超时未处理，已完成部分将照常合并输出\n", tb.limit, skipped)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// timelineSuffix 时间轴文件后缀，与输出音频同名，如 merged_audio.timeline.json
+const timelineSuffix = ".timeline.json"
+
+// timelineEntry 时间轴中的一个片段，时间单位为秒
+type timelineEntry struct {
+	Index int     `json:"index"`
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	File  string  `json:"file"`
+}
+
+// timeline JSON时间轴，供视频剪辑或字幕生成工具对齐使用
+type timeline struct {
+	Audio    string          `json:"audio"`
+	Duration float64         `json:"duration"`
+	Segments []timelineEntry `json:"segments"`
+}
+
+// timelinePath 返回输出音频对应的时间轴文件路径
+func timelinePath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + timelineSuffix
+}
+
+// writeTimeline 合并完成后按片段实际时长写出JSON时间轴
+// sources 为验证后的原始片段（用于查找文本和记录文件），files 为实际参与合并的文件（可能经过重采样或裁剪静音），两者一一对应
+// gap 为合并时片段之间插入的静音时长，计入后续片段的起始时间；无法测量时长的片段按文本长度估算
+// 返回写出的时间轴文件路径，未启用或写出失败时返回空字符串
+func writeTimeline(config *model.Config, outputPath string, sources, files []string, texts map[string]string, gap time.Duration) string {
+	if !config.Audio.Timeline {
+		return ""
+	}
+
+	segmentsDir := filepath.Join(config.Audio.OutputDir, segmentsDirName)
+	tempDir := filepath.Clean(config.Audio.TempDir)
+
+	doc := timeline{Audio: outputPath, Segments: make([]timelineEntry, 0, len(files))}
+	var offset time.Duration
+	estimated := 0
+	for i, file := range files {
+		if i > 0 {
+			offset += gap
+		}
+		source := sources[i]
+		text := texts[source]
+
+		duration, err := measureAudioDuration(file)
+		if err != nil {
+			duration = estimateSpeechDuration(text)
+			estimated++
+		}
+
+		// 保留了片段时指向输出目录中的副本，临时目录可能被清理
+		recorded := source
+		if config.Audio.KeepSegments && filepath.Dir(filepath.Clean(source)) == tempDir {
+			recorded = filepath.Join(segmentsDir, filepath.Base(source))
+		}
+
+		doc.Segments = append(doc.Segments, timelineEntry{
+			Index: i + 1,
+			Text:  text,
+			Start: roundSeconds(offset),
+			End:   roundSeconds(offset + duration),
+			File:  recorded,
+		})
+		offset += duration
+	}
+	doc.Duration = roundSeconds(offset)
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		Warnf("⚠️  生成时间轴失败: %v\n", err)
+		return ""
+	}
+
+	path := timelinePath(outputPath)
+	if err := writeFile(path, append(data, '\n')); err != nil {
+		Warnf("⚠️  写入时间轴失败: %v\n", err)
+		return ""
+	}
+	fmt.Printf("🕒 已导出时间轴: %s（%d 个片段，总时长 %s）\n", path, len(doc.Segments), offset.Round(time.Second))
+	if estimated > 0 {
+		fmt.Printf("   其中 %d 个片段无法测量时长，已按文本长度估算\n", estimated)
+	}
+	return path
+}
+
+// roundSeconds 把时长转为秒，保留3位小数
+func roundSeconds(d time.Duration) float64 {
+	return math.Round(d.Seconds()*1000) / 1000
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// defaultSilenceThreshold 默认静音阈值（dBFS），低于该电平视为静音
+const defaultSilenceThreshold = -50.0
+
+// silencePadding 裁剪后首尾保留的静音时长（秒），避免切掉字头字尾
+const silencePadding = 0.05
+
+// trimSilence 按配置裁剪每个片段首尾的静音，返回裁剪后的文件列表
+// 有ffmpeg时使用silenceremove滤镜，否则仅对PCM WAV做简单的电平检测，其他格式保持原样
+func trimSilence(config *model.Config, audioFiles []string) []string {
+	if !config.Audio.TrimSilence {
+		return audioFiles
+	}
+
+	threshold := config.Audio.SilenceThreshold
+	if threshold >= 0 {
+		threshold = defaultSilenceThreshold
+	}
+
+	useFFmpeg := IsFFmpegAvailable()
+	fmt.Printf("✂️  裁剪 %d 个音频片段的首尾静音（阈值 %.0f dB）...\n", len(audioFiles), threshold)
+
+	result := make([]string, len(audioFiles))
+	trimmed := 0
+	for i, file := range audioFiles {
+		result[i] = file
+		output := filepath.Join(config.Audio.TempDir, "trimmed_"+filepath.Base(file))
+
+		var err error
+		switch {
+		case useFFmpeg:
+			err = runFFmpeg("-y", "-loglevel", "error", "-i", file, "-af", silenceRemoveFilter(threshold), output)
+		case isWAVFile(file):
+			err = trimWAVSilence(file, output, threshold)
+		default:
+			continue
+		}
+
+		if err != nil {
+			Warnf("⚠️  静音裁剪失败，保留原文件: %s, 错误: %v\n", file, err)
+			continue
+		}
+		result[i] = output
+		trimmed++
+	}
+
+	if trimmed == 0 && !useFFmpeg {
+		fmt.Println("   未找到ffmpeg，且片段不是WAV格式，跳过静音裁剪")
+	}
+	return result
+}
+
+// isWAVFile 按文件头判断是否为WAV，扩展名与实际内容可能不符
+func isWAVFile(path string) bool {
+	format, err := DetectAudioFormat(path)
+	return err == nil && format == AudioFormatWAV
+}
+
+// silenceRemoveFilter 构造裁剪首尾静音的ffmpeg滤镜：先裁开头，反转后再裁一次即裁掉结尾
+func silenceRemoveFilter(threshold float64) string {
+	trim := fmt.Sprintf("silenceremove=start_periods=1:start_threshold=%gdB:start_silence=%g", threshold, silencePadding)
+	return trim + ",areverse," + trim + ",areverse"
+}
+
+// wavFormat WAV文件的PCM格式信息
+type wavFormat struct {
+	audioFormat   uint16
+	channels      uint16
+	sampleRate    uint32
+	bitsPerSample uint16
+}
+
+// trimWAVSilence 对16位或8位PCM WAV做首尾静音检测并写出裁剪后的文件
+func trimWAVSilence(inputPath, outputPath string, threshold float64) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("读取WAV失败: %v", err)
+	}
+
+	format, samples, err := parseWAV(data)
+	if err != nil {
+		return err
+	}
+	if format.audioFormat != 1 || (format.bitsPerSample != 16 && format.bitsPerSample != 8) {
+		return fmt.Errorf("仅支持8位或16位PCM WAV")
+	}
+
+	frameSize := int(format.channels) * int(format.bitsPerSample) / 8
+	frames := len(samples) / frameSize
+	if frames == 0 {
+		return fmt.Errorf("WAV不包含音频数据")
+	}
+
+	limit := math.Pow(10, threshold/20)
+	loud := func(frame int) bool {
+		for ch := 0; ch < int(format.channels); ch++ {
+			offset := frame*frameSize + ch*int(format.bitsPerSample)/8
+			var amplitude float64
+			if format.bitsPerSample == 16 {
+				amplitude = math.Abs(float64(int16(binary.LittleEndian.Uint16(samples[offset:])))) / 32768
+			} else {
+				amplitude = math.Abs(float64(int(samples[offset])-128)) / 128
+			}
+			if amplitude > limit {
+				return true
+			}
+		}
+		return false
+	}
+
+	start := 0
+	for start < frames && !loud(start) {
+		start++
+	}
+	if start == frames {
+		return fmt.Errorf("整段均为静音")
+	}
+	end := frames
+	for end > start && !loud(end-1) {
+		end--
+	}
+
+	padding := int(float64(format.sampleRate) * silencePadding)
+	start = max(0, start-padding)
+	end = min(frames, end+padding)
+
+	return writeFile(outputPath, buildWAV(format, samples[start*frameSize:end*frameSize]))
+}
+
+// parseWAV 解析RIFF WAV，返回格式信息和data块内容
+func parseWAV(data []byte) (wavFormat, []byte, error) {
+	var format wavFormat
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return format, nil, fmt.Errorf("不是有效的WAV文件")
+	}
+
+	foundFormat := false
+	for offset := 12; offset+8 <= len(data); {
+		chunkID := string(data[offset : offset+4])
+		chunkSize := int(binary.LittleEndian.Uint32(data[offset+4 : offset+8]))
+		body := offset + 8
+		if body+chunkSize > len(data) {
+			chunkSize = len(data) - body // 容忍截断的data块
+		}
+
+		switch chunkID {
+		case "fmt ":
+			if chunkSize < 16 {
+				return format, nil, fmt.Errorf("WAV格式块损坏")
+			}
+			format.audioFormat = binary.LittleEndian.Uint16(data[body:])
+			format.channels = binary.LittleEndian.Uint16(data[body+2:])
+			format.sampleRate = binary.LittleEndian.Uint32(data[body+4:])
+			format.bitsPerSample = binary.LittleEndian.Uint16(data[body+14:])
+			foundFormat = true
+		case "data":
+			if !foundFormat || format.channels == 0 {
+				return format, nil, fmt.Errorf("WAV缺少格式块")
+			}
+			return format, data[body : body+chunkSize], nil
+		}
+
+		offset = body + chunkSize + chunkSize%2 // 块按偶数字节对齐
+	}
+
+	return format, nil, fmt.Errorf("WAV缺少data块")
+}
+
+// buildWAV 用给定格式和PCM数据构造标准44字节头的WAV
+func buildWAV(format wavFormat, samples []byte) []byte {
+	blockAlign := format.channels * format.bitsPerSample / 8
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+len(samples)))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, format.audioFormat)
+	binary.Write(&buf, binary.LittleEndian, format.channels)
+	binary.Write(&buf, binary.LittleEndian, format.sampleRate)
+	binary.Write(&buf, binary.LittleEndian, format.sampleRate*uint32(blockAlign))
+	binary.Write(&buf, binary.LittleEndian, blockAlign)
+	binary.Write(&buf, binary.LittleEndian, format.bitsPerSample)
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(len(samples)))
+	buf.Write(samples)
+	return buf.Bytes()
+}
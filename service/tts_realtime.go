@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/difyz9/markdown2tts/model"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tts "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tts/v20190823"
+)
+
+// 腾讯云合成方式
+const (
+	TencentModeAuto     = "auto"     // 短文本用实时合成，长文本用异步任务（默认）
+	TencentModeTask     = "task"     // 始终使用长文本异步任务（创建任务 → 轮询 → 下载）
+	TencentModeRealtime = "realtime" // 始终使用实时合成（非流式），一次请求返回完整音频
+)
+
+// realtimeMaxChars 实时合成接口单次请求的最大字数（中文150字，全角标点算一个字）
+const realtimeMaxChars = 150
+
+// tencentSynthesisMode 返回配置的腾讯云合成方式，未知取值时警告并按 auto 处理
+func tencentSynthesisMode(config *model.Config) string {
+	mode := strings.ToLower(strings.TrimSpace(config.TTS.Mode))
+	switch mode {
+	case "":
+		return TencentModeAuto
+	case TencentModeAuto, TencentModeTask, TencentModeRealtime:
+		return mode
+	default:
+		Warnf("警告: 未知的腾讯云合成方式: %s（可选: auto, task, realtime），将按 auto 处理\n", mode)
+		return TencentModeAuto
+	}
+}
+
+// useRealtime 判断文本是否使用实时合成：realtime 模式始终使用（超长句子已在预处理时切分），auto 模式只用于短文本
+// SSML文本按去掉标签后的字数判断
+func useRealtime(mode, text string) bool {
+	switch mode {
+	case TencentModeRealtime:
+		return true
+	case TencentModeTask:
+		return false
+	default:
+		return utf8.RuneCountInString(ssmlVisibleText(text)) <= realtimeMaxChars
+	}
+}
+
+// SynthesizeRealtime 调用实时语音合成接口（TextToVoice，非流式），无需创建任务和轮询
+// 接口在一次响应中返回完整的base64音频，不是边合成边接收的流式接口（TextToStreamAudio）
+func (s *TTSService) SynthesizeRealtime(req *model.TTSRequest, audioPath string) error {
+	return s.SynthesizeRealtimeContext(context.Background(), req, audioPath)
+}
+
+// SynthesizeRealtimeContext 同 SynthesizeRealtime，ctx 取消或超时时中止请求
+func (s *TTSService) SynthesizeRealtimeContext(ctx context.Context, req *model.TTSRequest, audioPath string) error {
+	if err := applyTTSRequestDefaults(req); err != nil {
+		return err
+	}
+
+	request := tts.NewTextToVoiceRequest()
+	request.Text = common.StringPtr(tencentRequestText(req))
+	request.SessionId = common.StringPtr(fmt.Sprintf("markdown2tts-%d", time.Now().UnixNano()))
+	request.Volume = common.Float64Ptr(float64(req.Volume))
+	request.Speed = common.Float64Ptr(req.Speed)
+	request.VoiceType = common.Int64Ptr(req.VoiceType)
+	request.PrimaryLanguage = common.Int64Ptr(req.PrimaryLanguage)
+	request.SampleRate = common.Uint64Ptr(uint64(req.SampleRate))
+	request.Codec = common.StringPtr(req.Codec)
+	if req.EmotionCategory != "" {
+		request.EmotionCategory = common.StringPtr(req.EmotionCategory)
+	}
+	if req.EmotionIntensity != 0 {
+		request.EmotionIntensity = common.Int64Ptr(req.EmotionIntensity)
+	}
+
+	response, err := s.client.TextToVoiceWithContext(ctx, request)
+	if err != nil {
+		return fmt.Errorf("调用腾讯云实时TTS失败: %w", withContextError(ctx, err))
+	}
+	if response.Response == nil || response.Response.Audio == nil || *response.Response.Audio == "" {
+		return fmt.Errorf("%w: 腾讯云实时TTS未返回音频", errTTSTaskFailed)
+	}
+
+	file, err := createFile(audioPath)
+	if err != nil {
+		return fmt.Errorf("创建音频文件失败: %v", withDiskFullHint(err))
+	}
+	defer file.Close()
+
+	// 完整音频已随响应以base64返回，这里解码的同时写入文件，只是避免在内存中再保留一份解码后的副本
+	decoder := base64.NewDecoder(base64.StdEncoding, strings.NewReader(*response.Response.Audio))
+	if _, err := copyBuffered(file, decoder); err != nil {
+		return fmt.Errorf("保存音频文件失败: %v", withDiskFullHint(err))
+	}
+	return nil
+}
+
+// synthesizeRealtime 使用实时接口把任务合成到音频文件并验证
+func (cas *ConcurrentAudioService) synthesizeRealtime(ctx context.Context, task TTSTask, audioFile string) error {
+	if err := cas.limiters.realtime.Wait(ctx); err != nil {
+		return fmt.Errorf("等待速率限制失败: %w", err)
+	}
+	if err := cas.ttsService.SynthesizeRealtimeContext(ctx, cas.newTTSRequest(task.Text, cas.voiceTypeOf(task)), audioFile); err != nil {
+		return err
+	}
+	if err := cas.validateAudioFile(audioFile); err != nil {
+		os.Remove(audioFile)
+		return fmt.Errorf("%w: %v", errInvalidAudio, err)
+	}
+	return nil
+}
@@ -1,43 +1,80 @@
 package service
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/difyz9/markdown2tts/model"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	tts "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/tts/v20190823"
 	"os"
+	"sort"
+	"strings"
 )
 
+// defaultTencentSampleRate 未配置 tts.sample_rate 时腾讯云TTS使用的采样率
+const defaultTencentSampleRate = 16000
+
 type TTSService struct {
 	client *tts.Client
 }
 
-func NewTTSService(secretId, secretKey, region string) *TTSService {
+// 创建TTS任务
+func (s *TTSService) CreateTTSTask(req *model.TTSRequest) (*model.TTSResponse, error) {
+	return s.CreateTTSTaskContext(context.Background(), req)
+}
 
-	// 实例化一个认证对象
-	credential := common.NewCredential(
-		secretId,
-		secretKey,
-	)
-	// 实例化一个客户端配置对象
-	cpf := profile.NewClientProfile()
-	cpf.HttpProfile.Endpoint = "tts.tencentcloudapi.com"
+// CreateTTSTaskContext 同 CreateTTSTask，ctx 取消或超时时中止请求
+func (s *TTSService) CreateTTSTaskContext(ctx context.Context, req *model.TTSRequest) (*model.TTSResponse, error) {
+	if err := applyTTSRequestDefaults(req); err != nil {
+		return &model.TTSResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
 
-	// 实例化要请求产品的client对象
-	client, err := tts.NewClient(credential, region, cpf)
-	if err != nil {
-		fmt.Println("创建腾讯云TTS客户端失败:", err)
-		return nil
+	// 实例化一个请求对象
+	request := tts.NewCreateTtsTaskRequest()
+	request.Text = common.StringPtr(tencentRequestText(req))
+	request.Volume = common.Float64Ptr(float64(req.Volume))
+	request.Speed = common.Float64Ptr(req.Speed)
+	request.VoiceType = common.Int64Ptr(req.VoiceType)
+	request.PrimaryLanguage = common.Int64Ptr(req.PrimaryLanguage)
+	request.SampleRate = common.Uint64Ptr(uint64(req.SampleRate))
+	request.Codec = common.StringPtr(req.Codec)
+
+	// 可选高级参数：仅在显式配置时设置，避免影响不支持这些参数的音色
+	if req.EmotionCategory != "" {
+		request.EmotionCategory = common.StringPtr(req.EmotionCategory)
+	}
+	if req.EmotionIntensity != 0 {
+		request.EmotionIntensity = common.Int64Ptr(req.EmotionIntensity)
 	}
 
-	return &TTSService{
-		client: client,
+	// 发起请求
+	response, err := s.client.CreateTtsTaskWithContext(ctx, request)
+	if err != nil {
+		return nil, fmt.Errorf("调用腾讯云TTS失败: %w", withContextError(ctx, err))
 	}
+
+	return &model.TTSResponse{
+		Success: true,
+		TaskID:  *response.Response.Data.TaskId,
+		Message: "TTS任务创建成功",
+	}, nil
 }
 
-// 创建TTS任务
-func (s *TTSService) CreateTTSTask(req *model.TTSRequest) (*model.TTSResponse, error) {
+// applyTTSRequestDefaults 解析音色别名、为未设置的参数填入默认值并校验音色参数
+func applyTTSRequestDefaults(req *model.TTSRequest) error {
+	// 解析音色名称别名
+	if req.Voice != "" {
+		voiceType, err := ResolveTencentVoice(req.Voice)
+		if err != nil {
+			return err
+		}
+		req.VoiceType = voiceType
+	}
+
 	// 设置默认值
 	if req.VoiceType == 0 {
 		req.VoiceType = 101008 // 智琪 - 女声
@@ -52,51 +89,58 @@ func (s *TTSService) CreateTTSTask(req *model.TTSRequest) (*model.TTSResponse, e
 		req.PrimaryLanguage = 1
 	}
 	if req.SampleRate == 0 {
-		req.SampleRate = 16000
+		req.SampleRate = defaultTencentSampleRate
 	}
 	if req.Codec == "" {
 		req.Codec = "mp3"
 	}
 
-	// 实例化一个请求对象
-	request := tts.NewCreateTtsTaskRequest()
-	request.Text = common.StringPtr(req.Text)
-	request.Volume = common.Float64Ptr(float64(req.Volume))
-	request.Speed = common.Float64Ptr(req.Speed)
-	request.VoiceType = common.Int64Ptr(req.VoiceType)
-	request.PrimaryLanguage = common.Int64Ptr(req.PrimaryLanguage)
-	request.SampleRate = common.Uint64Ptr(uint64(req.SampleRate))
-	request.Codec = common.StringPtr(req.Codec)
+	// 参数确定后校验音色是否支持所选采样率和情感参数
+	return checkTencentVoiceParams(req.VoiceType, req.SampleRate, req.EmotionCategory)
+}
 
-	// 发起请求
-	response, err := s.client.CreateTtsTask(request)
-	if err != nil {
-		return &model.TTSResponse{
-			Success: false,
-			Error:   fmt.Sprintf("调用腾讯云TTS失败: %v", err),
-		}, nil
+// applyPronunciations 按发音替换表替换文本中的字词
+// 较长的词优先替换，避免短词先命中破坏长词；长度相同时按字典序，保证结果确定
+func applyPronunciations(text string, pronunciations map[string]string) string {
+	if len(pronunciations) == 0 {
+		return text
 	}
 
-	return &model.TTSResponse{
-		Success: true,
-		TaskID:  *response.Response.Data.TaskId,
-		Message: "TTS任务创建成功",
-	}, nil
+	words := make([]string, 0, len(pronunciations))
+	for word := range pronunciations {
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if len(words[i]) != len(words[j]) {
+			return len(words[i]) > len(words[j])
+		}
+		return words[i] < words[j]
+	})
+
+	pairs := make([]string, 0, len(words)*2)
+	for _, word := range words {
+		pairs = append(pairs, word, pronunciations[word])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
 }
 
 // 查询TTS任务状态
 func (s *TTSService) DescribeTTSTaskStatus(taskID string) (*model.TTSStatusResponse, error) {
+	return s.DescribeTTSTaskStatusContext(context.Background(), taskID)
+}
+
+// DescribeTTSTaskStatusContext 同 DescribeTTSTaskStatus，ctx 取消或超时时中止请求
+func (s *TTSService) DescribeTTSTaskStatusContext(ctx context.Context, taskID string) (*model.TTSStatusResponse, error) {
 	// 实例化一个请求对象
 	request := tts.NewDescribeTtsTaskStatusRequest()
 	request.TaskId = common.StringPtr(taskID)
 
 	// 发起请求
-	response, err := s.client.DescribeTtsTaskStatus(request)
+	response, err := s.client.DescribeTtsTaskStatusWithContext(ctx, request)
 	if err != nil {
-		return &model.TTSStatusResponse{
-			Success: false,
-			Error:   fmt.Sprintf("查询TTS任务状态失败: %v", err),
-		}, nil
+		return nil, fmt.Errorf("查询TTS任务状态失败: %w", withContextError(ctx, err))
 	}
 
 	result := &model.TTSStatusResponse{
@@ -119,7 +163,15 @@ func (s *TTSService) DescribeTTSTaskStatus(taskID string) (*model.TTSStatusRespo
 // EnsureDir 确保目录存在，如果不存在则创建
 func EnsureDir(dirPath string) error {
 	if _, err := os.Stat(dirPath); os.IsNotExist(err) {
-		return os.MkdirAll(dirPath, 0755)
+		return makeDirs(dirPath)
 	}
 	return nil
 }
+
+// withContextError SDK把 ctx 超时/取消也报告为网络错误，这里补上 ctx.Err()，便于用 errors.Is 区分
+func withContextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil && !errors.Is(err, ctxErr) {
+		return fmt.Errorf("%w: %w", ctxErr, err)
+	}
+	return err
+}
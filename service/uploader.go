@@ -0,0 +1,224 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 对象存储类型
+const (
+	UploadProviderCOS = "cos" // 腾讯云COS（使用S3兼容接口）
+	UploadProviderS3  = "s3"  // AWS S3或其他S3兼容存储
+)
+
+// Uploader 对象存储上传器（S3兼容接口，AWS Signature V4签名）
+type Uploader struct {
+	config     model.UploadConfig
+	secretID   string
+	secretKey  string
+	httpClient *http.Client
+}
+
+// NewUploader 创建上传器，未配置上传时返回nil
+func NewUploader(config *model.Config) (*Uploader, error) {
+	uc := config.Upload
+	if uc.Provider == "" {
+		return nil, nil
+	}
+
+	if uc.Provider != UploadProviderCOS && uc.Provider != UploadProviderS3 {
+		return nil, fmt.Errorf("不支持的对象存储类型: %s（可选: cos, s3）", uc.Provider)
+	}
+	if uc.Bucket == "" || uc.Region == "" {
+		return nil, fmt.Errorf("上传配置缺少 bucket 或 region")
+	}
+
+	secretID, secretKey := uc.SecretID, uc.SecretKey
+	// COS未单独配置密钥时复用腾讯云密钥
+	if uc.Provider == UploadProviderCOS && secretID == "" && secretKey == "" {
+		secretID, secretKey = config.TencentCloud.SecretID, config.TencentCloud.SecretKey
+	}
+	if secretID == "" || secretKey == "" {
+		return nil, fmt.Errorf("上传配置缺少访问密钥")
+	}
+
+	return &Uploader{
+		config:     uc,
+		secretID:   secretID,
+		secretKey:  secretKey,
+		httpClient: newHTTPClient(ResolveProxy(config), 10*time.Minute),
+	}, nil
+}
+
+// UploadOutputs 上传产物文件，失败只打印警告不影响本地产物
+func UploadOutputs(config *model.Config, files []string) {
+	uploader, err := NewUploader(config)
+	if err != nil {
+		Warnf("⚠️  上传配置无效，跳过上传: %v\n", err)
+		return
+	}
+	if uploader == nil {
+		return
+	}
+
+	for _, file := range files {
+		if _, err := os.Stat(file); err != nil {
+			Warnf("⚠️  待上传文件不存在，跳过: %s, 错误: %v\n", file, err)
+			continue
+		}
+		url, err := uploader.UploadFile(file)
+		if err != nil {
+			Warnf("⚠️  上传失败（本地文件已保留）: %s, 错误: %v\n", file, err)
+			continue
+		}
+		fmt.Printf("☁️  已上传: %s\n", url)
+	}
+}
+
+// UploadFile 上传单个文件，返回对象URL
+func (u *Uploader) UploadFile(localPath string) (string, error) {
+	key := path.Join(u.config.KeyPrefix, filepath.Base(localPath))
+	key = strings.TrimPrefix(key, "/")
+
+	payloadHash, err := fileSHA256(localPath)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.Open(localPath)
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", fmt.Errorf("获取文件信息失败: %v", err)
+	}
+
+	host := u.host()
+	objectURL := "https://" + host + "/" + awsURIEncode(key)
+
+	req, err := http.NewRequest(http.MethodPut, objectURL, file)
+	if err != nil {
+		return "", fmt.Errorf("创建上传请求失败: %v", err)
+	}
+	req.ContentLength = info.Size()
+	u.sign(req, host, key, payloadHash, time.Now().UTC())
+
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("上传请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("上传失败，状态码: %d, 响应: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return objectURL, nil
+}
+
+// host 返回存储桶访问域名
+func (u *Uploader) host() string {
+	if u.config.Endpoint != "" {
+		endpoint := strings.TrimPrefix(strings.TrimPrefix(u.config.Endpoint, "https://"), "http://")
+		return u.config.Bucket + "." + strings.TrimSuffix(endpoint, "/")
+	}
+	if u.config.Provider == UploadProviderCOS {
+		return fmt.Sprintf("%s.cos.%s.myqcloud.com", u.config.Bucket, u.config.Region)
+	}
+	return fmt.Sprintf("%s.s3.%s.amazonaws.com", u.config.Bucket, u.config.Region)
+}
+
+// sign 使用AWS Signature V4为PUT请求签名
+func (u *Uploader) sign(req *http.Request, host, key, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("Host", host)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		http.MethodPut,
+		"/" + awsURIEncode(key),
+		"",
+		"host:" + host,
+		"x-amz-content-sha256:" + payloadHash,
+		"x-amz-date:" + amzDate,
+		"",
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + u.config.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+u.secretKey), date)
+	signingKey = hmacSHA256(signingKey, u.config.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.secretID, scope, signedHeaders, signature))
+}
+
+// awsURIEncode 按S3规范编码对象键（保留 / 和非保留字符）
+func awsURIEncode(key string) string {
+	var sb strings.Builder
+	for _, b := range []byte(key) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' || b == '/' {
+			sb.WriteByte(b)
+		} else {
+			sb.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return sb.String()
+}
+
+// fileSHA256 计算文件的SHA256十六进制摘要
+func fileSHA256(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("打开文件失败: %v", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("计算文件摘要失败: %v", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/difyz9/markdown2tts/model"
+)
+
+// 统一音量标度：1.0为正常音量，0.0-2.0之间线性映射到各provider的原生标度
+const (
+	minVolumeScale = 0.0
+	maxVolumeScale = 2.0
+)
+
+// clampVolumeScale 把统一音量限制在有效范围内
+func clampVolumeScale(scale float64) float64 {
+	return math.Max(minVolumeScale, math.Min(maxVolumeScale, scale))
+}
+
+// TencentVolume 返回腾讯云使用的音量（1-10，5为正常）
+// 配置了 audio.volume_scale 时按统一标度换算，否则使用 tts.volume
+func TencentVolume(config *model.Config) int64 {
+	if config.Audio.VolumeScale <= 0 {
+		return config.TTS.Volume
+	}
+
+	// 0 会被当作未设置而回落到默认音量5，因此最小取1
+	volume := int64(math.Round(clampVolumeScale(config.Audio.VolumeScale) * 5))
+	return max(1, min(10, volume))
+}
+
+// EdgeVolume 返回Edge TTS使用的音量（如 "+20%"，"+0%"为正常）
+// 配置了 audio.volume_scale 时按统一标度换算，否则使用 edge_tts.volume
+func EdgeVolume(config *model.Config) string {
+	if config.Audio.VolumeScale <= 0 {
+		if config.EdgeTTS.Volume == "" {
+			return "+0%" // 默认正常音量
+		}
+		return config.EdgeTTS.Volume
+	}
+
+	percent := int(math.Round((clampVolumeScale(config.Audio.VolumeScale) - 1) * 100))
+	return fmt.Sprintf("%+d%%", percent)
+}